@@ -3,14 +3,43 @@ package cache
 var (
 	CalendarCache = NewETagCache()
 	FiltersCache  = NewETagCache()
+	MapCache      = NewETagCache()
+	StatsCache    = NewETagCache()
+
+	// Saved filters list their photo counts, so anything that changes the photos invalidates them too
+	SavedFiltersCache = NewETagCache()
 )
 
-func InvalidateOnPhotoCuration() {
+func invalidateAll() {
 	CalendarCache.Invalidate()
 	FiltersCache.Invalidate()
+	MapCache.Invalidate()
+	StatsCache.Invalidate()
+	SavedFiltersCache.Invalidate()
+}
+
+func InvalidateOnPhotoCuration() {
+	invalidateAll()
 }
 
 func InvalidateOnImport() {
-	CalendarCache.Invalidate()
+	invalidateAll()
+}
+
+// Coordinates and places feed the location filters, the map and the top locations, but not the calendar
+func InvalidateOnLocationChange() {
 	FiltersCache.Invalidate()
+	MapCache.Invalidate()
+	StatsCache.Invalidate()
+	SavedFiltersCache.Invalidate()
+}
+
+// Capture times decide the calendar months, the year filters and stats, and the newest photo of each map cluster
+func InvalidateOnDateChange() {
+	invalidateAll()
+}
+
+// Re-read metadata can change any of the camera, date and location columns, so everything is invalidated
+func InvalidateOnMetadataChange() {
+	invalidateAll()
 }
@@ -31,7 +31,15 @@ func (c *ETagCache) Invalidate() {
 }
 
 func (c *ETagCache) CheckAndRespond(w http.ResponseWriter, r *http.Request, maxAge int) bool {
-	etag := c.GetVersion()
+	return c.respond(w, r, maxAge, c.GetVersion())
+}
+
+// For responses that differ per user, so one user's cached copy is never valid for another
+func (c *ETagCache) CheckAndRespondForUser(w http.ResponseWriter, r *http.Request, maxAge int, userID int64) bool {
+	return c.respond(w, r, maxAge, c.GetVersion()+"-"+strconv.FormatInt(userID, 10))
+}
+
+func (c *ETagCache) respond(w http.ResponseWriter, r *http.Request, maxAge int, etag string) bool {
 	w.Header().Set("ETag", etag)
 	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
 
@@ -1,16 +1,59 @@
 import { ModalBackdrop, ModalContainer } from './Modal.jsx';
 import { CloseIcon, InfoIcon, PickIcon, RejectIcon, UnflagIcon, StarIcon } from './Icon.jsx';
 import getPhotoUrl from '../utils/getPhotoUrl.js';
+import getVideoUrl from '../utils/getVideoUrl.js';
 import formatDateTime from '../utils/formatDateTime.js';
+import formatUTCOffset from '../utils/formatUTCOffset.js';
 import formatFileSize from '../utils/formatFileSize.js';
 import formatExposureTime from '../utils/formatExposureTime.js';
 import formatDuration from '../utils/formatDuration.js';
 import formatFocalLength from '../utils/formatFocalLength.js';
+import formatExposureCompensation from '../utils/formatExposureCompensation.js';
 import getFileName from '../utils/getFileName.js';
 import './Lightbox.css';
 
 const { useState, useEffect } = React;
 
+const PROXY_RETRY_MS = 5000;
+
+// Videos browsers can't play answer 202 while their proxy is transcoded, so the player retries until it's ready
+function LightboxVideo({ photoId }) {
+  const [attempt, setAttempt] = useState(0);
+  const [isPreparing, setIsPreparing] = useState(false);
+
+  useEffect(() => {
+    setAttempt(0);
+    setIsPreparing(false);
+  }, [photoId]);
+
+  useEffect(() => {
+    if (!isPreparing) {
+      return;
+    }
+    const timer = setTimeout(() => setAttempt(attempt + 1), PROXY_RETRY_MS);
+    return () => clearTimeout(timer);
+  }, [isPreparing, attempt]);
+
+  async function handleError() {
+    const response = await fetch(getVideoUrl(photoId), { headers: { Range: 'bytes=0-0' } });
+    setIsPreparing(response.status === 202);
+  }
+
+  return (
+    <>
+      <video
+        key={attempt}
+        src={getVideoUrl(photoId)}
+        className="lightbox-image"
+        controls
+        onLoadedData={() => setIsPreparing(false)}
+        onError={handleError}
+      />
+      {isPreparing && <div className="lightbox-video-status">Preparing video for playback…</div>}
+    </>
+  );
+}
+
 export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
   const [currentIndex, setCurrentIndex] = useState(selectedIndex);
   const [isZoomed, setIsZoomed] = useState(false);
@@ -49,13 +92,13 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
           case 'p':
           case 'P':
             e.preventDefault();
-            onCurate(currentPhoto.filePath, true, false, 0);
+            onCurate(currentPhoto.photoId, true, false, 0);
             advanceToNext();
             break;
           case 'x':
           case 'X':
             e.preventDefault();
-            onCurate(currentPhoto.filePath, true, true, 0);
+            onCurate(currentPhoto.photoId, true, true, 0);
             advanceToNext();
             break;
           case '1':
@@ -64,7 +107,7 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
           case '4':
           case '5':
             e.preventDefault();
-            onCurate(currentPhoto.filePath, true, false, parseInt(e.key));
+            onCurate(currentPhoto.photoId, true, false, parseInt(e.key));
             advanceToNext();
             break;
         }
@@ -95,18 +138,12 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
     setShowMetadata(!showMetadata);
   }
 
-  const photoUrl = getPhotoUrl(currentPhoto.filePath);
+  const photoUrl = getPhotoUrl(currentPhoto.photoId);
   const isVideo = currentPhoto.isVideo;
 
   let mediaElement = null;
   if (isVideo) {
-    mediaElement = (
-      <video
-        src={photoUrl}
-        className="lightbox-image"
-        controls
-      />
-    );
+    mediaElement = <LightboxVideo photoId={currentPhoto.photoId} />;
   } else {
     mediaElement = (
       <img
@@ -126,7 +163,11 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
     metadataItems.push({ label: 'File Size', value: formatFileSize(currentPhoto.fileSize) });
     metadataItems.push({ label: 'Format', value: currentPhoto.fileFormat });
 
-    if (currentPhoto.dateTime) {
+    if (currentPhoto.localDateTime) {
+      // The time on the camera where the photo was taken, rather than in the viewer's timezone
+      const dateTaken = [formatDateTime(currentPhoto.localDateTime), formatUTCOffset(currentPhoto.utcOffset)].filter(Boolean).join(' ');
+      metadataItems.push({ label: 'Date Taken', value: dateTaken });
+    } else if (currentPhoto.dateTime) {
       metadataItems.push({ label: 'Date Taken', value: formatDateTime(currentPhoto.dateTime) });
     }
 
@@ -139,6 +180,12 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
       metadataItems.push({ label: 'Camera', value: camera });
     }
 
+    if (currentPhoto.lensModel) {
+      // Lens names usually start with the make already
+      const lens = currentPhoto.lensMake && !currentPhoto.lensModel.startsWith(currentPhoto.lensMake) ? `${currentPhoto.lensMake} ${currentPhoto.lensModel}` : currentPhoto.lensModel;
+      metadataItems.push({ label: 'Lens', value: lens });
+    }
+
     if (currentPhoto.iso) {
       metadataItems.push({ label: 'ISO', value: currentPhoto.iso });
     }
@@ -152,7 +199,31 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
     }
 
     if (currentPhoto.focalLength) {
-      metadataItems.push({ label: 'Focal Length', value: formatFocalLength(currentPhoto.focalLength) });
+      let focalLength = formatFocalLength(currentPhoto.focalLength);
+      if (currentPhoto.focalLength35mm && currentPhoto.focalLength35mm !== currentPhoto.focalLength) {
+        focalLength += ` (${formatFocalLength(currentPhoto.focalLength35mm)} in 35mm)`;
+      }
+      metadataItems.push({ label: 'Focal Length', value: focalLength });
+    }
+
+    if (currentPhoto.exposureCompensation !== undefined) {
+      metadataItems.push({ label: 'Exposure Compensation', value: formatExposureCompensation(currentPhoto.exposureCompensation) });
+    }
+
+    if (currentPhoto.exposureProgram) {
+      metadataItems.push({ label: 'Exposure Program', value: currentPhoto.exposureProgram });
+    }
+
+    if (currentPhoto.meteringMode) {
+      metadataItems.push({ label: 'Metering', value: currentPhoto.meteringMode });
+    }
+
+    if (currentPhoto.whiteBalance) {
+      metadataItems.push({ label: 'White Balance', value: currentPhoto.whiteBalance });
+    }
+
+    if (currentPhoto.flash) {
+      metadataItems.push({ label: 'Flash', value: currentPhoto.flash });
     }
 
     if (currentPhoto.city || currentPhoto.state || currentPhoto.countryName) {
@@ -164,10 +235,39 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
       metadataItems.push({ label: 'GPS', value: `${currentPhoto.latitude.toFixed(5)}, ${currentPhoto.longitude.toFixed(5)}`, });
     }
 
+    if (currentPhoto.altitude !== undefined) {
+      metadataItems.push({ label: 'Altitude', value: `${Math.round(currentPhoto.altitude)} m` });
+    }
+
+    if (currentPhoto.gpsDirection !== undefined) {
+      metadataItems.push({ label: 'Direction', value: `${Math.round(currentPhoto.gpsDirection)}°` });
+    }
+
     if (currentPhoto.duration) {
       metadataItems.push({ label: 'Duration', value: formatDuration(currentPhoto.duration) });
     }
 
+    if (currentPhoto.videoCodec) {
+      const codecs = [currentPhoto.videoCodec, currentPhoto.audioCodec].filter(Boolean).join(' / ');
+      metadataItems.push({ label: 'Codec', value: codecs.toUpperCase() });
+    }
+
+    if (currentPhoto.frameRate) {
+      metadataItems.push({ label: 'Frame Rate', value: `${currentPhoto.frameRate} fps` });
+    }
+
+    if (currentPhoto.colorProfile || currentPhoto.colorSpace) {
+      metadataItems.push({ label: 'Color Profile', value: currentPhoto.colorProfile || currentPhoto.colorSpace });
+    }
+
+    if (currentPhoto.software) {
+      metadataItems.push({ label: 'Software', value: currentPhoto.software });
+    }
+
+    if (currentPhoto.cameraSerial) {
+      metadataItems.push({ label: 'Camera Serial', value: currentPhoto.cameraSerial });
+    }
+
     if (currentPhoto.fileCreatedAt) {
       metadataItems.push({ label: 'File Created', value: formatDateTime(currentPhoto.fileCreatedAt) });
     }
@@ -200,21 +300,21 @@ export default function Lightbox({ photos, selectedIndex, onClose, onCurate }) {
   if (onCurate && !isZoomed) {
     curateButtons = (
       <>
-        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.filePath, true, false, 0); advanceToNext(); }} title="Pick (P)">
+        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.photoId, true, false, 0); advanceToNext(); }} title="Pick (P)">
           <PickIcon />
           <span>Pick</span>
         </div>
-        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.filePath, true, true, 0); advanceToNext(); }} title="Reject (X)">
+        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.photoId, true, true, 0); advanceToNext(); }} title="Reject (X)">
           <RejectIcon />
           <span>Reject</span>
         </div>
-        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.filePath, false, false, 0); advanceToNext(); }} title="Unflag (U)">
+        <div className="lightbox-button" onClick={() => { onCurate(currentPhoto.photoId, false, false, 0); advanceToNext(); }} title="Unflag (U)">
           <UnflagIcon />
           <span>Unflag</span>
         </div>
         <div className="lightbox-divider"></div>
         {[1, 2, 3, 4, 5].map(rating => (
-          <div key={rating} className="lightbox-button" onClick={() => { onCurate(currentPhoto.filePath, true, false, rating); advanceToNext(); }} title={`Rate ${rating} (${rating})`}>
+          <div key={rating} className="lightbox-button" onClick={() => { onCurate(currentPhoto.photoId, true, false, rating); advanceToNext(); }} title={`Rate ${rating} (${rating})`}>
             <StarIcon size={20} isFilled={currentPhoto.rating >= rating} />
           </div>
         ))}
@@ -1,7 +1,8 @@
 import { PrevIcon, NextIcon } from './Icon.jsx';
 import './Pagination.css';
 
-export default function Pagination({ pageStartRecord, pageEndRecord, totalRecords, onPrev, onNext, hasPrev, hasNext }) {
+// isTotalApproximate marks totalRecords as a lower bound
+export default function Pagination({ pageStartRecord, pageEndRecord, totalRecords, isTotalApproximate = false, onPrev, onNext, hasPrev, hasNext }) {
   function handlePrevClick(e) {
     e.preventDefault();
     if (!hasPrev) {
@@ -21,7 +22,7 @@ export default function Pagination({ pageStartRecord, pageEndRecord, totalRecord
   return (
     <div className="pagination-container">
       <div className="pagination">
-        <span className="pagination-text">{pageStartRecord} - {pageEndRecord} of {totalRecords}</span>
+        <span className="pagination-text">{pageStartRecord} - {pageEndRecord} of {totalRecords}{isTotalApproximate ? '+' : ''}</span>
         <a href="#" className={!hasPrev ? 'disabled' : ''} onClick={handlePrevClick}  >
           <PrevIcon />
         </a>
@@ -1,9 +1,46 @@
 import Link from './Link.jsx';
 import Logo from './Logo.jsx';
-import { ImportIcon, CurateIcon, LibraryIcon, FolderIcon, TrashIcon, CalendarIcon, SettingsIcon, ExportIcon } from './Icon.jsx';
+import { ImportIcon, CurateIcon, LibraryIcon, FolderIcon, TrashIcon, CalendarIcon, SettingsIcon, ExportIcon, MapPinIcon } from './Icon.jsx';
+import { useCurrentUser, hasRole } from '../../features/auth/AuthProvider.jsx';
 import './Sidebar.css';
 
 export default function Sidebar() {
+  const { user, logout } = useCurrentUser();
+
+  let importLink = null;
+  let exportLink = null;
+  let geotagLink = null;
+  if (hasRole(user, 'curator')) {
+    importLink = (
+      <Link className="sidebar-button" activeClassName="is-active" to="/import">
+        <ImportIcon />
+        Import
+      </Link>
+    );
+    exportLink = (
+      <Link className="sidebar-button" activeClassName="is-active" to="/export">
+        <ExportIcon />
+        Export
+      </Link>
+    );
+    geotagLink = (
+      <Link className="sidebar-button" activeClassName="is-active" to="/geotag">
+        <MapPinIcon />
+        Geotag
+      </Link>
+    );
+  }
+
+  let settingsLink = null;
+  if (hasRole(user, 'admin')) {
+    settingsLink = (
+      <Link className="sidebar-button" activeClassName="is-active" to="/settings">
+        <SettingsIcon />
+        Settings
+      </Link>
+    );
+  }
+
   return (
     <div className="sidebar-container">
       <div className="sidebar-fixed">
@@ -11,10 +48,7 @@ export default function Sidebar() {
           <Logo />
         </div>
 
-        <Link className="sidebar-button" activeClassName="is-active" to="/import">
-          <ImportIcon />
-          Import
-        </Link>
+        {importLink}
         <Link className="sidebar-button" activeClassName="is-active" to="/curate">
           <CurateIcon />
           Curate
@@ -31,18 +65,17 @@ export default function Sidebar() {
           <CalendarIcon />
           Calendar
         </Link>
-        <Link className="sidebar-button" activeClassName="is-active" to="/export">
-          <ExportIcon />
-          Export
-        </Link>
+        {exportLink}
+        {geotagLink}
         <Link className="sidebar-button" activeClassName="is-active" to="/trash">
           <TrashIcon />
           Trash
         </Link>
-        <Link className="sidebar-button" activeClassName="is-active" to="/settings">
-          <SettingsIcon />
-          Settings
-        </Link>
+        {settingsLink}
+      </div>
+      <div className="sidebar-footer">
+        <span className="sidebar-username">{user.username}</span>
+        <span className="sidebar-logout" onClick={logout}>Sign out</span>
       </div>
     </div>
   );
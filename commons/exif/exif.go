@@ -1,40 +1,22 @@
 package exif
 
-import (
-	"fmt"
-
-	"github.com/barasher/go-exiftool"
-)
-
-var et *exiftool.Exiftool
-
-func init() {
-	var err error
-	et, err = exiftool.NewExiftool()
-	if err != nil {
-		panic(fmt.Sprintf("failed to initialize exiftool: %v", err))
-	}
-}
-
 func ExtractExif(filePath string) (map[string]any, error) {
-	fileInfos := et.ExtractMetadata(filePath)
-	if len(fileInfos) == 0 {
-		return make(map[string]any), nil
-	}
-
-	fileInfo := fileInfos[0]
-	if fileInfo.Err != nil {
-		return nil, fileInfo.Err
+	tags, err := extractor.ReadTags(filePath)
+	if err != nil {
+		return nil, err
 	}
 
 	data := make(map[string]any)
+	if len(tags) == 0 {
+		return data, nil
+	}
 
 	// DateTime: Check multiple fields in priority order
 	// Photos: DateTimeOriginal
 	// Videos: CreationDate (has timezone, local time) preferred over CreateDate (often UTC)
 	dateFields := []string{"DateTimeOriginal", "CreationDate", "CreateDate", "MediaCreateDate", "TrackCreateDate"}
 	for _, dateField := range dateFields {
-		if val, err := fileInfo.GetString(dateField); err == nil && val != "" {
+		if val := tags[dateField]; val != "" {
 			data["DateTime"] = val
 			break
 		}
@@ -43,17 +25,26 @@ func ExtractExif(filePath string) (map[string]any, error) {
 	// OffsetTimeOriginal: Timezone offset for DateTimeOriginal (EXIF 2.31+)
 	offsetFields := []string{"OffsetTimeOriginal", "OffsetTimeDigitized", "OffsetTime"}
 	for _, offsetField := range offsetFields {
-		if val, err := fileInfo.GetString(offsetField); err == nil && val != "" {
+		if val := tags[offsetField]; val != "" {
 			data["OffsetTimeOriginal"] = val
 			break
 		}
 	}
 
 	// GPSDateTime: UTC time from GPS (can be used to calculate timezone offset)
-	if val, err := fileInfo.GetString("GPSDateTime"); err == nil && val != "" {
+	if val := tags["GPSDateTime"]; val != "" {
 		data["GPSDateTime"] = val
 	}
 
+	// LensModel: LensID is exiftool's lens database guess, used when the file doesn't name the lens
+	lensFields := []string{"LensModel", "Lens", "LensID"}
+	for _, lensField := range lensFields {
+		if val := tags[lensField]; val != "" {
+			data["LensModel"] = val
+			break
+		}
+	}
+
 	fieldMap := map[string]string{
 		"Make":           "Make",
 		"Model":          "Model",
@@ -71,19 +62,26 @@ func ExtractExif(filePath string) (map[string]any, error) {
 		"GPSLatitude":    "GPSLatitude",
 		"GPSLongitude":   "GPSLongitude",
 		"GPSCoordinates": "GPSCoordinates",
+
+		"LensMake":             "LensMake",
+		"FocalLength35mm":      "FocalLengthIn35mmFormat",
+		"ExposureProgram":      "ExposureProgram",
+		"ExposureMode":         "ExposureMode",
+		"ExposureCompensation": "ExposureCompensation",
+		"MeteringMode":         "MeteringMode",
+		"WhiteBalance":         "WhiteBalance",
+		"ColorProfile":         "ProfileDescription",
+		"GPSAltitude":          "GPSAltitude",
+		"GPSAltitudeRef":       "GPSAltitudeRef",
+		"GPSDirection":         "GPSImgDirection",
+		"CameraSerial":         "SerialNumber",
 	}
 
 	for key, exifKey := range fieldMap {
-		if val, err := fileInfo.GetString(exifKey); err == nil && val != "" {
+		if val := tags[exifKey]; val != "" {
 			data[key] = val
 		}
 	}
 
 	return data, nil
 }
-
-func Close() {
-	if et != nil {
-		et.Close()
-	}
-}
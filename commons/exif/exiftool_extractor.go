@@ -0,0 +1,87 @@
+package exif
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/barasher/go-exiftool"
+)
+
+type exiftoolExtractor struct {
+	et *exiftool.Exiftool
+}
+
+func newExiftoolExtractor() (*exiftoolExtractor, error) {
+	et, err := exiftool.NewExiftool()
+	if err != nil {
+		return nil, err
+	}
+	return &exiftoolExtractor{et: et}, nil
+}
+
+func (e *exiftoolExtractor) Name() string {
+	return "exiftool"
+}
+
+func (e *exiftoolExtractor) ReadTags(filePath string) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	fileInfos := e.et.ExtractMetadata(filePath)
+	if len(fileInfos) == 0 {
+		return tags, nil
+	}
+
+	fileInfo := fileInfos[0]
+	if fileInfo.Err != nil {
+		return nil, fileInfo.Err
+	}
+
+	for name := range fileInfo.Fields {
+		if val, err := fileInfo.GetString(name); err == nil {
+			tags[name] = val
+		}
+	}
+
+	return tags, nil
+}
+
+// Photos get the EXIF dates as wall clock time plus the offset tags when the timezone is known. Videos get the
+// QuickTime dates, which are UTC by the spec, and the Keys CreationDate that players show as local time.
+func (e *exiftoolExtractor) WriteCaptureTime(filePath string, captureTime time.Time, hasOffset bool, isVideo bool) error {
+	metadata := exiftool.EmptyFileMetadata()
+	metadata.File = filePath
+
+	const exifFormat = "2006:01:02 15:04:05"
+	offset := captureTime.Format("-07:00")
+
+	if isVideo {
+		utc := captureTime.UTC().Format(exifFormat)
+		metadata.SetString("QuickTime:CreateDate", utc)
+		metadata.SetString("QuickTime:ModifyDate", utc)
+		metadata.SetString("QuickTime:TrackCreateDate", utc)
+		metadata.SetString("QuickTime:MediaCreateDate", utc)
+		if hasOffset {
+			metadata.SetString("Keys:CreationDate", captureTime.Format(exifFormat)+offset)
+		}
+	} else {
+		local := captureTime.Format(exifFormat)
+		metadata.SetString("EXIF:DateTimeOriginal", local)
+		metadata.SetString("EXIF:CreateDate", local)
+		if hasOffset {
+			metadata.SetString("EXIF:OffsetTimeOriginal", offset)
+			metadata.SetString("EXIF:OffsetTimeDigitized", offset)
+		}
+	}
+
+	fileMetadata := []exiftool.FileMetadata{metadata}
+	e.et.WriteMetadata(fileMetadata)
+	if fileMetadata[0].Err != nil {
+		return fmt.Errorf("error writing capture time to %s: %w", filePath, fileMetadata[0].Err)
+	}
+
+	return nil
+}
+
+func (e *exiftoolExtractor) Close() {
+	e.et.Close()
+}
@@ -0,0 +1,57 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var ErrWriteUnsupported = errors.New("writing metadata needs exiftool")
+
+// Reads the metadata tags of a file, named and printed the way exiftool does so the rest of the pipeline doesn't
+// depend on which extractor read them
+type Extractor interface {
+	Name() string
+	ReadTags(filePath string) (map[string]string, error)
+	Close()
+}
+
+// Implemented by extractors that can also change a file's metadata
+type CaptureTimeWriter interface {
+	WriteCaptureTime(filePath string, captureTime time.Time, hasOffset bool, isVideo bool) error
+}
+
+// The built-in reader works without any external tools, so metadata can always be read
+var extractor Extractor = nativeExtractor{}
+
+// Picks the extractor for the server. exiftool reads far more formats and tags, so it's used when installed;
+// otherwise the built-in reader is kept and the returned error says why.
+func Init() error {
+	et, err := newExiftoolExtractor()
+	if err != nil {
+		return fmt.Errorf("exiftool unavailable, using the built-in metadata reader: %w", err)
+	}
+
+	extractor = et
+	slog.Info("metadata extractor ready", "extractor", extractor.Name())
+	return nil
+}
+
+func CanWriteMetadata() bool {
+	_, ok := extractor.(CaptureTimeWriter)
+	return ok
+}
+
+// Writes a corrected capture time into the file, when the extractor in use can write
+func WriteCaptureTime(filePath string, captureTime time.Time, hasOffset bool, isVideo bool) error {
+	writer, ok := extractor.(CaptureTimeWriter)
+	if !ok {
+		return ErrWriteUnsupported
+	}
+	return writer.WriteCaptureTime(filePath, captureTime, hasOffset, isVideo)
+}
+
+func Close() {
+	extractor.Close()
+}
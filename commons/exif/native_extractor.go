@@ -0,0 +1,305 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/adrium/goheif"
+	goexif "github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Reads EXIF from JPEG, TIFF and HEIC files and the movie header of MP4 and MOV files without any external
+// tools. It covers the tags the library uses, printed the way exiftool prints them.
+type nativeExtractor struct{}
+
+// goexif predates the EXIF 2.31 offset tags and the body serial number, so they're loaded from the EXIF sub-IFD
+// on top of its own fields
+var extraExifFields = map[uint16]goexif.FieldName{
+	0x9010: "OffsetTime",
+	0x9011: "OffsetTimeOriginal",
+	0x9012: "OffsetTimeDigitized",
+	0xA431: "SerialNumber",
+}
+
+type extraExifFieldsParser struct{}
+
+func (extraExifFieldsParser) Parse(x *goexif.Exif) error {
+	pointer, err := x.Get(goexif.ExifIFDPointer)
+	if err != nil || pointer.Count == 0 {
+		return nil
+	}
+	offset, err := pointer.Int64(0)
+	if err != nil {
+		return nil
+	}
+
+	r := bytes.NewReader(x.Raw)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		return nil
+	}
+
+	x.LoadTags(dir, extraExifFields, false)
+	return nil
+}
+
+func init() {
+	goexif.RegisterParsers(extraExifFieldsParser{})
+}
+
+// EXIF tags copied as they are, by their exiftool name
+var nativeStringTags = map[goexif.FieldName]string{
+	goexif.Make:              "Make",
+	goexif.Model:             "Model",
+	goexif.Software:          "Software",
+	goexif.LensMake:          "LensMake",
+	goexif.LensModel:         "LensModel",
+	goexif.DateTimeOriginal:  "DateTimeOriginal",
+	goexif.DateTimeDigitized: "CreateDate",
+	"OffsetTime":             "OffsetTime",
+	"OffsetTimeOriginal":     "OffsetTimeOriginal",
+	"OffsetTimeDigitized":    "OffsetTimeDigitized",
+	"SerialNumber":           "SerialNumber",
+}
+
+// Numeric EXIF tags, written as plain numbers that the normalization reads like exiftool's values
+var nativeNumberTags = map[goexif.FieldName]string{
+	goexif.Orientation:           "Orientation",
+	goexif.ISOSpeedRatings:       "ISO",
+	goexif.FNumber:               "FNumber",
+	goexif.ExposureTime:          "ExposureTime",
+	goexif.FocalLength:           "FocalLength",
+	goexif.FocalLengthIn35mmFilm: "FocalLengthIn35mmFormat",
+	goexif.ExposureBiasValue:     "ExposureCompensation",
+	goexif.GPSAltitude:           "GPSAltitude",
+	goexif.GPSAltitudeRef:        "GPSAltitudeRef",
+	goexif.GPSImgDirection:       "GPSImgDirection",
+}
+
+// Coded EXIF tags, with exiftool's descriptions so filters show the same values whichever extractor read them
+var nativeCodedTags = map[goexif.FieldName]struct {
+	Name   string
+	Values map[int]string
+}{
+	goexif.ExposureProgram: {"ExposureProgram", map[int]string{
+		0: "Not Defined", 1: "Manual", 2: "Program AE", 3: "Aperture-priority AE", 4: "Shutter speed priority AE",
+		5: "Creative (Slow speed)", 6: "Action (High speed)", 7: "Portrait", 8: "Landscape",
+	}},
+	goexif.ExposureMode: {"ExposureMode", map[int]string{
+		0: "Auto", 1: "Manual", 2: "Auto bracket",
+	}},
+	goexif.MeteringMode: {"MeteringMode", map[int]string{
+		0: "Unknown", 1: "Average", 2: "Center-weighted average", 3: "Spot", 4: "Multi-spot", 5: "Multi-segment",
+		6: "Partial", 255: "Other",
+	}},
+	goexif.WhiteBalance: {"WhiteBalance", map[int]string{
+		0: "Auto", 1: "Manual",
+	}},
+	goexif.ColorSpace: {"ColorSpace", map[int]string{
+		1: "sRGB", 2: "Adobe RGB", 0xFFFD: "Wide Gamut RGB", 0xFFFE: "ICC Profile", 0xFFFF: "Uncalibrated",
+	}},
+	goexif.Flash: {"Flash", map[int]string{
+		0x00: "No Flash", 0x01: "Fired", 0x05: "Fired, Return not detected", 0x07: "Fired, Return detected",
+		0x08: "On, Did not fire", 0x09: "On, Fired", 0x0D: "On, Return not detected", 0x0F: "On, Return detected",
+		0x10: "Off, Did not fire", 0x14: "Off, Did not fire, Return not detected", 0x18: "Auto, Did not fire",
+		0x19: "Auto, Fired", 0x1D: "Auto, Fired, Return not detected", 0x1F: "Auto, Fired, Return detected",
+		0x20: "No flash function", 0x30: "Off, No flash function", 0x41: "Fired, Red-eye reduction",
+		0x45: "Fired, Red-eye reduction, Return not detected", 0x47: "Fired, Red-eye reduction, Return detected",
+		0x49: "On, Red-eye reduction", 0x4D: "On, Red-eye reduction, Return not detected",
+		0x4F: "On, Red-eye reduction, Return detected", 0x50: "Off, Red-eye reduction",
+		0x58: "Auto, Did not fire, Red-eye reduction", 0x59: "Auto, Fired, Red-eye reduction",
+		0x5D: "Auto, Fired, Red-eye reduction, Return not detected",
+		0x5F: "Auto, Fired, Red-eye reduction, Return detected",
+	}},
+}
+
+func (nativeExtractor) Name() string {
+	return "built-in"
+}
+
+// goexif panics on some malformed tags, and a library's files aren't all well formed, so a panic is turned into
+// an error for that one file instead of taking down the import
+func (nativeExtractor) ReadTags(filePath string) (tags map[string]string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tags, err = nil, fmt.Errorf("error reading metadata of %s: %v", filePath, r)
+		}
+	}()
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".mp4", ".m4v", ".mov", ".3gp":
+		return readMovieTags(filePath)
+	}
+	return readImageTags(filePath)
+}
+
+func (nativeExtractor) Close() {}
+
+// Files without EXIF, or with EXIF goexif can't read, still get their dimensions
+func readImageTags(filePath string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	tags := make(map[string]string)
+
+	if config, _, err := image.DecodeConfig(file); err == nil && config.Width > 0 && config.Height > 0 {
+		tags["ImageWidth"] = strconv.Itoa(config.Width)
+		tags["ImageHeight"] = strconv.Itoa(config.Height)
+	}
+
+	x, err := decodeImageExif(file)
+	if x == nil || (err != nil && goexif.IsCriticalError(err)) {
+		return tags, nil
+	}
+
+	for field, name := range nativeStringTags {
+		if tag, err := x.Get(field); err == nil {
+			if val, err := tag.StringVal(); err == nil && strings.TrimSpace(val) != "" {
+				tags[name] = strings.TrimSpace(val)
+			}
+		}
+	}
+
+	for field, name := range nativeNumberTags {
+		if tag, err := x.Get(field); err == nil {
+			if val, ok := tagNumber(tag); ok {
+				tags[name] = strconv.FormatFloat(val, 'f', -1, 64)
+			}
+		}
+	}
+
+	for field, coded := range nativeCodedTags {
+		if tag, err := x.Get(field); err == nil {
+			if tag.Count == 0 {
+				continue
+			}
+			if val, err := tag.Int(0); err == nil && coded.Values[val] != "" {
+				tags[coded.Name] = coded.Values[val]
+			}
+		}
+	}
+
+	if tags["ImageWidth"] == "" {
+		width, widthErr := x.Get(goexif.PixelXDimension)
+		height, heightErr := x.Get(goexif.PixelYDimension)
+		if widthErr == nil && heightErr == nil {
+			if w, ok := tagNumber(width); ok {
+				tags["ImageWidth"] = strconv.Itoa(int(w))
+			}
+			if h, ok := tagNumber(height); ok {
+				tags["ImageHeight"] = strconv.Itoa(int(h))
+			}
+		}
+	}
+
+	if lat, lon, ok := latLong(x); ok {
+		tags["GPSLatitude"] = strconv.FormatFloat(lat, 'f', -1, 64)
+		tags["GPSLongitude"] = strconv.FormatFloat(lon, 'f', -1, 64)
+	}
+
+	if dateTime := gpsDateTime(x); dateTime != "" {
+		tags["GPSDateTime"] = dateTime
+	}
+
+	return tags, nil
+}
+
+// HEIC keeps its EXIF in an item of the HEIF container rather than in the image data
+func decodeImageExif(file *os.File) (*goexif.Exif, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Name()))
+	if ext == ".heic" || ext == ".heif" {
+		data, err := goheif.ExtractExif(file)
+		if err != nil {
+			return nil, err
+		}
+		return goexif.Decode(bytes.NewReader(data))
+	}
+
+	return goexif.Decode(file)
+}
+
+// goexif indexes tag values without checking how many there are, so every read checks Count first
+func tagNumber(tag *tiff.Tag) (float64, bool) {
+	if tag.Count == 0 {
+		return 0, false
+	}
+
+	switch tag.Format() {
+	case tiff.IntVal:
+		val, err := tag.Int64(0)
+		return float64(val), err == nil
+	case tiff.RatVal:
+		num, den, err := tag.Rat2(0)
+		if err != nil || den == 0 {
+			return 0, false
+		}
+		return float64(num) / float64(den), true
+	case tiff.FloatVal:
+		val, err := tag.Float(0)
+		return val, err == nil
+	}
+	return 0, false
+}
+
+func latLong(x *goexif.Exif) (float64, float64, bool) {
+	for _, field := range []goexif.FieldName{goexif.GPSLatitude, goexif.GPSLongitude} {
+		if tag, err := x.Get(field); err != nil || tag.Count == 0 {
+			return 0, 0, false
+		}
+	}
+
+	lat, lon, err := x.LatLong()
+	return lat, lon, err == nil
+}
+
+// The UTC time of the GPS fix, joined from its date and time tags like exiftool's composite GPSDateTime
+func gpsDateTime(x *goexif.Exif) string {
+	dateTag, err := x.Get(goexif.GPSDateStamp)
+	if err != nil {
+		return ""
+	}
+	date, err := dateTag.StringVal()
+	if err != nil || len(date) != len("2006:01:02") {
+		return ""
+	}
+
+	timeTag, err := x.Get(goexif.GPSTimeStamp)
+	if err != nil || timeTag.Count != 3 {
+		return ""
+	}
+
+	var parts [3]int
+	for i := range parts {
+		num, den, err := timeTag.Rat2(i)
+		if err != nil || den == 0 {
+			return ""
+		}
+		parts[i] = int(num / den)
+	}
+
+	return fmt.Sprintf("%s %02d:%02d:%02dZ", date, parts[0], parts[1], parts[2])
+}
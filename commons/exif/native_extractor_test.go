@@ -0,0 +1,356 @@
+package exif
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// A TIFF directory entry, with its values already encoded little endian
+type testIFDEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func asciiEntry(tag uint16, s string) testIFDEntry {
+	return testIFDEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func byteEntry(tag uint16, v byte) testIFDEntry {
+	return testIFDEntry{tag, 1, 1, []byte{v}}
+}
+
+func shortEntry(tag uint16, v uint16) testIFDEntry {
+	return testIFDEntry{tag, 3, 1, binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func longEntry(tag uint16, v uint32) testIFDEntry {
+	return testIFDEntry{tag, 4, 1, binary.LittleEndian.AppendUint32(nil, v)}
+}
+
+// Pairs of numerator and denominator
+func rationalEntry(tag uint16, values ...int32) testIFDEntry {
+	typ := uint16(5)
+	var b []byte
+	for _, v := range values {
+		if v < 0 {
+			typ = 10
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return testIFDEntry{tag, typ, uint32(len(values) / 2), b}
+}
+
+// A tag that claims no values, which goexif can't index
+func emptyEntry(tag uint16, typ uint16) testIFDEntry {
+	return testIFDEntry{tag, typ, 0, nil}
+}
+
+func ifdSize(entries []testIFDEntry) int {
+	size := 2 + 12*len(entries) + 4
+	for _, entry := range entries {
+		if len(entry.value) > 4 {
+			size += len(entry.value)
+		}
+	}
+	return size
+}
+
+// Writes a directory at offset, with the values that don't fit in an entry right after it
+func writeIFD(offset int, entries []testIFDEntry) []byte {
+	var b []byte
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(entries)))
+
+	dataOffset := offset + 2 + 12*len(entries) + 4
+	var data []byte
+	for _, entry := range entries {
+		b = binary.LittleEndian.AppendUint16(b, entry.tag)
+		b = binary.LittleEndian.AppendUint16(b, entry.typ)
+		b = binary.LittleEndian.AppendUint32(b, entry.count)
+		if len(entry.value) > 4 {
+			b = binary.LittleEndian.AppendUint32(b, uint32(dataOffset+len(data)))
+			data = append(data, entry.value...)
+		} else {
+			b = append(b, entry.value...)
+			b = append(b, make([]byte, 4-len(entry.value))...)
+		}
+	}
+
+	b = binary.LittleEndian.AppendUint32(b, 0) // No next directory
+	return append(b, data...)
+}
+
+// Builds a little endian TIFF with the main directory and, when given, the EXIF and GPS sub-directories
+func buildTIFF(main, exifEntries, gpsEntries []testIFDEntry) []byte {
+	main = append([]testIFDEntry{}, main...)
+	if exifEntries != nil {
+		main = append(main, longEntry(0x8769, 0))
+	}
+	if gpsEntries != nil {
+		main = append(main, longEntry(0x8825, 0))
+	}
+
+	exifOffset := 8 + ifdSize(main)
+	gpsOffset := exifOffset + ifdSize(exifEntries)
+	for i, entry := range main {
+		switch entry.tag {
+		case 0x8769:
+			main[i] = longEntry(0x8769, uint32(exifOffset))
+		case 0x8825:
+			main[i] = longEntry(0x8825, uint32(gpsOffset))
+		}
+	}
+
+	b := []byte("II*\x00")
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = append(b, writeIFD(8, main)...)
+	if exifEntries != nil {
+		b = append(b, writeIFD(exifOffset, exifEntries)...)
+	}
+	if gpsEntries != nil {
+		b = append(b, writeIFD(gpsOffset, gpsEntries)...)
+	}
+	return b
+}
+
+// Wraps a TIFF in the APP1 segment of an otherwise empty JPEG
+func buildJPEG(tiffData []byte) []byte {
+	b := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	b = binary.BigEndian.AppendUint16(b, uint16(2+6+len(tiffData)))
+	b = append(b, "Exif\x00\x00"...)
+	b = append(b, tiffData...)
+	return append(b, 0xFF, 0xD9)
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestNativeExtractorReadTags(t *testing.T) {
+	camera := buildTIFF(
+		[]testIFDEntry{
+			asciiEntry(0x010F, "FUJIFILM"),
+			asciiEntry(0x0110, "X-T5"),
+			shortEntry(0x0112, 6),
+		},
+		[]testIFDEntry{
+			rationalEntry(0x829A, 1, 250),
+			rationalEntry(0x829D, 28, 10),
+			shortEntry(0x8822, 3),
+			shortEntry(0x8827, 400),
+			asciiEntry(0x9003, "2024:03:15 14:30:00"),
+			asciiEntry(0x9011, "+09:00"),
+			rationalEntry(0x9204, -2, 3),
+			shortEntry(0x9207, 5),
+			shortEntry(0x9209, 0x10),
+			rationalEntry(0x920A, 23, 1),
+			shortEntry(0xA002, 6240),
+			shortEntry(0xA003, 4160),
+			shortEntry(0xA405, 35),
+			asciiEntry(0xA431, "1234567"),
+			asciiEntry(0xA434, "XF23mmF1.4 R LM WR"),
+		},
+		[]testIFDEntry{
+			asciiEntry(0x0001, "N"),
+			rationalEntry(0x0002, 35, 1, 39, 1, 3036, 100),
+			asciiEntry(0x0003, "E"),
+			rationalEntry(0x0004, 139, 1, 42, 1, 1020, 100),
+			byteEntry(0x0005, 1),
+			rationalEntry(0x0006, 12, 1),
+			rationalEntry(0x0011, 9050, 100),
+			rationalEntry(0x0007, 5, 1, 30, 1, 2, 1),
+			asciiEntry(0x001D, "2024:03:15"),
+		},
+	)
+
+	malformed := buildTIFF(
+		[]testIFDEntry{
+			asciiEntry(0x010F, "Canon"),
+			emptyEntry(0x0112, 3),
+		},
+		[]testIFDEntry{
+			emptyEntry(0x829D, 5),
+			emptyEntry(0x8822, 3),
+			emptyEntry(0x9209, 3),
+			shortEntry(0x8827, 100),
+		},
+		[]testIFDEntry{
+			asciiEntry(0x0001, "N"),
+			emptyEntry(0x0002, 5),
+			asciiEntry(0x0003, "E"),
+			emptyEntry(0x0004, 5),
+			emptyEntry(0x0007, 5),
+			asciiEntry(0x001D, "2024:03:15"),
+		},
+	)
+
+	jpeg := buildJPEG(camera)
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name:     "JPEG with camera, exposure, lens and GPS tags",
+			fileName: "photo.jpg",
+			data:     jpeg,
+			expected: map[string]string{
+				"Make":                    "FUJIFILM",
+				"Model":                   "X-T5",
+				"Orientation":             "6",
+				"ExposureTime":            "0.004",
+				"FNumber":                 "2.8",
+				"ExposureProgram":         "Aperture-priority AE",
+				"ISO":                     "400",
+				"DateTimeOriginal":        "2024:03:15 14:30:00",
+				"OffsetTimeOriginal":      "+09:00",
+				"ExposureCompensation":    "-0.6666666666666666",
+				"MeteringMode":            "Multi-segment",
+				"Flash":                   "Off, Did not fire",
+				"FocalLength":             "23",
+				"ImageWidth":              "6240",
+				"ImageHeight":             "4160",
+				"FocalLengthIn35mmFormat": "35",
+				"SerialNumber":            "1234567",
+				"LensModel":               "XF23mmF1.4 R LM WR",
+				"GPSLatitude":             "35.658433333333335",
+				"GPSLongitude":            "139.70283333333333",
+				"GPSAltitudeRef":          "1",
+				"GPSAltitude":             "12",
+				"GPSImgDirection":         "90.5",
+				"GPSDateTime":             "2024:03:15 05:30:02Z",
+			},
+		},
+		{
+			name:     "Bare TIFF",
+			fileName: "scan.tif",
+			data:     buildTIFF([]testIFDEntry{asciiEntry(0x010F, "Nikon"), shortEntry(0x0112, 1)}, nil, nil),
+			expected: map[string]string{"Make": "Nikon", "Orientation": "1"},
+		},
+		{
+			name:     "Tags without values don't fail the read",
+			fileName: "malformed.jpg",
+			data:     buildJPEG(malformed),
+			expected: map[string]string{},
+		},
+		{
+			name:     "JPEG without EXIF",
+			fileName: "plain.jpg",
+			data:     []byte{0xFF, 0xD8, 0xFF, 0xD9},
+			expected: map[string]string{},
+		},
+		{
+			name:     "Truncated EXIF",
+			fileName: "truncated.jpg",
+			data:     jpeg[:len(jpeg)/2],
+			expected: map[string]string{},
+		},
+		{
+			name:     "Not an image",
+			fileName: "notes.jpg",
+			data:     []byte("not an image at all"),
+			expected: map[string]string{},
+		},
+		{
+			name:     "Empty file",
+			fileName: "empty.heic",
+			data:     []byte{},
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.fileName, tt.data)
+
+			tags, err := nativeExtractor{}.ReadTags(path)
+			if err != nil {
+				t.Fatalf("ReadTags() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("ReadTags() = %v, want %v", tags, tt.expected)
+			}
+		})
+	}
+}
+
+// goexif rejects these while decoding, so they're built by hand to reach the checks before indexing
+func TestTagNumberWithoutValues(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  *tiff.Tag
+	}{
+		{name: "Integer without values", tag: &tiff.Tag{Type: tiff.DTShort}},
+		{name: "Rational without values", tag: &tiff.Tag{Type: tiff.DTRational}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if val, ok := tagNumber(tt.tag); ok {
+				t.Errorf("tagNumber() = %v, want no value", val)
+			}
+		})
+	}
+}
+
+func TestNativeExtractorMissingFile(t *testing.T) {
+	if _, err := (nativeExtractor{}).ReadTags(filepath.Join(t.TempDir(), "missing.jpg")); err == nil {
+		t.Error("ReadTags() error = nil, want an error for a missing file")
+	}
+}
+
+// The tags come out named and printed so ExtractExif maps them the same way it maps exiftool's
+func TestExtractExifWithNativeExtractor(t *testing.T) {
+	tiffData := buildTIFF(
+		[]testIFDEntry{asciiEntry(0x010F, "Sony")},
+		[]testIFDEntry{
+			asciiEntry(0x9003, "2023:07:01 08:00:00"),
+			asciiEntry(0x9011, "-04:00"),
+			rationalEntry(0x829A, 1, 125),
+			asciiEntry(0xA434, "FE 24-70mm F2.8 GM II"),
+		},
+		[]testIFDEntry{
+			asciiEntry(0x0001, "S"),
+			rationalEntry(0x0002, 33, 1, 52, 1, 0, 1),
+			asciiEntry(0x0003, "W"),
+			rationalEntry(0x0004, 70, 1, 30, 1, 0, 1),
+			byteEntry(0x0005, 0),
+			rationalEntry(0x0006, 2500, 1),
+		},
+	)
+	path := writeTestFile(t, "photo.jpg", buildJPEG(tiffData))
+
+	data, err := ExtractExif(path)
+	if err != nil {
+		t.Fatalf("ExtractExif() error = %v", err)
+	}
+
+	expected := map[string]any{
+		"Make":               "Sony",
+		"DateTime":           "2023:07:01 08:00:00",
+		"OffsetTimeOriginal": "-04:00",
+		"ExposureTime":       "0.008",
+		"LensModel":          "FE 24-70mm F2.8 GM II",
+		"GPSLatitude":        "-33.86666666666667",
+		"GPSLongitude":       "-70.5",
+		"GPSAltitude":        "2500",
+		"GPSAltitudeRef":     "0",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("ExtractExif() = %v, want %v", data, expected)
+	}
+}
@@ -7,8 +7,8 @@ import (
 
 // EXIF Data Pipeline: Extract -> Normalize -> Validate
 // Extract:
-// - Reads raw EXIF metadata from files using exiftool
-// - Returns all fields as strings (except specialized types from exiftool)
+// - Reads raw EXIF metadata from files using the extractor picked at startup (exiftool or the built-in reader)
+// - Returns all fields as strings, named and printed like exiftool
 // - No normalization or validation at this stage
 // Normalize:
 // - Converts strings to appropriate types (int, float64, etc.)
@@ -51,6 +51,18 @@ func ProcessExifData(filePath string) (map[string]any, error) {
 		}
 	}
 
+	if altitude, ok := rawData["GPSAltitude"].(string); ok {
+		ref, _ := rawData["GPSAltitudeRef"].(string)
+		if alt := normalization.NormalizeAltitude(altitude, ref); alt != nil {
+			normalized["Altitude"] = *alt
+		}
+	}
+	if direction, ok := rawData["GPSDirection"].(string); ok {
+		if d := normalization.NormalizeGPSDirection(direction); d != nil {
+			normalized["GPSDirection"] = *d
+		}
+	}
+
 	// Normalize other EXIF fields
 	if width, ok := rawData["Width"].(string); ok {
 		if w := normalization.NormalizeWidth(width); w != nil {
@@ -87,17 +99,29 @@ func ProcessExifData(filePath string) (map[string]any, error) {
 			normalized["FocalLength"] = *fl
 		}
 	}
+	if focalLength35mm, ok := rawData["FocalLength35mm"].(string); ok {
+		if fl := normalization.NormalizeFocalLength(focalLength35mm); fl != nil {
+			normalized["FocalLength35mm"] = *fl
+		}
+	}
+	if compensation, ok := rawData["ExposureCompensation"].(string); ok {
+		if ec := normalization.NormalizeExposureCompensation(compensation); ec != nil {
+			normalized["ExposureCompensation"] = *ec
+		}
+	}
 	if duration, ok := rawData["Duration"].(string); ok {
 		if d := normalization.NormalizeDuration(duration); d != nil {
 			normalized["Duration"] = *d
 		}
 	}
 
-	// Copy non-normalized fields (Make, Model, Software, DateTime, etc.)
+	// Copy non-normalized fields (Make, Model, LensModel, Software, DateTime, etc.)
 	fieldsToNormalize := map[string]bool{
 		"GPSLatitude": true, "GPSLongitude": true, "GPSCoordinates": true,
 		"Width": true, "Height": true, "Orientation": true, "ISO": true,
 		"FNumber": true, "ExposureTime": true, "FocalLength": true, "Duration": true,
+		"GPSAltitude": true, "GPSAltitudeRef": true, "GPSDirection": true,
+		"FocalLength35mm": true, "ExposureCompensation": true,
 	}
 	for key, value := range rawData {
 		if !fieldsToNormalize[key] {
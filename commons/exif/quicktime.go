@@ -0,0 +1,142 @@
+package exif
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// QuickTime counts seconds from the start of 1904, in UTC
+var quickTimeEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type atom struct {
+	Type   string
+	Offset int64 // Where the payload starts, after the header
+	Size   int64 // Of the payload
+}
+
+// Reads the creation time and duration from the movie header (moov/mvhd) and the ISO 6709 location from the
+// user data (moov/udta/©xyz) of MP4 and MOV files
+func readMovieTags(filePath string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading file info of %s: %w", filePath, err)
+	}
+
+	tags := make(map[string]string)
+
+	moov, ok := findAtom(file, 0, fileInfo.Size(), "moov")
+	if !ok {
+		return tags, nil
+	}
+
+	if mvhd, ok := findAtom(file, moov.Offset, moov.Offset+moov.Size, "mvhd"); ok {
+		readMovieHeader(file, mvhd, tags)
+	}
+
+	if udta, ok := findAtom(file, moov.Offset, moov.Offset+moov.Size, "udta"); ok {
+		if xyz, ok := findAtom(file, udta.Offset, udta.Offset+udta.Size, "\xa9xyz"); ok {
+			readMovieLocation(file, xyz, tags)
+		}
+	}
+
+	return tags, nil
+}
+
+// Walks the atoms between start and end, stopping at the first with the given type or at a truncated one
+func findAtom(r io.ReaderAt, start, end int64, atomType string) (atom, bool) {
+	header := make([]byte, 16)
+
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return atom{}, false
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+
+		switch size {
+		case 0: // Runs to the end
+			size = end - offset
+		case 1: // 64-bit size after the type
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return atom{}, false
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || offset+size > end {
+			return atom{}, false
+		}
+
+		if string(header[4:8]) == atomType {
+			return atom{Type: atomType, Offset: offset + headerSize, Size: size - headerSize}, true
+		}
+
+		offset += size
+	}
+
+	return atom{}, false
+}
+
+// Written like exiftool's CreateDate, which is UTC for QuickTime files
+func readMovieHeader(r io.ReaderAt, mvhd atom, tags map[string]string) {
+	data := make([]byte, min(mvhd.Size, 32))
+	if _, err := r.ReadAt(data, mvhd.Offset); err != nil || len(data) < 20 {
+		return
+	}
+
+	var created, timescale, duration uint64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return
+		}
+		created = binary.BigEndian.Uint64(data[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(data[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+
+	// Cameras without a clock leave the creation time at zero
+	if created > 0 {
+		createDate := quickTimeEpoch.Add(time.Duration(created) * time.Second)
+		tags["CreateDate"] = createDate.Format("2006:01:02 15:04:05")
+	}
+
+	if timescale > 0 && duration > 0 {
+		seconds := float64(duration) / float64(timescale)
+		tags["Duration"] = strconv.FormatFloat(seconds, 'f', 2, 64) + " s"
+	}
+}
+
+// The ©xyz payload is a string length, a language code and the string, such as "+37.7749-122.4194+010.000/"
+func readMovieLocation(r io.ReaderAt, xyz atom, tags map[string]string) {
+	if xyz.Size < 4 || xyz.Size > 256 {
+		return
+	}
+
+	data := make([]byte, xyz.Size)
+	if _, err := r.ReadAt(data, xyz.Offset); err != nil {
+		return
+	}
+
+	length := int(binary.BigEndian.Uint16(data[0:2]))
+	if length == 0 || 4+length > len(data) {
+		return
+	}
+
+	tags["GPSCoordinates"] = string(data[4 : 4+length])
+}
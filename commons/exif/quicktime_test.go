@@ -0,0 +1,220 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func box(atomType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	b = append(b, atomType...)
+	return append(b, body...)
+}
+
+// An atom with a 64-bit size, which cameras use for media data over 4 GB
+func largeBox(atomType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, 1)
+	b = append(b, atomType...)
+	b = binary.BigEndian.AppendUint64(b, uint64(16+len(body)))
+	return append(b, body...)
+}
+
+func mvhdV0(created, timescale, duration uint32) []byte {
+	b := []byte{0, 0, 0, 0}
+	b = binary.BigEndian.AppendUint32(b, created)
+	b = binary.BigEndian.AppendUint32(b, created) // Modified
+	b = binary.BigEndian.AppendUint32(b, timescale)
+	b = binary.BigEndian.AppendUint32(b, duration)
+	return append(b, make([]byte, 80)...)
+}
+
+func mvhdV1(created uint64, timescale uint32, duration uint64) []byte {
+	b := []byte{1, 0, 0, 0}
+	b = binary.BigEndian.AppendUint64(b, created)
+	b = binary.BigEndian.AppendUint64(b, created) // Modified
+	b = binary.BigEndian.AppendUint32(b, timescale)
+	b = binary.BigEndian.AppendUint64(b, duration)
+	return append(b, make([]byte, 80)...)
+}
+
+func xyz(location string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(location)))
+	b = append(b, 0x15, 0xC7)
+	return append(b, location...)
+}
+
+func TestReadMovieTags(t *testing.T) {
+	// 2024-03-15 14:30:00 UTC
+	const created = 3793357800
+
+	ftyp := box("ftyp", []byte("qt  \x00\x00\x00\x00qt  "))
+	location := box("udta", box("\xa9xyz", xyz("+37.7749-122.4194+010.000/")))
+	movie := append(ftyp, box("moov", box("mvhd", mvhdV0(created, 600, 600*95+300)), location)...)
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name:     "Version 0 header with location",
+			fileName: "clip.mov",
+			data:     movie,
+			expected: map[string]string{
+				"CreateDate":     "2024:03:15 14:30:00",
+				"Duration":       "95.50 s",
+				"GPSCoordinates": "+37.7749-122.4194+010.000/",
+			},
+		},
+		{
+			name:     "Version 1 header after 64-bit media data",
+			fileName: "clip.mp4",
+			data: bytes.Join([][]byte{
+				box("ftyp", []byte("isom")),
+				largeBox("mdat", make([]byte, 64)),
+				box("moov", box("mvhd", mvhdV1(created, 90000, 90000*12))),
+			}, nil),
+			expected: map[string]string{
+				"CreateDate": "2024:03:15 14:30:00",
+				"Duration":   "12.00 s",
+			},
+		},
+		{
+			name:     "Header without a creation time",
+			fileName: "clip.mp4",
+			data:     box("moov", box("mvhd", mvhdV0(0, 1000, 2500))),
+			expected: map[string]string{"Duration": "2.50 s"},
+		},
+		{
+			name:     "Atom running to the end of the file",
+			fileName: "clip.mp4",
+			data:     append(ftyp, append([]byte{0, 0, 0, 0, 'm', 'o', 'o', 'v'}, box("mvhd", mvhdV0(created, 600, 600))...)...),
+			expected: map[string]string{"CreateDate": "2024:03:15 14:30:00", "Duration": "1.00 s"},
+		},
+		{
+			name:     "Truncated movie atom",
+			fileName: "clip.mov",
+			data:     movie[:len(movie)-40],
+			expected: map[string]string{},
+		},
+		{
+			name:     "Atom smaller than its header",
+			fileName: "clip.mov",
+			data:     append(ftyp, 0, 0, 0, 4, 'm', 'o', 'o', 'v'),
+			expected: map[string]string{},
+		},
+		{
+			name:     "Short movie header",
+			fileName: "clip.mov",
+			data:     box("moov", box("mvhd", []byte{0, 0, 0, 0, 1, 2, 3, 4})),
+			expected: map[string]string{},
+		},
+		{
+			name:     "Short version 1 movie header",
+			fileName: "clip.mov",
+			data:     box("moov", box("mvhd", mvhdV1(created, 600, 600)[:28])),
+			expected: map[string]string{},
+		},
+		{
+			name:     "Location longer than its atom",
+			fileName: "clip.mov",
+			data:     box("moov", box("udta", box("\xa9xyz", []byte{0, 200, 0x15, 0xC7, '+', '1'}))),
+			expected: map[string]string{},
+		},
+		{
+			name:     "Empty location",
+			fileName: "clip.mov",
+			data:     box("moov", box("udta", box("\xa9xyz", xyz("")))),
+			expected: map[string]string{},
+		},
+		{
+			name:     "No movie atom",
+			fileName: "clip.mp4",
+			data:     ftyp,
+			expected: map[string]string{},
+		},
+		{
+			name:     "Empty file",
+			fileName: "clip.mp4",
+			data:     []byte{},
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.fileName, tt.data)
+
+			tags, err := nativeExtractor{}.ReadTags(path)
+			if err != nil {
+				t.Fatalf("ReadTags() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("ReadTags() = %v, want %v", tags, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindAtom(t *testing.T) {
+	data := bytes.Join([][]byte{
+		box("ftyp", []byte("isom")),
+		largeBox("mdat", make([]byte, 10)),
+		box("moov", []byte("payload")),
+	}, nil)
+
+	tests := []struct {
+		name     string
+		end      int64
+		atomType string
+		expected atom
+		found    bool
+	}{
+		{
+			name:     "First atom",
+			end:      int64(len(data)),
+			atomType: "ftyp",
+			expected: atom{Type: "ftyp", Offset: 8, Size: 4},
+			found:    true,
+		},
+		{
+			name:     "64-bit size",
+			end:      int64(len(data)),
+			atomType: "mdat",
+			expected: atom{Type: "mdat", Offset: 28, Size: 10},
+			found:    true,
+		},
+		{
+			name:     "After a 64-bit atom",
+			end:      int64(len(data)),
+			atomType: "moov",
+			expected: atom{Type: "moov", Offset: 46, Size: 7},
+			found:    true,
+		},
+		{
+			name:     "Past the end of the range",
+			end:      38,
+			atomType: "moov",
+		},
+		{
+			name:     "Missing",
+			end:      int64(len(data)),
+			atomType: "udta",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := findAtom(bytes.NewReader(data), 0, tt.end, tt.atomType)
+			if found != tt.found || result != tt.expected {
+				t.Errorf("findAtom() = %v, %v, want %v, %v", result, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
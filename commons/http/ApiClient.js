@@ -40,6 +40,10 @@ async function request(method, url, payload) {
     }
 
     if (error instanceof Response) {
+      if (error.status === 401 && !url.startsWith('/api/auth/')) {
+        window.dispatchEvent(new Event('unauthorized'));
+      }
+
       const contentType = error.headers.get('content-type') || '';
 
       if (contentType.includes('application/json')) {
@@ -64,6 +68,11 @@ async function request(method, url, payload) {
   }
 }
 
+const EXCLUDE_FILTER_KEYS = ['excludeYears', 'excludeCameraMakes', 'excludeCameraModels', 'excludePlaces', 'excludeCountries', 'excludeStates', 'excludeCities', 'excludeFileFormats', 'excludeLensModels'];
+
+// Range ends such as isoMin and fileSizeMax, dates, has GPS / notes and the search query
+const VALUE_FILTER_KEYS = ['isoMin', 'isoMax', 'fNumberMin', 'fNumberMax', 'exposureTimeMin', 'exposureTimeMax', 'focalLengthMin', 'focalLengthMax', 'focalLength35mmMin', 'focalLength35mmMax', 'exposureCompensationMin', 'exposureCompensationMax', 'fileSizeMin', 'fileSizeMax', 'megapixelsMin', 'megapixelsMax', 'durationMin', 'durationMax', 'dateFrom', 'dateTo', 'hasGps', 'hasNotes', 'q'];
+
 function buildFilterParams(filters) {
   if (!filters) {
     return ''
@@ -89,6 +98,9 @@ function buildFilterParams(filters) {
   if (filters.cameraModels && filters.cameraModels.length > 0) {
     filters.cameraModels.forEach(m => params.push(`cameraModels=${encodeURIComponent(m)}`));
   }
+  if (filters.places && filters.places.length > 0) {
+    filters.places.forEach(p => params.push(`places=${encodeURIComponent(p)}`));
+  }
   if (filters.countries && filters.countries.length > 0) {
     filters.countries.forEach(c => params.push(`countries=${encodeURIComponent(c)}`));
   }
@@ -101,14 +113,42 @@ function buildFilterParams(filters) {
   if (filters.fileFormats && filters.fileFormats.length > 0) {
     filters.fileFormats.forEach(f => params.push(`fileFormats=${encodeURIComponent(f)}`));
   }
+  if (filters.lensModels && filters.lensModels.length > 0) {
+    filters.lensModels.forEach(l => params.push(`lensModels=${encodeURIComponent(l)}`));
+  }
+  EXCLUDE_FILTER_KEYS.forEach(key => {
+    if (filters[key] && filters[key].length > 0) {
+      filters[key].forEach(v => params.push(`${key}=${encodeURIComponent(v)}`));
+    }
+  });
+  VALUE_FILTER_KEYS.forEach(key => {
+    if (filters[key]) {
+      params.push(`${key}=${encodeURIComponent(filters[key])}`);
+    }
+  });
 
   return params.length > 0 ? '&' + params.join('&') : '';
 }
 
-function buildPhotoUrl(basePath, offset, filters) {
+// page holds either an after/before cursor from a previous response or an offset, and the sort order the
+// cursor was made with
+function buildPhotoUrl(basePath, page, filters, curator) {
   const params = [];
-  if (offset > 0) {
-    params.push(`offset=${offset}`);
+  if (page.after) {
+    params.push(`after=${encodeURIComponent(page.after)}`);
+  } else if (page.before) {
+    params.push(`before=${encodeURIComponent(page.before)}`);
+  } else if (page.offset > 0) {
+    params.push(`offset=${page.offset}`);
+  }
+  if (page.count) {
+    params.push(`count=${page.count}`);
+  }
+  if (page.sort) {
+    params.push(`sort=${encodeURIComponent(page.sort)}`);
+  }
+  if (curator) {
+    params.push(`curator=${encodeURIComponent(curator)}`);
   }
   const filterParams = buildFilterParams(filters);
   if (filterParams) {
@@ -118,23 +158,42 @@ function buildPhotoUrl(basePath, offset, filters) {
   return queryString ? `${basePath}?${queryString}` : basePath;
 }
 
-async function getPhotos(offset, filters) {
-  const url = buildPhotoUrl('/api/photos/', offset, filters);
+async function getPhotos(page, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/', page, filters, curator);
   return await request('GET', url);
 }
 
-async function getUncuratedPhotos(offset, filters) {
-  const url = buildPhotoUrl('/api/photos/uncurated/', offset, filters);
+async function getUncuratedPhotos(page, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/uncurated/', page, filters, curator);
   return await request('GET', url);
 }
 
-async function getTrashedPhotos(offset, filters) {
-  const url = buildPhotoUrl('/api/photos/trashed/', offset, filters);
+async function getTrashedPhotos(page, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/trashed/', page, filters, curator);
   return await request('GET', url);
 }
 
-async function getFilterOptions() {
-  return await request('GET', '/api/photos/filters/');
+// scope is library, uncurated or trash
+async function getTimeline(scope, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/timeline/', {}, filters, curator);
+  return await request('GET', `${url}${url.includes('?') ? '&' : '?'}scope=${scope}`);
+}
+
+// date is a year, month or day; returns the after cursor and offset of the page starting there
+async function getTimelinePosition(scope, date, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/timeline/position/', {}, filters, curator);
+  return await request('GET', `${url}${url.includes('?') ? '&' : '?'}scope=${scope}&date=${encodeURIComponent(date)}`);
+}
+
+// Values of each filter with their photo counts within the scope, given the other active filters
+async function getFilterFacets(scope, filters, curator) {
+  const url = buildPhotoUrl('/api/photos/facets/', {}, filters, curator);
+  return await request('GET', `${url}${url.includes('?') ? '&' : '?'}scope=${scope}`);
+}
+
+async function getAlbumFacets(albumId, filters) {
+  const url = buildPhotoUrl(`/api/albums/${albumId}/facets/`, {}, filters);
+  return await request('GET', url);
 }
 
 async function getCalendarMonths() {
@@ -165,11 +224,81 @@ async function getBurstRebuildProgress() {
   return await request('GET', '/api/burst/rebuild/progress/');
 }
 
-async function curatePhoto(filePath, isCurated, isTrashed, rating) {
-  return await request('POST', '/api/photos/curate/', { filePath, isCurated, isTrashed, rating });
+async function rebuildGeocoding() {
+  return await request('POST', '/api/geocoding/rebuild/', {});
+}
+
+async function getGeocodingRebuildProgress() {
+  return await request('GET', '/api/geocoding/rebuild/progress/');
+}
+
+// Rescans the photos matching filters, or every photo without them
+async function rescanMetadata(filters) {
+  return await request('POST', '/api/metadata/rescan/', filters ? { filters } : {});
+}
+
+async function getMetadataRescanProgress() {
+  return await request('GET', '/api/metadata/rescan/progress/');
+}
+
+async function getGeocodingStatus() {
+  return await request('GET', '/api/geocoding/status/');
+}
+
+async function reloadGeocoding() {
+  return await request('POST', '/api/geocoding/reload/', {});
+}
+
+async function getPlaces() {
+  return await request('GET', '/api/places/');
+}
+
+async function createPlace(place) {
+  return await request('POST', '/api/places/', place);
+}
+
+async function updatePlace(placeId, place) {
+  return await request('PUT', `/api/places/${placeId}/`, place);
+}
+
+async function deletePlace(placeId) {
+  return await request('DELETE', `/api/places/${placeId}/`);
+}
+
+async function getSavedFilters(curator) {
+  const query = curator ? `?curator=${curator}` : '';
+  return await request('GET', `/api/saved-filters/${query}`);
+}
+
+async function createSavedFilter(savedFilter) {
+  return await request('POST', '/api/saved-filters/', savedFilter);
+}
+
+async function updateSavedFilter(savedFilterId, savedFilter) {
+  return await request('PUT', `/api/saved-filters/${savedFilterId}/`, savedFilter);
+}
+
+async function deleteSavedFilter(savedFilterId) {
+  return await request('DELETE', `/api/saved-filters/${savedFilterId}/`);
 }
 
-async function getAlbums() {
+async function curatePhoto(photoId, isCurated, isTrashed, rating, isFavorite) {
+  return await request('POST', '/api/photos/curate/', { photoId, isCurated, isTrashed, rating, isFavorite });
+}
+
+async function shiftPhotoTimes(photoIds, shiftSeconds, utcOffsetMinutes, writeToFile) {
+  return await request('POST', '/api/photos/time-shift/', { photoIds, shiftSeconds, utcOffsetMinutes, writeToFile });
+}
+
+async function getPhotoCurations(photoId) {
+  return await request('GET', `/api/photo/${photoId}/curations/`);
+}
+
+// Pass a parent album ID, or 0 for the top level, to list one level of the album tree
+async function getAlbums(parentAlbumId = null) {
+  if (parentAlbumId !== null) {
+    return await request('GET', `/api/albums/?parent=${parentAlbumId}`);
+  }
   return await request('GET', '/api/albums/');
 }
 
@@ -177,28 +306,61 @@ async function getAlbum(albumId) {
   return await request('GET', `/api/albums/${albumId}/`);
 }
 
-async function createAlbum(name, description) {
-  return await request('POST', '/api/albums/', { name, description });
+async function createAlbum(name, description, smartRules = null, parentAlbumId = null) {
+  return await request('POST', '/api/albums/', { name, description, smartRules, parentAlbumId });
 }
 
-async function addPhotosToAlbums(albumIds, filePaths) {
-  return await request('PUT', '/api/albums/photos/', { albumIds, filePaths });
+async function updateAlbum(albumId, changes) {
+  return await request('PUT', `/api/albums/${albumId}/`, changes);
 }
 
-async function removePhotosFromAlbum(albumId, filePaths) {
-  return await request('DELETE', `/api/albums/${albumId}/photos/`, { filePaths });
+async function reorderAlbumPhotos(albumId, photoIds, atEnd = false) {
+  return await request('PUT', `/api/albums/${albumId}/order/`, { photoIds, atEnd });
+}
+
+async function addPhotosToAlbums(albumIds, photoIds) {
+  return await request('PUT', '/api/albums/photos/', { albumIds, photoIds });
+}
+
+async function removePhotosFromAlbum(albumId, photoIds) {
+  return await request('DELETE', `/api/albums/${albumId}/photos/`, { photoIds });
+}
+
+async function getAlbumShares(albumId) {
+  return await request('GET', `/api/albums/${albumId}/shares/`);
+}
+
+async function createAlbumShare(albumId, share) {
+  return await request('POST', `/api/albums/${albumId}/shares/`, share);
+}
+
+async function deleteAlbumShare(albumId, shareId) {
+  return await request('DELETE', `/api/albums/${albumId}/shares/${shareId}/`);
 }
 
 async function deleteAlbum(albumId) {
   return await request('DELETE', `/api/albums/${albumId}/`);
 }
 
-async function getAlbumPhotos(albumId) {
-  return await request('GET', `/api/albums/${albumId}/photos/`);
+async function getAlbumPhotos(albumId, offset, filters, curator) {
+  const url = buildPhotoUrl(`/api/albums/${albumId}/photos/`, { offset }, filters, curator);
+  return await request('GET', url);
 }
 
-async function getPhotoAlbums(filePath) {
-  return await request('GET', `/api/photo/albums/?path=${encodeURIComponent(filePath)}`);
+async function getPhotoAlbums(photoId) {
+  return await request('GET', `/api/photo/${photoId}/albums/`);
+}
+
+async function previewGeotags(files, offsetSeconds, maxGapSeconds) {
+  const formData = new FormData();
+  files.forEach(file => formData.append('files', file));
+  formData.append('offsetSeconds', offsetSeconds);
+  formData.append('maxGapSeconds', maxGapSeconds);
+  return await request('POST', '/api/geotag/preview/', formData);
+}
+
+async function applyGeotags(matches) {
+  return await request('POST', '/api/geotag/apply/', { matches });
 }
 
 async function startImportSession() {
@@ -246,12 +408,47 @@ async function getExportSessions() {
   return await request('GET', '/api/export/sessions/');
 }
 
+async function getAuthStatus() {
+  return await request('GET', '/api/auth/status/');
+}
+
+async function setupAccount(username, password) {
+  return await request('POST', '/api/auth/setup/', { username, password });
+}
+
+async function login(username, password) {
+  return await request('POST', '/api/auth/login/', { username, password });
+}
+
+async function logout() {
+  return await request('POST', '/api/auth/logout/', {});
+}
+
+async function getUsers() {
+  return await request('GET', '/api/users/');
+}
+
+async function createUser(username, password, role) {
+  return await request('POST', '/api/users/', { username, password, role });
+}
+
+async function updateUser(userId, changes) {
+  return await request('PUT', `/api/users/${userId}/`, changes);
+}
+
+async function deleteUser(userId) {
+  return await request('DELETE', `/api/users/${userId}/`);
+}
+
 export default {
   request,
   getPhotos,
   getUncuratedPhotos,
   getTrashedPhotos,
-  getFilterOptions,
+  getTimeline,
+  getTimelinePosition,
+  getFilterFacets,
+  getAlbumFacets,
   getCalendarMonths,
   getSettings,
   updateSetting,
@@ -259,19 +456,50 @@ export default {
   getThumbnailRebuildProgress,
   rebuildBurstData,
   getBurstRebuildProgress,
+  rebuildGeocoding,
+  getGeocodingRebuildProgress,
+  rescanMetadata,
+  getMetadataRescanProgress,
+  getGeocodingStatus,
+  reloadGeocoding,
+  getPlaces,
+  createPlace,
+  updatePlace,
+  deletePlace,
+  getSavedFilters,
+  createSavedFilter,
+  updateSavedFilter,
+  deleteSavedFilter,
   curatePhoto,
+  getPhotoCurations,
+  shiftPhotoTimes,
   getAlbums,
   getAlbum,
   createAlbum,
+  updateAlbum,
+  reorderAlbumPhotos,
   addPhotosToAlbums,
   removePhotosFromAlbum,
   deleteAlbum,
+  getAlbumShares,
+  createAlbumShare,
+  deleteAlbumShare,
   getAlbumPhotos,
   getPhotoAlbums,
+  previewGeotags,
+  applyGeotags,
   startImportSession,
   getImportProgress,
   getImportSessions,
   startExportSession,
   getExportProgress,
-  getExportSessions
+  getExportSessions,
+  getAuthStatus,
+  setupAccount,
+  login,
+  logout,
+  getUsers,
+  createUser,
+  updateUser,
+  deleteUser
 };
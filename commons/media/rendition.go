@@ -0,0 +1,40 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Display-sized JPEGs for viewers that shouldn't receive the original file (e.g. share links)
+const (
+	RenditionWidth  = 2048
+	RenditionHeight = 2048
+)
+
+func GetRenditionPath(thumbnailPath string) string {
+	return strings.TrimSuffix(thumbnailPath, filepath.Ext(thumbnailPath)) + ".rendition.jpg"
+}
+
+func GenerateRendition(sourcePath, renditionPath string, orientation int) error {
+	if err := os.MkdirAll(filepath.Dir(renditionPath), 0755); err != nil {
+		return fmt.Errorf("failed to create rendition directory: %w", err)
+	}
+
+	imageData, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	renditionData, _, err := ResizeImage(imageData, sourcePath, RenditionWidth, RenditionHeight, orientation)
+	if err != nil {
+		return fmt.Errorf("failed to resize image: %w", err)
+	}
+
+	if err := os.WriteFile(renditionPath, renditionData, 0644); err != nil {
+		return fmt.Errorf("failed to write rendition: %w", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package media
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,6 +26,11 @@ func GenerateThumbnail(sourcePath, thumbnailPath string, orientation int, isVide
 		if err != nil {
 			return fmt.Errorf("failed to generate video thumbnail: %w", err)
 		}
+
+		// Previews are a nice-to-have for hover scrubbing, so a failure here shouldn't fail the thumbnail
+		if err := GenerateVideoPreviews(sourcePath, thumbnailPath); err != nil {
+			slog.Warn("failed to generate video previews", "file", sourcePath, "error", err)
+		}
 	} else {
 		imageData, err := os.ReadFile(sourcePath)
 		if err != nil {
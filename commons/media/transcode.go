@@ -0,0 +1,90 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Containers and codecs that browsers can play natively through a <video> element.
+// Anything outside these (MKV, AVI, WMV, HEVC, ProRes, etc.) is served through an H.264/AAC MP4 proxy.
+var (
+	playableContainers  = []string{".mp4", ".m4v", ".mov", ".webm"}
+	playableVideoCodecs = []string{"h264", "vp8", "vp9", "av1"}
+	playableAudioCodecs = []string{"", "aac", "mp3", "opus", "vorbis"}
+)
+
+func IsBrowserPlayable(filePath string, info *VideoInfo) bool {
+	if info == nil {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	return contains(playableContainers, ext) &&
+		contains(playableVideoCodecs, info.VideoCodec) &&
+		contains(playableAudioCodecs, info.AudioCodec)
+}
+
+func GenerateVideoProxy(sourcePath, proxyPath string) error {
+	if err := os.MkdirAll(filepath.Dir(proxyPath), 0755); err != nil {
+		return fmt.Errorf("failed to create proxy directory: %w", err)
+	}
+
+	// Write to a temp file first so a half-written proxy is never served
+	tempPath := proxyPath + ".tmp"
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-i", sourcePath,
+		"-map", "0:v:0",
+		"-map", "0:a:0?", // Audio is optional
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "23",
+		"-pix_fmt", "yuv420p", // 10-bit and 4:2:2 sources won't play in browsers otherwise
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", // libx264 requires even dimensions
+		"-c:a", "aac",
+		"-b:a", "160k",
+		"-movflags", "+faststart", // Move moov atom to the front so playback and seeking can start before the download completes
+		"-f", "mp4",
+		"-loglevel", "error",
+		tempPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		os.Remove(tempPath)
+		slog.Error("ffmpeg failed to generate video proxy", "file", sourcePath, "error", err, "stderr", stderr.String())
+		return fmt.Errorf("failed to generate video proxy: %w", err)
+	}
+
+	if err := os.Rename(tempPath, proxyPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move video proxy into place: %w", err)
+	}
+
+	return nil
+}
+
+func GetVideoProxyPath(libraryPath, thumbnailsPath, videoPath string) string {
+	thumbnailPath := GetThumbnailPath(libraryPath, thumbnailsPath, videoPath)
+	return strings.TrimSuffix(thumbnailPath, filepath.Ext(thumbnailPath)) + ".mp4"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -2,17 +2,27 @@ package media
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
 	"os/exec"
+	"strconv"
+	"strings"
 )
 
 func GenerateVideoThumbnail(filePath string, maxWidth, maxHeight int) ([]byte, string, error) {
+	duration := 0.0
+	if info, err := ProbeVideo(filePath); err == nil {
+		duration = info.Duration
+	}
+
 	cmd := exec.Command(
 		"ffmpeg",
+		"-ss", formatSeconds(representativeTimestamp(duration)), // Seek before decoding, so long clips don't decode from the start
 		"-i", filePath,
 		"-vframes", "1", // Extract 1 frame
-		"-vf", fmt.Sprintf("scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease", maxWidth, maxHeight), // Scale to max dimensions while preserving aspect ratio
+		"-vf", fmt.Sprintf("thumbnail=%d,scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease", representativeSkip, maxWidth, maxHeight), // Pick the most representative frame near the seek point, then scale to max dimensions while preserving aspect ratio
 		"-q:v", "2", // Better quality
 		"-f", "image2pipe",
 		"-an", // Disable audio processing
@@ -37,3 +47,119 @@ func GenerateVideoThumbnail(filePath string, maxWidth, maxHeight int) ([]byte, s
 
 	return thumbnailData, "image/jpeg", nil
 }
+
+type VideoInfo struct {
+	VideoCodec string
+	AudioCodec string
+	Width      int
+	Height     int
+	FrameRate  float64
+	Rotation   int
+	Duration   float64
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		AvgFrameRate string            `json:"avg_frame_rate"`
+		RFrameRate   string            `json:"r_frame_rate"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []struct {
+			Rotation int `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+func ProbeVideo(filePath string) (*VideoInfo, error) {
+	cmd := exec.Command(
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_streams",
+		"-show_format",
+		filePath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		slog.Error("ffprobe failed to read video", "file", filePath, "error", err, "stderr", stderr.String())
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	var output ffprobeOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &VideoInfo{}
+	hasVideo := false
+
+	for _, stream := range output.Streams {
+		switch stream.CodecType {
+		case "video":
+			if hasVideo {
+				continue
+			}
+			hasVideo = true
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+			info.FrameRate = parseFrameRate(stream.AvgFrameRate)
+			if info.FrameRate == 0 {
+				info.FrameRate = parseFrameRate(stream.RFrameRate)
+			}
+			// Older files carry rotation as a "rotate" tag, newer ffmpeg builds expose it as display matrix side data
+			if rotate, ok := stream.Tags["rotate"]; ok {
+				info.Rotation, _ = strconv.Atoi(rotate)
+			}
+			for _, sideData := range stream.SideDataList {
+				if sideData.Rotation != 0 {
+					info.Rotation = -sideData.Rotation
+				}
+			}
+			info.Rotation = ((info.Rotation % 360) + 360) % 360
+		case "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = stream.CodecName
+			}
+		}
+	}
+
+	if !hasVideo {
+		return nil, fmt.Errorf("no video stream found")
+	}
+
+	info.Duration, _ = strconv.ParseFloat(output.Format.Duration, 64)
+
+	return info, nil
+}
+
+// "30000/1001" -> 29.97
+func parseFrameRate(rate string) float64 {
+	parts := strings.Split(rate, "/")
+	if len(parts) != 2 {
+		return 0
+	}
+
+	numerator, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0
+	}
+
+	denominator, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || denominator == 0 {
+		return 0
+	}
+
+	return math.Round(numerator/denominator*100) / 100
+}
@@ -0,0 +1,180 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PreviewWidth       = 300
+	PreviewFrameRate   = 8
+	PreviewDuration    = 3.0
+	SpriteFrameWidth   = 160
+	SpriteFrameHeight  = 90
+	SpriteColumns      = 10
+	SpriteMaxFrames    = 100
+	SpriteMinInterval  = 1.0
+	representativeSkip = 50 // Frames the thumbnail filter compares when picking a representative frame
+)
+
+// Picks the timestamp to grab the poster frame from. The first frame of a clip is often black
+// (fade-in, lens cap, phone still in pocket), so start from the middle of the clip instead.
+func representativeTimestamp(duration float64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	return duration / 2
+}
+
+// Generates an animated WebP preview, a sprite sheet of evenly spaced frames and a WebVTT
+// index of the sprite for hover scrubbing. Files are written next to the thumbnail.
+func GenerateVideoPreviews(sourcePath, thumbnailPath string) error {
+	info, err := ProbeVideo(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	if err := generateAnimatedPreview(sourcePath, GetVideoPreviewPath(thumbnailPath), info.Duration); err != nil {
+		return err
+	}
+
+	if err := generateSpriteSheet(sourcePath, GetVideoSpritePath(thumbnailPath), GetVideoSpriteVTTPath(thumbnailPath), info.Duration); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func generateAnimatedPreview(sourcePath, previewPath string, duration float64) error {
+	start := 0.0
+	if duration > PreviewDuration {
+		start = (duration - PreviewDuration) / 2
+	}
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-ss", formatSeconds(start),
+		"-t", formatSeconds(PreviewDuration),
+		"-i", sourcePath,
+		"-vf", fmt.Sprintf("fps=%d,scale='min(%d,iw)':-2", PreviewFrameRate, PreviewWidth),
+		"-c:v", "libwebp",
+		"-loop", "0",
+		"-q:v", "60",
+		"-an",
+		"-loglevel", "error",
+		previewPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(previewPath)
+		slog.Error("ffmpeg failed to generate animated preview", "file", sourcePath, "error", err, "stderr", stderr.String())
+		return fmt.Errorf("failed to generate animated preview: %w", err)
+	}
+
+	return nil
+}
+
+func generateSpriteSheet(sourcePath, spritePath, vttPath string, duration float64) error {
+	if duration <= 0 {
+		return fmt.Errorf("unknown video duration")
+	}
+
+	interval := math.Max(SpriteMinInterval, duration/SpriteMaxFrames)
+	frameCount := int(math.Ceil(duration / interval))
+	if frameCount > SpriteMaxFrames {
+		frameCount = SpriteMaxFrames
+	}
+	rows := int(math.Ceil(float64(frameCount) / SpriteColumns))
+
+	filter := fmt.Sprintf(
+		"fps=1/%s,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		formatSeconds(interval),
+		SpriteFrameWidth, SpriteFrameHeight,
+		SpriteFrameWidth, SpriteFrameHeight,
+		SpriteColumns, rows,
+	)
+
+	cmd := exec.Command(
+		"ffmpeg",
+		"-y",
+		"-i", sourcePath,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "5",
+		"-an",
+		"-loglevel", "error",
+		spritePath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(spritePath)
+		slog.Error("ffmpeg failed to generate sprite sheet", "file", sourcePath, "error", err, "stderr", stderr.String())
+		return fmt.Errorf("failed to generate sprite sheet: %w", err)
+	}
+
+	vtt := buildSpriteVTT(filepath.Base(spritePath), frameCount, interval, duration)
+	if err := os.WriteFile(vttPath, []byte(vtt), 0644); err != nil {
+		return fmt.Errorf("failed to write sprite index: %w", err)
+	}
+
+	return nil
+}
+
+// Each cue points at one tile of the sprite using the media fragment syntax, e.g.
+//
+//	00:00:02.000 --> 00:00:04.000
+//	video.sprite.jpg#xywh=160,0,160,90
+func buildSpriteVTT(spriteName string, frameCount int, interval, duration float64) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+
+	for i := 0; i < frameCount; i++ {
+		start := float64(i) * interval
+		end := math.Min(start+interval, duration)
+		x := (i % SpriteColumns) * SpriteFrameWidth
+		y := (i / SpriteColumns) * SpriteFrameHeight
+
+		fmt.Fprintf(&sb, "%s --> %s\n", formatVTTTimestamp(start), formatVTTTimestamp(end))
+		fmt.Fprintf(&sb, "%s#xywh=%d,%d,%d,%d\n\n", spriteName, x, y, SpriteFrameWidth, SpriteFrameHeight)
+	}
+
+	return sb.String()
+}
+
+func formatVTTTimestamp(seconds float64) string {
+	totalMillis := int(math.Round(seconds * 1000))
+	hours := totalMillis / 3600000
+	minutes := (totalMillis / 60000) % 60
+	secs := (totalMillis / 1000) % 60
+	millis := totalMillis % 1000
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, secs, millis)
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+func GetVideoPreviewPath(thumbnailPath string) string {
+	return strings.TrimSuffix(thumbnailPath, filepath.Ext(thumbnailPath)) + ".preview.webp"
+}
+
+func GetVideoSpritePath(thumbnailPath string) string {
+	return strings.TrimSuffix(thumbnailPath, filepath.Ext(thumbnailPath)) + ".sprite.jpg"
+}
+
+func GetVideoSpriteVTTPath(thumbnailPath string) string {
+	return strings.TrimSuffix(thumbnailPath, filepath.Ext(thumbnailPath)) + ".sprite.vtt"
+}
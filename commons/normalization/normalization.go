@@ -158,6 +158,56 @@ func NormalizeFocalLength(focalLength string) *float64 {
 	return &val
 }
 
+// Normalize exposure compensation to signed EV, written like an exposure time
+// "-2/3", "+1/3", "+1", "0" -> -0.666..., 0.333..., 1.0, 0.0
+func NormalizeExposureCompensation(compensation string) *float64 {
+	compensation = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(compensation), "EV"))
+	return NormalizeExposureTime(compensation)
+}
+
+// Normalize altitude to meters, negative below sea level. The reference comes on its own or as part of the value.
+// ("123.4 m Above Sea Level", "") -> 123.4
+// ("12 m", "Below Sea Level"), ("12", "1") -> -12
+func NormalizeAltitude(altitude string, ref string) *float64 {
+	if altitude == "" {
+		return nil
+	}
+
+	matches := regexp.MustCompile(`^\s*([\d.]+)`).FindStringSubmatch(altitude)
+	if len(matches) != 2 {
+		return nil
+	}
+
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return nil
+	}
+
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(altitude, "Below") || strings.HasPrefix(ref, "Below") || ref == "1" {
+		val = -val
+	}
+
+	return &val
+}
+
+// Normalize GPS image direction to degrees
+// "123.45", "123.45 deg" -> 123.45
+func NormalizeGPSDirection(direction string) *float64 {
+	if direction == "" {
+		return nil
+	}
+
+	direction = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(direction), "deg"))
+
+	val, err := strconv.ParseFloat(direction, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &val
+}
+
 // Normalize duration string to total seconds
 // "0:01:23", "00:00:05", "1:30", "45" -> 83, 5, 90, 45
 // "12.68 s" -> 12
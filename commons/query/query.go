@@ -0,0 +1,310 @@
+// Parses search queries such as `iso:>3200 camera:"X-T5" -country:Japan` into a tree of terms.
+// Terms next to each other are ANDed; OR, NOT, a leading - and parentheses combine them further.
+// Keys aren't checked here, that's up to whoever turns the tree into a search.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+type Op string
+
+const (
+	OpEqual          Op = "="
+	OpGreater        Op = ">"
+	OpGreaterOrEqual Op = ">="
+	OpLess           Op = "<"
+	OpLessOrEqual    Op = "<="
+	OpRange          Op = ".." // Value..To, both ends included
+)
+
+type Node interface {
+	isNode()
+}
+
+type And struct {
+	Nodes []Node
+}
+
+type Or struct {
+	Nodes []Node
+}
+
+type Not struct {
+	Node Node
+}
+
+// A key:value condition, or free text when Key is empty
+type Term struct {
+	Key   string // Lowercased
+	Op    Op
+	Value string
+	To    string // Upper end of a range
+}
+
+func (And) isNode()  {}
+func (Or) isNode()   {}
+func (Not) isNode()  {}
+func (Term) isNode() {}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	term Term
+	text string
+}
+
+// Returns nil for a blank query
+func Parse(input string) (Node, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %s", ErrInvalidQuery, p.tokens[p.pos].text)
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) parseOr() (Node, error) {
+	nodes := []Node{}
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		if t := p.peek(); t == nil || t.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	nodes := []Node{}
+	for {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		t := p.peek()
+		if t == nil || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+		}
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.peek()
+	if t != nil && t.kind == tokenNot {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("%w: query ends too early", ErrInvalidQuery)
+	}
+
+	switch t.kind {
+	case tokenTerm:
+		p.pos++
+		return t.term, nil
+	case tokenOpen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing == nil || closing.kind != tokenClose {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", ErrInvalidQuery)
+		}
+		p.pos++
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("%w: unexpected %s", ErrInvalidQuery, t.text)
+}
+
+func tokenize(input string) ([]token, error) {
+	runes := []rune(input)
+	tokens := []token{}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, token{kind: tokenNot, text: "-"})
+			i++
+		case r == '"':
+			text, next, err := readQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenTerm, term: Term{Op: OpEqual, Value: text}, text: string(runes[i:next])})
+			i = next
+		default:
+			t, next, err := readTerm(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = next
+		}
+	}
+
+	return tokens, nil
+}
+
+func isTermEnd(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')'
+}
+
+func readQuoted(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end >= len(runes) {
+		return "", 0, fmt.Errorf("%w: missing closing quote", ErrInvalidQuery)
+	}
+	return string(runes[start+1 : end]), end + 1, nil
+}
+
+// Reads a word, which is a key:value term when it has a colon. Only uppercase AND, OR and NOT are keywords.
+func readTerm(runes []rune, start int) (token, int, error) {
+	i := start
+	for i < len(runes) && !isTermEnd(runes[i]) && runes[i] != ':' {
+		i++
+	}
+	word := string(runes[start:i])
+
+	if i >= len(runes) || runes[i] != ':' {
+		switch word {
+		case "AND":
+			return token{kind: tokenAnd, text: word}, i, nil
+		case "OR":
+			return token{kind: tokenOr, text: word}, i, nil
+		case "NOT":
+			return token{kind: tokenNot, text: word}, i, nil
+		}
+		return token{kind: tokenTerm, term: Term{Op: OpEqual, Value: word}, text: word}, i, nil
+	}
+
+	if word == "" {
+		return token{}, 0, fmt.Errorf("%w: missing field name before :", ErrInvalidQuery)
+	}
+
+	term := Term{Key: strings.ToLower(word), Op: OpEqual}
+	i++
+
+	if i < len(runes) && runes[i] == '"' {
+		value, next, err := readQuoted(runes, i)
+		if err != nil {
+			return token{}, 0, err
+		}
+		term.Value = value
+		return token{kind: tokenTerm, term: term, text: string(runes[start:next])}, next, nil
+	}
+
+	valueStart := i
+	for i < len(runes) && !isTermEnd(runes[i]) {
+		i++
+	}
+	value := string(runes[valueStart:i])
+
+	for _, op := range []Op{OpGreaterOrEqual, OpLessOrEqual, OpGreater, OpLess, OpEqual} {
+		if strings.HasPrefix(value, string(op)) {
+			term.Op = op
+			value = strings.TrimPrefix(value, string(op))
+			break
+		}
+	}
+
+	if from, to, ok := strings.Cut(value, ".."); ok && term.Op == OpEqual {
+		if from == "" || to == "" {
+			return token{}, 0, fmt.Errorf("%w: %s needs both ends of the range", ErrInvalidQuery, word)
+		}
+		term.Op = OpRange
+		term.Value = from
+		term.To = to
+	} else {
+		term.Value = value
+	}
+
+	if term.Value == "" {
+		return token{}, 0, fmt.Errorf("%w: missing value for %s", ErrInvalidQuery, word)
+	}
+
+	return token{kind: tokenTerm, term: term, text: string(runes[start:i])}, i, nil
+}
@@ -0,0 +1,121 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Node
+	}{
+		{
+			name:     "Blank query has no tree",
+			input:    "   ",
+			expected: nil,
+		},
+		{
+			name:     "Free text",
+			input:    "sunset",
+			expected: Term{Op: OpEqual, Value: "sunset"},
+		},
+		{
+			name:     "Comparison and lowercased key",
+			input:    "ISO:>3200",
+			expected: Term{Key: "iso", Op: OpGreater, Value: "3200"},
+		},
+		{
+			name:     "Quoted value keeps spaces and dashes",
+			input:    `camera:"X-T5 II"`,
+			expected: Term{Key: "camera", Op: OpEqual, Value: "X-T5 II"},
+		},
+		{
+			name:     "Range",
+			input:    "date:2019-01..2019-06",
+			expected: Term{Key: "date", Op: OpRange, Value: "2019-01", To: "2019-06"},
+		},
+		{
+			name:  "Adjacent terms are ANDed and a dash negates",
+			input: `iso:>3200 camera:"X-T5" -country:Japan`,
+			expected: And{Nodes: []Node{
+				Term{Key: "iso", Op: OpGreater, Value: "3200"},
+				Term{Key: "camera", Op: OpEqual, Value: "X-T5"},
+				Not{Node: Term{Key: "country", Op: OpEqual, Value: "Japan"}},
+			}},
+		},
+		{
+			name:  "AND binds tighter than OR",
+			input: "a OR b AND c",
+			expected: Or{Nodes: []Node{
+				Term{Op: OpEqual, Value: "a"},
+				And{Nodes: []Node{Term{Op: OpEqual, Value: "b"}, Term{Op: OpEqual, Value: "c"}}},
+			}},
+		},
+		{
+			name:  "Parentheses group",
+			input: "NOT (country:Japan OR country:Korea) has:gps",
+			expected: And{Nodes: []Node{
+				Not{Node: Or{Nodes: []Node{
+					Term{Key: "country", Op: OpEqual, Value: "Japan"},
+					Term{Key: "country", Op: OpEqual, Value: "Korea"},
+				}}},
+				Term{Key: "has", Op: OpEqual, Value: "gps"},
+			}},
+		},
+		{
+			name:  "Dash negates a group",
+			input: "-(country:Japan OR has:gps)",
+			expected: Not{Node: Or{Nodes: []Node{
+				Term{Key: "country", Op: OpEqual, Value: "Japan"},
+				Term{Key: "has", Op: OpEqual, Value: "gps"},
+			}}},
+		},
+		{
+			name:     "Lowercase or is just a word",
+			input:    "or",
+			expected: Term{Op: OpEqual, Value: "or"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.input, err)
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Parse(%q)\nexpected: %#v\nactual:   %#v", tt.input, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Unclosed parenthesis", "(iso:100"},
+		{"Stray closing parenthesis", "iso:100)"},
+		{"Unclosed quote", `camera:"X-T5`},
+		{"Missing value", "iso:"},
+		{"Missing comparison value", "iso:>"},
+		{"Open range", "iso:100.."},
+		{"Missing key", ":100"},
+		{"Dangling OR", "iso:100 OR"},
+		{"Leading AND", "AND iso:100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Parse(%q) expected ErrInvalidQuery, got %v", tt.input, err)
+			}
+		})
+	}
+}
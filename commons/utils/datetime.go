@@ -2,52 +2,101 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
+// Wall clock time as the camera showed it, without an offset. Sorts as text like date_time.
+const LocalDateTimeFormat = "2006-01-02T15:04:05"
+
+// When a photo was taken, in the timezone it was taken in
+type CaptureTime struct {
+	Time      time.Time
+	HasOffset bool // False when the file didn't record a timezone and the server's local timezone was assumed
+}
+
 // Converts datetime string to UTC in RFC3339 format.
 //
 // Photos taken across different timezones need UTC normalization for correct chronological
 // sorting. For example, a photo at 20:09+05:30 (India) is actually later than one at
 // 20:34+08:00 (Singapore) - but SQLite text sorting would order them wrong since "20:09" < "20:34".
 // Converting to UTC (14:39Z vs 12:34Z) makes text sorting work correctly.
+func NormalizeDateTime(dtStr string, exifData map[string]any) string {
+	captureTime := ParseCaptureTime(dtStr, exifData)
+	if captureTime == nil {
+		return dtStr
+	}
+	return captureTime.UTC()
+}
+
+// Parses an EXIF datetime keeping the timezone it was taken in, so the photo can be both sorted
+// in UTC and grouped by the day it was taken on. Returns nil when the datetime can't be parsed.
 //
 // Stages:
-//  1. If the input already has a timezone, parse and convert to UTC
+//  1. If the input already has a timezone, use it
 //  2. If no timezone, try OffsetTimeOriginal from EXIF (e.g., "+05:30")
 //  3. If no offset, try calculating from GPSDateTime (which is always UTC)
 //  4. Fallback: assume local timezone
-func NormalizeDateTime(dtStr string, exifData map[string]any) string {
+func ParseCaptureTime(dtStr string, exifData map[string]any) *CaptureTime {
 	if t := parseWithTimezone(dtStr); t != nil {
-		return t.UTC().Format(time.RFC3339)
+		return &CaptureTime{Time: *t, HasOffset: true}
 	}
 
 	parsedTime := parseWithoutTimezone(dtStr)
 	if parsedTime == nil {
-		return dtStr
+		return nil
 	}
 
 	if offsetStr, ok := exifData["OffsetTimeOriginal"].(string); ok && offsetStr != "" {
 		if loc := parseTimezoneOffset(offsetStr); loc != nil {
-			t := time.Date(parsedTime.Year(), parsedTime.Month(), parsedTime.Day(),
-				parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, loc)
-			return t.UTC().Format(time.RFC3339)
+			return &CaptureTime{Time: inLocation(*parsedTime, loc), HasOffset: true}
 		}
 	}
 
 	if gpsDateTimeStr, ok := exifData["GPSDateTime"].(string); ok && gpsDateTimeStr != "" {
 		if gpsTime := parseGPSDateTime(gpsDateTimeStr); gpsTime != nil {
-			offsetSeconds := int(parsedTime.Sub(*gpsTime).Seconds())
+			// The GPS fix is usually a few seconds off the shutter, while timezones are whole quarter hours
+			offsetSeconds := int(math.Round(parsedTime.Sub(*gpsTime).Seconds()/900)) * 900
 			loc := time.FixedZone("", offsetSeconds)
-			t := time.Date(parsedTime.Year(), parsedTime.Month(), parsedTime.Day(),
-				parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, loc)
-			return t.UTC().Format(time.RFC3339)
+			return &CaptureTime{Time: inLocation(*parsedTime, loc), HasOffset: true}
 		}
 	}
 
-	t := time.Date(parsedTime.Year(), parsedTime.Month(), parsedTime.Day(),
-		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, time.Local)
-	return t.UTC().Format(time.RFC3339)
+	return &CaptureTime{Time: inLocation(*parsedTime, time.Local), HasOffset: false}
+}
+
+// Same wall clock time in another location
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// RFC3339 in UTC, as stored in date_time
+func (c CaptureTime) UTC() string {
+	return c.Time.UTC().Format(time.RFC3339)
+}
+
+// Wall clock time where the photo was taken, as stored in local_date_time
+func (c CaptureTime) LocalDateTime() string {
+	return c.Time.Format(LocalDateTimeFormat)
+}
+
+// Minutes east of UTC as stored in utc_offset, nil when the timezone wasn't recorded
+func (c CaptureTime) UTCOffset() any {
+	if !c.HasOffset {
+		return nil
+	}
+	_, offsetSeconds := c.Time.Zone()
+	return offsetSeconds / 60
+}
+
+// Formats minutes east of UTC as "+05:30", the form EXIF offset tags use
+func FormatUTCOffset(offsetMinutes int) string {
+	sign := "+"
+	if offsetMinutes < 0 {
+		sign = "-"
+		offsetMinutes = -offsetMinutes
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offsetMinutes/60, offsetMinutes%60)
 }
 
 func ParseDateTime(dtStr string) *time.Time {
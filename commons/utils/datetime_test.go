@@ -164,3 +164,81 @@ func TestNormalizeDateTime_ChronologicalOrdering(t *testing.T) {
 		t.Errorf("India time should be later than Singapore time after UTC conversion\nIndia:     %s\nSingapore: %s", indiaTime, singaporeTime)
 	}
 }
+
+func TestParseCaptureTime(t *testing.T) {
+	tests := []struct {
+		name                  string
+		input                 string
+		exif                  map[string]any
+		expectedUTC           string
+		expectedLocalDateTime string
+		expectedOffset        any
+	}{
+		{
+			name:                  "Evening shot keeps its local day",
+			input:                 "2023:06:15 23:30:00",
+			exif:                  map[string]any{"OffsetTimeOriginal": "+05:30"},
+			expectedUTC:           "2023-06-15T18:00:00Z",
+			expectedLocalDateTime: "2023-06-15T23:30:00",
+			expectedOffset:        330,
+		},
+		{
+			name:                  "Negative offset moves UTC to the next day",
+			input:                 "2023-06-15 20:00:00-07:00",
+			exif:                  map[string]any{},
+			expectedUTC:           "2023-06-16T03:00:00Z",
+			expectedLocalDateTime: "2023-06-15T20:00:00",
+			expectedOffset:        -420,
+		},
+		{
+			name:                  "GPS offset is rounded to a quarter hour",
+			input:                 "2023:06:15 10:30:45",
+			exif:                  map[string]any{"GPSDateTime": "2023:06:15 05:00:41Z"},
+			expectedUTC:           "2023-06-15T05:00:45Z",
+			expectedLocalDateTime: "2023-06-15T10:30:45",
+			expectedOffset:        330,
+		},
+		{
+			name:                  "Local timezone fallback has no offset",
+			input:                 "2023:06:15 10:30:45",
+			exif:                  map[string]any{},
+			expectedLocalDateTime: "2023-06-15T10:30:45",
+			expectedOffset:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureTime := ParseCaptureTime(tt.input, tt.exif)
+			if captureTime == nil {
+				t.Fatalf("ParseCaptureTime(%q) returned nil", tt.input)
+			}
+
+			if tt.expectedUTC != "" && captureTime.UTC() != tt.expectedUTC {
+				t.Errorf("UTC()\nexpected: %q\nactual:   %q", tt.expectedUTC, captureTime.UTC())
+			}
+
+			if captureTime.LocalDateTime() != tt.expectedLocalDateTime {
+				t.Errorf("LocalDateTime()\nexpected: %q\nactual:   %q", tt.expectedLocalDateTime, captureTime.LocalDateTime())
+			}
+
+			if captureTime.UTCOffset() != tt.expectedOffset {
+				t.Errorf("UTCOffset()\nexpected: %v\nactual:   %v", tt.expectedOffset, captureTime.UTCOffset())
+			}
+		})
+	}
+
+	if ParseCaptureTime("not-a-date", map[string]any{}) != nil {
+		t.Errorf("Expected nil for an unparseable datetime")
+	}
+}
+
+func TestFormatUTCOffset(t *testing.T) {
+	tests := map[int]string{330: "+05:30", -420: "-07:00", 0: "+00:00", -210: "-03:30"}
+
+	for offsetMinutes, expected := range tests {
+		if actual := FormatUTCOffset(offsetMinutes); actual != expected {
+			t.Errorf("FormatUTCOffset(%d)\nexpected: %q\nactual:   %q", offsetMinutes, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// In thirds of a stop like cameras show it: -2/3 EV, +1 1/3 EV
+export default function formatExposureCompensation(ev) {
+  const value = parseFloat(ev);
+  if (isNaN(value)) {
+    return null;
+  }
+
+  const thirds = Math.round(Math.abs(value) * 3);
+  if (thirds === 0) {
+    return '0 EV';
+  }
+
+  const sign = value < 0 ? '-' : '+';
+  const whole = Math.floor(thirds / 3);
+  const fraction = thirds % 3 === 0 ? '' : `${thirds % 3}/3`;
+  const stops = [whole || null, fraction || null].filter(Boolean).join(' ');
+  return `${sign}${stops} EV`;
+}
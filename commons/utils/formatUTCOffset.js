@@ -0,0 +1,13 @@
+// Formats minutes east of UTC as "UTC+05:30", or an empty string when the timezone isn't known
+export default function formatUTCOffset(offsetMinutes) {
+  if (offsetMinutes === null || offsetMinutes === undefined) {
+    return '';
+  }
+
+  const sign = offsetMinutes < 0 ? '-' : '+';
+  const absolute = Math.abs(offsetMinutes);
+  const hours = String(Math.floor(absolute / 60)).padStart(2, '0');
+  const minutes = String(absolute % 60).padStart(2, '0');
+
+  return `UTC${sign}${hours}:${minutes}`;
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func CheckDirectories(paths ...string) error {
@@ -20,3 +22,29 @@ func CheckDirectories(paths ...string) error {
 	}
 	return nil
 }
+
+// Converts an absolute path inside the library to the library-relative form stored in the database
+func ToLibraryRelativePath(libraryPath, absolutePath string) (string, error) {
+	relativePath, err := filepath.Rel(filepath.Clean(libraryPath), filepath.Clean(absolutePath))
+	if err != nil {
+		return "", fmt.Errorf("path is not in library: %w", err)
+	}
+	if relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is not in library: %s", absolutePath)
+	}
+	return relativePath, nil
+}
+
+// Resolves a library-relative path to an absolute path, rejecting anything that would escape the library
+func ResolveLibraryPath(libraryPath, relativePath string) (string, error) {
+	if relativePath == "" || filepath.IsAbs(relativePath) {
+		return "", fmt.Errorf("invalid library path: %q", relativePath)
+	}
+
+	absolutePath := filepath.Join(libraryPath, relativePath)
+	if _, err := ToLibraryRelativePath(libraryPath, absolutePath); err != nil {
+		return "", err
+	}
+
+	return absolutePath, nil
+}
@@ -1,4 +1,3 @@
-export default function getPhotoUrl(filePath) {
-  const encoded = btoa(filePath);
-  return `/api/photo/?path=${encoded}`;
+export default function getPhotoUrl(photoId) {
+  return `/api/photo/${photoId}/`;
 }
@@ -1,4 +1,3 @@
-export default function getThumbnailUrl(filePath) {
-  const encoded = btoa(filePath);
-  return `/api/thumbnails/?path=${encoded}`;
+export default function getThumbnailUrl(photoId) {
+  return `/api/thumbnails/${photoId}/`;
 }
@@ -0,0 +1,7 @@
+export default function getVideoPreviewUrls(photoId) {
+  return {
+    animated: `/api/thumbnails/${photoId}/preview/`,
+    sprite: `/api/thumbnails/${photoId}/sprite/`,
+    spriteIndex: `/api/thumbnails/${photoId}/sprite/vtt/`
+  };
+}
@@ -0,0 +1,3 @@
+export default function getVideoUrl(photoId) {
+  return `/api/photo/${photoId}/video/`;
+}
@@ -34,6 +34,30 @@ func ValidateExifData(data map[string]any) map[string]any {
 					validated[key] = result
 				}
 			}
+		case "FocalLength35mm":
+			if v, ok := value.(float64); ok {
+				if result, valid := validateFocalLength35mm(v); valid {
+					validated[key] = result
+				}
+			}
+		case "ExposureCompensation":
+			if v, ok := value.(float64); ok {
+				if result, valid := validateExposureCompensation(v); valid {
+					validated[key] = result
+				}
+			}
+		case "Altitude":
+			if v, ok := value.(float64); ok {
+				if result, valid := validateAltitude(v); valid {
+					validated[key] = result
+				}
+			}
+		case "GPSDirection":
+			if v, ok := value.(float64); ok {
+				if result, valid := validateGPSDirection(v); valid {
+					validated[key] = result
+				}
+			}
 		case "Latitude":
 			if v, ok := value.(float64); ok {
 				if result, valid := validateLatitude(v); valid {
@@ -106,6 +130,45 @@ func validateFocalLength(focal float64) (float64, bool) {
 	return focal, true
 }
 
+// Check if 35mm equivalent focal length is set; cameras that don't know it write 0
+func validateFocalLength35mm(focal float64) (float64, bool) {
+	if focal <= 0 {
+		return 0, false // Reject unknown values
+	}
+
+	return validateFocalLength(focal)
+}
+
+// Check if exposure compensation is within reasonable range (-10 to +10 EV)
+func validateExposureCompensation(ev float64) (float64, bool) {
+	if ev < -10 || ev > 10 {
+		slog.Warn("exposure compensation out of expected range", "exposureCompensation", ev, "expected", "-10 to +10 EV")
+		// Don't reject - some cameras allow more in bracketing
+	}
+
+	return ev, true
+}
+
+// Check if altitude is within reasonable range (-500m to 10000m)
+func validateAltitude(alt float64) (float64, bool) {
+	if alt < -500 || alt > 10000 {
+		slog.Warn("altitude out of expected range", "altitude", alt, "expected", "-500 to 10000m")
+		// Don't reject - photos taken from planes or under water can exceed this
+	}
+
+	return alt, true
+}
+
+// Check if GPS direction is within valid range (0 to 360)
+func validateGPSDirection(direction float64) (float64, bool) {
+	if direction < 0 || direction > 360 {
+		slog.Warn("invalid gps direction", "gpsDirection", direction, "valid_range", "0 to 360")
+		return 0, false // Reject invalid directions
+	}
+
+	return direction, true
+}
+
 // Check if latitude is within valid range (-90 to 90)
 func validateLatitude(lat float64) (float64, bool) {
 	if lat < -90 || lat > 90 {
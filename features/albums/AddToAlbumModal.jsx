@@ -22,7 +22,8 @@ export default function AddToAlbumModal({ selectedPhotos, onClose }) {
   async function loadAlbums() {
     try {
       const data = await ApiClient.getAlbums();
-      setAlbums(data);
+      // Smart album membership comes from their rules, so photos can't be added by hand
+      setAlbums(data.filter(album => !album.isSmart));
     } catch (error) {
       showToast('Unable to load albums');
     }
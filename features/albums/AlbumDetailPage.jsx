@@ -1,27 +1,58 @@
 import ApiClient from '../../commons/http/ApiClient.js';
 import PhotoGallery from '../photos/PhotoGallery.jsx';
+import FilterPanel from '../photos/FilterPanel.jsx';
+import { parseFiltersFromUrl, filtersToUrlParams, countActiveFilters, CLEARED_FILTER_PARAMS } from '../photos/filterParams.js';
+import Pagination from '../../commons/components/Pagination.jsx';
 import IconButton from '../../commons/components/IconButton.jsx';
 import EmptyState from '../../commons/components/EmptyState.jsx';
 import LoadingContainer from '../../commons/components/LoadingContainer.jsx';
 import MessageBox from '../../commons/components/MessageBox.jsx';
 import SelectionCount from '../../commons/components/SelectionCount.jsx';
-import { TrashIcon, ImageIcon } from '../../commons/components/Icon.jsx';
+import ShareAlbumModal from './ShareAlbumModal.jsx';
+import EditAlbumModal from './EditAlbumModal.jsx';
+import CreateAlbumModal from './CreateAlbumModal.jsx';
+import { AlbumGrid } from './AlbumsPage.jsx';
+import Link from '../../commons/components/Link.jsx';
+import { useCurrentUser, hasRole } from '../auth/AuthProvider.jsx';
+import Badge from '../../commons/components/Badge.jsx';
+import { TrashIcon, ImageIcon, LinkIcon, FolderIcon, ChevronLeftIcon, FilterIcon } from '../../commons/components/Icon.jsx';
 import { showToast } from '../../commons/components/Toast.jsx';
 import pluralize from '../../commons/utils/pluralize.js';
+import useSearchParams from '../../commons/hooks/useSearchParams.js';
+import { updateSearchParams } from '../../commons/components/Link.jsx';
 import './AlbumDetailPage.css';
 
 const { useState, useEffect } = React;
 
 export default function AlbumDetailPage({ albumId }) {
+  const { user } = useCurrentUser();
+  const searchParams = useSearchParams();
+
+  const offsetParam = searchParams.get('offset');
+  const offset = offsetParam ? parseInt(offsetParam, 10) : 0;
+  const filters = parseFiltersFromUrl(searchParams);
+  const filtersKey = JSON.stringify(filters);
+
   const [album, setAlbum] = useState(null);
   const [photos, setPhotos] = useState([]);
+  const [groups, setGroups] = useState([]);
+  const [bursts, setBursts] = useState([]);
+  const [expandedBursts, setExpandedBursts] = useState(new Set());
+  const [totalRecords, setTotalRecords] = useState(0);
+  const [pageStartRecord, setPageStartRecord] = useState(0);
+  const [pageEndRecord, setPageEndRecord] = useState(0);
+  const [isFilterPanelOpen, setIsFilterPanelOpen] = useState(false);
   const [selectedIndices, setSelectedIndices] = useState(new Set());
   const [isLoading, setIsLoading] = useState(true);
   const [error, setError] = useState(null);
+  const [isShareModalOpen, setIsShareModalOpen] = useState(false);
+  const [isEditModalOpen, setIsEditModalOpen] = useState(false);
+  const [isCreateModalOpen, setIsCreateModalOpen] = useState(false);
+  const [childAlbums, setChildAlbums] = useState([]);
 
   useEffect(function () {
     loadAlbum();
-  }, [albumId]);
+  }, [albumId, offset, filtersKey]);
 
   async function loadAlbum() {
     setIsLoading(true);
@@ -30,8 +61,16 @@ export default function AlbumDetailPage({ albumId }) {
     try {
       const albumData = await ApiClient.getAlbum(albumId);
       setAlbum(albumData);
-      const albumPhotos = await ApiClient.getAlbumPhotos(albumId);
-      setPhotos(albumPhotos);
+      const data = await ApiClient.getAlbumPhotos(albumId, offset, filters);
+      setPhotos(data.photos || []);
+      setGroups(data.groups || []);
+      setBursts(data.bursts || []);
+      setTotalRecords(data.totalRecords || 0);
+      setPageStartRecord(data.pageStartRecord || 0);
+      setPageEndRecord(data.pageEndRecord || 0);
+      setSelectedIndices(new Set());
+      const children = await ApiClient.getAlbums(albumId);
+      setChildAlbums(children);
     } catch (err) {
       setError(err.message);
     } finally {
@@ -43,17 +82,48 @@ export default function AlbumDetailPage({ albumId }) {
     setSelectedIndices(indices);
   }
 
+  function handleBurstToggle(burstId) {
+    setExpandedBursts(prev => {
+      const next = new Set(prev);
+      if (next.has(burstId)) {
+        next.delete(burstId);
+      } else {
+        next.add(burstId);
+      }
+      return next;
+    });
+  }
+
+  function handleFiltersChange(newFilters) {
+    updateSearchParams({ ...CLEARED_FILTER_PARAMS, offset: null, ...filtersToUrlParams(newFilters) });
+  }
+
+  const hasPrev = offset > 0;
+  const hasNext = pageEndRecord < totalRecords;
+
+  function handlePrevPage() {
+    if (hasPrev) {
+      updateSearchParams({ offset: Math.max(0, offset - 100) });
+    }
+  }
+
+  function handleNextPage() {
+    if (hasNext) {
+      updateSearchParams({ offset: offset + 100 });
+    }
+  }
+
   async function handleRemoveFromAlbum() {
     if (selectedIndices.size === 0) {
       showToast('Select photos to remove');
       return;
     }
 
-    const selectedPhotoPaths = Array.from(selectedIndices).map(index => photos[index].filePath);
+    const selectedPhotoIds = Array.from(selectedIndices).map(index => photos[index].photoId);
 
     try {
-      await ApiClient.removePhotosFromAlbum(albumId, selectedPhotoPaths);
-      const count = selectedPhotoPaths.length;
+      await ApiClient.removePhotosFromAlbum(albumId, selectedPhotoIds);
+      const count = selectedPhotoIds.length;
       showToast(`Removed ${count} ${pluralize(count, 'photo')}`);
       setSelectedIndices(new Set());
       loadAlbum();
@@ -62,6 +132,38 @@ export default function AlbumDetailPage({ albumId }) {
     }
   }
 
+  async function handleSetCover() {
+    const photoId = photos[Array.from(selectedIndices)[0]].photoId;
+
+    try {
+      await ApiClient.updateAlbum(albumId, { coverPhotoId: photoId });
+      showToast('Cover updated');
+      loadAlbum();
+    } catch (error) {
+      console.error('Failed to set album cover:', error);
+    }
+  }
+
+  async function handleMove(atEnd) {
+    const selectedPhotoIds = Array.from(selectedIndices).sort((a, b) => a - b).map(index => photos[index].photoId);
+
+    try {
+      await ApiClient.reorderAlbumPhotos(albumId, selectedPhotoIds, atEnd);
+      setSelectedIndices(new Set());
+      loadAlbum();
+    } catch (error) {
+      console.error('Failed to reorder album photos:', error);
+    }
+  }
+
+  const activeFilterCount = countActiveFilters(filters);
+
+  // Child albums are shown above the first page of photos
+  let childAlbumGrid = null;
+  if (childAlbums.length > 0 && offset === 0) {
+    childAlbumGrid = <AlbumGrid albums={childAlbums} />;
+  }
+
   let content = null;
 
   if (error) {
@@ -72,6 +174,19 @@ export default function AlbumDetailPage({ albumId }) {
     );
   } else if (isLoading) {
     content = <LoadingContainer size={32} />;
+  } else if (photos.length === 0 && activeFilterCount > 0) {
+    content = (
+      <>
+        {childAlbumGrid}
+        <EmptyState
+          icon={<FilterIcon />}
+          title="No photos found"
+          description="Try adjusting your filters"
+        />
+      </>
+    );
+  } else if (photos.length === 0 && childAlbumGrid) {
+    content = childAlbumGrid;
   } else if (photos.length === 0) {
     content = (
       <EmptyState
@@ -82,29 +197,34 @@ export default function AlbumDetailPage({ albumId }) {
     );
   } else {
     content = (
-      <PhotoGallery
-        photos={photos}
-        groups={[]}
-        bursts={[]}
-        expandedBursts={new Set()}
-        onBurstToggle={() => { }}
-        selectedIndices={selectedIndices}
-        onSelectionChange={handleSelectionChange}
-        fadingPhotos={new Set()}
-        isCurateMode={false}
-      />
+      <>
+        {childAlbumGrid}
+        <PhotoGallery
+          photos={photos}
+          groups={groups}
+          bursts={bursts}
+          expandedBursts={expandedBursts}
+          onBurstToggle={handleBurstToggle}
+          selectedIndices={selectedIndices}
+          onSelectionChange={handleSelectionChange}
+          fadingPhotos={new Set()}
+          isCurateMode={false}
+        />
+      </>
     );
   }
 
   let albumTitle = 'Album';
   let photoCount = 0;
+  let isSmart = false;
   if (album) {
     albumTitle = album.name;
     photoCount = album.photoCount;
+    isSmart = album.isSmart;
   }
 
   let removeButton = null;
-  if (selectedIndices.size > 0) {
+  if (selectedIndices.size > 0 && !isSmart) {
     removeButton = (
       <IconButton onClick={handleRemoveFromAlbum}>
         <TrashIcon /> Remove from Album
@@ -112,6 +232,63 @@ export default function AlbumDetailPage({ albumId }) {
     );
   }
 
+  const isCurator = hasRole(user, 'curator');
+
+  let coverButton = null;
+  if (isCurator && selectedIndices.size === 1) {
+    coverButton = (
+      <IconButton onClick={handleSetCover}>
+        <ImageIcon /> Set as Cover
+      </IconButton>
+    );
+  }
+
+  let moveButtons = null;
+  if (isCurator && selectedIndices.size > 0 && !isSmart) {
+    moveButtons = (
+      <>
+        <IconButton onClick={() => handleMove(false)}>Move to Start</IconButton>
+        <IconButton onClick={() => handleMove(true)}>Move to End</IconButton>
+      </>
+    );
+  }
+
+  let shareButton = null;
+  let editButton = null;
+  let newAlbumButton = null;
+  if (isCurator) {
+    shareButton = (
+      <IconButton onClick={() => setIsShareModalOpen(true)}>
+        <LinkIcon /> Share
+      </IconButton>
+    );
+    editButton = (
+      <IconButton onClick={() => setIsEditModalOpen(true)}>
+        Edit
+      </IconButton>
+    );
+    newAlbumButton = (
+      <IconButton onClick={() => setIsCreateModalOpen(true)}>
+        <FolderIcon /> New Album
+      </IconButton>
+    );
+  }
+
+  let editModal = null;
+  if (isEditModalOpen && album) {
+    editModal = <EditAlbumModal album={album} onClose={() => setIsEditModalOpen(false)} onAlbumUpdated={loadAlbum} />;
+  }
+
+  let createModal = null;
+  if (isCreateModalOpen) {
+    createModal = <CreateAlbumModal parentAlbumId={album.albumId} onClose={() => setIsCreateModalOpen(false)} onAlbumCreated={loadAlbum} />;
+  }
+
+  let shareModal = null;
+  if (isShareModalOpen) {
+    shareModal = <ShareAlbumModal albumId={albumId} onClose={() => setIsShareModalOpen(false)} />;
+  }
+
   let selectionCountElement = null;
   if (!isLoading && !error && photos.length > 0) {
     selectionCountElement = <SelectionCount count={selectedIndices.size} />;
@@ -119,9 +296,22 @@ export default function AlbumDetailPage({ albumId }) {
 
   let albumHeader = null;
   if (!isLoading) {
+    let parentLink = null;
+    if (album && album.parentAlbumId) {
+      parentLink = (
+        <Link to={`/albums/${album.parentAlbumId}`} className="album-detail-page-parent">
+          <ChevronLeftIcon /> Parent album
+        </Link>
+      );
+    }
+
     albumHeader = (
       <div>
-        <h3>{albumTitle}</h3>
+        {parentLink}
+        <h3>
+          {albumTitle}
+          {isSmart && <Badge variant="neutral">Smart</Badge>}
+        </h3>
         <div className="album-detail-page-count">
           {photoCount} {pluralize(photoCount, 'photo')}
         </div>
@@ -129,14 +319,64 @@ export default function AlbumDetailPage({ albumId }) {
     );
   }
 
+  let paginationElement = null;
+  if (!error && (hasPrev || hasNext)) {
+    paginationElement = (
+      <Pagination
+        pageStartRecord={pageStartRecord}
+        pageEndRecord={pageEndRecord}
+        totalRecords={totalRecords}
+        onPrev={handlePrevPage}
+        onNext={handleNextPage}
+        hasPrev={hasPrev}
+        hasNext={hasNext}
+      />
+    );
+  }
+
+  let filterButton = null;
+  if (!error) {
+    let filterBadge = null;
+    if (activeFilterCount > 0) {
+      filterBadge = <span className="filter-badge">{activeFilterCount}</span>;
+    }
+    filterButton = (
+      <IconButton
+        className={`filter-button ${activeFilterCount > 0 ? 'has-filters' : ''}`}
+        onClick={() => setIsFilterPanelOpen(true)}
+        title="Filters"
+      >
+        <FilterIcon />
+        {filterBadge}
+      </IconButton>
+    );
+  }
+
   return (
     <div className="page-container">
       <div className="page-toolbar">
         {albumHeader}
         {selectionCountElement}
+        {coverButton}
+        {moveButtons}
         {removeButton}
+        {newAlbumButton}
+        {editButton}
+        {shareButton}
+        {filterButton}
+        {paginationElement}
       </div>
       {content}
+      <FilterPanel
+        isOpen={isFilterPanelOpen}
+        onClose={() => setIsFilterPanelOpen(false)}
+        filters={filters}
+        onFiltersChange={handleFiltersChange}
+        albumId={albumId}
+      />
+      {shareModal}
+      {editModal}
+      {createModal}
     </div>
   );
 }
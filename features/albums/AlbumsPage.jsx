@@ -8,6 +8,7 @@ import { FolderIcon } from '../../commons/components/Icon.jsx';
 import CreateAlbumModal from './CreateAlbumModal.jsx';
 import getThumbnailUrl from '../../commons/utils/getThumbnailUrl.js';
 import pluralize from '../../commons/utils/pluralize.js';
+import Badge from '../../commons/components/Badge.jsx';
 import './AlbumsPage.css';
 
 const { useState, useEffect } = React;
@@ -26,7 +27,7 @@ export default function AlbumsPage() {
     setIsLoading(true);
     setError(null);
     try {
-      const data = await ApiClient.getAlbums();
+      const data = await ApiClient.getAlbums(0);
       setAlbums(data);
     } catch (err) {
       setError(err.message);
@@ -102,11 +103,11 @@ export default function AlbumsPage() {
   );
 }
 
-function AlbumGrid({ albums }) {
+export function AlbumGrid({ albums }) {
   const cards = albums.map(album => {
     let coverImage = null;
-    if (album.coverPath) {
-      const thumbnailUrl = getThumbnailUrl(album.coverPath);
+    if (album.coverPhotoId) {
+      const thumbnailUrl = getThumbnailUrl(album.coverPhotoId);
       coverImage = (<img src={thumbnailUrl} alt={album.name} className="albums-page-card-cover" />);
     } else {
       coverImage = (
@@ -120,9 +121,13 @@ function AlbumGrid({ albums }) {
       <Link key={album.albumId} to={`/albums/${album.albumId}`} className="albums-page-card">
         {coverImage}
         <div className="albums-page-card-info">
-          <div className="albums-page-card-name">{album.name}</div>
+          <div className="albums-page-card-name">
+            {album.name}
+            {album.isSmart && <Badge variant="neutral">Smart</Badge>}
+          </div>
           <div className="albums-page-card-count">
             {album.photoCount} {pluralize(album.photoCount, 'photo')}
+            {album.childCount > 0 && ` · ${album.childCount} ${pluralize(album.childCount, 'album')}`}
           </div>
         </div>
       </Link>
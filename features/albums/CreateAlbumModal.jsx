@@ -7,10 +7,14 @@ import './CreateAlbumModal.css';
 
 const { useState } = React;
 
-export default function CreateAlbumModal({ onClose, onAlbumCreated }) {
+// Passing smartRules creates a smart album whose photos are whatever currently matches them
+export default function CreateAlbumModal({ onClose, onAlbumCreated, smartRules = null, parentAlbumId = null }) {
   const [albumName, setAlbumName] = useState('');
   const [albumDescription, setAlbumDescription] = useState('');
+  const [dateFrom, setDateFrom] = useState('');
+  const [dateTo, setDateTo] = useState('');
   const [isLoading, setIsLoading] = useState(false);
+  const isSmart = smartRules !== null;
 
   async function handleCreateAlbum() {
     if (!albumName.trim()) {
@@ -20,7 +24,11 @@ export default function CreateAlbumModal({ onClose, onAlbumCreated }) {
 
     setIsLoading(true);
     try {
-      const newAlbum = await ApiClient.createAlbum(albumName.trim(), albumDescription.trim());
+      let rules = null;
+      if (isSmart) {
+        rules = { ...smartRules, dateFrom, dateTo };
+      }
+      const newAlbum = await ApiClient.createAlbum(albumName.trim(), albumDescription.trim(), rules, parentAlbumId);
       showToast('Album created');
       if (onAlbumCreated) {
         onAlbumCreated(newAlbum);
@@ -36,7 +44,7 @@ export default function CreateAlbumModal({ onClose, onAlbumCreated }) {
   return (
     <ModalBackdrop onClose={onClose}>
       <ModalContainer className="create-album-modal">
-        <ModalHeader title="New Album" onClose={onClose} />
+        <ModalHeader title={isSmart ? 'New Smart Album' : 'New Album'} onClose={onClose} />
         <ModalContent>
           <Input
             id="album-name"
@@ -54,13 +62,31 @@ export default function CreateAlbumModal({ onClose, onAlbumCreated }) {
             onChange={(e) => setAlbumDescription(e.target.value)}
             rows={3}
           />
+          {isSmart && (
+            <div className="smart-album-dates">
+              <Input
+                id="smart-album-date-from"
+                type="date"
+                label="From"
+                value={dateFrom}
+                onChange={(e) => setDateFrom(e.target.value)}
+              />
+              <Input
+                id="smart-album-date-to"
+                type="date"
+                label="To"
+                value={dateTo}
+                onChange={(e) => setDateTo(e.target.value)}
+              />
+            </div>
+          )}
         </ModalContent>
         <ModalFooter isRightAligned>
           <Button onClick={onClose} variant="secondary">
             Cancel
           </Button>
           <Button onClick={handleCreateAlbum} disabled={isLoading} variant="primary">
-            Create Album
+            {isSmart ? 'Create Smart Album' : 'Create Album'}
           </Button>
         </ModalFooter>
       </ModalContainer>
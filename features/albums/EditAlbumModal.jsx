@@ -0,0 +1,98 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import { ModalBackdrop, ModalContainer, ModalHeader, ModalContent, ModalFooter } from '../../commons/components/Modal.jsx';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import RadioGroup from '../../commons/components/RadioGroup.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import './EditAlbumModal.css';
+
+const { useState, useEffect } = React;
+
+export default function EditAlbumModal({ album, onClose, onAlbumUpdated }) {
+  const [albumName, setAlbumName] = useState(album.name);
+  const [albumDescription, setAlbumDescription] = useState(album.description || '');
+  const [parentAlbumId, setParentAlbumId] = useState(album.parentAlbumId || 0);
+  const [allAlbums, setAllAlbums] = useState([]);
+  const [isLoading, setIsLoading] = useState(false);
+
+  useEffect(() => {
+    loadAlbums();
+  }, []);
+
+  async function loadAlbums() {
+    try {
+      const data = await ApiClient.getAlbums();
+      setAllAlbums(data.filter(a => a.albumId !== album.albumId));
+    } catch (error) {
+      showToast('Unable to load albums');
+    }
+  }
+
+  async function handleSave() {
+    if (!albumName.trim()) {
+      showToast('Enter an album name');
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      const updatedAlbum = await ApiClient.updateAlbum(album.albumId, {
+        name: albumName.trim(),
+        description: albumDescription.trim(),
+        parentAlbumId,
+      });
+      showToast('Album updated');
+      if (onAlbumUpdated) {
+        onAlbumUpdated(updatedAlbum);
+      }
+      onClose();
+    } catch (error) {
+      showToast('Unable to update album');
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  const parentOptions = [
+    { value: 0, label: 'None (top level)' },
+    ...allAlbums.map(a => ({ value: a.albumId, label: a.name })),
+  ];
+
+  return (
+    <ModalBackdrop onClose={onClose}>
+      <ModalContainer className="edit-album-modal">
+        <ModalHeader title="Edit Album" onClose={onClose} />
+        <ModalContent>
+          <Input
+            id="edit-album-name"
+            type="text"
+            label="Name"
+            value={albumName}
+            onChange={(e) => setAlbumName(e.target.value)}
+            autoFocus
+          />
+          <Input
+            id="edit-album-description"
+            type="textarea"
+            label="Description"
+            value={albumDescription}
+            onChange={(e) => setAlbumDescription(e.target.value)}
+            rows={3}
+          />
+          <div className="edit-album-modal-parent-label">Inside</div>
+          <div className="edit-album-modal-parents">
+            <RadioGroup options={parentOptions} selected={parentAlbumId} onChange={setParentAlbumId} />
+          </div>
+        </ModalContent>
+        <ModalFooter isRightAligned>
+          <Button onClick={onClose} variant="secondary">
+            Cancel
+          </Button>
+          <Button onClick={handleSave} disabled={isLoading} variant="primary">
+            Save
+          </Button>
+        </ModalFooter>
+      </ModalContainer>
+    </ModalBackdrop>
+  );
+}
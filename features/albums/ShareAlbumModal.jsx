@@ -0,0 +1,158 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import { ModalBackdrop, ModalContainer, ModalHeader, ModalContent, ModalFooter } from '../../commons/components/Modal.jsx';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import Checkbox from '../../commons/components/Checkbox.jsx';
+import Badge from '../../commons/components/Badge.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import pluralize from '../../commons/utils/pluralize.js';
+import './ShareAlbumModal.css';
+
+const { useState, useEffect } = React;
+
+function getShareUrl(share) {
+  return `${window.location.origin}/s/${share.token}/`;
+}
+
+export default function ShareAlbumModal({ albumId, onClose }) {
+  const [shares, setShares] = useState([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [isCreating, setIsCreating] = useState(false);
+  const [password, setPassword] = useState('');
+  const [expiryDate, setExpiryDate] = useState('');
+  const [allowDownload, setAllowDownload] = useState(false);
+
+  useEffect(() => {
+    loadShares();
+  }, [albumId]);
+
+  async function loadShares() {
+    try {
+      const data = await ApiClient.getAlbumShares(albumId);
+      setShares(data);
+    } catch (error) {
+      console.error('Failed to load share links:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  async function handleCreateShare() {
+    let expiresAt = null;
+    if (expiryDate) {
+      // Links stay valid until the end of the chosen day
+      expiresAt = new Date(`${expiryDate}T23:59:59`).toISOString();
+    }
+
+    setIsCreating(true);
+    try {
+      const share = await ApiClient.createAlbumShare(albumId, { password, allowDownload, expiresAt });
+      await copyShareUrl(share);
+      setPassword('');
+      setExpiryDate('');
+      setAllowDownload(false);
+      loadShares();
+    } catch (error) {
+      console.error('Failed to create share link:', error);
+    } finally {
+      setIsCreating(false);
+    }
+  }
+
+  async function copyShareUrl(share) {
+    try {
+      await navigator.clipboard.writeText(getShareUrl(share));
+      showToast('Link copied');
+    } catch (error) {
+      showToast('Unable to copy link');
+    }
+  }
+
+  async function handleRevokeShare(share) {
+    if (!confirm('Revoke this link? Anyone using it will lose access.')) {
+      return;
+    }
+
+    try {
+      await ApiClient.deleteAlbumShare(albumId, share.shareId);
+      showToast('Link revoked');
+      loadShares();
+    } catch (error) {
+      console.error('Failed to revoke share link:', error);
+    }
+  }
+
+  const shareElements = shares.map(share => {
+    const badges = [];
+    if (share.expiresAt && new Date(share.expiresAt) < new Date()) {
+      badges.push(<Badge key="expired" variant="error">Expired</Badge>);
+    } else if (share.expiresAt) {
+      const expiry = new Date(share.expiresAt).toLocaleDateString('en-US', { day: 'numeric', month: 'short', year: 'numeric' });
+      badges.push(<Badge key="expires" variant="warning">Until {expiry}</Badge>);
+    }
+    if (share.hasPassword) {
+      badges.push(<Badge key="password">Password</Badge>);
+    }
+    if (share.allowDownload) {
+      badges.push(<Badge key="download" variant="success">Downloads</Badge>);
+    }
+
+    return (
+      <div key={share.shareId} className="share-album-modal-link">
+        <div className="share-album-modal-link-details">
+          <div className="share-album-modal-link-url">{getShareUrl(share)}</div>
+          <div className="share-album-modal-link-meta">
+            {badges}
+            <span>{share.viewCount} {pluralize(share.viewCount, 'view')}</span>
+          </div>
+        </div>
+        <Button variant="ghost" onClick={() => copyShareUrl(share)}>Copy</Button>
+        <Button variant="ghost" onClick={() => handleRevokeShare(share)}>Revoke</Button>
+      </div>
+    );
+  });
+
+  let shareList = null;
+  if (!isLoading && shares.length > 0) {
+    shareList = <div className="share-album-modal-links">{shareElements}</div>;
+  } else if (!isLoading) {
+    shareList = <p className="share-album-modal-empty">No links yet. Anyone with a link can view this album without an account.</p>;
+  }
+
+  return (
+    <ModalBackdrop onClose={onClose}>
+      <ModalContainer className="share-album-modal">
+        <ModalHeader title="Share Album" onClose={onClose} />
+        <ModalContent>
+          {shareList}
+          <div className="share-album-modal-form">
+            <Input
+              id="share-password"
+              type="password"
+              label="Password"
+              placeholder="Optional"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+            />
+            <Input
+              id="share-expiry"
+              type="date"
+              label="Expires"
+              value={expiryDate}
+              onChange={(e) => setExpiryDate(e.target.value)}
+            />
+            <Checkbox checked={allowDownload} onChange={(e) => setAllowDownload(e.target.checked)} label="Allow downloading originals" />
+          </div>
+        </ModalContent>
+        <ModalFooter isRightAligned>
+          <Button onClick={onClose} variant="secondary">
+            Done
+          </Button>
+          <Button onClick={handleCreateShare} disabled={isCreating} variant="primary">
+            Create Link
+          </Button>
+        </ModalFooter>
+      </ModalContainer>
+    </ModalBackdrop>
+  );
+}
@@ -2,44 +2,104 @@ package albums
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"riffle/commons/sqlite"
+	"riffle/features/photos"
 	"time"
 )
 
+var ErrAlbumNotFound = errors.New("album not found")
+
 type Album struct {
-	AlbumID     int       `json:"albumId"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	PhotoCount  int       `json:"photoCount"`
-	CoverPath   string    `json:"coverPath"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	AlbumID            int              `json:"albumId"`
+	Name               string           `json:"name"`
+	Description        string           `json:"description"`
+	PhotoCount         int              `json:"photoCount"`
+	CoverPhotoID       *int64           `json:"coverPhotoId"`
+	ChosenCoverPhotoID *int64           `json:"chosenCoverPhotoId"`
+	ParentAlbumID      *int             `json:"parentAlbumId"`
+	ChildCount         int              `json:"childCount"`
+	IsSmart            bool             `json:"isSmart"`
+	SmartRules         *SmartAlbumRules `json:"smartRules,omitempty"`
+	CreatedAt          time.Time        `json:"createdAt"`
+	UpdatedAt          time.Time        `json:"updatedAt"`
+}
+
+// TopLevelAlbums selects albums without a parent when listing children
+const TopLevelAlbums = 0
+
+// Regular albums default to their first photo as cover
+const albumQuery = `
+	SELECT
+		a.album_id,
+		a.name,
+		a.description,
+		(SELECT COUNT(*) FROM album_photos ap WHERE ap.album_id = a.album_id) as photo_count,
+		COALESCE(
+			a.cover_photo_id,
+			(SELECT ap.photo_id FROM album_photos ap WHERE ap.album_id = a.album_id ORDER BY ap.position, ap.photo_id LIMIT 1)
+		) as cover_photo_id,
+		a.cover_photo_id,
+		a.parent_album_id,
+		(SELECT COUNT(*) FROM albums c WHERE c.parent_album_id = a.album_id) as child_count,
+		a.smart_rules,
+		a.created_at,
+		a.updated_at
+	FROM
+		albums a
+`
+
+type albumRowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlbum(row albumRowScanner) (*Album, error) {
+	var album Album
+	var smartRules sql.NullString
+	err := row.Scan(
+		&album.AlbumID,
+		&album.Name,
+		&album.Description,
+		&album.PhotoCount,
+		&album.CoverPhotoID,
+		&album.ChosenCoverPhotoID,
+		&album.ParentAlbumID,
+		&album.ChildCount,
+		&smartRules,
+		&album.CreatedAt,
+		&album.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	album.SmartRules, err = parseSmartRules(smartRules)
+	if err != nil {
+		return nil, err
+	}
+
+	album.IsSmart = album.SmartRules != nil
+
+	return &album, nil
 }
 
 func GetAllAlbums() ([]Album, error) {
-	query := `
-		SELECT
-			a.album_id,
-			a.name,
-			a.description,
-			COUNT(ap.file_path) as photo_count,
-			COALESCE(MIN(ap.file_path), '') as cover_path,
-			a.created_at,
-			a.updated_at
-		FROM
-			albums a
-		LEFT JOIN
-			album_photos ap ON a.album_id = ap.album_id
-		GROUP BY
-			a.album_id
-		ORDER BY
-			a.name ASC
-	`
+	return queryAlbums(albumQuery + ` ORDER BY a.name ASC`)
+}
 
-	rows, err := sqlite.DB.Query(query)
+// Lists the direct children of an album, or the top level albums for TopLevelAlbums
+func GetChildAlbums(parentAlbumID int) ([]Album, error) {
+	if parentAlbumID == TopLevelAlbums {
+		return queryAlbums(albumQuery + ` WHERE a.parent_album_id IS NULL ORDER BY a.name ASC`)
+	}
+	return queryAlbums(albumQuery+` WHERE a.parent_album_id = ? ORDER BY a.name ASC`, parentAlbumID)
+}
+
+func queryAlbums(query string, args ...any) ([]Album, error) {
+	rows, err := sqlite.DB.Query(query, args...)
 	if err != nil {
 		err = fmt.Errorf("failed to get albums: %w", err)
 		slog.Error(err.Error())
@@ -49,78 +109,70 @@ func GetAllAlbums() ([]Album, error) {
 
 	albums := make([]Album, 0)
 	for rows.Next() {
-		var album Album
-		err := rows.Scan(
-			&album.AlbumID,
-			&album.Name,
-			&album.Description,
-			&album.PhotoCount,
-			&album.CoverPath,
-			&album.CreatedAt,
-			&album.UpdatedAt,
-		)
+		album, err := scanAlbum(rows)
 		if err != nil {
 			slog.Error("failed to scan album", "error", err)
 			continue
 		}
-		albums = append(albums, album)
+		albums = append(albums, *album)
+	}
+	rows.Close()
+
+	// Smart album counts need their own queries, which can't run while rows holds the connection
+	for i := range albums {
+		if !albums[i].IsSmart {
+			continue
+		}
+		if err := loadSmartAlbumStats(&albums[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	return albums, nil
 }
 
 func GetAlbumByID(albumID int) (*Album, error) {
-	query := `
-		SELECT
-			a.album_id,
-			a.name,
-			a.description,
-			COUNT(ap.file_path) as photo_count,
-			COALESCE(MIN(ap.file_path), '') as cover_path,
-			a.created_at,
-			a.updated_at
-		FROM
-			albums a
-		LEFT JOIN
-			album_photos ap ON a.album_id = ap.album_id
-		WHERE
-			a.album_id = ?
-		GROUP BY
-			a.album_id
-	`
-
-	var album Album
-	err := sqlite.DB.QueryRow(query, albumID).Scan(
-		&album.AlbumID,
-		&album.Name,
-		&album.Description,
-		&album.PhotoCount,
-		&album.CoverPath,
-		&album.CreatedAt,
-		&album.UpdatedAt,
-	)
-
+	album, err := scanAlbum(sqlite.DB.QueryRow(albumQuery+` WHERE a.album_id = ?`, albumID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("album not found")
+			return nil, ErrAlbumNotFound
 		}
 		err = fmt.Errorf("failed to get album: %w", err)
 		slog.Error(err.Error())
 		return nil, err
 	}
 
-	return &album, nil
+	if album.IsSmart {
+		if err := loadSmartAlbumStats(album); err != nil {
+			return nil, err
+		}
+	}
+
+	return album, nil
 }
 
-func CreateAlbum(name, description string) (*Album, error) {
+// Pass nil rules for a regular album and a nil parent for a top level album
+func CreateAlbum(name, description string, smartRules *SmartAlbumRules, parentAlbumID *int) (*Album, error) {
+	var encodedRules *string
+	if smartRules != nil {
+		rulesJSON, err := json.Marshal(smartRules)
+		if err != nil {
+			err = fmt.Errorf("failed to encode smart album rules: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		rulesString := string(rulesJSON)
+		encodedRules = &rulesString
+	}
+
 	query := `
 		INSERT INTO
-			albums (name, description)
+			albums (name, description, smart_rules, parent_album_id)
 		VALUES
-			(?, ?)
+			(?, ?, ?, ?)
 	`
 
-	result, err := sqlite.DB.Exec(query, name, description)
+	result, err := sqlite.DB.Exec(query, name, description, encodedRules, parentAlbumID)
 	if err != nil {
 		err = fmt.Errorf("failed to create album: %w", err)
 		slog.Error(err.Error())
@@ -137,7 +189,95 @@ func CreateAlbum(name, description string) (*Album, error) {
 	return GetAlbumByID(int(albumID))
 }
 
-func AddPhotosToAlbum(albumID int, filePaths []string) error {
+func UpdateAlbumDetails(albumID int, name, description string) error {
+	query := `UPDATE albums SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE album_id = ?`
+
+	_, err := sqlite.DB.Exec(query, name, description, albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to update album details: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func UpdateAlbumSmartRules(albumID int, smartRules *SmartAlbumRules) error {
+	rulesJSON, err := json.Marshal(smartRules)
+	if err != nil {
+		err = fmt.Errorf("failed to encode smart album rules: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	query := `UPDATE albums SET smart_rules = ?, updated_at = CURRENT_TIMESTAMP WHERE album_id = ?`
+
+	_, err = sqlite.DB.Exec(query, string(rulesJSON), albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to update smart album rules: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Pass nil to go back to the automatic cover
+func SetAlbumCover(albumID int, photoID *int64) error {
+	query := `UPDATE albums SET cover_photo_id = ?, updated_at = CURRENT_TIMESTAMP WHERE album_id = ?`
+
+	_, err := sqlite.DB.Exec(query, photoID, albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to set album cover: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Pass nil to move the album to the top level
+func SetAlbumParent(albumID int, parentAlbumID *int) error {
+	query := `UPDATE albums SET parent_album_id = ?, updated_at = CURRENT_TIMESTAMP WHERE album_id = ?`
+
+	_, err := sqlite.DB.Exec(query, parentAlbumID, albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to set album parent: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Reports whether ancestorID is albumID itself or one of its parents, grandparents and so on
+func IsAlbumAncestor(ancestorID, albumID int) (bool, error) {
+	query := `
+		WITH RECURSIVE ancestors(album_id) AS (
+			SELECT ?
+			UNION
+			SELECT a.parent_album_id
+			FROM albums a
+			JOIN ancestors ON a.album_id = ancestors.album_id
+			WHERE a.parent_album_id IS NOT NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE album_id = ?)
+	`
+
+	var isAncestor bool
+	err := sqlite.DB.QueryRow(query, albumID, ancestorID).Scan(&isAncestor)
+	if err != nil {
+		err = fmt.Errorf("failed to check album ancestors: %w", err)
+		slog.Error(err.Error())
+		return false, err
+	}
+
+	return isAncestor, nil
+}
+
+// Moves the given photos, in the given order, to the start or end of the album. The rest keep their
+// current order. Photos that aren't in the album are ignored.
+func ReorderAlbumPhotos(albumID int, photoIDs []int64, atEnd bool) error {
 	tx, err := sqlite.DB.Begin()
 	if err != nil {
 		err = fmt.Errorf("failed to begin transaction: %w", err)
@@ -146,11 +286,98 @@ func AddPhotosToAlbum(albumID int, filePaths []string) error {
 	}
 	defer tx.Rollback()
 
+	rows, err := tx.Query(`SELECT photo_id FROM album_photos WHERE album_id = ? ORDER BY position ASC, photo_id ASC`, albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to get album order: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	var currentOrder []int64
+	inAlbum := make(map[int64]bool)
+	for rows.Next() {
+		var photoID int64
+		if err := rows.Scan(&photoID); err != nil {
+			rows.Close()
+			err = fmt.Errorf("failed to scan album photo id: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+		currentOrder = append(currentOrder, photoID)
+		inAlbum[photoID] = true
+	}
+	rows.Close()
+
+	moved := make([]int64, 0, len(photoIDs))
+	isMoved := make(map[int64]bool)
+	for _, photoID := range photoIDs {
+		if inAlbum[photoID] && !isMoved[photoID] {
+			moved = append(moved, photoID)
+			isMoved[photoID] = true
+		}
+	}
+
+	rest := make([]int64, 0, len(currentOrder))
+	for _, photoID := range currentOrder {
+		if !isMoved[photoID] {
+			rest = append(rest, photoID)
+		}
+	}
+
+	var newOrder []int64
+	if atEnd {
+		newOrder = append(rest, moved...)
+	} else {
+		newOrder = append(moved, rest...)
+	}
+
+	stmt, err := tx.Prepare(`UPDATE album_photos SET position = ? WHERE album_id = ? AND photo_id = ?`)
+	if err != nil {
+		err = fmt.Errorf("failed to prepare statement: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer stmt.Close()
+
+	for i, photoID := range newOrder {
+		if _, err = stmt.Exec(i+1, albumID, photoID); err != nil {
+			err = fmt.Errorf("failed to update photo position: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE albums SET updated_at = CURRENT_TIMESTAMP WHERE album_id = ?`, albumID)
+	if err != nil {
+		err = fmt.Errorf("failed to update album timestamp: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func AddPhotosToAlbum(albumID int, photoIDs []int64) error {
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("failed to begin transaction: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	// New photos go to the end of the album's manual order
 	query := `
 		INSERT OR IGNORE INTO
-			album_photos (album_id, file_path)
+			album_photos (album_id, photo_id, position)
 		VALUES
-			(?, ?)
+			(?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM album_photos WHERE album_id = ?))
 	`
 
 	stmt, err := tx.Prepare(query)
@@ -161,8 +388,8 @@ func AddPhotosToAlbum(albumID int, filePaths []string) error {
 	}
 	defer stmt.Close()
 
-	for _, filePath := range filePaths {
-		_, err = stmt.Exec(albumID, filePath)
+	for _, photoID := range photoIDs {
+		_, err = stmt.Exec(albumID, photoID, albumID)
 		if err != nil {
 			err = fmt.Errorf("failed to add photo to album: %w", err)
 			slog.Error(err.Error())
@@ -195,7 +422,7 @@ func AddPhotosToAlbum(albumID int, filePaths []string) error {
 	return nil
 }
 
-func RemovePhotosFromAlbum(albumID int, filePaths []string) error {
+func RemovePhotosFromAlbum(albumID int, photoIDs []int64) error {
 	tx, err := sqlite.DB.Begin()
 	if err != nil {
 		err = fmt.Errorf("failed to begin transaction: %w", err)
@@ -208,7 +435,7 @@ func RemovePhotosFromAlbum(albumID int, filePaths []string) error {
 		DELETE FROM
 			album_photos
 		WHERE
-			album_id = ? AND file_path = ?
+			album_id = ? AND photo_id = ?
 	`
 
 	stmt, err := tx.Prepare(query)
@@ -219,8 +446,8 @@ func RemovePhotosFromAlbum(albumID int, filePaths []string) error {
 	}
 	defer stmt.Close()
 
-	for _, filePath := range filePaths {
-		_, err = stmt.Exec(albumID, filePath)
+	for _, photoID := range photoIDs {
+		_, err = stmt.Exec(albumID, photoID)
 		if err != nil {
 			err = fmt.Errorf("failed to remove photo from album: %w", err)
 			slog.Error(err.Error())
@@ -228,16 +455,20 @@ func RemovePhotosFromAlbum(albumID int, filePaths []string) error {
 		}
 	}
 
+	// A chosen cover that was removed falls back to the automatic one
 	updateQuery := `
 		UPDATE
 			albums
 		SET
+			cover_photo_id = CASE
+				WHEN cover_photo_id IN (SELECT photo_id FROM album_photos WHERE album_id = ?) THEN cover_photo_id
+			END,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE
 			album_id = ?
 	`
 
-	_, err = tx.Exec(updateQuery, albumID)
+	_, err = tx.Exec(updateQuery, albumID, albumID)
 	if err != nil {
 		err = fmt.Errorf("failed to update album timestamp: %w", err)
 		slog.Error(err.Error())
@@ -253,16 +484,16 @@ func RemovePhotosFromAlbum(albumID int, filePaths []string) error {
 	return nil
 }
 
-func GetPhotoAlbums(filePath string) ([]int, error) {
+func GetPhotoAlbums(photoID int64) ([]int, error) {
 	query := `
 		SELECT
 			album_id
 		FROM
 			album_photos
 		WHERE
-			file_path = ?
+			photo_id = ?
 	`
-	rows, err := sqlite.DB.Query(query, filePath)
+	rows, err := sqlite.DB.Query(query, photoID)
 	if err != nil {
 		err = fmt.Errorf("failed to get photo albums: %w", err)
 		slog.Error(err.Error())
@@ -283,134 +514,96 @@ func GetPhotoAlbums(filePath string) ([]int, error) {
 	return albumIDs, nil
 }
 
-func GetAlbumPhotoPaths(albumID int) ([]string, error) {
-	query := `
-		SELECT
-			file_path
-		FROM
-			album_photos
-		WHERE
-			album_id = ?
-		ORDER BY
-			created_at DESC
-	`
-	rows, err := sqlite.DB.Query(query, albumID)
+// Pages through the album's photos like the library does, with filters applied on top of the album's membership
+func GetAlbumPhotosWithDayGroups(albumID int, page photos.PageRequest, curationUserID int64, filters *photos.PhotoFilters) (*photos.PhotosPage, error) {
+	album, err := GetAlbumByID(albumID)
 	if err != nil {
-		err = fmt.Errorf("failed to get album photos: %w", err)
-		slog.Error(err.Error())
 		return nil, err
 	}
-	defer rows.Close()
 
-	filePaths := make([]string, 0)
-	for rows.Next() {
-		var filePath string
-		if err := rows.Scan(&filePath); err != nil {
-			slog.Error("failed to scan file path", "error", err)
-			continue
-		}
-		filePaths = append(filePaths, filePath)
+	return photos.GetScopedPhotosWithDayGroups(page, albumPhotoScope(album), curationUserID, filters)
+}
+
+func GetAlbumFilterFacets(albumID int, curationUserID int64, filters *photos.PhotoFilters) (*photos.FilterFacets, error) {
+	album, err := GetAlbumByID(albumID)
+	if err != nil {
+		return nil, err
 	}
 
-	return filePaths, nil
+	return photos.GetScopedFilterFacets(albumPhotoScope(album), curationUserID, filters)
 }
 
-func GetAlbumPhotosWithMetadata(albumID int) ([]map[string]interface{}, error) {
-	query := `
+type AlbumPhoto struct {
+	PhotoID  int64   `json:"photoId"`
+	FilePath string  `json:"filePath"`
+	DateTime *string `json:"dateTime"`
+	IsVideo  bool    `json:"isVideo"`
+}
+
+// Lists the album's photos in display order, for both regular and smart albums
+func GetAlbumPhotos(albumID int) ([]AlbumPhoto, error) {
+	album, err := GetAlbumByID(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, args := albumPhotosSource(album)
+
+	query := fmt.Sprintf(`
 		SELECT
+			p.photo_id,
 			p.file_path,
-			p.thumbnail_path,
 			p.date_time,
-			p.is_video,
-			p.dhash,
-			p.camera_make,
-			p.camera_model,
-			p.latitude,
-			p.longitude,
-			p.city,
-			p.state,
-			p.country_name,
-			p.rating,
-			p.is_curated,
-			p.is_trashed,
-			p.notes
+			p.is_video
 		FROM
-			photos p
-		INNER JOIN
-			album_photos ap ON p.file_path = ap.file_path
-		WHERE
-			ap.album_id = ?
+			%s
 		ORDER BY
-			p.date_time DESC
-	`
+			%s
+	`, source, albumPhotosOrder(album))
 
-	rows, err := sqlite.DB.Query(query, albumID)
+	rows, err := sqlite.DB.Query(query, args...)
 	if err != nil {
-		err = fmt.Errorf("failed to get album photos with metadata: %w", err)
+		err = fmt.Errorf("failed to get album photos: %w", err)
 		slog.Error(err.Error())
 		return nil, err
 	}
 	defer rows.Close()
 
-	photos := make([]map[string]interface{}, 0)
+	albumPhotos := make([]AlbumPhoto, 0)
 	for rows.Next() {
-		var filePath, thumbnailPath, dateTime string
-		var isVideo, isCurated, isTrashed bool
-		var dhash, cameraMake, cameraModel, latitude, longitude, city, state, country, notes sql.NullString
-		var rating sql.NullInt64
-
-		err := rows.Scan(
-			&filePath,
-			&thumbnailPath,
-			&dateTime,
-			&isVideo,
-			&dhash,
-			&cameraMake,
-			&cameraModel,
-			&latitude,
-			&longitude,
-			&city,
-			&state,
-			&country,
-			&rating,
-			&isCurated,
-			&isTrashed,
-			&notes,
-		)
-		if err != nil {
-			slog.Error("failed to scan photo row", "error", err)
+		var photo AlbumPhoto
+		if err := rows.Scan(&photo.PhotoID, &photo.FilePath, &photo.DateTime, &photo.IsVideo); err != nil {
+			slog.Error("failed to scan album photo", "error", err)
 			continue
 		}
+		albumPhotos = append(albumPhotos, photo)
+	}
 
-		photo := map[string]interface{}{
-			"filePath":      filePath,
-			"thumbnailPath": thumbnailPath,
-			"dateTime":      dateTime,
-			"isVideo":       isVideo,
-			"dhash":         dhash.String,
-			"cameraMake":    cameraMake.String,
-			"cameraModel":   cameraModel.String,
-			"latitude":      latitude.String,
-			"longitude":     longitude.String,
-			"city":          city.String,
-			"state":         state.String,
-			"country":       country.String,
-			"rating":        int(rating.Int64),
-			"isCurated":     isCurated,
-			"isTrashed":     isTrashed,
-			"notes":         notes.String,
-		}
-		photos = append(photos, photo)
+	return albumPhotos, nil
+}
+
+func ContainsPhoto(albumID int, photoID int64) (bool, error) {
+	album, err := GetAlbumByID(albumID)
+	if err != nil {
+		return false, err
 	}
 
-	return photos, nil
+	return containsPhoto(album, photoID)
 }
 
-type AlbumPhoto struct {
-	FilePath     string `json:"filePath"`
-	ThumbnailURL string `json:"thumbnailUrl"`
-	DateTime     string `json:"dateTime"`
-	IsVideo      bool   `json:"isVideo"`
+func containsPhoto(album *Album, photoID int64) (bool, error) {
+	source, args := albumPhotosSource(album)
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s AND p.photo_id = ?)`, source)
+
+	var exists bool
+	err := sqlite.DB.QueryRow(query, append(args, photoID)...).Scan(&exists)
+	if err != nil {
+		err = fmt.Errorf("failed to check album photo: %w", err)
+		slog.Error(err.Error())
+		return false, err
+	}
+
+	return exists, nil
 }
 
 func DeleteAlbum(albumID int) error {
@@ -2,24 +2,56 @@ package albums
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"riffle/commons/utils"
+	"riffle/features/photos"
 	"strconv"
 )
 
 type CreateAlbumRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	SmartRules    *SmartAlbumRules `json:"smartRules"`
+	ParentAlbumID *int             `json:"parentAlbumId"`
+}
+
+// Only the fields that are present are changed. A coverPhotoId of 0 goes back to the automatic cover
+// and a parentAlbumId of 0 moves the album to the top level.
+type UpdateAlbumRequest struct {
+	Name          *string          `json:"name"`
+	Description   *string          `json:"description"`
+	SmartRules    *SmartAlbumRules `json:"smartRules"`
+	CoverPhotoID  *int64           `json:"coverPhotoId"`
+	ParentAlbumID *int             `json:"parentAlbumId"`
+}
+
+type ReorderPhotosRequest struct {
+	PhotoIDs []int64 `json:"photoIds"`
+	AtEnd    bool    `json:"atEnd"`
 }
 
 type AddPhotosRequest struct {
-	AlbumIDs  []int    `json:"albumIds"`
-	FilePaths []string `json:"filePaths"`
+	AlbumIDs []int   `json:"albumIds"`
+	PhotoIDs []int64 `json:"photoIds"`
 }
 
+// Lists every album, or with ?parent=<id> only that album's children (0 for the top level)
 func HandleGetAlbums(w http.ResponseWriter, r *http.Request) {
-	albums, err := GetAllAlbums()
+	var albums []Album
+	var err error
+
+	if parentParam := r.URL.Query().Get("parent"); parentParam != "" {
+		parentAlbumID, parseErr := strconv.Atoi(parentParam)
+		if parseErr != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PARENT_ID", "Invalid parent album ID")
+			return
+		}
+		albums, err = GetChildAlbums(parentAlbumID)
+	} else {
+		albums, err = GetAllAlbums()
+	}
 	if err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ALBUMS_ERROR", "Failed to get albums")
 		return
@@ -61,7 +93,21 @@ func HandleCreateAlbum(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	album, err := CreateAlbum(req.Name, req.Description)
+	if req.SmartRules != nil {
+		if err := req.SmartRules.Validate(); err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SMART_RULES", err.Error())
+			return
+		}
+	}
+
+	if req.ParentAlbumID != nil {
+		if _, err := GetAlbumByID(*req.ParentAlbumID); err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PARENT", "Parent album not found")
+			return
+		}
+	}
+
+	album, err := CreateAlbum(req.Name, req.Description, req.SmartRules, req.ParentAlbumID)
 	if err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_ALBUM_ERROR", "Failed to create album")
 		return
@@ -72,6 +118,164 @@ func HandleCreateAlbum(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(album)
 }
 
+func HandleUpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return
+	}
+
+	album, err := GetAlbumByID(albumID)
+	if err != nil {
+		if errors.Is(err, ErrAlbumNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "ALBUM_NOT_FOUND", "Album not found")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ALBUM_ERROR", "Failed to get album")
+		return
+	}
+
+	var req UpdateAlbumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if errCode, errMessage := validateAlbumUpdate(album, &req); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	if req.Name != nil || req.Description != nil {
+		name, description := album.Name, album.Description
+		if req.Name != nil {
+			name = *req.Name
+		}
+		if req.Description != nil {
+			description = *req.Description
+		}
+		if err := UpdateAlbumDetails(albumID, name, description); err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ALBUM_ERROR", "Failed to update album")
+			return
+		}
+	}
+
+	if req.SmartRules != nil {
+		if err := UpdateAlbumSmartRules(albumID, req.SmartRules); err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ALBUM_ERROR", "Failed to update album")
+			return
+		}
+	}
+
+	if req.CoverPhotoID != nil {
+		var coverPhotoID *int64
+		if *req.CoverPhotoID != 0 {
+			coverPhotoID = req.CoverPhotoID
+		}
+		if err := SetAlbumCover(albumID, coverPhotoID); err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ALBUM_ERROR", "Failed to update album")
+			return
+		}
+	}
+
+	if req.ParentAlbumID != nil {
+		var parentAlbumID *int
+		if *req.ParentAlbumID != TopLevelAlbums {
+			parentAlbumID = req.ParentAlbumID
+		}
+		if err := SetAlbumParent(albumID, parentAlbumID); err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ALBUM_ERROR", "Failed to update album")
+			return
+		}
+	}
+
+	updatedAlbum, err := GetAlbumByID(albumID)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ALBUM_ERROR", "Failed to update album")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updatedAlbum)
+}
+
+// Checks the whole request up front so a bad field doesn't leave the album half updated
+func validateAlbumUpdate(album *Album, req *UpdateAlbumRequest) (string, string) {
+	if req.Name != nil && *req.Name == "" {
+		return "MISSING_NAME", "Album name is required"
+	}
+
+	if req.SmartRules != nil {
+		if !album.IsSmart {
+			return "NOT_SMART_ALBUM", "Only smart albums have rules"
+		}
+		if err := req.SmartRules.Validate(); err != nil {
+			return "INVALID_SMART_RULES", err.Error()
+		}
+	}
+
+	if req.CoverPhotoID != nil && *req.CoverPhotoID != 0 {
+		// Checked against the new rules when they change in the same request
+		coverAlbum := album
+		if req.SmartRules != nil {
+			updated := *album
+			updated.SmartRules = req.SmartRules
+			coverAlbum = &updated
+		}
+
+		inAlbum, err := containsPhoto(coverAlbum, *req.CoverPhotoID)
+		if err != nil || !inAlbum {
+			return "INVALID_COVER", "Cover photo must be in the album"
+		}
+	}
+
+	if req.ParentAlbumID != nil && *req.ParentAlbumID != TopLevelAlbums {
+		if _, err := GetAlbumByID(*req.ParentAlbumID); err != nil {
+			return "INVALID_PARENT", "Parent album not found"
+		}
+
+		isCycle, err := IsAlbumAncestor(album.AlbumID, *req.ParentAlbumID)
+		if err != nil || isCycle {
+			return "INVALID_PARENT", "An album can't be moved inside itself"
+		}
+	}
+
+	return "", ""
+}
+
+func HandleReorderAlbumPhotos(w http.ResponseWriter, r *http.Request) {
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return
+	}
+
+	var req ReorderPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if len(req.PhotoIDs) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "Photo IDs are required")
+		return
+	}
+
+	if !requireRegularAlbum(w, albumID) {
+		return
+	}
+
+	if err := ReorderAlbumPhotos(albumID, req.PhotoIDs, req.AtEnd); err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "REORDER_PHOTOS_ERROR", "Failed to reorder album photos")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
 func HandleAddPhotosToAlbums(w http.ResponseWriter, r *http.Request) {
 	var req AddPhotosRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -79,13 +283,19 @@ func HandleAddPhotosToAlbums(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.AlbumIDs) == 0 || len(req.FilePaths) == 0 {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "Album IDs and file paths are required")
+	if len(req.AlbumIDs) == 0 || len(req.PhotoIDs) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "Album IDs and photo IDs are required")
 		return
 	}
 
 	for _, albumID := range req.AlbumIDs {
-		if err := AddPhotosToAlbum(albumID, req.FilePaths); err != nil {
+		if !requireRegularAlbum(w, albumID) {
+			return
+		}
+	}
+
+	for _, albumID := range req.AlbumIDs {
+		if err := AddPhotosToAlbum(albumID, req.PhotoIDs); err != nil {
 			slog.Error("failed to add photos to album", "albumId", albumID, "error", err)
 			utils.SendErrorResponse(w, http.StatusInternalServerError, "ADD_PHOTOS_ERROR", "Failed to add photos to album")
 			return
@@ -105,7 +315,27 @@ func HandleGetAlbumPhotos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	photos, err := GetAlbumPhotosWithMetadata(albumID)
+	filters, err := photos.ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	page, err := photos.ParsePageRequest(r)
+	if errors.Is(err, photos.ErrInvalidSort) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SORT", "Sort must be newest, oldest, imported, rating, size, filename or camera")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_CURSOR", "Invalid page cursor")
+		return
+	}
+
+	photosPage, err := GetAlbumPhotosWithDayGroups(albumID, page, photos.ParseCurationUser(r), filters)
+	if errors.Is(err, ErrAlbumNotFound) {
+		utils.SendErrorResponse(w, http.StatusNotFound, "ALBUM_NOT_FOUND", "Album not found")
+		return
+	}
 	if err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ALBUM_PHOTOS_ERROR", "Failed to get album photos")
 		return
@@ -113,7 +343,36 @@ func HandleGetAlbumPhotos(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(photos)
+	json.NewEncoder(w).Encode(photos.NewPhotosResponse(photosPage))
+}
+
+func HandleGetAlbumFacets(w http.ResponseWriter, r *http.Request) {
+	albumIDStr := r.PathValue("id")
+	albumID, err := strconv.Atoi(albumIDStr)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return
+	}
+
+	filters, err := photos.ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	facets, err := GetAlbumFilterFacets(albumID, photos.ParseCurationUser(r), filters)
+	if errors.Is(err, ErrAlbumNotFound) {
+		utils.SendErrorResponse(w, http.StatusNotFound, "ALBUM_NOT_FOUND", "Album not found")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ALBUM_FACETS_ERROR", "Failed to get album filter options")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(facets)
 }
 
 func HandleRemovePhotosFromAlbum(w http.ResponseWriter, r *http.Request) {
@@ -125,19 +384,23 @@ func HandleRemovePhotosFromAlbum(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		FilePaths []string `json:"filePaths"`
+		PhotoIDs []int64 `json:"photoIds"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 		return
 	}
 
-	if len(req.FilePaths) == 0 {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "File paths are required")
+	if len(req.PhotoIDs) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "Photo IDs are required")
 		return
 	}
 
-	if err := RemovePhotosFromAlbum(albumID, req.FilePaths); err != nil {
+	if !requireRegularAlbum(w, albumID) {
+		return
+	}
+
+	if err := RemovePhotosFromAlbum(albumID, req.PhotoIDs); err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "REMOVE_PHOTOS_ERROR", "Failed to remove photos from album")
 		return
 	}
@@ -166,13 +429,13 @@ func HandleDeleteAlbum(w http.ResponseWriter, r *http.Request) {
 }
 
 func HandleGetPhotoAlbums(w http.ResponseWriter, r *http.Request) {
-	filePath := r.URL.Query().Get("path")
-	if filePath == "" {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_FILE_PATH", "File path is required")
+	photoID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PHOTO_ID", "Invalid photo ID")
 		return
 	}
 
-	albumIDs, err := GetPhotoAlbums(filePath)
+	albumIDs, err := GetPhotoAlbums(photoID)
 	if err != nil {
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_PHOTO_ALBUMS_ERROR", "Failed to get photo albums")
 		return
@@ -182,3 +445,23 @@ func HandleGetPhotoAlbums(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(albumIDs)
 }
+
+// Smart album membership comes from their rules, so photos can't be added or removed by hand
+func requireRegularAlbum(w http.ResponseWriter, albumID int) bool {
+	album, err := GetAlbumByID(albumID)
+	if err != nil {
+		if errors.Is(err, ErrAlbumNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "ALBUM_NOT_FOUND", "Album not found")
+			return false
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ALBUM_ERROR", "Failed to get album")
+		return false
+	}
+
+	if album.IsSmart {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "SMART_ALBUM", "Photos in smart albums are chosen by their filters")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,172 @@
+package albums
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"riffle/features/photos"
+	"time"
+)
+
+const (
+	SmartCurationPicked    = "picked"
+	SmartCurationUncurated = "uncurated"
+	SmartCurationRejected  = "rejected"
+	SmartCurationAny       = "any"
+)
+
+type SmartAlbumRules struct {
+	Filters   photos.PhotoFilters `json:"filters"`
+	MinRating int                 `json:"minRating"`
+	Curation  string              `json:"curation"` // picked, uncurated, rejected or any
+	DateFrom  string              `json:"dateFrom"` // YYYY-MM-DD, inclusive
+	DateTo    string              `json:"dateTo"`   // YYYY-MM-DD, inclusive
+}
+
+func (rules *SmartAlbumRules) Validate() error {
+	switch rules.Curation {
+	case "":
+		rules.Curation = SmartCurationPicked
+	case SmartCurationPicked, SmartCurationUncurated, SmartCurationRejected, SmartCurationAny:
+	default:
+		return fmt.Errorf("curation must be picked, uncurated, rejected or any")
+	}
+
+	if rules.MinRating < 0 || rules.MinRating > 5 {
+		return fmt.Errorf("minimum rating must be between 0 and 5")
+	}
+
+	for _, date := range []string{rules.DateFrom, rules.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("dates must be in YYYY-MM-DD format")
+		}
+	}
+
+	if rules.DateFrom != "" && rules.DateTo != "" && rules.DateFrom > rules.DateTo {
+		return fmt.Errorf("start date must be before end date")
+	}
+
+	if err := rules.Filters.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Builds a WHERE clause over photos for the rules. Column names are unqualified like BuildFilterConditions.
+func buildSmartAlbumConditions(rules *SmartAlbumRules) (string, []any) {
+	whereClause := "WHERE 1=1"
+	args := []any{}
+
+	switch rules.Curation {
+	case SmartCurationPicked:
+		whereClause += " AND is_curated = 1 AND is_trashed = 0"
+	case SmartCurationUncurated:
+		whereClause += " AND is_curated = 0 AND is_trashed = 0"
+	case SmartCurationRejected:
+		whereClause += " AND is_trashed = 1"
+	}
+
+	if rules.MinRating > 0 {
+		whereClause += " AND rating >= ?"
+		args = append(args, rules.MinRating)
+	}
+
+	if rules.DateFrom != "" {
+		whereClause += " AND SUBSTR(local_date_time, 1, 10) >= ?"
+		args = append(args, rules.DateFrom)
+	}
+
+	if rules.DateTo != "" {
+		whereClause += " AND SUBSTR(local_date_time, 1, 10) <= ?"
+		args = append(args, rules.DateTo)
+	}
+
+	filterSQL, filterArgs := photos.BuildFilterConditions(&rules.Filters)
+	if filterSQL != "" {
+		whereClause += filterSQL
+		args = append(args, filterArgs...)
+	}
+
+	return whereClause, args
+}
+
+func parseSmartRules(raw sql.NullString) (*SmartAlbumRules, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	var rules SmartAlbumRules
+	if err := json.Unmarshal([]byte(raw.String), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse smart album rules: %w", err)
+	}
+	return &rules, nil
+}
+
+// Returns the FROM and WHERE part of a query selecting the album's photos as p
+func albumPhotosSource(album *Album) (string, []any) {
+	if album.SmartRules == nil {
+		return `photos p INNER JOIN album_photos ap ON p.photo_id = ap.photo_id WHERE ap.album_id = ?`, []any{album.AlbumID}
+	}
+
+	whereClause, args := buildSmartAlbumConditions(album.SmartRules)
+	return "photos p " + whereClause, args
+}
+
+// Membership is checked against the photos table itself, so smart rules see the team's decisions
+// whichever curator's view is being listed
+func albumPhotoScope(album *Album) photos.PhotoScope {
+	source, args := albumPhotosSource(album)
+	scope := photos.PhotoScope{
+		Condition: fmt.Sprintf(`photo_id IN (SELECT p.photo_id FROM %s)`, source),
+		Args:      args,
+	}
+
+	if album.SmartRules == nil {
+		scope.OrderBy = `(SELECT ap.position FROM album_photos ap WHERE ap.album_id = ? AND ap.photo_id = photos.photo_id) ASC, date_time DESC`
+		scope.OrderArgs = []any{album.AlbumID}
+	}
+
+	return scope
+}
+
+// Regular albums keep their manual order; smart albums have none and show newest first
+func albumPhotosOrder(album *Album) string {
+	if album.SmartRules == nil {
+		return `ap.position ASC, p.date_time DESC`
+	}
+	return `p.date_time DESC`
+}
+
+// Smart albums are counted at query time. The chosen cover is used while it still matches the rules,
+// otherwise the newest matching photo is.
+func loadSmartAlbumStats(album *Album) error {
+	source, args := albumPhotosSource(album)
+
+	query := fmt.Sprintf(`
+		SELECT
+			(SELECT COUNT(*) FROM %s),
+			COALESCE(
+				(SELECT p.photo_id FROM %s AND p.photo_id = ?),
+				(SELECT p.photo_id FROM %s ORDER BY p.date_time DESC LIMIT 1)
+			)
+	`, source, source, source)
+
+	queryArgs := append(append([]any{}, args...), args...)
+	queryArgs = append(queryArgs, album.ChosenCoverPhotoID)
+	queryArgs = append(queryArgs, args...)
+
+	err := sqlite.DB.QueryRow(query, queryArgs...).Scan(&album.PhotoCount, &album.CoverPhotoID)
+	if err != nil {
+		err = fmt.Errorf("failed to get smart album stats: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
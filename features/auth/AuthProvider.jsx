@@ -0,0 +1,76 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import LoadingContainer from '../../commons/components/LoadingContainer.jsx';
+import LoginPage from './LoginPage.jsx';
+
+const { useState, useEffect, createContext, useContext } = React;
+
+const roleRanks = { viewer: 1, curator: 2, admin: 3 };
+
+const AuthContext = createContext(null);
+
+export function useCurrentUser() {
+  return useContext(AuthContext);
+}
+
+export function hasRole(user, role) {
+  if (!user) {
+    return false;
+  }
+  return (roleRanks[user.role] || 0) >= roleRanks[role];
+}
+
+export default function AuthProvider({ children }) {
+  const [user, setUser] = useState(null);
+  const [isSetupRequired, setIsSetupRequired] = useState(false);
+  const [isLoading, setIsLoading] = useState(true);
+
+  useEffect(() => {
+    loadAuthStatus();
+
+    function handleUnauthorized() {
+      setUser(null);
+    }
+
+    window.addEventListener('unauthorized', handleUnauthorized);
+    return () => window.removeEventListener('unauthorized', handleUnauthorized);
+  }, []);
+
+  async function loadAuthStatus() {
+    try {
+      const status = await ApiClient.getAuthStatus();
+      setUser(status.user);
+      setIsSetupRequired(status.setupRequired);
+    } catch (error) {
+      console.error('Failed to load auth status:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  async function handleLogout() {
+    try {
+      await ApiClient.logout();
+    } finally {
+      setUser(null);
+    }
+  }
+
+  function handleLogin(loggedInUser) {
+    setIsSetupRequired(false);
+    setUser(loggedInUser);
+  }
+
+  if (isLoading) {
+    return <LoadingContainer size={32} />;
+  }
+
+  if (!user) {
+    return <LoginPage isSetup={isSetupRequired} onLogin={handleLogin} />;
+  }
+
+  return (
+    <AuthContext.Provider value={{ user, logout: handleLogout }}>
+      {children}
+    </AuthContext.Provider>
+  );
+}
@@ -0,0 +1,73 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import Logo from '../../commons/components/Logo.jsx';
+import Input from '../../commons/components/Input.jsx';
+import Button from '../../commons/components/Button.jsx';
+import './LoginPage.css';
+
+const { useState } = React;
+
+export default function LoginPage({ isSetup, onLogin }) {
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+
+  async function handleSubmit(e) {
+    e.preventDefault();
+
+    if (!username.trim() || !password) {
+      return;
+    }
+
+    setIsLoading(true);
+    try {
+      let user = null;
+      if (isSetup) {
+        user = await ApiClient.setupAccount(username.trim(), password);
+      } else {
+        user = await ApiClient.login(username.trim(), password);
+      }
+      onLogin(user);
+    } catch (error) {
+      console.error('Failed to sign in:', error);
+      setPassword('');
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  let title = 'Sign in';
+  let description = null;
+  let submitLabel = 'Sign In';
+  if (isSetup) {
+    title = 'Create admin account';
+    description = <p className="login-page-description">This account can manage settings and invite other users.</p>;
+    submitLabel = 'Create Account';
+  }
+
+  return (
+    <div className="login-page">
+      <form className="login-page-form" onSubmit={handleSubmit}>
+        <Logo />
+        <h3>{title}</h3>
+        {description}
+        <Input
+          id="username"
+          placeholder="Username"
+          value={username}
+          onChange={(e) => setUsername(e.target.value)}
+          autoFocus
+        />
+        <Input
+          id="password"
+          type="password"
+          placeholder="Password"
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+        />
+        <Button type="submit" variant="primary" isLoading={isLoading}>
+          {submitLabel}
+        </Button>
+      </form>
+    </div>
+  );
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"riffle/commons/utils"
+	"strings"
+	"time"
+)
+
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72 // bcrypt ignores anything past 72 bytes
+)
+
+type AuthStatusResponse struct {
+	SetupRequired bool  `json:"setupRequired"`
+	User          *User `json:"user"`
+}
+
+type CredentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func HandleGetAuthStatus(w http.ResponseWriter, r *http.Request) {
+	userCount, err := CountUsers()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "AUTH_STATUS_ERROR", "Failed to get auth status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AuthStatusResponse{
+		SetupRequired: userCount == 0,
+		User:          GetCurrentUser(r),
+	})
+}
+
+// Creates the first admin account. Only allowed while there are no users.
+func HandleSetup(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if errCode, errMessage := validateCredentials(username, req.Password); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	user, err := CreateFirstAdmin(username, req.Password)
+	if errors.Is(err, ErrAlreadySetup) {
+		utils.SendErrorResponse(w, http.StatusConflict, "ALREADY_SETUP", "An admin account already exists")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "SETUP_ERROR", "Failed to create account")
+		return
+	}
+
+	slog.Info("created admin account", "username", user.Username)
+
+	startSession(w, r, user)
+}
+
+func HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	user, err := GetUserByUsername(strings.TrimSpace(req.Username))
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "LOGIN_ERROR", "Failed to sign in")
+		return
+	}
+
+	if !CheckPassword(user, req.Password) {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Incorrect username or password")
+		return
+	}
+
+	if err := DeleteExpiredSessions(); err != nil {
+		slog.Warn("failed to clean up expired sessions", "error", err)
+	}
+
+	startSession(w, r, user)
+}
+
+func HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		DeleteSession(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+func startSession(w http.ResponseWriter, r *http.Request, user *User) {
+	token, expiresAt, err := CreateSession(user.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "SESSION_ERROR", "Failed to start session")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func validateCredentials(username, password string) (string, string) {
+	if username == "" {
+		return "MISSING_USERNAME", "Username is required"
+	}
+	if len(password) < MinPasswordLength {
+		return "WEAK_PASSWORD", "Password must be at least 8 characters"
+	}
+	if len(password) > MaxPasswordLength {
+		return "PASSWORD_TOO_LONG", "Password must be at most 72 characters"
+	}
+	return "", ""
+}
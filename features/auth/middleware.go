@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"riffle/commons/utils"
+	"strings"
+)
+
+const SessionCookieName = "riffle_session"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// Endpoints reachable without a session. Everything else under /api/ requires one.
+var publicPaths = []string{
+	"/api/auth/status/",
+	"/api/auth/login/",
+	"/api/auth/setup/",
+}
+
+// Resolves the session cookie to a user and stores it in the request context.
+// API requests without a valid session are rejected; the page and static assets are
+// always served so the frontend can render the login screen.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := getRequestUser(r); user != nil {
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, "/api/") || isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+	})
+}
+
+// Wraps a handler so it only runs for users whose role includes the given role
+func RequireRole(role Role, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := GetCurrentUser(r)
+		if user == nil {
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+			return
+		}
+
+		if !user.Role.Includes(role) {
+			utils.SendErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to do this")
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func GetCurrentUser(r *http.Request) *User {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	return user
+}
+
+func getRequestUser(r *http.Request) *User {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+
+	user, err := GetSessionUser(cookie.Value)
+	if err != nil {
+		return nil
+	}
+
+	return user
+}
+
+func isPublicPath(path string) bool {
+	for _, publicPath := range publicPaths {
+		if path == publicPath {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"time"
+)
+
+const SessionDuration = 30 * 24 * time.Hour
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Creates a session for the user and returns the token to hand to the client.
+// Only the token's hash is stored.
+func CreateSession(userID int64) (string, time.Time, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		err = fmt.Errorf("error generating session token: %w", err)
+		slog.Error(err.Error())
+		return "", time.Time{}, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(SessionDuration).UTC()
+
+	query := `INSERT INTO sessions (session_id, user_id, expires_at) VALUES (?, ?, ?)`
+
+	_, err := sqlite.DB.Exec(query, hashToken(token), userID, expiresAt)
+	if err != nil {
+		err = fmt.Errorf("error creating session: %w", err)
+		slog.Error(err.Error())
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func GetSessionUser(token string) (*User, error) {
+	query := `
+		SELECT u.user_id, u.username, u.password_hash, u.role, u.created_at, u.updated_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.user_id
+		WHERE s.session_id = ? AND s.expires_at > ?
+	`
+
+	user, err := scanUser(sqlite.DB.QueryRow(query, hashToken(token), time.Now().UTC()))
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	return user, err
+}
+
+func DeleteSession(token string) error {
+	_, err := sqlite.DB.Exec(`DELETE FROM sessions WHERE session_id = ?`, hashToken(token))
+	if err != nil {
+		err = fmt.Errorf("error deleting session: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func DeleteUserSessions(userID int64) error {
+	_, err := sqlite.DB.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		err = fmt.Errorf("error deleting user sessions: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func DeleteExpiredSessions() error {
+	_, err := sqlite.DB.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, time.Now().UTC())
+	if err != nil {
+		err = fmt.Errorf("error deleting expired sessions: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"riffle/commons/utils"
+	"strconv"
+	"strings"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
+type UpdateUserRequest struct {
+	Role     *Role   `json:"role"`
+	Password *string `json:"password"`
+}
+
+func HandleGetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := GetAllUsers()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_USERS_ERROR", "Failed to get users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+func HandleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if errCode, errMessage := validateCredentials(username, req.Password); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	if !req.Role.IsValid() {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ROLE", "Role must be admin, curator or viewer")
+		return
+	}
+
+	user, err := CreateUser(username, req.Password, req.Role)
+	if err != nil {
+		if errors.Is(err, ErrUsernameTaken) {
+			utils.SendErrorResponse(w, http.StatusConflict, "USERNAME_TAKEN", "Username is already taken")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_USER_ERROR", "Failed to create user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	// Everything is checked before anything is written, so a rejected password doesn't leave a changed role behind
+	role := req.Role
+	if role != nil && *role == user.Role {
+		role = nil
+	}
+
+	if role != nil {
+		if !role.IsValid() {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ROLE", "Role must be admin, curator or viewer")
+			return
+		}
+
+		if user.Role == RoleAdmin && isLastAdmin(w) {
+			return
+		}
+	}
+
+	if req.Password != nil {
+		if errCode, errMessage := validateCredentials(user.Username, *req.Password); errCode != "" {
+			utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+			return
+		}
+	}
+
+	if err := UpdateUser(user.UserID, role, req.Password); err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_USER_ERROR", "Failed to update user")
+		return
+	}
+
+	// Sign the user out of existing sessions unless they changed their own password
+	if req.Password != nil {
+		currentUser := GetCurrentUser(r)
+		if currentUser == nil || currentUser.UserID != user.UserID {
+			DeleteUserSessions(user.UserID)
+		}
+	}
+
+	updatedUser, err := GetUserByID(user.UserID)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_USER_ERROR", "Failed to update user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updatedUser)
+}
+
+func HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := getUserFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	currentUser := GetCurrentUser(r)
+	if currentUser != nil && currentUser.UserID == user.UserID {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "CANNOT_DELETE_SELF", "You can't delete your own account")
+		return
+	}
+
+	if user.Role == RoleAdmin && isLastAdmin(w) {
+		return
+	}
+
+	if err := DeleteUser(user.UserID); err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "DELETE_USER_ERROR", "Failed to delete user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func getUserFromPath(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_USER_ID", "Invalid user ID")
+		return nil, false
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+			return nil, false
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_USER_ERROR", "Failed to get user")
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Prevents locking everyone out of settings and user management
+func isLastAdmin(w http.ResponseWriter) bool {
+	adminCount, err := CountAdmins()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_USER_ERROR", "Failed to update user")
+		return true
+	}
+
+	if adminCount <= 1 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "LAST_ADMIN", "At least one admin account is required")
+		return true
+	}
+
+	return false
+}
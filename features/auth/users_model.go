@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleCurator Role = "curator"
+	RoleViewer  Role = "viewer"
+)
+
+// Higher ranks include every permission of the lower ones
+var roleRanks = map[Role]int{
+	RoleViewer:  1,
+	RoleCurator: 2,
+	RoleAdmin:   3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+var ErrUserNotFound = errors.New("user not found")
+var ErrUsernameTaken = errors.New("username already taken")
+var ErrAlreadySetup = errors.New("an admin account already exists")
+
+type User struct {
+	UserID       int64     `json:"userId"`
+	Username     string    `json:"username"`
+	Role         Role      `json:"role"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Compared against when the username doesn't exist, so a sign in takes as long either way and the response time
+// doesn't tell which usernames are taken. It's a bcrypt hash at the default cost, like the stored ones.
+const dummyPasswordHash = "$2a$10$ec0jZiUayTAvhjgcV35AkesD5WXvqL3N1ZPWVCY8ASr/lukWUCEQa"
+
+// A nil user still costs a bcrypt comparison and never matches
+func CheckPassword(user *User, password string) bool {
+	if user == nil {
+		CheckPasswordHash(dummyPasswordHash, password)
+		return false
+	}
+	return CheckPasswordHash(user.PasswordHash, password)
+}
+
+func CheckPasswordHash(passwordHash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+func CreateUser(username, password string, role Role) (*User, error) {
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	query := `INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)`
+
+	result, err := sqlite.DB.Exec(query, username, passwordHash, role)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, ErrUsernameTaken
+		}
+		err = fmt.Errorf("error creating user: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	userID, err := result.LastInsertId()
+	if err != nil {
+		err = fmt.Errorf("error getting user id: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return GetUserByID(userID)
+}
+
+// Creates the first admin account. The check for existing users is part of the insert, so two setups at once
+// can't both create one; the later one gets ErrAlreadySetup.
+func CreateFirstAdmin(username, password string) (*User, error) {
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO users (username, password_hash, role)
+		SELECT ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM users)
+	`
+
+	result, err := sqlite.DB.Exec(query, username, passwordHash, RoleAdmin)
+	if err != nil {
+		err = fmt.Errorf("error creating admin account: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("error getting rows affected: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrAlreadySetup
+	}
+
+	userID, err := result.LastInsertId()
+	if err != nil {
+		err = fmt.Errorf("error getting user id: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return GetUserByID(userID)
+}
+
+func GetUserByID(userID int64) (*User, error) {
+	query := `
+		SELECT user_id, username, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE user_id = ?
+	`
+	return scanUser(sqlite.DB.QueryRow(query, userID))
+}
+
+func GetUserByUsername(username string) (*User, error) {
+	query := `
+		SELECT user_id, username, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE username = ?
+	`
+	return scanUser(sqlite.DB.QueryRow(query, username))
+}
+
+func scanUser(row *sql.Row) (*User, error) {
+	var user User
+	err := row.Scan(&user.UserID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		err = fmt.Errorf("error getting user: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	return &user, nil
+}
+
+func GetAllUsers() ([]User, error) {
+	query := `
+		SELECT user_id, username, password_hash, role, created_at, updated_at
+		FROM users
+		ORDER BY username ASC
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error getting users: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			slog.Error("error scanning user row", "error", err)
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func CountUsers() (int, error) {
+	var count int
+	err := sqlite.DB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	if err != nil {
+		err = fmt.Errorf("error counting users: %w", err)
+		slog.Error(err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+func CountAdmins() (int, error) {
+	var count int
+	err := sqlite.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ?`, RoleAdmin).Scan(&count)
+	if err != nil {
+		err = fmt.Errorf("error counting admins: %w", err)
+		slog.Error(err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// Applies a role and password change together, so a failure leaves the user as it was. A nil field is left as it is.
+func UpdateUser(userID int64, role *Role, password *string) error {
+	var passwordHash string
+	if password != nil {
+		hash, err := HashPassword(*password)
+		if err != nil {
+			slog.Error(err.Error())
+			return err
+		}
+		passwordHash = hash
+	}
+
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting user update: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	if role != nil {
+		query := `UPDATE users SET role = ?, updated_at = CURRENT_TIMESTAMP WHERE user_id = ?`
+
+		if _, err = tx.Exec(query, *role, userID); err != nil {
+			err = fmt.Errorf("error updating user role: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	if password != nil {
+		query := `UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE user_id = ?`
+
+		if _, err = tx.Exec(query, passwordHash, userID); err != nil {
+			err = fmt.Errorf("error updating user password: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing user update: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Deleting a user drops their curation decisions, so the team aggregate on photos is recomputed for every
+// photo they had decided on in the same transaction. This is the same update photos runs after each decision;
+// photos depends on auth, so it can't be called from here.
+func DeleteUser(userID int64) error {
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting user deletion: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	photoIDs, err := getCuratedPhotoIDs(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM users WHERE user_id = ?`, userID)
+	if err != nil {
+		err = fmt.Errorf("error deleting user: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	refreshQuery := `
+		UPDATE photos
+		SET
+			is_curated = COALESCE((SELECT any_picked OR all_rejected FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			is_trashed = COALESCE((SELECT all_rejected AND NOT any_picked FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			rating = COALESCE((SELECT consensus_rating FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			photo_id = ?
+	`
+
+	for _, photoID := range photoIDs {
+		if _, err = tx.Exec(refreshQuery, photoID); err != nil {
+			err = fmt.Errorf("error refreshing team curation: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing user deletion: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func getCuratedPhotoIDs(tx *sql.Tx, userID int64) ([]int64, error) {
+	rows, err := tx.Query(`SELECT photo_id FROM photo_curations WHERE user_id = ?`, userID)
+	if err != nil {
+		err = fmt.Errorf("error querying user curations: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photoIDs []int64
+	for rows.Next() {
+		var photoID int64
+		if err := rows.Scan(&photoID); err != nil {
+			err = fmt.Errorf("error scanning user curation: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		photoIDs = append(photoIDs, photoID)
+	}
+
+	return photoIDs, rows.Err()
+}
+
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
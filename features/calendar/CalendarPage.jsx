@@ -88,8 +88,8 @@ function renderMonthTile(month, handleMonthClick) {
   );
 
   let thumbnailElement = null;
-  if (month.coverPhotoPath) {
-    const thumbnailUrl = getThumbnailUrl(month.coverPhotoPath);
+  if (month.coverPhotoId) {
+    const thumbnailUrl = getThumbnailUrl(month.coverPhotoId);
     thumbnailElement = (
       <img
         src={thumbnailUrl}
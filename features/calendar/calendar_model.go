@@ -8,37 +8,37 @@ import (
 )
 
 type CalendarMonth struct {
-	Year            int     `json:"year"`
-	Month           int     `json:"month"`
-	MonthName       string  `json:"monthName"`
-	CuratedPhotos   int     `json:"curatedPhotos"`
-	UncuratedPhotos int     `json:"uncuratedPhotos"`
-	CoverPhotoPath  *string `json:"coverPhotoPath"`
+	Year            int    `json:"year"`
+	Month           int    `json:"month"`
+	MonthName       string `json:"monthName"`
+	CuratedPhotos   int    `json:"curatedPhotos"`
+	UncuratedPhotos int    `json:"uncuratedPhotos"`
+	CoverPhotoID    *int64 `json:"coverPhotoId"`
 }
 
 func GetCalendarMonths() ([]CalendarMonth, error) {
 	query := `
 		WITH month_stats AS (
 			SELECT
-				strftime('%Y', date_time) as year,
-				strftime('%m', date_time) as month,
-				strftime('%Y-%m', date_time) as year_month,
+				strftime('%Y', local_date_time) as year,
+				strftime('%m', local_date_time) as month,
+				strftime('%Y-%m', local_date_time) as year_month,
 				SUM(CASE WHEN is_curated = 1 THEN 1 ELSE 0 END) as curated_count,
 				SUM(CASE WHEN is_curated = 0 THEN 1 ELSE 0 END) as uncurated_count
 			FROM photos
-			WHERE date_time IS NOT NULL
+			WHERE local_date_time IS NOT NULL
 			  AND is_trashed = 0
 			GROUP BY year_month
 		),
 		cover_photos AS (
 			SELECT DISTINCT
-				strftime('%Y-%m', date_time) as year_month,
-				FIRST_VALUE(file_path) OVER (
-					PARTITION BY strftime('%Y-%m', date_time)
+				strftime('%Y-%m', local_date_time) as year_month,
+				FIRST_VALUE(photo_id) OVER (
+					PARTITION BY strftime('%Y-%m', local_date_time)
 					ORDER BY rating DESC, date_time ASC
 				) as cover_photo
 			FROM photos
-			WHERE date_time IS NOT NULL
+			WHERE local_date_time IS NOT NULL
 			  AND is_trashed = 0
 		)
 		SELECT
@@ -64,7 +64,7 @@ func GetCalendarMonths() ([]CalendarMonth, error) {
 	for rows.Next() {
 		var m CalendarMonth
 		var yearStr, monthStr string
-		err := rows.Scan(&yearStr, &monthStr, &m.CuratedPhotos, &m.UncuratedPhotos, &m.CoverPhotoPath)
+		err := rows.Scan(&yearStr, &monthStr, &m.CuratedPhotos, &m.UncuratedPhotos, &m.CoverPhotoID)
 		if err != nil {
 			slog.Error("error scanning calendar month row", "error", err)
 			continue
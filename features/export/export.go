@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"riffle/commons/sqlite"
+	"riffle/commons/utils"
 	"riffle/features/settings"
 	"time"
 )
@@ -23,7 +24,7 @@ type ExportResult struct {
 	ErrorCount     int `json:"errorCount"`
 }
 
-func StartExport(exportPath string, criteria ExportCriteria) {
+func StartExport(exportPath, libraryPath string, criteria ExportCriteria) {
 	go func() {
 		startedAt := time.Now()
 		exportID, err := CreateExportSession(exportPath, criteria)
@@ -31,7 +32,7 @@ func StartExport(exportPath string, criteria ExportCriteria) {
 			slog.Error("failed to create export log", "error", err)
 		}
 
-		result, err := ProcessExport(exportPath, criteria, exportID)
+		result, err := ProcessExport(exportPath, libraryPath, criteria, exportID)
 		if err != nil {
 			slog.Error("export failed", "error", err)
 			UpdateProgress(StatusExportError, 0, 0, err.Error())
@@ -49,7 +50,7 @@ func StartExport(exportPath string, criteria ExportCriteria) {
 	}()
 }
 
-func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (*ExportResult, error) {
+func ProcessExport(exportPath, libraryPath string, criteria ExportCriteria, exportID int64) (*ExportResult, error) {
 	cleanupEnabled, err := settings.GetExportCleanupEnabled()
 	if err != nil {
 		slog.Warn("failed to get export cleanup setting, using default", "error", err)
@@ -86,7 +87,7 @@ func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (
 		skippedCount := 0
 
 		for _, photo := range photos {
-			wasExported, err := WasPhotoExported(photo.FilePath)
+			wasExported, err := WasPhotoExported(photo.PhotoID)
 			if err != nil {
 				slog.Error("error checking export history", "error", err)
 				continue
@@ -95,7 +96,7 @@ func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (
 			if wasExported {
 				skippedCount++
 				if exportID > 0 {
-					RecordExportedPhoto(exportID, photo.FilePath, "skipped", "already exported")
+					RecordExportedPhoto(exportID, photo.PhotoID, photo.FilePath, "skipped", "already exported")
 				}
 			} else {
 				filteredPhotos = append(filteredPhotos, photo)
@@ -136,11 +137,11 @@ func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (
 	for i, photo := range photos {
 		UpdateProgress(StatusExporting, i, len(photos), fmt.Sprintf("Exporting %d/%d", i+1, len(photos)))
 
-		if err := exportPhoto(photo, exportPath, organizationMode); err != nil {
+		if err := exportPhoto(photo, libraryPath, exportPath, organizationMode); err != nil {
 			slog.Error("error exporting photo", "error", err, "path", photo.FilePath)
 			result.ErrorCount++
 			if exportID > 0 {
-				RecordExportedPhoto(exportID, photo.FilePath, "error", err.Error())
+				RecordExportedPhoto(exportID, photo.PhotoID, photo.FilePath, "error", err.Error())
 				IncrementExportErrors(exportID)
 			}
 			continue
@@ -148,7 +149,7 @@ func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (
 
 		result.ExportedPhotos++
 		if exportID > 0 {
-			RecordExportedPhoto(exportID, photo.FilePath, "success", "")
+			RecordExportedPhoto(exportID, photo.PhotoID, photo.FilePath, "success", "")
 			IncrementExportedPhotos(exportID)
 		}
 	}
@@ -157,13 +158,15 @@ func ProcessExport(exportPath string, criteria ExportCriteria, exportID int64) (
 }
 
 type PhotoToExport struct {
+	PhotoID  int64
 	FilePath string
-	DateTime sql.NullTime
+	// Wall clock time where the photo was taken, so folders match the day it was taken on
+	LocalDateTime sql.NullString
 }
 
 func getPhotosForExport(criteria ExportCriteria) ([]PhotoToExport, error) {
 	query := `
-		SELECT file_path, date_time
+		SELECT photo_id, file_path, local_date_time
 		FROM photos
 		WHERE 1=1
 	`
@@ -192,7 +195,7 @@ func getPhotosForExport(criteria ExportCriteria) ([]PhotoToExport, error) {
 	photos := []PhotoToExport{}
 	for rows.Next() {
 		var photo PhotoToExport
-		if err := rows.Scan(&photo.FilePath, &photo.DateTime); err != nil {
+		if err := rows.Scan(&photo.PhotoID, &photo.FilePath, &photo.LocalDateTime); err != nil {
 			slog.Error("error scanning photo row", "error", err)
 			continue
 		}
@@ -220,17 +223,22 @@ func cleanupExportDirectory(exportPath string) error {
 	return nil
 }
 
-func exportPhoto(photo PhotoToExport, exportPath string, organizationMode settings.ExportOrganizationMode) error {
+func exportPhoto(photo PhotoToExport, libraryPath, exportPath string, organizationMode settings.ExportOrganizationMode) error {
+	sourcePath, err := utils.ResolveLibraryPath(libraryPath, photo.FilePath)
+	if err != nil {
+		return err
+	}
+
 	var destPath string
 
 	if organizationMode == settings.ExportOrgOrganized {
-		var destDir string
-		if !photo.DateTime.Valid {
-			destDir = filepath.Join(exportPath, "Unknown")
-		} else {
-			year := photo.DateTime.Time.Format("2006")
-			month := photo.DateTime.Time.Format("01 - January")
-			destDir = filepath.Join(exportPath, year, month)
+		destDir := filepath.Join(exportPath, "Unknown")
+		if photo.LocalDateTime.Valid {
+			if dateTime, err := time.Parse(utils.LocalDateTimeFormat, photo.LocalDateTime.String); err == nil {
+				year := dateTime.Format("2006")
+				month := dateTime.Format("01 - January")
+				destDir = filepath.Join(exportPath, year, month)
+			}
 		}
 
 		if err := os.MkdirAll(destDir, 0755); err != nil {
@@ -241,7 +249,7 @@ func exportPhoto(photo PhotoToExport, exportPath string, organizationMode settin
 		destPath = filepath.Join(exportPath, filepath.Base(photo.FilePath))
 	}
 
-	sourceFile, err := os.Open(photo.FilePath)
+	sourceFile, err := os.Open(sourcePath)
 	if err != nil {
 		return fmt.Errorf("error opening source file: %w", err)
 	}
@@ -257,7 +265,7 @@ func exportPhoto(photo PhotoToExport, exportPath string, organizationMode settin
 		return fmt.Errorf("error copying file: %w", err)
 	}
 
-	sourceInfo, err := os.Stat(photo.FilePath)
+	sourceInfo, err := os.Stat(sourcePath)
 	if err != nil {
 		slog.Warn("could not get source file info", "error", err)
 		return nil
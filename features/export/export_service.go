@@ -45,7 +45,7 @@ func HandleCreateExportSession(w http.ResponseWriter, r *http.Request) {
 		CurationStatus: curationStatus,
 	}
 
-	StartExport(exportPath, criteria)
+	StartExport(exportPath, os.Getenv("LIBRARY_PATH"), criteria)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
@@ -141,15 +141,16 @@ func CompleteExportSession(exportID int64, startedAt time.Time, result *ExportRe
 	return nil
 }
 
-func RecordExportedPhoto(exportID int64, filePath, status, errorMessage string) error {
+func RecordExportedPhoto(exportID, photoID int64, filePath, status, errorMessage string) error {
 	query := `
-		INSERT INTO exported_photos (export_id, file_path, status, error_message, exported_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO exported_photos (export_id, photo_id, file_path, status, error_message, exported_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := sqlite.DB.Exec(
 		query,
 		exportID,
+		photoID,
 		filePath,
 		status,
 		sql.NullString{String: errorMessage, Valid: errorMessage != ""},
@@ -177,20 +178,20 @@ func UpdateExportSessionSkippedCount(exportID int64, skippedCount int) error {
 	return nil
 }
 
-func WasPhotoExported(filePath string) (bool, error) {
+func WasPhotoExported(photoID int64) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1
 			FROM exported_photos ep
 			JOIN export_sessions es ON ep.export_id = es.export_id
-			WHERE ep.file_path = ?
+			WHERE ep.photo_id = ?
 			AND ep.status = 'success'
 			AND es.status = 'completed'
 		)
 	`
 
 	var exists bool
-	err := sqlite.DB.QueryRow(query, filePath).Scan(&exists)
+	err := sqlite.DB.QueryRow(query, photoID).Scan(&exists)
 	if err != nil {
 		err = fmt.Errorf("error checking if photo was exported: %w", err)
 		slog.Error(err.Error())
@@ -2,18 +2,54 @@ package geocoding
 
 import (
 	"bufio"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"riffle/commons/sqlite"
 	"strconv"
 	"strings"
 )
 
-const geonamesPath = ".geonames"
+const defaultGeonamesPath = ".geonames"
 const countryInfoFileName = "countryInfo.txt"
 const admin1FileName = "admin1CodesASCII.txt"
-const citiesFileName = "cities1000.txt"
+const admin2FileName = "admin2Codes.txt"
+
+// cities500 is a superset of cities1000 and wins when both are present
+var citiesFileNames = []string{"cities500.txt", "cities1000.txt"}
+var alternateNamesFileNames = []string{"alternateNamesV2.txt", "alternateNames.txt"}
+
+var ErrGeonamesFilesMissing = errors.New("geonames files missing")
+
+// Paths of the GeoNames files to load. Optional ones are empty when the file isn't there.
+type sourceFiles struct {
+	CountryInfo    string
+	Admin1         string
+	Admin2         string
+	Cities         string
+	AlternateNames string
+}
+
+func (s *sourceFiles) paths() []string {
+	paths := []string{}
+	for _, path := range []string{s.CountryInfo, s.Admin1, s.Admin2, s.Cities, s.AlternateNames} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Folder holding the GeoNames files, from GEONAMES_PATH or .geonames in the working directory
+func GeonamesPath() string {
+	if path := os.Getenv("GEONAMES_PATH"); path != "" {
+		return path
+	}
+	return defaultGeonamesPath
+}
 
 func Init() error {
 	count, err := getCitiesCount()
@@ -26,48 +62,136 @@ func Init() error {
 		return nil
 	}
 
-	countryInfoPath := geonamesPath + "/" + countryInfoFileName
-	admin1Path := geonamesPath + "/" + admin1FileName
-	citiesPath := geonamesPath + "/" + citiesFileName
-
-	if _, err := os.Stat(countryInfoPath); os.IsNotExist(err) {
-		slog.Warn("geonames country info file not found, geocoding disabled", "path", countryInfoPath)
+	sources, err := findSourceFiles(GeonamesPath())
+	if err != nil {
+		slog.Warn("geocoding disabled", "error", err)
 		return nil
 	}
 
-	if _, err := os.Stat(admin1Path); os.IsNotExist(err) {
-		slog.Warn("geonames admin1 file not found, geocoding disabled", "path", admin1Path)
-		return nil
+	return loadGeonames(sources)
+}
+
+func findSourceFiles(folder string) (*sourceFiles, error) {
+	sources := &sourceFiles{
+		CountryInfo:    findFile(folder, []string{countryInfoFileName}),
+		Admin1:         findFile(folder, []string{admin1FileName}),
+		Admin2:         findFile(folder, []string{admin2FileName}),
+		Cities:         findFile(folder, citiesFileNames),
+		AlternateNames: findFile(folder, alternateNamesFileNames),
 	}
 
-	if _, err := os.Stat(citiesPath); os.IsNotExist(err) {
-		slog.Warn("geonames cities file not found, geocoding disabled", "path", citiesPath)
-		return nil
+	var missing []string
+	if sources.CountryInfo == "" {
+		missing = append(missing, countryInfoFileName)
+	}
+	if sources.Admin1 == "" {
+		missing = append(missing, admin1FileName)
+	}
+	if sources.Cities == "" {
+		missing = append(missing, strings.Join(citiesFileNames, " or "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w in %s: %s", ErrGeonamesFilesMissing, folder, strings.Join(missing, ", "))
+	}
+
+	return sources, nil
+}
+
+// Returns the path of the first file that exists in the folder, or an empty string
+func findFile(folder string, fileNames []string) string {
+	for _, fileName := range fileNames {
+		path := filepath.Join(folder, fileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
 	}
+	return ""
+}
 
-	slog.Info("loading geocoding data from geonames files")
+// Replaces the loaded cities with the ones in the files. Everything happens in one transaction so
+// lookups keep using the previous data until the new data is complete.
+func loadGeonames(sources *sourceFiles) error {
+	slog.Info("loading geocoding data from geonames files", "cities", sources.Cities)
 
-	countryMap, err := parseCountryInfo(countryInfoPath)
+	countryMap, err := parseCountryInfo(sources.CountryInfo)
 	if err != nil {
 		return fmt.Errorf("error parsing country info: %w", err)
 	}
 
-	adminMap, err := parseAdmin1Codes(admin1Path)
+	adminMap, err := parseAdminCodes(sources.Admin1)
 	if err != nil {
 		return fmt.Errorf("error parsing admin1 codes: %w", err)
 	}
 
-	err = loadCities(citiesPath, adminMap, countryMap)
+	// Counties are optional, places just go without one
+	admin2Map := map[string]string{}
+	if sources.Admin2 != "" {
+		admin2Map, err = parseAdminCodes(sources.Admin2)
+		if err != nil {
+			return fmt.Errorf("error parsing admin2 codes: %w", err)
+		}
+	} else {
+		slog.Warn("geonames admin2 file not found, counties disabled")
+	}
+
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"cities", "cities_rtree", "geonames_sources"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("error clearing %s: %w", table, err)
+		}
+	}
+
+	cityIDs, err := loadCities(tx, sources.Cities, adminMap, admin2Map, countryMap)
 	if err != nil {
 		return fmt.Errorf("error loading cities: %w", err)
 	}
 
-	count, _ = getCitiesCount()
-	slog.Info("geocoding data loaded successfully", "cities", count)
+	if language := os.Getenv("GEONAMES_LANGUAGE"); language != "" {
+		if sources.AlternateNames != "" {
+			if err := loadLocalizedNames(tx, sources.AlternateNames, language, cityIDs); err != nil {
+				return fmt.Errorf("error loading localized names: %w", err)
+			}
+		} else {
+			slog.Warn("geonames alternate names file not found, using default place names", "files", alternateNamesFileNames)
+		}
+	}
+
+	if err := recordSources(tx, sources); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	slog.Info("geocoding data loaded successfully", "cities", len(cityIDs))
 
 	return nil
 }
 
+func recordSources(tx *sql.Tx, sources *sourceFiles) error {
+	for _, path := range sources.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO geonames_sources (file_name, file_size, modified_at) VALUES (?, ?, ?)",
+			filepath.Base(path), info.Size(), info.ModTime().UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("error recording geonames source: %w", err)
+		}
+	}
+	return nil
+}
+
 func parseCountryInfo(path string) (map[string]string, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -104,10 +228,11 @@ func parseCountryInfo(path string) (map[string]string, error) {
 	return countryMap, nil
 }
 
-func parseAdmin1Codes(path string) (map[string]string, error) {
+// Reads admin1CodesASCII.txt or admin2Codes.txt, keyed by "CC.ADMIN1" or "CC.ADMIN1.ADMIN2"
+func parseAdminCodes(path string) (map[string]string, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("error opening admin1 file: %w", err)
+		return nil, fmt.Errorf("error opening admin codes file: %w", err)
 	}
 	defer file.Close()
 
@@ -123,47 +248,41 @@ func parseAdmin1Codes(path string) (map[string]string, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning admin1 file: %w", err)
+		return nil, fmt.Errorf("error scanning admin codes file: %w", err)
 	}
 
-	slog.Info("parsed admin1 codes", "count", len(adminMap))
+	slog.Info("parsed admin codes", "path", path, "count", len(adminMap))
 	return adminMap, nil
 }
 
-func loadCities(path string, adminMap, countryMap map[string]string) error {
+// Returns the ids of the loaded cities so alternate names for other features can be skipped
+func loadCities(tx *sql.Tx, path string, adminMap, admin2Map, countryMap map[string]string) (map[int]bool, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("error opening cities file: %w", err)
+		return nil, fmt.Errorf("error opening cities file: %w", err)
 	}
 	defer file.Close()
 
-	tx, err := sqlite.DB.Begin()
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
-	}
-
-	cityStmt, err := tx.Prepare("INSERT INTO cities (geoname_id, name, state, country_code, country_name, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	cityStmt, err := tx.Prepare("INSERT INTO cities (geoname_id, name, county, state, country_code, country_name, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error preparing city statement: %w", err)
+		return nil, fmt.Errorf("error preparing city statement: %w", err)
 	}
 	defer cityStmt.Close()
 
 	rtreeStmt, err := tx.Prepare("INSERT INTO cities_rtree (id, min_lat, max_lat, min_lon, max_lon) VALUES (?, ?, ?, ?, ?)")
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error preparing rtree statement: %w", err)
+		return nil, fmt.Errorf("error preparing rtree statement: %w", err)
 	}
 	defer rtreeStmt.Close()
 
 	scanner := bufio.NewScanner(file)
-	count := 0
+	cityIDs := map[int]bool{}
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		fields := strings.Split(line, "\t")
 
-		if len(fields) < 11 {
+		if len(fields) < 12 {
 			continue
 		}
 
@@ -192,39 +311,103 @@ func loadCities(path string, adminMap, countryMap map[string]string) error {
 			state = adminMap[adminKey]
 		}
 
+		county := ""
+		if admin2Code := fields[11]; admin1Code != "" && admin2Code != "" {
+			county = admin2Map[countryCode+"."+admin1Code+"."+admin2Code]
+		}
+
 		countryName := countryMap[countryCode]
 		if countryName == "" {
 			countryName = countryCode
 		}
 
-		_, err = cityStmt.Exec(geonameID, name, state, countryCode, countryName, lat, lon)
+		_, err = cityStmt.Exec(geonameID, name, county, state, countryCode, countryName, lat, lon)
 		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error inserting city: %w", err)
+			return nil, fmt.Errorf("error inserting city: %w", err)
 		}
 
 		_, err = rtreeStmt.Exec(geonameID, lat, lat, lon, lon)
 		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("error inserting rtree: %w", err)
+			return nil, fmt.Errorf("error inserting rtree: %w", err)
 		}
 
-		count++
-		if count%50000 == 0 {
-			slog.Info("loading cities", "count", count)
+		cityIDs[geonameID] = true
+		if len(cityIDs)%50000 == 0 {
+			slog.Info("loading cities", "count", len(cityIDs))
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("error scanning cities file: %w", err)
+		return nil, fmt.Errorf("error scanning cities file: %w", err)
 	}
 
-	err = tx.Commit()
+	return cityIDs, nil
+}
+
+// Picks one name per city in the given ISO language from the alternate names dump. Preferred names win over
+// regular ones, which win over short ones; colloquial and historic names are skipped.
+func loadLocalizedNames(tx *sql.Tx, path, language string, cityIDs map[int]bool) error {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+		return fmt.Errorf("error opening alternate names file: %w", err)
+	}
+	defer file.Close()
+
+	type candidate struct {
+		name string
+		rank int
+	}
+	names := map[int]candidate{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// alternateNameId, geonameid, isolanguage, name, isPreferredName, isShortName, isColloquial, isHistoric, ...
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 || fields[2] != language {
+			continue
+		}
+
+		geonameID, err := strconv.Atoi(fields[1])
+		if err != nil || !cityIDs[geonameID] {
+			continue
+		}
+
+		flag := func(i int) bool {
+			return len(fields) > i && fields[i] == "1"
+		}
+		if flag(6) || flag(7) {
+			continue
+		}
+
+		rank := 2
+		if flag(4) {
+			rank = 3
+		} else if flag(5) {
+			rank = 1
+		}
+
+		if existing, ok := names[geonameID]; !ok || rank > existing.rank {
+			names[geonameID] = candidate{name: fields[3], rank: rank}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error scanning alternate names file: %w", err)
+	}
+
+	stmt, err := tx.Prepare("UPDATE cities SET localized_name = ? WHERE geoname_id = ?")
+	if err != nil {
+		return fmt.Errorf("error preparing localized name statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for geonameID, c := range names {
+		if _, err := stmt.Exec(c.name, geonameID); err != nil {
+			return fmt.Errorf("error updating localized name: %w", err)
+		}
 	}
 
+	slog.Info("loaded localized place names", "language", language, "count", len(names))
 	return nil
 }
 
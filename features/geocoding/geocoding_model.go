@@ -1,60 +1,192 @@
 package geocoding
 
 import (
-	"database/sql"
-	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"riffle/commons/geo"
 	"riffle/commons/sqlite"
+	"strings"
 )
 
+// City is in the configured language when a localized name was loaded. County is empty where GeoNames
+// has no admin2 division. Place is set when the coordinates fall inside a custom place, which then names
+// the location ahead of the city; the GeoNames fields are still filled in when a city is near.
 type Location struct {
+	PlaceID     int64
+	Place       string
 	City        string
+	County      string
 	State       string
+	CountryCode string
 	CountryName string
+	DistanceKm  float64 // From the given coordinates to the matched city
 }
 
-const defaultEpsilon = 0.1
+const (
+	// Photos further than this from every city get no city, e.g. shots from a boat or a remote trail
+	maxCityDistanceKm = 50.0
+
+	// The search starts small, where almost every photo finds its city, and widens up to the cutoff
+	initialSearchRadiusKm = 5.0
+
+	kmPerDegreeLatitude = 111.2
+)
 
 func ReverseGeocode(latitude, longitude float64) (*Location, error) {
-	return reverseGeocodeWithEpsilon(latitude, longitude, defaultEpsilon)
+	location, err := findNearestCity(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	place, err := findPlace(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	if place != nil {
+		if location == nil {
+			location = &Location{}
+		}
+		location.PlaceID = place.PlaceID
+		location.Place = place.Name
+	}
+
+	return location, nil
+}
+
+// Returns the city nearest to the coordinates by great-circle distance, or nil when none is within maxCityDistanceKm.
+// The rtree narrows the candidates to a box around the coordinates and the candidates are ranked by haversine
+// distance. The box contains the whole search circle, so a city inside the circle is the true nearest; otherwise
+// the search widens.
+func findNearestCity(latitude, longitude float64) (*Location, error) {
+	for radiusKm := initialSearchRadiusKm; ; radiusKm *= 2 {
+		radiusKm = math.Min(radiusKm, maxCityDistanceKm)
+
+		location, err := findNearestCityInBox(latitude, longitude, searchBox(latitude, longitude, radiusKm))
+		if err != nil {
+			return nil, err
+		}
+
+		if location != nil && location.DistanceKm <= radiusKm {
+			return location, nil
+		}
+
+		if radiusKm >= maxCityDistanceKm {
+			return nil, nil
+		}
+	}
+}
+
+type boundingBox struct {
+	MinLat float64
+	MaxLat float64
+	// Longitude ranges, two of them when the box crosses the antimeridian
+	LonRanges [][2]float64
+}
+
+// Returns a box that contains every point within radiusKm of the coordinates
+func searchBox(latitude, longitude, radiusKm float64) boundingBox {
+	deltaLat := radiusKm / kmPerDegreeLatitude
+	box := boundingBox{
+		MinLat: math.Max(latitude-deltaLat, -90),
+		MaxLat: math.Min(latitude+deltaLat, 90),
+	}
+
+	// Degrees of longitude shrink towards the poles, so the box is sized for its most poleward latitude
+	poleward := math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))
+	cosLat := math.Cos(poleward * math.Pi / 180)
+	if poleward >= 90 || cosLat*180*kmPerDegreeLatitude <= radiusKm {
+		box.LonRanges = [][2]float64{{-180, 180}}
+		return box
+	}
+
+	deltaLon := radiusKm / (kmPerDegreeLatitude * cosLat)
+	minLon := longitude - deltaLon
+	maxLon := longitude + deltaLon
+
+	switch {
+	case minLon < -180:
+		box.LonRanges = [][2]float64{{minLon + 360, 180}, {-180, maxLon}}
+	case maxLon > 180:
+		box.LonRanges = [][2]float64{{minLon, 180}, {-180, maxLon - 360}}
+	default:
+		box.LonRanges = [][2]float64{{minLon, maxLon}}
+	}
+
+	return box
 }
 
-func reverseGeocodeWithEpsilon(latitude, longitude, epsilon float64) (*Location, error) {
-	query := `
-		SELECT c.name, c.state, c.country_name,
-			((c.latitude - ?) * (c.latitude - ?) + (c.longitude - ?) * (c.longitude - ?)) AS dist
+func findNearestCityInBox(latitude, longitude float64, box boundingBox) (*Location, error) {
+	// The rtree stores each city as a point, so overlap with the box is the same as containment
+	lonConditions := []string{}
+	args := []any{box.MinLat, box.MaxLat}
+	for _, lonRange := range box.LonRanges {
+		lonConditions = append(lonConditions, "(r.max_lon >= ? AND r.min_lon <= ?)")
+		args = append(args, lonRange[0], lonRange[1])
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(c.localized_name, c.name), COALESCE(c.county, ''), c.state, c.country_code, c.country_name,
+			c.latitude, c.longitude
 		FROM cities_rtree r
 		JOIN cities c ON r.id = c.geoname_id
-		WHERE r.min_lat >= ? AND r.max_lat <= ?
-		  AND r.min_lon >= ? AND r.max_lon <= ?
-		ORDER BY dist ASC
-		LIMIT 1
-	`
-
-	minLat := latitude - epsilon
-	maxLat := latitude + epsilon
-	minLon := longitude - epsilon
-	maxLon := longitude + epsilon
-
-	var location Location
-	var dist float64
-	err := sqlite.DB.QueryRow(query,
-		latitude, latitude, longitude, longitude,
-		minLat, maxLat, minLon, maxLon,
-	).Scan(&location.City, &location.State, &location.CountryName, &dist)
-
-	if errors.Is(err, sql.ErrNoRows) {
-		if epsilon < 1.0 {
-			return reverseGeocodeWithEpsilon(latitude, longitude, epsilon*2)
+		WHERE r.max_lat >= ? AND r.min_lat <= ?
+		  AND (%s)
+	`, strings.Join(lonConditions, " OR "))
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error in reverse geocoding: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nearest *Location
+	for rows.Next() {
+		var location Location
+		var cityLatitude, cityLongitude float64
+		err := rows.Scan(&location.City, &location.County, &location.State, &location.CountryCode, &location.CountryName,
+			&cityLatitude, &cityLongitude)
+		if err != nil {
+			err = fmt.Errorf("error scanning reverse geocoding candidate: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+
+		location.DistanceKm = geo.HaversineDistance(latitude, longitude, cityLatitude, cityLongitude)
+		if nearest == nil || location.DistanceKm < nearest.DistanceKm {
+			nearest = &location
 		}
-		return nil, nil
 	}
 
-	if err != nil {
-		slog.Error("error in reverse geocoding", "error", err)
-		return nil, fmt.Errorf("error in reverse geocoding: %w", err)
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating reverse geocoding candidates: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return nearest, nil
+}
+
+// Column values for storing a location on a photo: place_id, city, county, state, country_name and
+// country_code. Missing values are nil, and all are nil when there is no location.
+func (l *Location) Columns() (placeID, city, county, state, countryName, countryCode any) {
+	if l == nil {
+		return nil, nil, nil, nil, nil, nil
+	}
+
+	if l.PlaceID != 0 {
+		placeID = l.PlaceID
 	}
 
-	return &location, nil
+	return placeID, nullIfEmpty(l.City), nullIfEmpty(l.County), nullIfEmpty(l.State), nullIfEmpty(l.CountryName), nullIfEmpty(l.CountryCode)
+}
+
+func nullIfEmpty(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
 }
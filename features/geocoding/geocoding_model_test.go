@@ -0,0 +1,224 @@
+package geocoding
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"riffle/commons/sqlite"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type testCity struct {
+	id        int
+	name      string
+	state     string
+	country   string
+	latitude  float64
+	longitude float64
+}
+
+var testCities = []testCity{
+	{1, "Munich", "Bavaria", "DE", 48.1372, 11.5755},
+	{2, "Augsburg", "Bavaria", "DE", 48.3705, 10.8978},
+	{3, "Longyearbyen", "Svalbard", "SJ", 78.2232, 15.6267},
+	{4, "Barentsburg", "Svalbard", "SJ", 78.0648, 14.2335},
+	{5, "Waiyevo", "Northern", "FJ", -16.7833, 179.9833},
+	{6, "Tubou", "Eastern", "FJ", -18.2333, -178.8000},
+}
+
+// Sets up an in-memory database with the migrations applied and the test cities loaded
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find migrations: %v", err)
+	}
+	version := func(path string) int {
+		v, _ := strconv.Atoi(strings.Split(filepath.Base(path), "_")[0])
+		return v
+	}
+	sort.Slice(files, func(i, j int) bool { return version(files[i]) < version(files[j]) })
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			t.Fatalf("Failed to apply %s: %v", file, err)
+		}
+	}
+
+	for _, city := range testCities {
+		_, err := db.Exec("INSERT INTO cities (geoname_id, name, state, country_code, country_name, latitude, longitude) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			city.id, city.name, city.state, city.country, city.country, city.latitude, city.longitude)
+		if err != nil {
+			t.Fatalf("Failed to insert city: %v", err)
+		}
+		_, err = db.Exec("INSERT INTO cities_rtree (id, min_lat, max_lat, min_lon, max_lon) VALUES (?, ?, ?, ?, ?)",
+			city.id, city.latitude, city.latitude, city.longitude, city.longitude)
+		if err != nil {
+			t.Fatalf("Failed to insert city into rtree: %v", err)
+		}
+	}
+
+	previous := sqlite.DB
+	sqlite.DB = db
+	t.Cleanup(func() { sqlite.DB = previous })
+}
+
+func TestReverseGeocode(t *testing.T) {
+	setupTestDB(t)
+
+	tests := []struct {
+		name         string
+		latitude     float64
+		longitude    float64
+		expectedCity string
+	}{
+		{
+			name:         "Marienplatz is in Munich",
+			latitude:     48.1374,
+			longitude:    11.5755,
+			expectedCity: "Munich",
+		},
+		{
+			name:         "Between two cities picks the nearer one",
+			latitude:     48.30,
+			longitude:    11.05,
+			expectedCity: "Augsburg",
+		},
+		{
+			// Squared degrees favor Barentsburg, which is 0.07° of longitude away but 43km north,
+			// while Longyearbyen is 1.3° of longitude away but only 39km at this latitude
+			name:         "High latitude ranks by distance rather than degrees",
+			latitude:     78.45,
+			longitude:    14.30,
+			expectedCity: "Longyearbyen",
+		},
+		{
+			name:         "West of the antimeridian finds a city east of it",
+			latitude:     -16.80,
+			longitude:    -179.99,
+			expectedCity: "Waiyevo",
+		},
+		{
+			name:         "East of the antimeridian stays east of it",
+			latitude:     -18.20,
+			longitude:    -178.85,
+			expectedCity: "Tubou",
+		},
+		{
+			name:         "Far from every city is not labelled",
+			latitude:     48.68,
+			longitude:    11.58,
+			expectedCity: "",
+		},
+		{
+			name:         "Open ocean is not labelled",
+			latitude:     40.0,
+			longitude:    -40.0,
+			expectedCity: "",
+		},
+		{
+			name:         "North pole is not labelled",
+			latitude:     90.0,
+			longitude:    0.0,
+			expectedCity: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := ReverseGeocode(tt.latitude, tt.longitude)
+			if err != nil {
+				t.Fatalf("ReverseGeocode(%v, %v) returned error: %v", tt.latitude, tt.longitude, err)
+			}
+
+			actualCity := ""
+			if location != nil {
+				actualCity = location.City
+			}
+
+			if actualCity != tt.expectedCity {
+				t.Errorf("ReverseGeocode(%v, %v)\nexpected: %q\nactual:   %q", tt.latitude, tt.longitude, tt.expectedCity, actualCity)
+			}
+
+			if location != nil && location.DistanceKm > maxCityDistanceKm {
+				t.Errorf("Expected distance within %vkm, got %vkm", maxCityDistanceKm, location.DistanceKm)
+			}
+		})
+	}
+}
+
+func TestReverseGeocode_CustomPlaceWithoutCity(t *testing.T) {
+	setupTestDB(t)
+
+	_, err := sqlite.DB.Exec("INSERT INTO places (name, latitude, longitude, radius_meters) VALUES ('Boat', 40.0, -40.0, 1000)")
+	if err != nil {
+		t.Fatalf("Failed to insert place: %v", err)
+	}
+
+	location, err := ReverseGeocode(40.001, -40.001)
+	if err != nil {
+		t.Fatalf("ReverseGeocode returned error: %v", err)
+	}
+
+	if location == nil || location.Place != "Boat" || location.City != "" {
+		t.Errorf("Expected the custom place without a city, got %+v", location)
+	}
+}
+
+func TestSearchBox(t *testing.T) {
+	tests := []struct {
+		name           string
+		latitude       float64
+		longitude      float64
+		radiusKm       float64
+		expectedRanges int
+		isFullRange    bool
+	}{
+		{"Mid latitude has one range", 48.0, 11.0, 50, 1, false},
+		{"Near the antimeridian splits in two", -16.8, 179.9, 50, 2, false},
+		{"Near the antimeridian from the west splits in two", -16.8, -179.9, 50, 2, false},
+		{"Close to the pole covers every longitude", 89.9, 0.0, 50, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := searchBox(tt.latitude, tt.longitude, tt.radiusKm)
+
+			if len(box.LonRanges) != tt.expectedRanges {
+				t.Fatalf("Expected %d longitude ranges, got %v", tt.expectedRanges, box.LonRanges)
+			}
+
+			isFullRange := box.LonRanges[0] == [2]float64{-180, 180}
+			if isFullRange != tt.isFullRange {
+				t.Errorf("Expected full range %v, got %v", tt.isFullRange, box.LonRanges)
+			}
+
+			for _, lonRange := range box.LonRanges {
+				if lonRange[0] < -180 || lonRange[1] > 180 || lonRange[0] > lonRange[1] {
+					t.Errorf("Invalid longitude range %v", lonRange)
+				}
+			}
+
+			if box.MinLat < -90 || box.MaxLat > 90 || box.MinLat > tt.latitude || box.MaxLat < tt.latitude {
+				t.Errorf("Invalid latitude range %v to %v", box.MinLat, box.MaxLat)
+			}
+		})
+	}
+}
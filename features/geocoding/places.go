@@ -0,0 +1,142 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"riffle/commons/cache"
+	"riffle/commons/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxPlaceNameLength = 100
+	minPlaceRadius     = 10
+	maxPlaceRadius     = 100000
+)
+
+type PlaceRequest struct {
+	Name         string  `json:"name"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radiusMeters"`
+}
+
+func HandleGetPlaces(w http.ResponseWriter, r *http.Request) {
+	places, err := GetAllPlaces()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch places")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(places)
+}
+
+func HandleCreatePlace(w http.ResponseWriter, r *http.Request) {
+	var req PlaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if errCode, errMessage := validatePlace(req); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	place, err := CreatePlace(req.Name, req.Latitude, req.Longitude, req.RadiusMeters)
+	if err != nil {
+		if errors.Is(err, ErrPlaceNameTaken) {
+			utils.SendErrorResponse(w, http.StatusConflict, "PLACE_NAME_TAKEN", "A place with this name already exists")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_ERROR", "Failed to create place")
+		return
+	}
+
+	cache.InvalidateOnLocationChange()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(place)
+}
+
+func HandleUpdatePlace(w http.ResponseWriter, r *http.Request) {
+	placeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid place ID")
+		return
+	}
+
+	var req PlaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if errCode, errMessage := validatePlace(req); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	place, err := UpdatePlace(placeID, req.Name, req.Latitude, req.Longitude, req.RadiusMeters)
+	if err != nil {
+		if errors.Is(err, ErrPlaceNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Place not found")
+			return
+		}
+		if errors.Is(err, ErrPlaceNameTaken) {
+			utils.SendErrorResponse(w, http.StatusConflict, "PLACE_NAME_TAKEN", "A place with this name already exists")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ERROR", "Failed to update place")
+		return
+	}
+
+	cache.InvalidateOnLocationChange()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(place)
+}
+
+func HandleDeletePlace(w http.ResponseWriter, r *http.Request) {
+	placeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid place ID")
+		return
+	}
+
+	if err := DeletePlace(placeID); err != nil {
+		if errors.Is(err, ErrPlaceNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Place not found")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "DELETE_ERROR", "Failed to delete place")
+		return
+	}
+
+	cache.InvalidateOnLocationChange()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func validatePlace(req PlaceRequest) (string, string) {
+	if req.Name == "" || len(req.Name) > maxPlaceNameLength {
+		return "INVALID_NAME", "Place name must be between 1 and 100 characters"
+	}
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return "INVALID_POSITION", "Latitude must be between -90 and 90 and longitude between -180 and 180"
+	}
+	if req.RadiusMeters < minPlaceRadius || req.RadiusMeters > maxPlaceRadius {
+		return "INVALID_RADIUS", "Radius must be between 10 and 100000 meters"
+	}
+	return "", ""
+}
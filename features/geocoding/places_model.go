@@ -0,0 +1,319 @@
+package geocoding
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"riffle/commons/geo"
+	"riffle/commons/sqlite"
+	"strings"
+)
+
+var ErrPlaceNotFound = errors.New("place not found")
+var ErrPlaceNameTaken = errors.New("place name already taken")
+
+type Place struct {
+	PlaceID      int64   `json:"placeId"`
+	Name         string  `json:"name"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters float64 `json:"radiusMeters"`
+	PhotoCount   int     `json:"photoCount"`
+}
+
+func GetAllPlaces() ([]Place, error) {
+	query := `
+		SELECT
+			pl.place_id, pl.name, pl.latitude, pl.longitude, pl.radius_meters,
+			(SELECT COUNT(*) FROM photos p WHERE p.place_id = pl.place_id) AS photo_count
+		FROM
+			places pl
+		ORDER BY
+			pl.name COLLATE NOCASE
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error querying places: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []Place{}
+	for rows.Next() {
+		var p Place
+		if err := rows.Scan(&p.PlaceID, &p.Name, &p.Latitude, &p.Longitude, &p.RadiusMeters, &p.PhotoCount); err != nil {
+			err = fmt.Errorf("error scanning place: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		places = append(places, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating places: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return places, nil
+}
+
+// Same as GetAllPlaces without the photo counts, for matching coordinates
+func getPlaceAreas() ([]Place, error) {
+	rows, err := sqlite.DB.Query(`SELECT place_id, name, latitude, longitude, radius_meters FROM places`)
+	if err != nil {
+		err = fmt.Errorf("error querying place areas: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []Place{}
+	for rows.Next() {
+		var p Place
+		if err := rows.Scan(&p.PlaceID, &p.Name, &p.Latitude, &p.Longitude, &p.RadiusMeters); err != nil {
+			err = fmt.Errorf("error scanning place area: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		places = append(places, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating place areas: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return places, nil
+}
+
+func GetPlaceByID(placeID int64) (*Place, error) {
+	query := `
+		SELECT place_id, name, latitude, longitude, radius_meters
+		FROM places
+		WHERE place_id = ?
+	`
+
+	var p Place
+	err := sqlite.DB.QueryRow(query, placeID).Scan(&p.PlaceID, &p.Name, &p.Latitude, &p.Longitude, &p.RadiusMeters)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPlaceNotFound
+	}
+	if err != nil {
+		err = fmt.Errorf("error getting place: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Creates the place and tags the photos inside it
+func CreatePlace(name string, latitude, longitude, radiusMeters float64) (*Place, error) {
+	query := `
+		INSERT INTO places (name, latitude, longitude, radius_meters)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := sqlite.DB.Exec(query, name, latitude, longitude, radiusMeters)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, ErrPlaceNameTaken
+		}
+		err = fmt.Errorf("error creating place: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	placeID, _ := result.LastInsertId()
+	place := &Place{PlaceID: placeID, Name: name, Latitude: latitude, Longitude: longitude, RadiusMeters: radiusMeters}
+
+	if err := assignPlaces(*place); err != nil {
+		return nil, err
+	}
+
+	return place, nil
+}
+
+// Moves, resizes or renames the place, then re-tags photos in both the old and the new area
+func UpdatePlace(placeID int64, name string, latitude, longitude, radiusMeters float64) (*Place, error) {
+	previous, err := GetPlaceByID(placeID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE places
+		SET name = ?, latitude = ?, longitude = ?, radius_meters = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE place_id = ?
+	`
+
+	_, err = sqlite.DB.Exec(query, name, latitude, longitude, radiusMeters, placeID)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, ErrPlaceNameTaken
+		}
+		err = fmt.Errorf("error updating place: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	place := &Place{PlaceID: placeID, Name: name, Latitude: latitude, Longitude: longitude, RadiusMeters: radiusMeters}
+
+	if err := assignPlaces(*previous, *place); err != nil {
+		return nil, err
+	}
+
+	return place, nil
+}
+
+// Deletes the place. Its photos fall back to any other place they are inside.
+func DeletePlace(placeID int64) error {
+	place, err := GetPlaceByID(placeID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sqlite.DB.Exec(`DELETE FROM places WHERE place_id = ?`, placeID); err != nil {
+		err = fmt.Errorf("error deleting place: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return assignPlaces(*place)
+}
+
+// Returns the place containing the coordinates, the one with the nearest center if several do
+func matchPlace(places []Place, latitude, longitude float64) *Place {
+	var match *Place
+	nearest := math.Inf(1)
+
+	for i := range places {
+		distanceKm := geo.HaversineDistance(latitude, longitude, places[i].Latitude, places[i].Longitude)
+		if distanceKm*1000 <= places[i].RadiusMeters && distanceKm < nearest {
+			match = &places[i]
+			nearest = distanceKm
+		}
+	}
+
+	return match
+}
+
+func findPlace(latitude, longitude float64) (*Place, error) {
+	places, err := getPlaceAreas()
+	if err != nil {
+		return nil, err
+	}
+	return matchPlace(places, latitude, longitude), nil
+}
+
+// Re-tags the photos with coordinates inside any of the given areas against the current places
+func assignPlaces(areas ...Place) error {
+	places, err := getPlaceAreas()
+	if err != nil {
+		return err
+	}
+
+	positions := map[int64]photoPosition{}
+	for _, area := range areas {
+		areaPositions, err := getPhotoPositionsNear(area)
+		if err != nil {
+			return err
+		}
+		for _, position := range areaPositions {
+			positions[position.PhotoID] = position
+		}
+	}
+
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting place assignment transaction: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, position := range positions {
+		var placeID any
+		if place := matchPlace(places, position.Latitude, position.Longitude); place != nil {
+			placeID = place.PlaceID
+		}
+
+		if _, err := tx.Exec(`UPDATE photos SET place_id = ? WHERE photo_id = ?`, placeID, position.PhotoID); err != nil {
+			err = fmt.Errorf("error assigning photo place: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing place assignment: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Photos within the bounding box around the place's circle, plus those currently tagged with it
+func getPhotoPositionsNear(place Place) ([]photoPosition, error) {
+	latDelta := place.RadiusMeters / 1000 / 111.32
+	lonDelta := 180.0
+	if cos := math.Cos(place.Latitude * math.Pi / 180); cos > 0.01 {
+		lonDelta = math.Min(180, latDelta/cos)
+	}
+
+	lonCondition := "longitude BETWEEN ? AND ?"
+	west, east := place.Longitude-lonDelta, place.Longitude+lonDelta
+	if west < -180 || east > 180 {
+		// The box wraps around the antimeridian
+		lonCondition = "(longitude >= ? OR longitude <= ?)"
+		west, east = math.Mod(west+540, 360)-180, math.Mod(east+540, 360)-180
+	}
+
+	query := fmt.Sprintf(`
+		SELECT photo_id, latitude, longitude
+		FROM photos
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		AND (
+			(latitude BETWEEN ? AND ? AND %s)
+			OR place_id = ?
+		)
+	`, lonCondition)
+
+	rows, err := sqlite.DB.Query(query, place.Latitude-latDelta, place.Latitude+latDelta, west, east, place.PlaceID)
+	if err != nil {
+		err = fmt.Errorf("error querying photos near place: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := []photoPosition{}
+	for rows.Next() {
+		var p photoPosition
+		if err := rows.Scan(&p.PhotoID, &p.Latitude, &p.Longitude); err != nil {
+			err = fmt.Errorf("error scanning photo position: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating photos near place: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
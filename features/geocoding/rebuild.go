@@ -0,0 +1,53 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"riffle/commons/utils"
+)
+
+type RebuildResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+func HandleRebuildGeocoding(w http.ResponseWriter, r *http.Request) {
+	currentProgress := GetRebuildProgress()
+	if currentProgress.Status == StatusRebuildProcessing {
+		utils.SendErrorResponse(w, http.StatusConflict, "REBUILD_IN_PROGRESS", "Geocoding rebuild already in progress")
+		return
+	}
+
+	count, err := getCitiesCount()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GEOCODING_ERROR", "Failed to check geocoding data")
+		return
+	}
+	if count == 0 {
+		utils.SendErrorResponse(w, http.StatusConflict, "GEOCODING_DATA_MISSING", "GeoNames data is not loaded, add the files and restart first")
+		return
+	}
+
+	go func() {
+		if err := RebuildPhotoLocations(); err != nil {
+			slog.Error("failed to rebuild photo locations", "error", err)
+			return
+		}
+		slog.Info("geocoding rebuild completed")
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RebuildResponse{
+		Success: true,
+		Message: "geocoding rebuild started",
+	})
+}
+
+func HandleGetRebuildProgress(w http.ResponseWriter, r *http.Request) {
+	progress := GetRebuildProgress()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(progress)
+}
@@ -0,0 +1,50 @@
+package geocoding
+
+import (
+	"sync"
+)
+
+type RebuildStatus string
+
+const (
+	StatusRebuildIdle       RebuildStatus = "idle"
+	StatusRebuildProcessing RebuildStatus = "processing"
+	StatusRebuildComplete   RebuildStatus = "complete"
+)
+
+type RebuildProgress struct {
+	Status    RebuildStatus `json:"status"`
+	Completed int           `json:"completed"`
+	Total     int           `json:"total"`
+	Percent   int           `json:"percent"`
+	Resolved  int           `json:"resolved"`
+}
+
+var (
+	rebuildProgressMutex   sync.RWMutex
+	currentRebuildProgress RebuildProgress
+)
+
+func UpdateRebuildProgress(status RebuildStatus, completed, total, resolved int) {
+	rebuildProgressMutex.Lock()
+	defer rebuildProgressMutex.Unlock()
+
+	percent := 0
+	if total > 0 {
+		percent = int(float64(completed) / float64(total) * 100)
+	}
+
+	currentRebuildProgress = RebuildProgress{
+		Status:    status,
+		Completed: completed,
+		Total:     total,
+		Percent:   percent,
+		Resolved:  resolved,
+	}
+}
+
+func GetRebuildProgress() RebuildProgress {
+	rebuildProgressMutex.RLock()
+	defer rebuildProgressMutex.RUnlock()
+	return currentRebuildProgress
+}
@@ -0,0 +1,128 @@
+package geocoding
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/cache"
+	"riffle/commons/sqlite"
+)
+
+var ErrNoGeocodingData = errors.New("geocoding data is not loaded")
+
+type photoPosition struct {
+	PhotoID   int64
+	Latitude  float64
+	Longitude float64
+}
+
+// Resolves the location of every photo with coordinates again, for photos imported before the GeoNames data
+// was available or after it changed. Photos with no city or custom place nearby have their location cleared.
+func RebuildPhotoLocations() error {
+	slog.Info("starting geocoding rebuild")
+
+	UpdateRebuildProgress(StatusRebuildProcessing, 0, 0, 0)
+
+	count, err := getCitiesCount()
+	if err != nil || count == 0 {
+		// Without cities every photo would lose its place
+		UpdateRebuildProgress(StatusRebuildIdle, 0, 0, 0)
+		if err != nil {
+			return err
+		}
+		return ErrNoGeocodingData
+	}
+
+	positions, err := getPhotoPositions()
+	if err != nil {
+		UpdateRebuildProgress(StatusRebuildIdle, 0, 0, 0)
+		return err
+	}
+
+	total := len(positions)
+	slog.Info("rebuilding photo locations", "totalPhotos", total)
+	UpdateRebuildProgress(StatusRebuildProcessing, 0, total, 0)
+
+	resolved := 0
+	failed := 0
+	for i, position := range positions {
+		location, err := ReverseGeocode(position.Latitude, position.Longitude)
+		if err == nil {
+			err = UpdatePhotoLocation(position.PhotoID, location)
+		}
+
+		if err != nil {
+			failed++
+		} else if location != nil {
+			resolved++
+		}
+
+		if (i+1)%100 == 0 {
+			UpdateRebuildProgress(StatusRebuildProcessing, i+1, total, resolved)
+		}
+	}
+
+	cache.InvalidateOnLocationChange()
+
+	UpdateRebuildProgress(StatusRebuildComplete, total, total, resolved)
+	slog.Info("geocoding rebuild complete", "total", total, "resolved", resolved, "failed", failed)
+
+	return nil
+}
+
+// Same rule as import, which skips zero coordinates since that is what cameras write when they have no fix
+func getPhotoPositions() ([]photoPosition, error) {
+	query := `
+		SELECT photo_id, latitude, longitude
+		FROM photos
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		AND latitude != 0 AND longitude != 0
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error getting photo positions: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := []photoPosition{}
+	for rows.Next() {
+		var p photoPosition
+		if err := rows.Scan(&p.PhotoID, &p.Latitude, &p.Longitude); err != nil {
+			err = fmt.Errorf("error scanning photo position: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating photo positions: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// Writes the place of a photo, clearing it when location is nil
+func UpdatePhotoLocation(photoID int64, location *Location) error {
+	query := `
+		UPDATE photos
+		SET place_id = ?, city = ?, county = ?, state = ?, country_name = ?, country_code = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE photo_id = ?
+	`
+
+	placeID, city, county, state, countryName, countryCode := location.Columns()
+
+	_, err := sqlite.DB.Exec(query, placeID, city, county, state, countryName, countryCode, photoID)
+	if err != nil {
+		err = fmt.Errorf("error updating photo location: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
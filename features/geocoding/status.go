@@ -0,0 +1,212 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"riffle/commons/sqlite"
+	"riffle/commons/utils"
+	"sync"
+	"time"
+)
+
+type SourceFileStatus struct {
+	Name       string  `json:"name"`
+	IsPresent  bool    `json:"isPresent"`
+	Size       int64   `json:"size"`
+	ModifiedAt *string `json:"modifiedAt"`
+	LoadedAt   *string `json:"loadedAt"`  // When the loaded data was read from this file, nil if it wasn't
+	IsChanged  bool    `json:"isChanged"` // The file on disk differs from the one that was loaded
+}
+
+type Status struct {
+	Path            string             `json:"path"`
+	Language        string             `json:"language"`
+	Cities          int                `json:"cities"`
+	LocalizedNames  int                `json:"localizedNames"`
+	Places          int                `json:"places"`
+	IsReloading     bool               `json:"isReloading"`
+	LastReloadError string             `json:"lastReloadError,omitempty"`
+	Files           []SourceFileStatus `json:"files"`
+}
+
+type loadedSource struct {
+	FileSize   int64
+	ModifiedAt time.Time
+	LoadedAt   time.Time
+}
+
+var ErrReloadInProgress = errors.New("geonames reload already in progress")
+
+var (
+	reloadMutex     sync.Mutex
+	isReloading     bool
+	lastReloadError string
+)
+
+func GetStatus() (*Status, error) {
+	status := &Status{
+		Path:     GeonamesPath(),
+		Language: os.Getenv("GEONAMES_LANGUAGE"),
+		Files:    []SourceFileStatus{},
+	}
+
+	reloadMutex.Lock()
+	status.IsReloading = isReloading
+	status.LastReloadError = lastReloadError
+	reloadMutex.Unlock()
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM cities),
+			(SELECT COUNT(*) FROM cities WHERE localized_name IS NOT NULL),
+			(SELECT COUNT(*) FROM places)
+	`
+	if err := sqlite.DB.QueryRow(query).Scan(&status.Cities, &status.LocalizedNames, &status.Places); err != nil {
+		err = fmt.Errorf("error counting geocoding data: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	loaded, err := getLoadedSources()
+	if err != nil {
+		return nil, err
+	}
+
+	fileNames := []string{countryInfoFileName, admin1FileName, admin2FileName}
+	fileNames = append(fileNames, citiesFileNames...)
+	fileNames = append(fileNames, alternateNamesFileNames...)
+
+	for _, fileName := range fileNames {
+		file := SourceFileStatus{Name: fileName}
+
+		if info, err := os.Stat(filepath.Join(status.Path, fileName)); err == nil {
+			modifiedAt := info.ModTime().UTC().Format(time.RFC3339)
+			file.IsPresent = true
+			file.Size = info.Size()
+			file.ModifiedAt = &modifiedAt
+		}
+
+		if source, ok := loaded[fileName]; ok {
+			loadedAt := source.LoadedAt.UTC().Format(time.RFC3339)
+			file.LoadedAt = &loadedAt
+			file.IsChanged = !file.IsPresent || file.Size != source.FileSize || *file.ModifiedAt != source.ModifiedAt.UTC().Format(time.RFC3339)
+		}
+
+		status.Files = append(status.Files, file)
+	}
+
+	return status, nil
+}
+
+func getLoadedSources() (map[string]loadedSource, error) {
+	rows, err := sqlite.DB.Query(`SELECT file_name, file_size, modified_at, loaded_at FROM geonames_sources`)
+	if err != nil {
+		err = fmt.Errorf("error querying geonames sources: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := map[string]loadedSource{}
+	for rows.Next() {
+		var fileName string
+		var source loadedSource
+		if err := rows.Scan(&fileName, &source.FileSize, &source.ModifiedAt, &source.LoadedAt); err != nil {
+			err = fmt.Errorf("error scanning geonames source: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		sources[fileName] = source
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating geonames sources: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// Loads the GeoNames files again and then re-resolves every photo's location against the new data
+func Reload() error {
+	sources, err := findSourceFiles(GeonamesPath())
+	if err != nil {
+		return err
+	}
+
+	reloadMutex.Lock()
+	if isReloading {
+		reloadMutex.Unlock()
+		return ErrReloadInProgress
+	}
+	isReloading = true
+	lastReloadError = ""
+	reloadMutex.Unlock()
+
+	go func() {
+		err := loadGeonames(sources)
+
+		reloadMutex.Lock()
+		isReloading = false
+		if err != nil {
+			lastReloadError = err.Error()
+		}
+		reloadMutex.Unlock()
+
+		if err != nil {
+			slog.Error("failed to reload geonames data", "error", err)
+			return
+		}
+
+		if err := RebuildPhotoLocations(); err != nil {
+			slog.Error("failed to rebuild photo locations after reload", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func HandleGetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := GetStatus()
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to get geocoding status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
+
+func HandleReload(w http.ResponseWriter, r *http.Request) {
+	if GetRebuildProgress().Status == StatusRebuildProcessing {
+		utils.SendErrorResponse(w, http.StatusConflict, "REBUILD_IN_PROGRESS", "Wait for the location rebuild to finish")
+		return
+	}
+
+	if err := Reload(); err != nil {
+		if errors.Is(err, ErrReloadInProgress) {
+			utils.SendErrorResponse(w, http.StatusConflict, "RELOAD_IN_PROGRESS", "GeoNames data is already being reloaded")
+			return
+		}
+		if errors.Is(err, ErrGeonamesFilesMissing) {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "GEONAMES_FILES_MISSING", err.Error())
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "RELOAD_ERROR", "Failed to reload GeoNames data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RebuildResponse{
+		Success: true,
+		Message: "geonames reload started",
+	})
+}
@@ -0,0 +1,186 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import Checkbox from '../../commons/components/Checkbox.jsx';
+import EmptyState from '../../commons/components/EmptyState.jsx';
+import { Table, TableHeader, TableHeaderCell, TableBody, TableRow, TableCell } from '../../commons/components/Table.jsx';
+import { MapPinIcon } from '../../commons/components/Icon.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import getThumbnailUrl from '../../commons/utils/getThumbnailUrl.js';
+import formatDateTime from '../../commons/utils/formatDateTime.js';
+import pluralize from '../../commons/utils/pluralize.js';
+import './GeotagPage.css';
+
+const { useState } = React;
+
+const DEFAULT_MAX_GAP_MINUTES = 10;
+
+export default function GeotagPage() {
+  const [files, setFiles] = useState([]);
+  const [offsetMinutes, setOffsetMinutes] = useState('0');
+  const [maxGapMinutes, setMaxGapMinutes] = useState(String(DEFAULT_MAX_GAP_MINUTES));
+  const [preview, setPreview] = useState(null);
+  const [selectedPhotoIds, setSelectedPhotoIds] = useState(new Set());
+  const [isPreviewing, setIsPreviewing] = useState(false);
+  const [isApplying, setIsApplying] = useState(false);
+
+  function handleFilesChange(e) {
+    setFiles(Array.from(e.target.files));
+    setPreview(null);
+  }
+
+  async function handlePreview() {
+    if (files.length === 0) {
+      showToast('Choose a GPX, KML or FIT file');
+      return;
+    }
+
+    const offsetSeconds = Math.round(parseFloat(offsetMinutes || '0') * 60);
+    const maxGapSeconds = Math.round(parseFloat(maxGapMinutes || '0') * 60);
+    if (isNaN(offsetSeconds) || isNaN(maxGapSeconds) || maxGapSeconds <= 0) {
+      showToast('Enter the offset and max gap in minutes');
+      return;
+    }
+
+    setIsPreviewing(true);
+    try {
+      const data = await ApiClient.previewGeotags(files, offsetSeconds, maxGapSeconds);
+      setPreview(data);
+      setSelectedPhotoIds(new Set(data.matches.map(match => match.photoId)));
+    } catch (error) {
+      console.error('Failed to preview geotags:', error);
+    } finally {
+      setIsPreviewing(false);
+    }
+  }
+
+  function handleToggle(photoId) {
+    setSelectedPhotoIds(prev => {
+      const next = new Set(prev);
+      if (next.has(photoId)) {
+        next.delete(photoId);
+      } else {
+        next.add(photoId);
+      }
+      return next;
+    });
+  }
+
+  function handleToggleAll() {
+    if (selectedPhotoIds.size === preview.matches.length) {
+      setSelectedPhotoIds(new Set());
+    } else {
+      setSelectedPhotoIds(new Set(preview.matches.map(match => match.photoId)));
+    }
+  }
+
+  async function handleApply() {
+    const matches = preview.matches
+      .filter(match => selectedPhotoIds.has(match.photoId))
+      .map(match => ({ photoId: match.photoId, latitude: match.latitude, longitude: match.longitude }));
+
+    setIsApplying(true);
+    try {
+      const result = await ApiClient.applyGeotags(matches);
+      showToast(`Geotagged ${result.updated} ${pluralize(result.updated, 'photo')}`);
+      setPreview(null);
+      setSelectedPhotoIds(new Set());
+    } catch (error) {
+      console.error('Failed to apply geotags:', error);
+    } finally {
+      setIsApplying(false);
+    }
+  }
+
+  let previewContent = null;
+  if (preview && preview.matches.length === 0) {
+    previewContent = (
+      <EmptyState
+        icon={<MapPinIcon />}
+        title="No matches"
+        description={`None of the photos without a location were taken within the max gap of the ${preview.trackPoints} track ${pluralize(preview.trackPoints, 'point')}. Try a clock offset or a larger gap.`}
+      />
+    );
+  } else if (preview) {
+    const rows = preview.matches.map(match => (
+      <TableRow key={match.photoId}>
+        <TableCell>
+          <Checkbox checked={selectedPhotoIds.has(match.photoId)} onChange={() => handleToggle(match.photoId)} />
+        </TableCell>
+        <TableCell>
+          <img className="geotag-thumbnail" src={getThumbnailUrl(match.photoId)} loading="lazy" />
+        </TableCell>
+        <TableCell>{formatDateTime(match.dateTime)}</TableCell>
+        <TableCell>{match.latitude.toFixed(5)}, {match.longitude.toFixed(5)}</TableCell>
+        <TableCell>{formatGap(match.gapSeconds)}</TableCell>
+      </TableRow>
+    ));
+
+    const count = selectedPhotoIds.size;
+    previewContent = (
+      <>
+        <div className="geotag-summary">
+          {preview.matches.length} {pluralize(preview.matches.length, 'photo')} matched from {formatDateTime(preview.trackStart)} to {formatDateTime(preview.trackEnd)}
+        </div>
+        <Table>
+          <TableHeader>
+            <TableHeaderCell>
+              <Checkbox checked={count === preview.matches.length} onChange={handleToggleAll} />
+            </TableHeaderCell>
+            <TableHeaderCell>Photo</TableHeaderCell>
+            <TableHeaderCell>Taken</TableHeaderCell>
+            <TableHeaderCell>Position</TableHeaderCell>
+            <TableHeaderCell>Nearest point</TableHeaderCell>
+          </TableHeader>
+          <TableBody>
+            {rows}
+          </TableBody>
+        </Table>
+        <div className="geotag-action">
+          <Button variant="primary" onClick={handleApply} isLoading={isApplying} isDisabled={count === 0}>
+            Apply to {count} {pluralize(count, 'photo')}
+          </Button>
+        </div>
+      </>
+    );
+  }
+
+  return (
+    <div className="geotag-page">
+      <div className="geotag-form">
+        <div className="input-container">
+          <label htmlFor="geotag-files">Track files</label>
+          <div className="input-hint">GPX, KML or FIT. Several files are merged into one track.</div>
+          <input id="geotag-files" type="file" accept=".gpx,.kml,.fit" multiple onChange={handleFilesChange} />
+        </div>
+        <Input
+          id="geotag-offset"
+          label="Camera clock offset (minutes)"
+          type="number"
+          hint="Added to photo times. Use a positive value if the camera was behind the GPS."
+          value={offsetMinutes}
+          onChange={e => setOffsetMinutes(e.target.value)}
+        />
+        <Input
+          id="geotag-max-gap"
+          label="Max gap (minutes)"
+          type="number"
+          hint="Photos further than this from a track point are left alone."
+          value={maxGapMinutes}
+          onChange={e => setMaxGapMinutes(e.target.value)}
+        />
+        <div className="geotag-action">
+          <Button onClick={handlePreview} isLoading={isPreviewing}>Preview Matches</Button>
+        </div>
+      </div>
+      {previewContent}
+    </div>
+  );
+}
+
+function formatGap(seconds) {
+  if (seconds < 60) {
+    return `${seconds}s`;
+  }
+  return `${Math.round(seconds / 60)} min`;
+}
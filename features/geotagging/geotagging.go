@@ -0,0 +1,152 @@
+package geotagging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"riffle/commons/cache"
+	"riffle/commons/utils"
+	"strconv"
+	"time"
+)
+
+const maxUploadSize = 64 << 20
+
+type PreviewResponse struct {
+	Matches     []Match `json:"matches"`
+	TrackPoints int     `json:"trackPoints"`
+	TrackStart  *string `json:"trackStart"`
+	TrackEnd    *string `json:"trackEnd"`
+}
+
+type ApplyRequest struct {
+	Matches []Position `json:"matches"`
+}
+
+type ApplyResponse struct {
+	Updated int `json:"updated"`
+}
+
+// POST /api/geotag/preview/ with multipart "files" (GPX, KML or FIT), "offsetSeconds" and "maxGapSeconds".
+// Nothing is written; the matches are returned for review.
+func HandlePreviewGeotags(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_UPLOAD", "Track files must be uploaded as multipart form data under 64 MB")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	offset := time.Duration(0)
+	if value := r.FormValue("offsetSeconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_OFFSET", "Offset must be a whole number of seconds")
+			return
+		}
+		offset = time.Duration(seconds) * time.Second
+	}
+
+	maxGap := DefaultMaxGap
+	if value := r.FormValue("maxGapSeconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > MaxMaxGap {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_MAX_GAP", fmt.Sprintf("Max gap must be between 1 and %d seconds", int(MaxMaxGap.Seconds())))
+			return
+		}
+		maxGap = time.Duration(seconds) * time.Second
+	}
+
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "NO_FILES", "Upload at least one track file")
+		return
+	}
+
+	points := []TrackPoint{}
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_UPLOAD", "Failed to read uploaded file")
+			return
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_UPLOAD", "Failed to read uploaded file")
+			return
+		}
+
+		trackPoints, err := ParseTrack(header.Filename, data)
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedTrack) {
+				utils.SendErrorResponse(w, http.StatusBadRequest, "UNSUPPORTED_TRACK", fmt.Sprintf("%s is not a GPX, KML or FIT file", header.Filename))
+				return
+			}
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_TRACK", fmt.Sprintf("Failed to parse %s", header.Filename))
+			return
+		}
+		points = append(points, trackPoints...)
+	}
+
+	// Tracks from several files are merged into one timeline
+	points = sortTrackPoints(points)
+
+	matches, err := FindMatches(points, offset, maxGap)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "MATCH_ERROR", "Failed to match photos to the track")
+		return
+	}
+
+	response := PreviewResponse{
+		Matches:     matches,
+		TrackPoints: len(points),
+	}
+	if len(points) > 0 {
+		start := points[0].Time.Format(time.RFC3339)
+		end := points[len(points)-1].Time.Format(time.RFC3339)
+		response.TrackStart = &start
+		response.TrackEnd = &end
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// POST /api/geotag/apply/ with the reviewed matches from a preview
+func HandleApplyGeotags(w http.ResponseWriter, r *http.Request) {
+	var req ApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	if len(req.Matches) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "NO_MATCHES", "No matches to apply")
+		return
+	}
+
+	for _, match := range req.Matches {
+		if match.Latitude < -90 || match.Latitude > 90 || match.Longitude < -180 || match.Longitude > 180 {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_POSITION", fmt.Sprintf("Invalid position for photo %d", match.PhotoID))
+			return
+		}
+	}
+
+	updated, err := ApplyPositions(req.Matches)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "APPLY_ERROR", "Failed to apply geotags")
+		return
+	}
+
+	if updated > 0 {
+		cache.InvalidateOnLocationChange()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ApplyResponse{Updated: updated})
+}
@@ -0,0 +1,164 @@
+package geotagging
+
+import (
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"riffle/commons/utils"
+	"riffle/features/geocoding"
+	"time"
+)
+
+type Match struct {
+	PhotoID    int64   `json:"photoId"`
+	DateTime   string  `json:"dateTime"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	GapSeconds int     `json:"gapSeconds"`
+}
+
+type Position struct {
+	PhotoID   int64   `json:"photoId"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type untaggedPhoto struct {
+	PhotoID  int64
+	DateTime string
+}
+
+// Matches photos without coordinates against the track. The offset is added to each photo's time
+// to correct a camera clock that was off from the GPS clock.
+func FindMatches(points []TrackPoint, offset, maxGap time.Duration) ([]Match, error) {
+	matches := []Match{}
+	if len(points) == 0 {
+		return matches, nil
+	}
+
+	// Widened by a day so the string comparison still catches times stored with a UTC offset
+	from := points[0].Time.Add(-offset - maxGap - 24*time.Hour)
+	to := points[len(points)-1].Time.Add(-offset + maxGap + 24*time.Hour)
+
+	candidates, err := getUntaggedPhotos(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, photo := range candidates {
+		photoTime := utils.ParseDateTime(photo.DateTime)
+		if photoTime == nil {
+			continue
+		}
+
+		latitude, longitude, gap, ok := Interpolate(points, photoTime.UTC().Add(offset), maxGap)
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, Match{
+			PhotoID:    photo.PhotoID,
+			DateTime:   photo.DateTime,
+			Latitude:   latitude,
+			Longitude:  longitude,
+			GapSeconds: int(gap.Seconds()),
+		})
+	}
+
+	return matches, nil
+}
+
+func getUntaggedPhotos(from, to time.Time) ([]untaggedPhoto, error) {
+	query := `
+		SELECT
+			photo_id, date_time
+		FROM
+			photos
+		WHERE
+			is_trashed = 0
+			AND (latitude IS NULL OR longitude IS NULL)
+			AND date_time BETWEEN ? AND ?
+		ORDER BY
+			date_time
+	`
+
+	rows, err := sqlite.DB.Query(query, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if err != nil {
+		err = fmt.Errorf("error querying untagged photos: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []untaggedPhoto{}
+	for rows.Next() {
+		var p untaggedPhoto
+		if err := rows.Scan(&p.PhotoID, &p.DateTime); err != nil {
+			err = fmt.Errorf("error scanning untagged photo: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		photos = append(photos, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating untagged photos: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// Writes the positions and the reverse geocoded place. Photos that got coordinates in the meantime are
+// left alone so a stale preview can't overwrite them. The positions are marked as set by hand, so re-reading
+// the metadata keeps them. Returns how many photos were updated.
+func ApplyPositions(positions []Position) (int, error) {
+	// Geocoding runs before the transaction since it reads from the same database
+	locations := make([]*geocoding.Location, len(positions))
+	for i, position := range positions {
+		location, err := geocoding.ReverseGeocode(position.Latitude, position.Longitude)
+		if err != nil {
+			return 0, err
+		}
+		locations[i] = location
+	}
+
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting geotag transaction: %w", err)
+		slog.Error(err.Error())
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE photos
+		SET latitude = ?, longitude = ?, place_id = ?, city = ?, county = ?, state = ?, country_name = ?, country_code = ?,
+			is_location_manual = 1
+		WHERE photo_id = ? AND (latitude IS NULL OR longitude IS NULL)
+	`
+
+	updated := 0
+	for i, position := range positions {
+		placeID, city, county, state, countryName, countryCode := locations[i].Columns()
+
+		result, err := tx.Exec(query, position.Latitude, position.Longitude, placeID, city, county, state, countryName, countryCode, position.PhotoID)
+		if err != nil {
+			err = fmt.Errorf("error geotagging photo %d: %w", position.PhotoID, err)
+			slog.Error(err.Error())
+			return 0, err
+		}
+
+		affected, _ := result.RowsAffected()
+		updated += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing geotags: %w", err)
+		slog.Error(err.Error())
+		return 0, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,75 @@
+package geotagging
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	DefaultMaxGap = 10 * time.Minute
+	MaxMaxGap     = 24 * time.Hour
+)
+
+// Finds the position at t along a track sorted by time. Between two points no more than maxGap apart
+// the position is interpolated linearly; otherwise the nearest point is used if it is within maxGap.
+// The returned gap is how far t is from the closest track point, for judging how reliable a match is.
+func Interpolate(points []TrackPoint, t time.Time, maxGap time.Duration) (latitude, longitude float64, gap time.Duration, ok bool) {
+	if len(points) == 0 {
+		return 0, 0, 0, false
+	}
+
+	// Index of the first point at or after t
+	i := sort.Search(len(points), func(i int) bool {
+		return !points[i].Time.Before(t)
+	})
+
+	if i < len(points) && points[i].Time.Equal(t) {
+		return points[i].Latitude, points[i].Longitude, 0, true
+	}
+
+	if i > 0 && i < len(points) {
+		before, after := points[i-1], points[i]
+		segment := after.Time.Sub(before.Time)
+		if segment <= maxGap {
+			fraction := float64(t.Sub(before.Time)) / float64(segment)
+			latitude = before.Latitude + (after.Latitude-before.Latitude)*fraction
+			longitude = interpolateLongitude(before.Longitude, after.Longitude, fraction)
+			gap = min(t.Sub(before.Time), after.Time.Sub(t))
+			return latitude, longitude, gap, true
+		}
+	}
+
+	var nearest *TrackPoint
+	if i > 0 {
+		nearest = &points[i-1]
+		gap = t.Sub(nearest.Time)
+	}
+	if i < len(points) && (nearest == nil || points[i].Time.Sub(t) < gap) {
+		nearest = &points[i]
+		gap = points[i].Time.Sub(t)
+	}
+
+	if gap > maxGap {
+		return 0, 0, 0, false
+	}
+
+	return nearest.Latitude, nearest.Longitude, gap, true
+}
+
+// Takes the short way around when a segment crosses the antimeridian
+func interpolateLongitude(from, to, fraction float64) float64 {
+	delta := to - from
+	if delta > 180 {
+		delta -= 360
+	} else if delta < -180 {
+		delta += 360
+	}
+
+	longitude := from + delta*fraction
+	if longitude > 180 {
+		longitude -= 360
+	} else if longitude < -180 {
+		longitude += 360
+	}
+	return longitude
+}
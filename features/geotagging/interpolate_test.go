@@ -0,0 +1,146 @@
+package geotagging
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	points := []TrackPoint{
+		{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 35.0, Longitude: 139.0},
+		{Time: trackTime("2024-03-15T10:04:00Z"), Latitude: 35.4, Longitude: 139.4},
+		// A 30 minute hole in the track, such as the logger losing its fix indoors
+		{Time: trackTime("2024-03-15T10:34:00Z"), Latitude: 36.0, Longitude: 140.0},
+		{Time: trackTime("2024-03-15T10:36:00Z"), Latitude: 36.2, Longitude: 140.2},
+	}
+
+	tests := []struct {
+		name      string
+		points    []TrackPoint
+		time      string
+		maxGap    time.Duration
+		latitude  float64
+		longitude float64
+		gap       time.Duration
+		ok        bool
+	}{
+		{
+			name:      "On a track point",
+			points:    points,
+			time:      "2024-03-15T10:04:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  35.4,
+			longitude: 139.4,
+			ok:        true,
+		},
+		{
+			name:      "Between two close points",
+			points:    points,
+			time:      "2024-03-15T10:01:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  35.1,
+			longitude: 139.1,
+			gap:       time.Minute,
+			ok:        true,
+		},
+		{
+			name:      "In a hole, near its start",
+			points:    points,
+			time:      "2024-03-15T10:10:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  35.4,
+			longitude: 139.4,
+			gap:       6 * time.Minute,
+			ok:        true,
+		},
+		{
+			name:      "In a hole, near its end",
+			points:    points,
+			time:      "2024-03-15T10:30:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  36.0,
+			longitude: 140.0,
+			gap:       4 * time.Minute,
+			ok:        true,
+		},
+		{
+			name:   "In a hole, further than the max gap from either end",
+			points: points,
+			time:   "2024-03-15T10:19:00Z",
+			maxGap: DefaultMaxGap,
+		},
+		{
+			name:      "In a hole shorter than a larger max gap",
+			points:    points,
+			time:      "2024-03-15T10:19:00Z",
+			maxGap:    time.Hour,
+			latitude:  35.7,
+			longitude: 139.7,
+			gap:       15 * time.Minute,
+			ok:        true,
+		},
+		{
+			name:      "Just before the track, within the max gap",
+			points:    points,
+			time:      "2024-03-15T09:55:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  35.0,
+			longitude: 139.0,
+			gap:       5 * time.Minute,
+			ok:        true,
+		},
+		{
+			name:   "Long before the track",
+			points: points,
+			time:   "2024-03-15T08:00:00Z",
+			maxGap: DefaultMaxGap,
+		},
+		{
+			name:      "Exactly the max gap after the track",
+			points:    points,
+			time:      "2024-03-15T10:46:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  36.2,
+			longitude: 140.2,
+			gap:       DefaultMaxGap,
+			ok:        true,
+		},
+		{
+			name:   "Just past the max gap after the track",
+			points: points,
+			time:   "2024-03-15T10:46:01Z",
+			maxGap: DefaultMaxGap,
+		},
+		{
+			name: "Across the antimeridian",
+			points: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: -17.0, Longitude: 179.0},
+				{Time: trackTime("2024-03-15T10:04:00Z"), Latitude: -17.0, Longitude: -179.0},
+			},
+			time:      "2024-03-15T10:03:00Z",
+			maxGap:    DefaultMaxGap,
+			latitude:  -17.0,
+			longitude: -179.5,
+			gap:       time.Minute,
+			ok:        true,
+		},
+		{
+			name:   "Empty track",
+			time:   "2024-03-15T10:00:00Z",
+			maxGap: DefaultMaxGap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latitude, longitude, gap, ok := Interpolate(tt.points, trackTime(tt.time), tt.maxGap)
+
+			if ok != tt.ok || gap != tt.gap ||
+				math.Abs(latitude-tt.latitude) > 1e-9 || math.Abs(longitude-tt.longitude) > 1e-9 {
+				t.Errorf("Interpolate() = %v, %v, %v, %v, want %v, %v, %v, %v",
+					latitude, longitude, gap, ok, tt.latitude, tt.longitude, tt.gap, tt.ok)
+			}
+		})
+	}
+}
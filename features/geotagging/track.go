@@ -0,0 +1,339 @@
+package geotagging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+}
+
+var ErrUnsupportedTrack = errors.New("unsupported track format, expected .gpx, .kml or .fit")
+
+// Reads the timed points from a GPX, KML or FIT file, picking the parser by extension.
+// Points without a time are skipped since they can't be matched to photos.
+func ParseTrack(filename string, data []byte) ([]TrackPoint, error) {
+	var points []TrackPoint
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gpx":
+		points, err = parseGPX(data)
+	case ".kml":
+		points, err = parseKML(data)
+	case ".fit":
+		points, err = parseFIT(data)
+	default:
+		return nil, ErrUnsupportedTrack
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+	}
+
+	return sortTrackPoints(points), nil
+}
+
+func sortTrackPoints(points []TrackPoint) []TrackPoint {
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points
+}
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Time      string  `xml:"time"`
+}
+
+// Track, route and waypoints all count, as long as they carry a time
+func parseGPX(data []byte) ([]TrackPoint, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	points := []TrackPoint{}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "trkpt", "rtept", "wpt":
+			var point gpxPoint
+			if err := decoder.DecodeElement(&point, &start); err != nil {
+				return nil, err
+			}
+
+			pointTime, err := time.Parse(time.RFC3339, strings.TrimSpace(point.Time))
+			if err != nil {
+				continue
+			}
+
+			points = append(points, TrackPoint{Time: pointTime.UTC(), Latitude: point.Latitude, Longitude: point.Longitude})
+		}
+	}
+
+	return points, nil
+}
+
+type kmlTrack struct {
+	Whens  []string `xml:"when"`
+	Coords []string `xml:"coord"`
+}
+
+type kmlPlacemark struct {
+	When        string     `xml:"TimeStamp>when"`
+	Coordinates string     `xml:"Point>coordinates"`
+	Tracks      []kmlTrack `xml:"Track"`
+	MultiTracks []kmlTrack `xml:"MultiTrack>Track"`
+}
+
+// Handles gx:Track (paired when/coord lists, as exported by Google and most loggers) and
+// placemarks with a TimeStamp and a Point
+func parseKML(data []byte) ([]TrackPoint, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	points := []TrackPoint{}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		var placemark kmlPlacemark
+		if err := decoder.DecodeElement(&placemark, &start); err != nil {
+			return nil, err
+		}
+
+		for _, track := range append(placemark.Tracks, placemark.MultiTracks...) {
+			for i := 0; i < len(track.Whens) && i < len(track.Coords); i++ {
+				// gx:coord is space separated "lon lat alt"
+				if point, ok := kmlPoint(track.Whens[i], strings.Fields(track.Coords[i])); ok {
+					points = append(points, point)
+				}
+			}
+		}
+
+		if placemark.When != "" && placemark.Coordinates != "" {
+			// Point coordinates are comma separated "lon,lat,alt"
+			if point, ok := kmlPoint(placemark.When, strings.Split(strings.TrimSpace(placemark.Coordinates), ",")); ok {
+				points = append(points, point)
+			}
+		}
+	}
+
+	return points, nil
+}
+
+func kmlPoint(when string, lonLat []string) (TrackPoint, bool) {
+	if len(lonLat) < 2 {
+		return TrackPoint{}, false
+	}
+
+	pointTime, err := time.Parse(time.RFC3339, strings.TrimSpace(when))
+	if err != nil {
+		return TrackPoint{}, false
+	}
+
+	longitude, lonErr := strconv.ParseFloat(strings.TrimSpace(lonLat[0]), 64)
+	latitude, latErr := strconv.ParseFloat(strings.TrimSpace(lonLat[1]), 64)
+	if lonErr != nil || latErr != nil {
+		return TrackPoint{}, false
+	}
+
+	return TrackPoint{Time: pointTime.UTC(), Latitude: latitude, Longitude: longitude}, true
+}
+
+const (
+	fitRecordMessage     = 20
+	fitFieldTimestamp    = 253
+	fitFieldPositionLat  = 0
+	fitFieldPositionLong = 1
+	fitInvalidSint32     = 0x7FFFFFFF
+	fitInvalidUint32     = 0xFFFFFFFF
+
+	// FIT timestamps count seconds from 1989-12-31 00:00:00 UTC
+	fitEpoch = 631065600
+)
+
+type fitFieldDefinition struct {
+	Number byte
+	Size   int
+}
+
+type fitMessageDefinition struct {
+	GlobalNumber uint16
+	ByteOrder    binary.ByteOrder
+	Fields       []fitFieldDefinition
+	DevDataSize  int
+}
+
+// Reads the record messages of a Garmin FIT activity. Only the timestamp and position fields are decoded;
+// everything else is skipped using the sizes from the definition messages.
+func parseFIT(data []byte) ([]TrackPoint, error) {
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return nil, fmt.Errorf("not a FIT file")
+	}
+
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if headerSize < 12 || end > len(data) {
+		return nil, fmt.Errorf("truncated FIT file")
+	}
+
+	definitions := map[byte]*fitMessageDefinition{}
+	points := []TrackPoint{}
+	var lastTimestamp uint32
+	pos := headerSize
+
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var localType byte
+		var compressedOffset int = -1
+
+		switch {
+		case header&0x80 != 0:
+			// Compressed timestamp header: a data message whose time is an offset from the last timestamp
+			localType = (header >> 5) & 0x03
+			compressedOffset = int(header & 0x1F)
+		case header&0x40 != 0:
+			definition, size, err := parseFITDefinition(data[pos:end], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[header&0x0F] = definition
+			pos += size
+			continue
+		default:
+			localType = header & 0x0F
+		}
+
+		definition, ok := definitions[localType]
+		if !ok {
+			return nil, fmt.Errorf("data message without a definition")
+		}
+
+		timestamp := uint32(fitInvalidUint32)
+		if compressedOffset >= 0 {
+			timestamp = (lastTimestamp &^ 0x1F) + uint32(compressedOffset)
+			if uint32(compressedOffset) < lastTimestamp&0x1F {
+				timestamp += 0x20
+			}
+		}
+
+		latitude, longitude := int32(fitInvalidSint32), int32(fitInvalidSint32)
+		for _, field := range definition.Fields {
+			if pos+field.Size > end {
+				return nil, fmt.Errorf("truncated FIT record")
+			}
+			value := data[pos : pos+field.Size]
+			pos += field.Size
+
+			if field.Size != 4 {
+				continue
+			}
+
+			switch field.Number {
+			case fitFieldTimestamp:
+				timestamp = definition.ByteOrder.Uint32(value)
+			case fitFieldPositionLat:
+				latitude = int32(definition.ByteOrder.Uint32(value))
+			case fitFieldPositionLong:
+				longitude = int32(definition.ByteOrder.Uint32(value))
+			}
+		}
+		pos += definition.DevDataSize
+
+		if timestamp != fitInvalidUint32 {
+			lastTimestamp = timestamp
+		}
+
+		if definition.GlobalNumber != fitRecordMessage || timestamp == fitInvalidUint32 ||
+			latitude == fitInvalidSint32 || longitude == fitInvalidSint32 {
+			continue
+		}
+
+		points = append(points, TrackPoint{
+			Time:      time.Unix(int64(timestamp)+fitEpoch, 0).UTC(),
+			Latitude:  semicirclesToDegrees(latitude),
+			Longitude: semicirclesToDegrees(longitude),
+		})
+	}
+
+	return points, nil
+}
+
+// Returns the definition and how many bytes it took after the record header
+func parseFITDefinition(data []byte, hasDevData bool) (*fitMessageDefinition, int, error) {
+	if len(data) < 5 {
+		return nil, 0, fmt.Errorf("truncated FIT definition")
+	}
+
+	definition := &fitMessageDefinition{ByteOrder: binary.LittleEndian}
+	if data[1] == 1 {
+		definition.ByteOrder = binary.BigEndian
+	}
+	definition.GlobalNumber = definition.ByteOrder.Uint16(data[2:4])
+
+	fieldCount := int(data[4])
+	pos := 5
+	if pos+fieldCount*3 > len(data) {
+		return nil, 0, fmt.Errorf("truncated FIT definition")
+	}
+	for i := 0; i < fieldCount; i++ {
+		definition.Fields = append(definition.Fields, fitFieldDefinition{Number: data[pos], Size: int(data[pos+1])})
+		pos += 3
+	}
+
+	if hasDevData {
+		if pos >= len(data) {
+			return nil, 0, fmt.Errorf("truncated FIT definition")
+		}
+		devFieldCount := int(data[pos])
+		pos++
+		if pos+devFieldCount*3 > len(data) {
+			return nil, 0, fmt.Errorf("truncated FIT definition")
+		}
+		for i := 0; i < devFieldCount; i++ {
+			definition.DevDataSize += int(data[pos+1])
+			pos += 3
+		}
+	}
+
+	return definition, pos, nil
+}
+
+func semicirclesToDegrees(semicircles int32) float64 {
+	return float64(semicircles) * 180 / (1 << 31)
+}
@@ -0,0 +1,309 @@
+package geotagging
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func trackTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t.UTC()
+}
+
+func TestParseTrackGPX(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expected  []TrackPoint
+		expectErr bool
+	}{
+		{
+			name: "Track, route and waypoints, sorted by time",
+			data: `<?xml version="1.0"?>
+<gpx version="1.1">
+  <wpt lat="35.0" lon="139.0"><time>2024-03-15T10:00:00Z</time></wpt>
+  <trk><trkseg>
+    <trkpt lat="35.2" lon="139.2"><time>2024-03-15T10:02:00Z</time></trkpt>
+    <trkpt lat="35.1" lon="139.1"><time>2024-03-15T19:01:00+09:00</time></trkpt>
+  </trkseg></trk>
+  <rte><rtept lat="35.3" lon="139.3"><time>2024-03-15T10:03:00Z</time></rtept></rte>
+</gpx>`,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 35.0, Longitude: 139.0},
+				{Time: trackTime("2024-03-15T10:01:00Z"), Latitude: 35.1, Longitude: 139.1},
+				{Time: trackTime("2024-03-15T10:02:00Z"), Latitude: 35.2, Longitude: 139.2},
+				{Time: trackTime("2024-03-15T10:03:00Z"), Latitude: 35.3, Longitude: 139.3},
+			},
+		},
+		{
+			name: "Points without a time are skipped",
+			data: `<gpx><trk><trkseg>
+    <trkpt lat="1" lon="2"></trkpt>
+    <trkpt lat="3" lon="4"><time>not a time</time></trkpt>
+    <trkpt lat="5" lon="6"><time>2024-03-15T10:00:00Z</time></trkpt>
+</trkseg></trk></gpx>`,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 5, Longitude: 6},
+			},
+		},
+		{
+			name:     "No points",
+			data:     `<gpx></gpx>`,
+			expected: []TrackPoint{},
+		},
+		{
+			name:      "Truncated",
+			data:      `<gpx><trk><trkseg><trkpt lat="1" lon="2"><time>2024-03-15T10:00:00Z</ti`,
+			expectErr: true,
+		},
+		{
+			name:      "Not XML",
+			data:      "\x00\x01<<",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := ParseTrack("walk.gpx", []byte(tt.data))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ParseTrack() = %v, want an error", points)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTrack() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(points, tt.expected) {
+				t.Errorf("ParseTrack() = %v, want %v", points, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrackKML(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expected  []TrackPoint
+		expectErr bool
+	}{
+		{
+			name: "gx:Track and timestamped placemarks, sorted by time",
+			data: `<?xml version="1.0"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Document>
+    <Placemark>
+      <gx:Track>
+        <when>2024-03-15T10:02:00Z</when>
+        <when>2024-03-15T10:00:00Z</when>
+        <gx:coord>139.2 35.2 10</gx:coord>
+        <gx:coord>139.0 35.0 10</gx:coord>
+      </gx:Track>
+    </Placemark>
+    <Placemark>
+      <TimeStamp><when>2024-03-15T10:01:00Z</when></TimeStamp>
+      <Point><coordinates>139.1,35.1,0</coordinates></Point>
+    </Placemark>
+  </Document>
+</kml>`,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 35.0, Longitude: 139.0},
+				{Time: trackTime("2024-03-15T10:01:00Z"), Latitude: 35.1, Longitude: 139.1},
+				{Time: trackTime("2024-03-15T10:02:00Z"), Latitude: 35.2, Longitude: 139.2},
+			},
+		},
+		{
+			name: "gx:MultiTrack",
+			data: `<kml xmlns:gx="http://www.google.com/kml/ext/2.2"><Placemark><gx:MultiTrack>
+  <gx:Track><when>2024-03-15T10:00:00Z</when><gx:coord>-0.1 51.5 0</gx:coord></gx:Track>
+  <gx:Track><when>2024-03-15T11:00:00Z</when><gx:coord>-0.2 51.6 0</gx:coord></gx:Track>
+</gx:MultiTrack></Placemark></kml>`,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 51.5, Longitude: -0.1},
+				{Time: trackTime("2024-03-15T11:00:00Z"), Latitude: 51.6, Longitude: -0.2},
+			},
+		},
+		{
+			name: "Unpaired, untimed and malformed coordinates are skipped",
+			data: `<kml xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Placemark><gx:Track>
+    <when>2024-03-15T10:00:00Z</when>
+    <when>2024-03-15T10:01:00Z</when>
+    <when>2024-03-15T10:02:00Z</when>
+    <gx:coord>139.0 35.0 0</gx:coord>
+    <gx:coord>east north</gx:coord>
+  </gx:Track></Placemark>
+  <Placemark><Point><coordinates>139.1,35.1,0</coordinates></Point></Placemark>
+  <Placemark><TimeStamp><when>2024-03-15T10:05:00Z</when></TimeStamp><Point><coordinates>139.1</coordinates></Point></Placemark>
+</kml>`,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 35.0, Longitude: 139.0},
+			},
+		},
+		{
+			name:      "Truncated",
+			data:      `<kml><Placemark><TimeStamp><when>2024-03-15T10:00:00Z</when></TimeStamp><Point><coordin`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := ParseTrack("Location History.KML", []byte(tt.data))
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ParseTrack() = %v, want an error", points)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTrack() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(points, tt.expected) {
+				t.Errorf("ParseTrack() = %v, want %v", points, tt.expected)
+			}
+		})
+	}
+}
+
+// Builds a FIT file from its records, with the 12 byte header and no CRC
+func fitFile(records ...[]byte) []byte {
+	var body []byte
+	for _, record := range records {
+		body = append(body, record...)
+	}
+
+	header := []byte{12, 0x10, 0, 0}
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(body)))
+	header = append(header, ".FIT"...)
+	return append(header, body...)
+}
+
+// A little endian definition of timestamp, position_lat and position_long for the given message
+func fitDefinition(localType byte, globalNumber uint16) []byte {
+	record := []byte{0x40 | localType, 0, 0}
+	record = binary.LittleEndian.AppendUint16(record, globalNumber)
+	return append(record, 3,
+		fitFieldTimestamp, 4, 0x86,
+		fitFieldPositionLat, 4, 0x85,
+		fitFieldPositionLong, 4, 0x85,
+	)
+}
+
+func fitRecord(localType byte, timestamp uint32, latitude, longitude int32) []byte {
+	record := []byte{localType}
+	record = binary.LittleEndian.AppendUint32(record, timestamp)
+	record = binary.LittleEndian.AppendUint32(record, uint32(latitude))
+	return binary.LittleEndian.AppendUint32(record, uint32(longitude))
+}
+
+func TestParseTrackFIT(t *testing.T) {
+	// 2024-03-15 10:00:00 UTC in FIT time
+	const start = 1710496800 - fitEpoch
+
+	// 45 and -90 degrees
+	const latitude, longitude = 1 << 29, -(1 << 30)
+
+	valid := fitFile(
+		fitDefinition(0, fitRecordMessage),
+		fitRecord(0, start+120, latitude, longitude),
+		fitRecord(0, start, latitude/2, longitude/2),
+		fitRecord(0, start+60, fitInvalidSint32, longitude),
+	)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		expected  []TrackPoint
+		expectErr bool
+	}{
+		{
+			name: "Records sorted by time, without the ones missing a position",
+			data: valid,
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 22.5, Longitude: -45},
+				{Time: trackTime("2024-03-15T10:02:00Z"), Latitude: 45, Longitude: -90},
+			},
+		},
+		{
+			name: "Compressed timestamps and other messages",
+			data: fitFile(
+				fitDefinition(0, fitRecordMessage),
+				fitDefinition(2, 21), // Event
+				fitRecord(2, start+5, latitude, longitude),
+				fitRecord(0, start, latitude, longitude),
+				// Records with a compressed timestamp header leave the timestamp out of their definition
+				[]byte{0x41, 0, 0, fitRecordMessage, 0, 2, fitFieldPositionLat, 4, 0x85, fitFieldPositionLong, 4, 0x85},
+				// 10 seconds after the last timestamp, in local type 1
+				append([]byte{0x80 | 1<<5 | byte((start+10)&0x1F)}, fitRecord(1, 0, latitude, longitude)[5:]...),
+			),
+			expected: []TrackPoint{
+				{Time: trackTime("2024-03-15T10:00:00Z"), Latitude: 45, Longitude: -90},
+				{Time: trackTime("2024-03-15T10:00:10Z"), Latitude: 45, Longitude: -90},
+			},
+		},
+		{
+			name:      "Truncated file",
+			data:      valid[:len(valid)-6],
+			expectErr: true,
+		},
+		{
+			name: "Truncated record",
+			data: fitFile(
+				fitDefinition(0, fitRecordMessage),
+				fitRecord(0, start, latitude, longitude)[:7],
+			),
+			expectErr: true,
+		},
+		{
+			name:      "Truncated definition",
+			data:      fitFile(fitDefinition(0, fitRecordMessage)[:8]),
+			expectErr: true,
+		},
+		{
+			name:      "Data message without a definition",
+			data:      fitFile(fitRecord(2, start, latitude, longitude)),
+			expectErr: true,
+		},
+		{
+			name:      "Not a FIT file",
+			data:      []byte("<gpx></gpx>"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := ParseTrack("ride.fit", tt.data)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ParseTrack() = %v, want an error", points)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTrack() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(points, tt.expected) {
+				t.Errorf("ParseTrack() = %v, want %v", points, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrackUnsupported(t *testing.T) {
+	if _, err := ParseTrack("track.csv", []byte("time,lat,lon")); !errors.Is(err, ErrUnsupportedTrack) {
+		t.Errorf("ParseTrack() error = %v, want %v", err, ErrUnsupportedTrack)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"riffle/commons/exif"
 	"riffle/commons/hash"
 	"riffle/commons/media"
+	"riffle/commons/utils"
 	"riffle/features/settings"
 	"runtime"
 	"strings"
@@ -381,6 +382,7 @@ func ExecuteMoves(libraryPath, thumbnailsPath string, stats *AnalysisStats, impo
 	UpdateProgress(StatusImporting, 0, total)
 
 	movedToLibrary := 0
+	transcodeOnImport, _ := settings.GetVideoTranscodeOnImport()
 
 	for _, action := range stats.FilesToImport {
 		photo := PhotoFile{
@@ -412,13 +414,15 @@ func ExecuteMoves(libraryPath, thumbnailsPath string, stats *AnalysisStats, impo
 		}
 
 		photo.Path = newPath
+		relativePath, _ := utils.ToLibraryRelativePath(libraryPath, newPath)
 		photo.FileFormat, photo.MimeType = media.GetFileMetadata(newPath)
 		photo.IsVideo = media.IsVideoFile(newPath)
 
-		if err := CreatePhoto(photo); err != nil {
+		photoID, err := CreatePhoto(photo, libraryPath, sessionID)
+		if err != nil {
 			slog.Error("failed to insert photo to database", "file", photo.Path, "error", err)
 			if sessionID > 0 {
-				RecordImportedPhoto(sessionID, photo.Path, "error", err.Error())
+				RecordImportedPhoto(sessionID, relativePath, "error", err.Error())
 				IncrementImportErrors(sessionID)
 			}
 			continue
@@ -432,13 +436,18 @@ func ExecuteMoves(libraryPath, thumbnailsPath string, stats *AnalysisStats, impo
 		if err := media.GenerateThumbnail(photo.Path, thumbnailPath, orientation, photo.IsVideo); err != nil {
 			slog.Error("failed to generate thumbnail", "file", photo.Path, "error", err)
 		} else {
-			if err := UpdatePhotoThumbnail(photo.Path, thumbnailPath); err != nil {
+			relativeThumbnailPath, _ := filepath.Rel(thumbnailsPath, thumbnailPath)
+			if err := UpdatePhotoThumbnail(photoID, relativeThumbnailPath); err != nil {
 				slog.Error("failed to update photo thumbnail path", "file", photo.Path, "error", err)
 			}
 		}
 
+		if photo.IsVideo {
+			processVideo(photoID, photo.Path, libraryPath, thumbnailsPath, transcodeOnImport)
+		}
+
 		if sessionID > 0 {
-			RecordImportedPhoto(sessionID, photo.Path, "success", "")
+			RecordImportedPhoto(sessionID, relativePath, "success", "")
 		}
 
 		movedToLibrary++
@@ -473,6 +482,27 @@ func ExecuteMoves(libraryPath, thumbnailsPath string, stats *AnalysisStats, impo
 	return nil
 }
 
+func processVideo(photoID int64, videoPath, libraryPath, thumbnailsPath string, transcodeOnImport bool) {
+	info, err := media.ProbeVideo(videoPath)
+	if err != nil {
+		slog.Error("failed to probe video", "file", videoPath, "error", err)
+		return
+	}
+
+	if err := UpdatePhotoVideoInfo(photoID, info); err != nil {
+		slog.Error("failed to update video metadata", "file", videoPath, "error", err)
+	}
+
+	if !transcodeOnImport || media.IsBrowserPlayable(videoPath, info) {
+		return
+	}
+
+	proxyPath := media.GetVideoProxyPath(libraryPath, thumbnailsPath, videoPath)
+	if err := media.GenerateVideoProxy(videoPath, proxyPath); err != nil {
+		slog.Error("failed to generate video proxy", "file", videoPath, "error", err)
+	}
+}
+
 func transferFile(photo PhotoFile, destDir string, importMode settings.ImportMode) (string, error) {
 	var dateTime time.Time
 	var hasDateTime bool
@@ -3,28 +3,45 @@ package ingest
 import (
 	"fmt"
 	"log/slog"
+	"riffle/commons/media"
 	"riffle/commons/sqlite"
 	"riffle/commons/utils"
 	"riffle/features/geocoding"
 	"time"
 )
 
-func CreatePhoto(photo PhotoFile) error {
+// Stores the photo with its path relative to the library root and returns its photo ID. importID is the
+// session bringing it in, 0 for none.
+func CreatePhoto(photo PhotoFile, libraryPath string, importID int64) (int64, error) {
+	relativePath, err := utils.ToLibraryRelativePath(libraryPath, photo.Path)
+	if err != nil {
+		slog.Error("photo is outside the library", "file", photo.Path, "error", err)
+		return 0, err
+	}
+
 	query := `
 		INSERT INTO photos (
-			file_path, original_filepath, sha256_hash, dhash, file_size, date_time,
+			file_path, original_filepath, sha256_hash, dhash, file_size, date_time, local_date_time, utc_offset,
 			camera_make, camera_model, width, height, orientation,
 			latitude, longitude, iso, f_number, exposure_time, focal_length,
 			file_format, mime_type, is_video, duration,
 			file_created_at, file_modified_at,
-			city, state, country_name
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			place_id, city, county, state, country_name, country_code, import_id,
+			lens_make, lens_model, focal_length_35mm, exposure_program, exposure_mode, exposure_compensation,
+			metering_mode, white_balance, flash, software, color_space, color_profile,
+			altitude, gps_direction, camera_serial
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
 		ON CONFLICT(file_path) DO UPDATE SET
 			original_filepath = excluded.original_filepath,
 			sha256_hash = excluded.sha256_hash,
 			dhash = excluded.dhash,
 			file_size = excluded.file_size,
 			date_time = excluded.date_time,
+			local_date_time = excluded.local_date_time,
+			utc_offset = excluded.utc_offset,
 			camera_make = excluded.camera_make,
 			camera_model = excluded.camera_model,
 			width = excluded.width,
@@ -42,19 +59,41 @@ func CreatePhoto(photo PhotoFile) error {
 			duration = excluded.duration,
 			file_created_at = excluded.file_created_at,
 			file_modified_at = excluded.file_modified_at,
+			place_id = excluded.place_id,
 			city = excluded.city,
+			county = excluded.county,
 			state = excluded.state,
 			country_name = excluded.country_name,
+			country_code = excluded.country_code,
+			import_id = COALESCE(excluded.import_id, photos.import_id),
+			lens_make = excluded.lens_make,
+			lens_model = excluded.lens_model,
+			focal_length_35mm = excluded.focal_length_35mm,
+			exposure_program = excluded.exposure_program,
+			exposure_mode = excluded.exposure_mode,
+			exposure_compensation = excluded.exposure_compensation,
+			metering_mode = excluded.metering_mode,
+			white_balance = excluded.white_balance,
+			flash = excluded.flash,
+			software = excluded.software,
+			color_space = excluded.color_space,
+			color_profile = excluded.color_profile,
+			altitude = excluded.altitude,
+			gps_direction = excluded.gps_direction,
+			camera_serial = excluded.camera_serial,
 			updated_at = CURRENT_TIMESTAMP
+		RETURNING photo_id
 	`
 
-	var dateTime string
+	var captureTime *utils.CaptureTime
 	if dtStr, ok := photo.ExifData["DateTime"].(string); ok {
-		dateTime = utils.NormalizeDateTime(dtStr, photo.ExifData)
-	} else if !photo.FileModifiedAt.IsZero() {
-		dateTime = photo.FileModifiedAt.Format(time.RFC3339)
-	} else {
-		dateTime = time.Now().Format(time.RFC3339)
+		captureTime = utils.ParseCaptureTime(dtStr, photo.ExifData)
+	}
+	if captureTime == nil && !photo.FileModifiedAt.IsZero() {
+		captureTime = &utils.CaptureTime{Time: photo.FileModifiedAt.In(time.Local)}
+	}
+	if captureTime == nil {
+		captureTime = &utils.CaptureTime{Time: time.Now()}
 	}
 
 	var cameraMake, cameraModel interface{}
@@ -116,42 +155,52 @@ func CreatePhoto(photo PhotoFile) error {
 		fileModifiedAt = photo.FileModifiedAt
 	}
 
-	var city, state, countryName interface{}
+	var placeID, city, county, state, countryName, countryCode interface{}
 	if lat, ok := photo.ExifData["Latitude"].(float64); ok {
 		if lon, ok := photo.ExifData["Longitude"].(float64); ok {
 			if lat != 0 && lon != 0 {
 				location, err := geocoding.ReverseGeocode(lat, lon)
-				if err == nil && location != nil {
-					city = location.City
-					state = location.State
-					countryName = location.CountryName
+				if err == nil {
+					placeID, city, county, state, countryName, countryCode = location.Columns()
 				}
 			}
 		}
 	}
 
-	_, err := sqlite.DB.Exec(
+	var sessionID interface{}
+	if importID > 0 {
+		sessionID = importID
+	}
+
+	// Missing fields come out of the map as nil and are stored as NULL
+	exifData := photo.ExifData
+
+	var photoID int64
+	err = sqlite.DB.QueryRow(
 		query,
-		photo.Path, photo.OriginalFilepath, photo.Hash, dhashStr, photo.Size, dateTime,
+		relativePath, photo.OriginalFilepath, photo.Hash, dhashStr, photo.Size, captureTime.UTC(), captureTime.LocalDateTime(), captureTime.UTCOffset(),
 		cameraMake, cameraModel, width, height, orientation,
 		latitude, longitude, iso, fNumber, exposureTime, focalLength,
 		photo.FileFormat, photo.MimeType, photo.IsVideo, duration,
 		fileCreatedAt, fileModifiedAt,
-		city, state, countryName,
-	)
+		placeID, city, county, state, countryName, countryCode, sessionID,
+		exifData["LensMake"], exifData["LensModel"], exifData["FocalLength35mm"], exifData["ExposureProgram"], exifData["ExposureMode"], exifData["ExposureCompensation"],
+		exifData["MeteringMode"], exifData["WhiteBalance"], exifData["Flash"], exifData["Software"], exifData["ColorSpace"], exifData["ColorProfile"],
+		exifData["Altitude"], exifData["GPSDirection"], exifData["CameraSerial"],
+	).Scan(&photoID)
 
 	if err != nil {
 		err = fmt.Errorf("error inserting photo: %w", err)
 		slog.Error(err.Error())
-		return err
+		return 0, err
 	}
 
-	return nil
+	return photoID, nil
 }
 
-func UpdatePhotoThumbnail(filePath, thumbnailPath string) error {
-	query := `UPDATE photos SET thumbnail_path = ?, updated_at = CURRENT_TIMESTAMP WHERE file_path = ?`
-	_, err := sqlite.DB.Exec(query, thumbnailPath, filePath)
+func UpdatePhotoThumbnail(photoID int64, thumbnailPath string) error {
+	query := `UPDATE photos SET thumbnail_path = ?, updated_at = CURRENT_TIMESTAMP WHERE photo_id = ?`
+	_, err := sqlite.DB.Exec(query, thumbnailPath, photoID)
 	if err != nil {
 		err = fmt.Errorf("error updating photo thumbnail: %w", err)
 		slog.Error(err.Error())
@@ -160,6 +209,29 @@ func UpdatePhotoThumbnail(filePath, thumbnailPath string) error {
 	return nil
 }
 
+func UpdatePhotoVideoInfo(photoID int64, info *media.VideoInfo) error {
+	query := `
+		UPDATE photos
+		SET video_codec = ?, audio_codec = ?, frame_rate = ?, rotation = ?,
+			width = COALESCE(width, ?), height = COALESCE(height, ?),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE photo_id = ?
+	`
+
+	var audioCodec interface{}
+	if info.AudioCodec != "" {
+		audioCodec = info.AudioCodec
+	}
+
+	_, err := sqlite.DB.Exec(query, info.VideoCodec, audioCodec, info.FrameRate, info.Rotation, info.Width, info.Height, photoID)
+	if err != nil {
+		err = fmt.Errorf("error updating photo video info: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
 func CheckHashExists(hash string) (bool, error) {
 	query := `SELECT COUNT(*) FROM photos WHERE sha256_hash = ?`
 	var count int
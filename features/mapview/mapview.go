@@ -0,0 +1,137 @@
+package mapview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"riffle/commons/cache"
+	"riffle/commons/utils"
+	"riffle/features/photos"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPhotoLimit = 500
+	maxPhotoLimit     = 2000
+)
+
+type ClustersResponse struct {
+	Clusters []Cluster `json:"clusters"`
+	Zoom     int       `json:"zoom"`
+	CellSize float64   `json:"cellSize"`
+}
+
+type MapPhotosResponse struct {
+	Photos       []MapPhoto `json:"photos"`
+	TotalRecords int        `json:"totalRecords"`
+}
+
+// GET /api/map/clusters/?bbox=west,south,east,north&zoom=N plus the usual photo filters
+func HandleGetClusters(w http.ResponseWriter, r *http.Request) {
+	bbox, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BBOX", err.Error())
+		return
+	}
+
+	zoom, err := strconv.Atoi(r.URL.Query().Get("zoom"))
+	if err != nil || zoom < 0 || zoom > MaxZoom {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ZOOM", fmt.Sprintf("Zoom must be between 0 and %d", MaxZoom))
+		return
+	}
+
+	if cache.MapCache.CheckAndRespond(w, r, 3600) {
+		return
+	}
+
+	filters, err := photos.ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	clusters, err := GetClusters(bbox, zoom, filters)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch map clusters")
+		return
+	}
+
+	response := ClustersResponse{
+		Clusters: clusters,
+		Zoom:     zoom,
+		CellSize: CellSize(zoom),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GET /api/map/photos/?bbox=west,south,east,north&limit=N plus the usual photo filters
+func HandleGetMapPhotos(w http.ResponseWriter, r *http.Request) {
+	bbox, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BBOX", err.Error())
+		return
+	}
+
+	limit := defaultPhotoLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			limit = min(v, maxPhotoLimit)
+		}
+	}
+
+	if cache.MapCache.CheckAndRespond(w, r, 3600) {
+		return
+	}
+
+	filters, err := photos.ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	mapPhotos, total, err := GetPhotosInBox(bbox, filters, limit)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch map photos")
+		return
+	}
+
+	response := MapPhotosResponse{
+		Photos:       mapPhotos,
+		TotalRecords: total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func parseBoundingBox(value string) (BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("bbox must be west,south,east,north")
+	}
+
+	var edges [4]float64
+	for i, part := range parts {
+		edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bbox must be west,south,east,north")
+		}
+		edges[i] = edge
+	}
+
+	bbox := BoundingBox{West: edges[0], South: edges[1], East: edges[2], North: edges[3]}
+
+	if bbox.South < -90 || bbox.North > 90 || bbox.South > bbox.North {
+		return BoundingBox{}, fmt.Errorf("bbox latitudes must be between -90 and 90 with south below north")
+	}
+	if bbox.West < -180 || bbox.West > 180 || bbox.East < -180 || bbox.East > 180 {
+		return BoundingBox{}, fmt.Errorf("bbox longitudes must be between -180 and 180")
+	}
+
+	return bbox, nil
+}
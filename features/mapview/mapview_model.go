@@ -0,0 +1,172 @@
+package mapview
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"riffle/commons/sqlite"
+	"riffle/features/photos"
+)
+
+const (
+	MaxZoom = 22
+
+	// Grid cells per 256px map tile along each axis, so markers end up roughly 64px apart
+	cellsPerTile = 4
+)
+
+// West/east and south/north edges in degrees. West is greater than east when the box crosses the antimeridian.
+type BoundingBox struct {
+	West  float64
+	South float64
+	East  float64
+	North float64
+}
+
+type Cluster struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+	PhotoID   int64   `json:"photoId"` // Newest photo in the cluster, for the marker thumbnail
+}
+
+type MapPhoto struct {
+	PhotoID   int64   `json:"photoId"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	DateTime  *string `json:"dateTime"`
+	IsVideo   bool    `json:"isVideo"`
+}
+
+// Cell edge in degrees for a zoom level, matching web map tiles where zoom 0 shows the world in one tile
+func CellSize(zoom int) float64 {
+	return 360 / math.Pow(2, float64(zoom)) / cellsPerTile
+}
+
+// Only library photos (picked, not rejected) with coordinates inside the box and matching the filters
+func buildMapConditions(bbox BoundingBox, filters *photos.PhotoFilters) (string, []any) {
+	whereClause := `
+		WHERE is_curated = 1 AND is_trashed = 0
+		AND latitude IS NOT NULL AND longitude IS NOT NULL
+		AND latitude BETWEEN ? AND ?`
+	args := []any{bbox.South, bbox.North}
+
+	if bbox.West <= bbox.East {
+		whereClause += " AND longitude BETWEEN ? AND ?"
+	} else {
+		whereClause += " AND (longitude >= ? OR longitude <= ?)"
+	}
+	args = append(args, bbox.West, bbox.East)
+
+	filterSQL, filterArgs := photos.BuildFilterConditions(filters)
+	whereClause += filterSQL
+	args = append(args, filterArgs...)
+
+	return whereClause, args
+}
+
+// Groups photos into a grid over the box. Each cluster sits at the average position of its photos
+// rather than the cell center, so single photos land where they were taken.
+func GetClusters(bbox BoundingBox, zoom int, filters *photos.PhotoFilters) ([]Cluster, error) {
+	whereClause, args := buildMapConditions(bbox, filters)
+	cellSize := CellSize(zoom)
+
+	// Coordinates are shifted to be positive so the integer cast floors them. With a single MAX aggregate,
+	// SQLite takes photo_id from the row holding the newest date_time.
+	query := fmt.Sprintf(`
+		SELECT
+			AVG(latitude),
+			AVG(longitude),
+			COUNT(*),
+			photo_id,
+			MAX(date_time)
+		FROM
+			photos
+		%s
+		GROUP BY
+			CAST((longitude + 180) / ? AS INTEGER),
+			CAST((latitude + 90) / ? AS INTEGER)
+	`, whereClause)
+
+	args = append(args, cellSize, cellSize)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying map clusters: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := []Cluster{}
+	for rows.Next() {
+		var c Cluster
+		var newestDateTime *string
+		if err := rows.Scan(&c.Latitude, &c.Longitude, &c.Count, &c.PhotoID, &newestDateTime); err != nil {
+			err = fmt.Errorf("error scanning map cluster: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating map clusters: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
+// Returns up to limit photos inside the box, newest first, along with how many there are in total
+func GetPhotosInBox(bbox BoundingBox, filters *photos.PhotoFilters, limit int) ([]MapPhoto, int, error) {
+	whereClause, args := buildMapConditions(bbox, filters)
+
+	var total int
+	err := sqlite.DB.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM photos %s`, whereClause), args...).Scan(&total)
+	if err != nil {
+		err = fmt.Errorf("error counting map photos: %w", err)
+		slog.Error(err.Error())
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			photo_id, latitude, longitude, date_time, is_video
+		FROM
+			photos
+		%s
+		ORDER BY
+			date_time DESC
+		LIMIT
+			?
+	`, whereClause)
+
+	rows, err := sqlite.DB.Query(query, append(args, limit)...)
+	if err != nil {
+		err = fmt.Errorf("error querying map photos: %w", err)
+		slog.Error(err.Error())
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mapPhotos := []MapPhoto{}
+	for rows.Next() {
+		var p MapPhoto
+		if err := rows.Scan(&p.PhotoID, &p.Latitude, &p.Longitude, &p.DateTime, &p.IsVideo); err != nil {
+			err = fmt.Errorf("error scanning map photo: %w", err)
+			slog.Error(err.Error())
+			return nil, 0, err
+		}
+		mapPhotos = append(mapPhotos, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating map photos: %w", err)
+		slog.Error(err.Error())
+		return nil, 0, err
+	}
+
+	return mapPhotos, total, nil
+}
@@ -1,9 +1,12 @@
 import ApiClient from '../../commons/http/ApiClient.js';
 import Button from '../../commons/components/Button.jsx';
+import Checkbox from '../../commons/components/Checkbox.jsx';
 import CheckboxGroup from '../../commons/components/CheckboxGroup.jsx';
 import RadioGroup from '../../commons/components/RadioGroup.jsx';
 import { Accordion, AccordionItem } from '../../commons/components/Accordion.jsx';
 import { CloseIcon, LoadingSpinner } from '../../commons/components/Icon.jsx';
+import SavedFilters from './SavedFilters.jsx';
+import { countActiveFilters } from './filterParams.js';
 import './FilterPanel.css';
 
 const { useState, useEffect } = React;
@@ -30,21 +33,154 @@ const ORIENTATIONS = [
   { value: 'square', label: 'Square' },
 ];
 
-export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange }) {
-  const [filterOptions, setFilterOptions] = useState(null);
+const PRESENCE = [
+  { value: 'all', label: 'All' },
+  { value: 'true', label: 'Yes' },
+  { value: 'false', label: 'No' },
+];
+
+const BYTES_PER_MB = 1024 * 1024;
+
+// Ranges are sent in the units the server stores, some are typed in friendlier ones
+const RANGES = [
+  { key: 'iso', label: 'ISO', placeholder: ['100', '6400'] },
+  { key: 'fNumber', label: 'Aperture (f/)', placeholder: ['1.4', '16'] },
+  { key: 'exposureTime', label: 'Shutter speed (s)', placeholder: ['1/4000', '30'], toParam: parseShutterSpeed, fromParam: formatShutterSpeed },
+  { key: 'focalLength', label: 'Focal length (mm)', placeholder: ['14', '600'] },
+  { key: 'focalLength35mm', label: 'Focal length, 35mm equivalent (mm)', placeholder: ['14', '600'] },
+  { key: 'exposureCompensation', label: 'Exposure compensation (EV)', placeholder: ['-3', '3'] },
+  { key: 'fileSize', label: 'File size (MB)', placeholder: ['1', '50'], toParam: v => parseFloat(v) * BYTES_PER_MB, fromParam: v => String(parseFloat(v) / BYTES_PER_MB) },
+  { key: 'megapixels', label: 'Megapixels', placeholder: ['12', '60'] },
+  { key: 'duration', label: 'Video length (s)', placeholder: ['0', '600'] },
+];
+
+// Exclude lists and the facets they're picked from
+const EXCLUSIONS = [
+  { key: 'excludeYears', label: 'Year', options: 'years' },
+  { key: 'excludeCameraMakes', label: 'Make', options: 'cameraMakes' },
+  { key: 'excludeCameraModels', label: 'Model', options: 'cameraModels' },
+  { key: 'excludeLensModels', label: 'Lens', options: 'lensModels' },
+  { key: 'excludePlaces', label: 'Place', options: 'places' },
+  { key: 'excludeCountries', label: 'Country', options: 'countries' },
+  { key: 'excludeStates', label: 'State', options: 'states' },
+  { key: 'excludeCities', label: 'City', options: 'cities' },
+  { key: 'excludeFileFormats', label: 'File format', options: 'fileFormats' },
+];
+
+// 1/250 or 2, in seconds
+function parseShutterSpeed(value) {
+  const [numerator, denominator] = value.split('/');
+  if (denominator === undefined) {
+    return parseFloat(numerator);
+  }
+  return parseFloat(numerator) / parseFloat(denominator);
+}
+
+function formatShutterSpeed(seconds) {
+  const value = parseFloat(seconds);
+  if (value > 0 && value < 1) {
+    return `1/${Math.round(1 / value)}`;
+  }
+  return String(value);
+}
+
+function FacetLabel({ label, count }) {
+  return (
+    <>
+      {label}
+      <span className="facet-count">{count.toLocaleString()}</span>
+    </>
+  );
+}
+
+// Facet values as checkbox options with their counts. Selected values the other filters leave without photos
+// are kept at zero, so they can still be unchecked.
+function facetOptions(values, selected = [], formatLabel = value => value) {
+  const options = values.map(({ value, count }) => ({ value, label: <FacetLabel label={formatLabel(value)} count={count} /> }));
+  selected
+    .filter(value => !values.some(v => v.value === value))
+    .forEach(value => options.push({ value, label: <FacetLabel label={formatLabel(value)} count={0} /> }));
+  return options;
+}
+
+// The same state or city name can sit under more than one country, so flattening the tree adds them up
+function mergeFacetValues(values) {
+  const counts = new Map();
+  values.forEach(({ value, count }) => counts.set(value, (counts.get(value) || 0) + count));
+  return Array.from(counts, ([value, count]) => ({ value, count })).sort((a, b) => a.value.localeCompare(b.value));
+}
+
+// Flat lists of every facet, for the exclude section
+function flattenFacets(facets) {
+  const states = facets.countries.flatMap(country => country.states);
+  const cities = facets.countries.flatMap(country => [...country.cities, ...country.states.flatMap(state => state.cities)]);
+  return {
+    ...facets,
+    states: mergeFacetValues(states),
+    cities: mergeFacetValues(cities),
+  };
+}
+
+// Keeps what's typed until Enter or leaving the field, so half typed values don't filter the photos
+function CommitInput({ value, placeholder, type = 'text', onCommit }) {
+  const [draft, setDraft] = useState(value || '');
+
+  useEffect(() => {
+    setDraft(value || '');
+  }, [value]);
+
+  function commit() {
+    if (draft.trim() !== (value || '')) {
+      onCommit(draft.trim());
+    }
+  }
+
+  function handleKeyDown(e) {
+    if (e.key === 'Enter') {
+      commit();
+    }
+  }
+
+  return (
+    <input
+      type={type}
+      className="filter-input"
+      placeholder={placeholder}
+      value={draft}
+      onChange={e => setDraft(e.target.value)}
+      onBlur={commit}
+      onKeyDown={handleKeyDown}
+    />
+  );
+}
+
+// Options are counted within the listing's scope or the album, and saved filters are offered when the panel
+// is given a scope
+export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange, scope, albumId, sort, curator, onApplySavedFilter }) {
+  const [facets, setFacets] = useState(null);
   const [isLoading, setIsLoading] = useState(true);
 
+  const filtersKey = JSON.stringify(filters);
+
+  // Counts follow the other filters, so they're fetched again as filters change
   useEffect(() => {
-    if (isOpen && !filterOptions) {
-      fetchFilterOptions();
+    if (isOpen) {
+      fetchFacets();
     }
-  }, [isOpen]);
+  }, [isOpen, filtersKey, scope, albumId, curator]);
 
-  async function fetchFilterOptions() {
-    setIsLoading(true);
+  async function fetchFacets() {
+    if (!facets) {
+      setIsLoading(true);
+    }
     try {
-      const options = await ApiClient.getFilterOptions();
-      setFilterOptions(options);
+      let data;
+      if (albumId) {
+        data = await ApiClient.getAlbumFacets(albumId, filters);
+      } else {
+        data = await ApiClient.getFilterFacets(scope || 'library', filters, curator);
+      }
+      setFacets(data);
     } catch (err) {
       console.error('Failed to fetch filter options:', err);
     } finally {
@@ -52,6 +188,15 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     }
   }
 
+  function handleToggleValue(key, value) {
+    const selected = filters[key] || [];
+    if (selected.includes(value)) {
+      handleFilterChange(key, selected.filter(v => v !== value));
+    } else {
+      handleFilterChange(key, [...selected, value]);
+    }
+  }
+
   function handleFilterChange(key, value) {
     onFiltersChange({
       ...filters,
@@ -63,26 +208,21 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     onFiltersChange({});
   }
 
-  function getActiveFilterCount() {
-    let count = 0;
-    if (filters.ratings && filters.ratings.length > 0) count += filters.ratings.length;
-    if (filters.mediaType && filters.mediaType !== 'all') count++;
-    if (filters.orientation && filters.orientation !== 'all') count++;
-    if (filters.years && filters.years.length > 0) count += filters.years.length;
-    if (filters.cameraMakes && filters.cameraMakes.length > 0) count += filters.cameraMakes.length;
-    if (filters.cameraModels && filters.cameraModels.length > 0) count += filters.cameraModels.length;
-    if (filters.countries && filters.countries.length > 0) count += filters.countries.length;
-    if (filters.states && filters.states.length > 0) count += filters.states.length;
-    if (filters.cities && filters.cities.length > 0) count += filters.cities.length;
-    if (filters.fileFormats && filters.fileFormats.length > 0) count += filters.fileFormats.length;
-    return count;
+  function handleRangeChange(range, bound, value) {
+    const key = range.key + bound;
+    if (value === '') {
+      handleFilterChange(key, null);
+      return;
+    }
+    const number = range.toParam ? range.toParam(value) : parseFloat(value);
+    handleFilterChange(key, isNaN(number) ? null : String(number));
   }
 
   if (!isOpen) {
     return null;
   }
 
-  const activeCount = getActiveFilterCount();
+  const activeCount = countActiveFilters(filters);
 
   let content = null;
   if (isLoading) {
@@ -94,7 +234,7 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     );
   } else {
     let yearSection = null;
-    if (filterOptions && filterOptions.years.length > 0) {
+    if (hasFacet('years', 'years')) {
       yearSection = (
         <AccordionItem value="year" title="Year">
           {renderYearOptions()}
@@ -103,7 +243,7 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     }
 
     let cameraSection = null;
-    if (filterOptions && (filterOptions.cameraMakes.length > 0 || filterOptions.cameraModels.length > 0)) {
+    if (hasFacet('cameraMakes', 'cameraMakes') || hasFacet('cameraModels', 'cameraModels') || hasFacet('lensModels', 'lensModels')) {
       cameraSection = (
         <AccordionItem value="camera" title="Camera">
           {renderCameraOptions()}
@@ -112,7 +252,7 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     }
 
     let locationSection = null;
-    if (filterOptions && (filterOptions.countries.length > 0 || filterOptions.states.length > 0 || filterOptions.cities.length > 0)) {
+    if (hasFacet('places', 'places') || hasFacet('countries', 'countries') || hasSelected('states') || hasSelected('cities')) {
       locationSection = (
         <AccordionItem value="location" title="Location">
           {renderLocationOptions()}
@@ -121,7 +261,7 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     }
 
     let formatSection = null;
-    if (filterOptions && filterOptions.fileFormats.length > 0) {
+    if (hasFacet('fileFormats', 'fileFormats')) {
       formatSection = (
         <AccordionItem value="format" title="File Format">
           {renderFormatOptions()}
@@ -129,8 +269,30 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
       );
     }
 
+    let excludeSection = null;
+    if (facets && EXCLUSIONS.some(exclusion => flattenFacets(facets)[exclusion.options].length > 0 || hasSelected(exclusion.key))) {
+      excludeSection = (
+        <AccordionItem value="exclude" title="Exclude">
+          {renderExcludeOptions()}
+        </AccordionItem>
+      );
+    }
+
+    let savedSection = null;
+    if (scope) {
+      savedSection = (
+        <AccordionItem value="saved" title="Saved Filters">
+          <SavedFilters scope={scope} sort={sort} curator={curator} filters={filters} onApply={onApplySavedFilter} />
+        </AccordionItem>
+      );
+    }
+
     content = (
-      <Accordion defaultOpen={['rating', 'mediaType']}>
+      <Accordion defaultOpen={['saved', 'search', 'rating', 'mediaType']}>
+        {savedSection}
+        <AccordionItem value="search" title="Search">
+          {renderSearchOptions()}
+        </AccordionItem>
         <AccordionItem value="rating" title="Rating">
           {renderRatingOptions()}
         </AccordionItem>
@@ -144,14 +306,60 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
         {cameraSection}
         {locationSection}
         {formatSection}
+        <AccordionItem value="date" title="Date Range">
+          {renderDateOptions()}
+        </AccordionItem>
+        <AccordionItem value="ranges" title="Camera Settings & Size">
+          {renderRangeOptions()}
+        </AccordionItem>
+        <AccordionItem value="gps" title="Location Data">
+          {renderPresenceOptions('hasGps')}
+        </AccordionItem>
+        <AccordionItem value="notes" title="Notes">
+          {renderPresenceOptions('hasNotes')}
+        </AccordionItem>
+        {excludeSection}
       </Accordion>
     );
   }
 
+  function hasSelected(key) {
+    return (filters[key] || []).length > 0;
+  }
+
+  // A facet is offered while it has values, or selections to uncheck
+  function hasFacet(facetKey, filterKey) {
+    return facets !== null && (facets[facetKey].length > 0 || hasSelected(filterKey));
+  }
+
+  function renderSearchOptions() {
+    return (
+      <div className="filter-subsection">
+        <CommitInput
+          value={filters.q}
+          placeholder={'iso:>3200 camera:"X-T5" -country:Japan'}
+          onCommit={(value) => handleFilterChange('q', value || null)}
+        />
+        <div className="filter-hint">
+          Combine terms with OR, NOT or a leading -, and compare with :&gt;, :&lt; or ranges like date:2019-01..2019-06
+        </div>
+      </div>
+    );
+  }
+
+  // Ratings keep their labels, with only those that have photos listed once the counts are in
   function renderRatingOptions() {
+    let options = RATINGS;
+    if (facets) {
+      const selected = filters.ratings || [];
+      options = RATINGS
+        .map(rating => ({ ...rating, count: facets.ratings.find(r => r.value === rating.value)?.count || 0 }))
+        .filter(rating => rating.count > 0 || selected.includes(rating.value))
+        .map(rating => ({ value: rating.value, label: <FacetLabel label={rating.label} count={rating.count} /> }));
+    }
     return (
       <CheckboxGroup
-        options={RATINGS}
+        options={options}
         selected={filters.ratings || []}
         onChange={(values) => handleFilterChange('ratings', values)}
       />
@@ -181,7 +389,7 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
   function renderYearOptions() {
     return (
       <CheckboxGroup
-        options={filterOptions.years}
+        options={facetOptions(facets.years, filters.years)}
         selected={filters.years || []}
         onChange={(values) => handleFilterChange('years', values)}
       />
@@ -190,12 +398,12 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
 
   function renderCameraOptions() {
     let makeOptions = null;
-    if (filterOptions.cameraMakes.length > 0) {
+    if (hasFacet('cameraMakes', 'cameraMakes')) {
       makeOptions = (
         <div className="filter-subsection">
           <div className="filter-subsection-label">Make</div>
           <CheckboxGroup
-            options={filterOptions.cameraMakes}
+            options={facetOptions(facets.cameraMakes, filters.cameraMakes)}
             selected={filters.cameraMakes || []}
             onChange={(values) => handleFilterChange('cameraMakes', values)}
           />
@@ -204,12 +412,12 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
     }
 
     let modelOptions = null;
-    if (filterOptions.cameraModels.length > 0) {
+    if (hasFacet('cameraModels', 'cameraModels')) {
       modelOptions = (
         <div className="filter-subsection">
           <div className="filter-subsection-label">Model</div>
           <CheckboxGroup
-            options={filterOptions.cameraModels}
+            options={facetOptions(facets.cameraModels, filters.cameraModels)}
             selected={filters.cameraModels || []}
             onChange={(values) => handleFilterChange('cameraModels', values)}
           />
@@ -217,81 +425,187 @@ export default function FilterPanel({ isOpen, onClose, filters, onFiltersChange
       );
     }
 
+    let lensOptions = null;
+    if (hasFacet('lensModels', 'lensModels')) {
+      lensOptions = (
+        <div className="filter-subsection">
+          <div className="filter-subsection-label">Lens</div>
+          <CheckboxGroup
+            options={facetOptions(facets.lensModels, filters.lensModels)}
+            selected={filters.lensModels || []}
+            onChange={(values) => handleFilterChange('lensModels', values)}
+          />
+        </div>
+      );
+    }
+
     return (
       <>
         {makeOptions}
         {modelOptions}
+        {lensOptions}
       </>
     );
   }
 
-  function renderLocationOptions() {
-    let countryOptions = null;
-    if (filterOptions.countries.length > 0) {
-      countryOptions = (
-        <div className="filter-subsection">
-          <div className="filter-subsection-label">Country</div>
-          <CheckboxGroup
-            options={filterOptions.countries}
-            selected={filters.countries || []}
-            onChange={(values) => handleFilterChange('countries', values)}
-          />
+  function renderLocationCheckbox(key, facet) {
+    return (
+      <Checkbox checked={(filters[key] || []).includes(facet.value)} onChange={() => handleToggleValue(key, facet.value)}>
+        <FacetLabel label={facet.value} count={facet.count} />
+      </Checkbox>
+    );
+  }
+
+  function renderCityCheckboxes(cities) {
+    return cities.map(city => (
+      <div key={city.value} className="facet-tree-item">
+        {renderLocationCheckbox('cities', city)}
+      </div>
+    ));
+  }
+
+  // Each country lists its states and their cities beneath it, with cities that have no state directly under
+  // the country
+  function renderLocationTree() {
+    const countryElements = facets.countries.map(country => {
+      const stateElements = country.states.map(state => (
+        <div key={state.value} className="facet-tree-item">
+          {renderLocationCheckbox('states', state)}
+          <div className="facet-tree-children">
+            {renderCityCheckboxes(state.cities)}
+          </div>
+        </div>
+      ));
+
+      return (
+        <div key={country.value} className="facet-tree-item">
+          {renderLocationCheckbox('countries', country)}
+          <div className="facet-tree-children">
+            {stateElements}
+            {renderCityCheckboxes(country.cities)}
+          </div>
         </div>
       );
-    }
+    });
 
-    let stateOptions = null;
-    if (filterOptions.states.length > 0) {
-      stateOptions = (
+    // Picks the other filters leave without photos drop out of the tree, so they're listed after it
+    const flat = flattenFacets(facets);
+    const missingElements = [['countries', facets.countries], ['states', flat.states], ['cities', flat.cities]].flatMap(([key, values]) =>
+      (filters[key] || [])
+        .filter(value => !values.some(v => v.value === value))
+        .map(value => (
+          <div key={`${key}-${value}`} className="facet-tree-item">
+            {renderLocationCheckbox(key, { value, count: 0 })}
+          </div>
+        ))
+    );
+
+    return (
+      <div className="filter-options filter-options-scrollable facet-tree">
+        {countryElements}
+        {missingElements}
+      </div>
+    );
+  }
+
+  function renderLocationOptions() {
+    let placeOptions = null;
+    if (hasFacet('places', 'places')) {
+      placeOptions = (
         <div className="filter-subsection">
-          <div className="filter-subsection-label">State</div>
+          <div className="filter-subsection-label">Place</div>
           <CheckboxGroup
-            options={filterOptions.states}
-            selected={filters.states || []}
-            onChange={(values) => handleFilterChange('states', values)}
+            options={facetOptions(facets.places, filters.places)}
+            selected={filters.places || []}
+            onChange={(values) => handleFilterChange('places', values)}
           />
         </div>
       );
     }
 
-    let cityOptions = null;
-    if (filterOptions.cities.length > 0) {
-      cityOptions = (
+    let treeOptions = null;
+    if (hasFacet('countries', 'countries') || hasSelected('states') || hasSelected('cities')) {
+      treeOptions = (
         <div className="filter-subsection">
-          <div className="filter-subsection-label">City</div>
-          <CheckboxGroup
-            options={filterOptions.cities}
-            selected={filters.cities || []}
-            onChange={(values) => handleFilterChange('cities', values)}
-            className="filter-options-scrollable"
-          />
+          <div className="filter-subsection-label">Country, state & city</div>
+          {renderLocationTree()}
         </div>
       );
     }
 
     return (
       <>
-        {countryOptions}
-        {stateOptions}
-        {cityOptions}
+        {placeOptions}
+        {treeOptions}
       </>
     );
   }
 
   function renderFormatOptions() {
-    const formatOptions = filterOptions.fileFormats.map(format => ({
-      value: format,
-      label: format.toUpperCase()
-    }));
     return (
       <CheckboxGroup
-        options={formatOptions}
+        options={facetOptions(facets.fileFormats, filters.fileFormats, format => format.toUpperCase())}
         selected={filters.fileFormats || []}
         onChange={(values) => handleFilterChange('fileFormats', values)}
       />
     );
   }
 
+  function renderDateOptions() {
+    return (
+      <div className="filter-range">
+        <CommitInput type="date" value={filters.dateFrom} onCommit={(value) => handleFilterChange('dateFrom', value || null)} />
+        <span>to</span>
+        <CommitInput type="date" value={filters.dateTo} onCommit={(value) => handleFilterChange('dateTo', value || null)} />
+      </div>
+    );
+  }
+
+  function renderRangeOptions() {
+    return RANGES.map(range => {
+      const format = range.fromParam || (v => v);
+      const min = filters[range.key + 'Min'];
+      const max = filters[range.key + 'Max'];
+      return (
+        <div className="filter-subsection" key={range.key}>
+          <div className="filter-subsection-label">{range.label}</div>
+          <div className="filter-range">
+            <CommitInput value={min ? format(min) : ''} placeholder={range.placeholder[0]} onCommit={(value) => handleRangeChange(range, 'Min', value)} />
+            <span>to</span>
+            <CommitInput value={max ? format(max) : ''} placeholder={range.placeholder[1]} onCommit={(value) => handleRangeChange(range, 'Max', value)} />
+          </div>
+        </div>
+      );
+    });
+  }
+
+  function renderPresenceOptions(key) {
+    return (
+      <RadioGroup
+        options={PRESENCE}
+        selected={filters[key] || 'all'}
+        onChange={(value) => handleFilterChange(key, value === 'all' ? null : value)}
+      />
+    );
+  }
+
+  function renderExcludeOptions() {
+    const flat = flattenFacets(facets);
+    return EXCLUSIONS
+      .filter(exclusion => flat[exclusion.options].length > 0 || hasSelected(exclusion.key))
+      .map(exclusion => (
+        <div className="filter-subsection" key={exclusion.key}>
+          <div className="filter-subsection-label">{exclusion.label}</div>
+          <CheckboxGroup
+            options={facetOptions(flat[exclusion.options], filters[exclusion.key])}
+            selected={filters[exclusion.key] || []}
+            onChange={(values) => handleFilterChange(exclusion.key, values)}
+            className="filter-options-scrollable"
+          />
+        </div>
+      ));
+  }
+
   let clearButton = null;
   if (activeCount > 0) {
     clearButton = (
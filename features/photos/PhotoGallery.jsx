@@ -3,6 +3,7 @@ import IconButton from '../../commons/components/IconButton.jsx';
 import Button from '../../commons/components/Button.jsx';
 import MasonryGrid from '../../commons/components/MasonryGrid.jsx';
 import { StackIcon } from '../../commons/components/Icon.jsx';
+import VideoThumbnail from './VideoThumbnail.jsx';
 import getThumbnailUrl from '../../commons/utils/getThumbnailUrl.js';
 import isVideoFile from '../../commons/utils/isVideoFile.js';
 import formatGroupDate from '../../commons/utils/formatGroupDate.js';
@@ -59,7 +60,7 @@ export default function PhotoGallery({
       }
 
       const currentPhoto = photos[selectedIndex];
-      if (!currentPhoto || fadingSet.has(currentPhoto.filePath)) {
+      if (!currentPhoto || fadingSet.has(currentPhoto.photoId)) {
         return;
       }
 
@@ -161,7 +162,7 @@ export default function PhotoGallery({
   }
 
   function renderBurstStack(photo, index, burstInfo) {
-    const thumbnailUrl = getThumbnailUrl(photo.filePath);
+    const thumbnailUrl = getThumbnailUrl(photo.photoId);
     const isSelected = selectedSet.has(index);
 
     let className = 'gallery-item burst-stack masonry-item';
@@ -195,9 +196,9 @@ export default function PhotoGallery({
     }
 
     const isVideo = photo.isVideo || isVideoFile(photo.filePath);
-    const thumbnailUrl = getThumbnailUrl(photo.filePath);
+    const thumbnailUrl = getThumbnailUrl(photo.photoId);
     const isSelected = selectedSet.has(index);
-    const isFading = fadingSet.has(photo.filePath);
+    const isFading = fadingSet.has(photo.photoId);
 
     let className = 'gallery-item masonry-item';
     if (isSelected) {
@@ -222,7 +223,7 @@ export default function PhotoGallery({
         <div className="undo-overlay">
           <Button variant="primary" onClick={(e) => {
             e.stopPropagation();
-            onUndo(photo.filePath);
+            onUndo(photo.photoId);
           }}>Undo</Button>
         </div>
       );
@@ -238,6 +239,20 @@ export default function PhotoGallery({
       );
     }
 
+    let media = null;
+    if (isVideo) {
+      media = <VideoThumbnail photoId={photo.photoId} filePath={photo.filePath} thumbnailUrl={thumbnailUrl} />;
+    } else {
+      media = (
+        <img
+          src={thumbnailUrl}
+          alt={photo.filePath}
+          className="gallery-media"
+          loading="lazy"
+        />
+      );
+    }
+
     let burstIndicator = null;
     if (burstContext) {
       burstIndicator = (
@@ -249,16 +264,11 @@ export default function PhotoGallery({
 
     return (
       <div
-        key={photo.filePath}
+        key={photo.photoId}
         className={className}
         onClick={(e) => handlePhotoClick(index, e)}
       >
-        <img
-          src={thumbnailUrl}
-          alt={photo.filePath}
-          className="gallery-media"
-          loading="lazy"
-        />
+        {media}
         {videoIndicator}
         {burstIndicator}
         {undoButton}
@@ -319,7 +329,23 @@ export default function PhotoGallery({
 
       const photoElements = renderPhotosWithBursts(groupStartIndex, groupEndIndex);
 
-      const dateStr = formatGroupDate(group.date);
+      // Groups other than days are titled, with the import date alongside an import's title
+      let headingText = formatGroupDate(group.date);
+      if (group.title) {
+        headingText = group.date ? `${group.title} · ${formatGroupDate(group.date)}` : group.title;
+      }
+
+      // A day split across pages shows its full totals, with the part on this page called out
+      const dayPhotoCount = group.dayPhotoCount || group.photoCount;
+      let countText = `${dayPhotoCount} ${pluralize(dayPhotoCount, 'photo')}`;
+      if (dayPhotoCount > group.photoCount) {
+        countText = `${group.photoCount} of ${dayPhotoCount} photos`;
+      }
+
+      let continuedLabel = null;
+      if (group.isContinued) {
+        continuedLabel = <span className="group-continued">continued</span>;
+      }
 
       let selectAllButton = null;
       if (onSelectionChange) {
@@ -351,12 +377,13 @@ export default function PhotoGallery({
       }
 
       return (
-        <div key={`${group.date}-${photos[groupStartIndex]?.sha256Hash || groupStartIndex}`} className="group-container">
+        <div key={`${group.title || group.date}-${photos[groupStartIndex]?.sha256Hash || groupStartIndex}`} className="group-container">
           <div className="group-header">
             <div className="group-header-info">
-              <span className="group-date">{dateStr}</span>
-              <span className="group-count">{group.photoCount} {pluralize(group.photoCount, 'photo')}</span>
-              <span className="group-size">{formatFileSize(group.totalSize)}</span>
+              <span className="group-date">{headingText}</span>
+              {continuedLabel}
+              <span className="group-count">{countText}</span>
+              <span className="group-size">{formatFileSize(group.dayTotalSize || group.totalSize)}</span>
             </div>
             {selectAllButton}
           </div>
@@ -1,153 +1,105 @@
 import ApiClient from '../../commons/http/ApiClient.js';
 import PhotoGallery from './PhotoGallery.jsx';
 import FilterPanel from './FilterPanel.jsx';
+import { parseFiltersFromUrl, filtersToUrlParams, filtersToRules, rulesToFilters, countActiveFilters, CLEARED_FILTER_PARAMS } from './filterParams.js';
 import Pagination from '../../commons/components/Pagination.jsx';
 import AddToAlbumModal from '../albums/AddToAlbumModal.jsx';
+import CreateAlbumModal from '../albums/CreateAlbumModal.jsx';
+import TimeShiftModal from './TimeShiftModal.jsx';
+import { useCurrentUser, hasRole } from '../auth/AuthProvider.jsx';
 import IconButton from '../../commons/components/IconButton.jsx';
 import EmptyState from '../../commons/components/EmptyState.jsx';
 import MessageBox from '../../commons/components/MessageBox.jsx';
 import SelectionCount from '../../commons/components/SelectionCount.jsx';
-import { LoadingSpinner, PickIcon, RejectIcon, UnflagIcon, FilterIcon, TrashEmptyIcon, SparklesIcon, ImageIcon, FolderIcon, StarIcon } from '../../commons/components/Icon.jsx';
+import SegmentedControl from '../../commons/components/SegmentedControl.jsx';
+import { LoadingSpinner, PickIcon, RejectIcon, UnflagIcon, FilterIcon, TrashEmptyIcon, SparklesIcon, ImageIcon, FolderIcon, StarIcon, HeartIcon, ClockIcon } from '../../commons/components/Icon.jsx';
 import { showToast } from '../../commons/components/Toast.jsx';
 import useSearchParams from '../../commons/hooks/useSearchParams.js';
-import { updateSearchParams } from '../../commons/components/Link.jsx';
+import { updateSearchParams, navigateTo } from '../../commons/components/Link.jsx';
 import './LibraryPage.css';
 import './Loading.css';
 
 const { useState, useEffect } = React;
 
+// Whose decisions a page shows: everyone's combined or only the signed-in user's
+const CURATOR_OPTIONS = [
+  { value: 'team', label: 'Team' },
+  { value: 'me', label: 'Mine' },
+];
+
+// Newest first is the default and is left out of the URL
+const SORT_OPTIONS = [
+  { value: 'newest', label: 'Newest first' },
+  { value: 'oldest', label: 'Oldest first' },
+  { value: 'imported', label: 'Recently imported' },
+  { value: 'rating', label: 'Rating' },
+  { value: 'size', label: 'File size' },
+  { value: 'filename', label: 'Filename' },
+  { value: 'camera', label: 'Camera' },
+];
+const DEFAULT_SORT = 'newest';
+
+const PAGE_SIZE = 100;
+
+// Pages are read by cursor, so imports don't shift them; start only tracks the position for display
+const CLEARED_PAGE_PARAMS = { after: null, before: null, start: null, offset: null };
+
 const PAGE_CONFIG = {
   library: {
-    fetchPhotos: (offset, filters) => ApiClient.getPhotos(offset, filters),
+    scope: 'library',
+    path: '/library',
+    fetchPhotos: (page, filters, curator) => ApiClient.getPhotos(page, filters, curator),
     emptyState: {
       icon: ImageIcon,
       title: 'No photos',
       description: 'Picked photos will appear here',
     },
     initialSelectedIndex: null,
+    defaultCurator: 'team',
+    smartCuration: 'picked',
   },
   curate: {
-    fetchPhotos: (offset, filters) => ApiClient.getUncuratedPhotos(offset, filters),
+    scope: 'uncurated',
+    path: '/curate',
+    fetchPhotos: (page, filters, curator) => ApiClient.getUncuratedPhotos(page, filters, curator),
     emptyState: {
       icon: SparklesIcon,
       title: 'No photos to review',
       description: 'New imports will appear here for curation',
     },
     initialSelectedIndex: 0,
+    defaultCurator: 'me',
+    smartCuration: 'uncurated',
   },
   trash: {
-    fetchPhotos: (offset, filters) => ApiClient.getTrashedPhotos(offset, filters),
+    scope: 'trash',
+    path: '/trash',
+    fetchPhotos: (page, filters, curator) => ApiClient.getTrashedPhotos(page, filters, curator),
     emptyState: {
       icon: TrashEmptyIcon,
       title: 'No rejected photos',
       description: 'Rejected photos will appear here',
     },
     initialSelectedIndex: null,
+    defaultCurator: 'team',
+    smartCuration: 'rejected',
   },
 };
 
-function parseFiltersFromUrl(searchParams) {
-  const filters = {};
-
-  const ratings = searchParams.getAll('ratings');
-  if (ratings.length > 0) {
-    filters.ratings = ratings.map(r => parseInt(r, 10)).filter(r => !isNaN(r));
-  }
-
-  const mediaType = searchParams.get('mediaType');
-  if (mediaType && mediaType !== 'all') {
-    filters.mediaType = mediaType;
-  }
-
-  const orientation = searchParams.get('orientation');
-  if (orientation && orientation !== 'all') {
-    filters.orientation = orientation;
-  }
-
-  const years = searchParams.getAll('years');
-  if (years.length > 0) {
-    filters.years = years.map(y => parseInt(y, 10)).filter(y => !isNaN(y));
-  }
-
-  const cameraMakes = searchParams.getAll('cameraMakes');
-  if (cameraMakes.length > 0) {
-    filters.cameraMakes = cameraMakes;
-  }
-
-  const cameraModels = searchParams.getAll('cameraModels');
-  if (cameraModels.length > 0) {
-    filters.cameraModels = cameraModels;
-  }
-
-  const countries = searchParams.getAll('countries');
-  if (countries.length > 0) {
-    filters.countries = countries;
-  }
-
-  const states = searchParams.getAll('states');
-  if (states.length > 0) {
-    filters.states = states;
-  }
-
-  const cities = searchParams.getAll('cities');
-  if (cities.length > 0) {
-    filters.cities = cities;
-  }
-
-  const fileFormats = searchParams.getAll('fileFormats');
-  if (fileFormats.length > 0) {
-    filters.fileFormats = fileFormats;
-  }
-
-  return filters;
-}
-
-function filtersToUrlParams(filters) {
-  const params = {};
-
-  if (filters.ratings && filters.ratings.length > 0) {
-    params.ratings = filters.ratings;
-  }
-  if (filters.mediaType && filters.mediaType !== 'all') {
-    params.mediaType = filters.mediaType;
-  }
-  if (filters.orientation && filters.orientation !== 'all') {
-    params.orientation = filters.orientation;
-  }
-  if (filters.years && filters.years.length > 0) {
-    params.years = filters.years;
-  }
-  if (filters.cameraMakes && filters.cameraMakes.length > 0) {
-    params.cameraMakes = filters.cameraMakes;
-  }
-  if (filters.cameraModels && filters.cameraModels.length > 0) {
-    params.cameraModels = filters.cameraModels;
-  }
-  if (filters.countries && filters.countries.length > 0) {
-    params.countries = filters.countries;
-  }
-  if (filters.states && filters.states.length > 0) {
-    params.states = filters.states;
-  }
-  if (filters.cities && filters.cities.length > 0) {
-    params.cities = filters.cities;
-  }
-  if (filters.fileFormats && filters.fileFormats.length > 0) {
-    params.fileFormats = filters.fileFormats;
-  }
-
-  return params;
-}
-
 export default function PhotoListPage({ mode = 'library' }) {
   const config = PAGE_CONFIG[mode];
   const isCurateMode = mode === 'curate';
   const searchParams = useSearchParams();
 
-  const offsetParam = searchParams.get('offset');
-  const offset = offsetParam ? parseInt(offsetParam, 10) : 0;
+  const after = searchParams.get('after');
+  const before = searchParams.get('before');
+  const startParam = searchParams.get('start');
+  const start = startParam ? parseInt(startParam, 10) : 1;
 
   const filters = parseFiltersFromUrl(searchParams);
+  const curator = searchParams.get('curator') || config.defaultCurator;
+  const sort = searchParams.get('sort') || DEFAULT_SORT;
+  const isOwnDecisions = curator === 'me';
 
   const [photos, setPhotos] = useState([]);
   const [groups, setGroups] = useState([]);
@@ -156,14 +108,19 @@ export default function PhotoListPage({ mode = 'library' }) {
   const [isLoading, setIsLoading] = useState(true);
   const [error, setError] = useState(null);
   const [totalRecords, setTotalRecords] = useState(0);
-  const [pageStartRecord, setPageStartRecord] = useState(0);
-  const [pageEndRecord, setPageEndRecord] = useState(0);
+  const [isCountApproximate, setIsCountApproximate] = useState(false);
+  const [nextCursor, setNextCursor] = useState(null);
+  const [prevCursor, setPrevCursor] = useState(null);
   const initialSelection = config.initialSelectedIndex !== null ? new Set([config.initialSelectedIndex]) : new Set();
   const [selectedIndices, setSelectedIndices] = useState(initialSelection);
   const [fadingPhotos, setFadingPhotos] = useState(new Set());
   const [isCurating, setIsCurating] = useState(false);
   const [isFilterPanelOpen, setIsFilterPanelOpen] = useState(false);
   const [isAlbumModalOpen, setIsAlbumModalOpen] = useState(false);
+  const [isSmartAlbumModalOpen, setIsSmartAlbumModalOpen] = useState(false);
+  const [isTimeShiftModalOpen, setIsTimeShiftModalOpen] = useState(false);
+  const [reloadCount, setReloadCount] = useState(0);
+  const { user } = useCurrentUser();
 
   const filtersKey = JSON.stringify(filters);
 
@@ -173,15 +130,16 @@ export default function PhotoListPage({ mode = 'library' }) {
       setError(null);
 
       try {
-        const data = await config.fetchPhotos(offset, filters);
+        const data = await config.fetchPhotos({ after, before, sort, count: 'approximate' }, filters, curator);
         const newPhotos = data.photos || [];
         setPhotos(newPhotos);
         setGroups(data.groups || []);
         setBursts(data.bursts || []);
         setExpandedBursts(new Set());
         setTotalRecords(data.totalRecords || 0);
-        setPageStartRecord(data.pageStartRecord || 0);
-        setPageEndRecord(data.pageEndRecord || 0);
+        setIsCountApproximate(data.isCountApproximate || false);
+        setNextCursor(data.nextCursor || null);
+        setPrevCursor(data.prevCursor || null);
 
         if (newPhotos.length === 0) {
           setSelectedIndices(new Set());
@@ -202,10 +160,12 @@ export default function PhotoListPage({ mode = 'library' }) {
     }
 
     fetchPhotos();
-  }, [offset, filtersKey]);
+  }, [after, before, sort, filtersKey, curator, reloadCount]);
 
-  const hasPrev = offset > 0;
-  const hasNext = pageEndRecord < totalRecords;
+  const hasPrev = prevCursor !== null;
+  const hasNext = nextCursor !== null;
+  const pageStartRecord = photos.length === 0 ? 0 : (hasPrev ? start : 1);
+  const pageEndRecord = photos.length === 0 ? 0 : pageStartRecord + photos.length - 1;
 
   useEffect(() => {
     function handleKeyDown(e) {
@@ -228,7 +188,7 @@ export default function PhotoListPage({ mode = 'library' }) {
 
     document.addEventListener('keydown', handleKeyDown);
     return () => document.removeEventListener('keydown', handleKeyDown);
-  }, [hasNext, hasPrev]);
+  }, [nextCursor, prevCursor, pageStartRecord, pageEndRecord]);
 
   useEffect(() => {
     function handleCurateKeyDown(e) {
@@ -257,6 +217,13 @@ export default function PhotoListPage({ mode = 'library' }) {
           e.preventDefault();
           handleUnflagClick();
           break;
+        case 'f':
+        case 'F':
+          if (isOwnDecisions) {
+            e.preventDefault();
+            handleFavoriteClick();
+          }
+          break;
         case '1':
         case '2':
         case '3':
@@ -270,7 +237,7 @@ export default function PhotoListPage({ mode = 'library' }) {
 
     document.addEventListener('keydown', handleCurateKeyDown);
     return () => document.removeEventListener('keydown', handleCurateKeyDown);
-  }, [selectedIndices, photos]);
+  }, [selectedIndices, photos, isOwnDecisions]);
 
 
   function handleBurstToggle(burstId) {
@@ -286,40 +253,58 @@ export default function PhotoListPage({ mode = 'library' }) {
   }
 
   function handleFiltersChange(newFilters) {
-    const filterParams = filtersToUrlParams(newFilters);
-    const clearParams = {
-      ratings: null,
-      mediaType: null,
-      orientation: null,
-      years: null,
-      cameraMakes: null,
-      cameraModels: null,
-      countries: null,
-      states: null,
-      cities: null,
-      fileFormats: null,
-      offset: null,
-    };
-    updateSearchParams({ ...clearParams, ...filterParams });
+    updateSearchParams({ ...CLEARED_FILTER_PARAMS, ...CLEARED_PAGE_PARAMS, ...filtersToUrlParams(newFilters) });
+  }
+
+  // Saved filters for another listing open that listing with the filters and sort
+  function handleApplySavedFilter(savedFilter) {
+    const params = { ...filtersToUrlParams(rulesToFilters(savedFilter.filters)) };
+    if (savedFilter.sort && savedFilter.sort !== DEFAULT_SORT) {
+      params.sort = savedFilter.sort;
+    }
+    const target = Object.values(PAGE_CONFIG).find(c => c.scope === savedFilter.scope);
+    setIsFilterPanelOpen(false);
+
+    if (!target || target === config) {
+      updateSearchParams({ ...CLEARED_FILTER_PARAMS, ...CLEARED_PAGE_PARAMS, sort: null, ...params });
+      return;
+    }
+
+    const searchParams = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      [].concat(value).forEach(v => searchParams.append(key, v));
+    });
+    const query = searchParams.toString();
+    navigateTo(query ? `${target.path}?${query}` : target.path);
+  }
+
+  function handleCuratorChange(value) {
+    updateSearchParams({ ...CLEARED_PAGE_PARAMS, curator: value === config.defaultCurator ? null : value });
+  }
+
+  // Cursors belong to the sort they were made with, so a new sort starts from the first page
+  function handleSortChange(e) {
+    const value = e.target.value;
+    updateSearchParams({ ...CLEARED_PAGE_PARAMS, sort: value === DEFAULT_SORT ? null : value });
   }
 
   function handleSelectionChange(indices) {
     setSelectedIndices(indices);
   }
 
-  async function handleCurate(filePath, isCurated, isTrashed, rating) {
+  async function handleCurate(photoId, isCurated, isTrashed, rating) {
     setIsCurating(true);
     try {
-      await ApiClient.curatePhoto(filePath, isCurated, isTrashed, rating);
+      await ApiClient.curatePhoto(photoId, isCurated, isTrashed, rating);
 
       if (isCurated && !isTrashed && rating === 0) {
-        setFadingPhotos(prev => new Set([...prev, filePath]));
+        setFadingPhotos(prev => new Set([...prev, photoId]));
       } else if (isTrashed) {
-        setFadingPhotos(prev => new Set([...prev, filePath]));
+        setFadingPhotos(prev => new Set([...prev, photoId]));
       }
 
       setPhotos(prevPhotos => prevPhotos.map(p => {
-        if (p.filePath === filePath) {
+        if (p.photoId === photoId) {
           return { ...p, isCurated, isTrashed, rating };
         }
         return p;
@@ -331,17 +316,17 @@ export default function PhotoListPage({ mode = 'library' }) {
     }
   }
 
-  async function handleUndo(filePath) {
-    const photo = photos.find(p => p.filePath === filePath);
+  async function handleUndo(photoId) {
+    const photo = photos.find(p => p.photoId === photoId);
     if (!photo) {
       return;
     }
 
     try {
-      await ApiClient.curatePhoto(filePath, false, false, 0);
+      await ApiClient.curatePhoto(photoId, false, false, 0);
 
       setPhotos(prevPhotos => prevPhotos.map(p => {
-        if (p.filePath === filePath) {
+        if (p.photoId === photoId) {
           return { ...p, isCurated: false, isTrashed: false, rating: 0 };
         }
         return p;
@@ -349,7 +334,7 @@ export default function PhotoListPage({ mode = 'library' }) {
 
       setFadingPhotos(prev => {
         const next = new Set(prev);
-        next.delete(filePath);
+        next.delete(photoId);
         return next;
       });
     } catch (err) {
@@ -357,75 +342,81 @@ export default function PhotoListPage({ mode = 'library' }) {
     }
   }
 
-  function getSelectedFilePaths() {
-    return Array.from(selectedIndices).map(i => photos[i]?.filePath).filter(Boolean);
+  function getSelectedPhotoIds() {
+    return Array.from(selectedIndices).map(i => photos[i]?.photoId).filter(Boolean);
   }
 
   function handlePickClick() {
-    const filePaths = getSelectedFilePaths();
-    if (filePaths.length === 0) {
+    const photoIds = getSelectedPhotoIds();
+    if (photoIds.length === 0) {
       return;
     }
-    filePaths.forEach(filePath => {
-      const photo = photos.find(p => p.filePath === filePath);
+    photoIds.forEach(photoId => {
+      const photo = photos.find(p => p.photoId === photoId);
       const currentRating = photo ? (photo.rating || 0) : 0;
-      handleCurate(filePath, true, false, currentRating);
+      handleCurate(photoId, true, false, currentRating);
     });
   }
 
   function handleRejectClick() {
-    const filePaths = getSelectedFilePaths();
-    if (filePaths.length === 0) {
+    const photoIds = getSelectedPhotoIds();
+    if (photoIds.length === 0) {
       return;
     }
-    filePaths.forEach(filePath => handleCurate(filePath, true, true, 0));
+    photoIds.forEach(photoId => handleCurate(photoId, true, true, 0));
   }
 
   function handleUnflagClick() {
-    const filePaths = getSelectedFilePaths();
-    if (filePaths.length === 0) {
+    const photoIds = getSelectedPhotoIds();
+    if (photoIds.length === 0) {
       return;
     }
-    filePaths.forEach(filePath => handleCurate(filePath, false, false, 0));
+    photoIds.forEach(photoId => handleCurate(photoId, false, false, 0));
+  }
+
+  // Only offered on the user's own decisions, so the pick/reject/rating sent back are theirs too
+  async function handleFavoriteClick() {
+    const photoIds = getSelectedPhotoIds();
+    if (photoIds.length === 0) {
+      return;
+    }
+
+    const selectedPhotos = photos.filter(p => photoIds.includes(p.photoId));
+    const isFavorite = !selectedPhotos.every(p => p.isFavorite);
+
+    setIsCurating(true);
+    try {
+      await Promise.all(selectedPhotos.map(p => ApiClient.curatePhoto(p.photoId, p.isCurated, p.isTrashed, p.rating || 0, isFavorite)));
+      setPhotos(prevPhotos => prevPhotos.map(p => photoIds.includes(p.photoId) ? { ...p, isFavorite } : p));
+    } catch (err) {
+      showToast('Unable to update favorites');
+    } finally {
+      setIsCurating(false);
+    }
   }
 
   function handleRateClick(rating) {
-    const filePaths = getSelectedFilePaths();
-    if (filePaths.length === 0) {
+    const photoIds = getSelectedPhotoIds();
+    if (photoIds.length === 0) {
       return;
     }
-    filePaths.forEach(filePath => handleCurate(filePath, true, false, rating));
+    photoIds.forEach(photoId => handleCurate(photoId, true, false, rating));
   }
 
   function handlePrevPage() {
     if (hasPrev) {
-      const newOffset = Math.max(0, offset - 100);
-      updateSearchParams({ offset: newOffset });
+      updateSearchParams({ ...CLEARED_PAGE_PARAMS, before: prevCursor, start: Math.max(1, pageStartRecord - PAGE_SIZE) });
     }
   }
 
   function handleNextPage() {
     if (hasNext) {
-      updateSearchParams({ offset: offset + 100 });
+      updateSearchParams({ ...CLEARED_PAGE_PARAMS, after: nextCursor, start: pageEndRecord + 1 });
     }
   }
 
-  function getActiveFilterCount() {
-    let count = 0;
-    if (filters.ratings && filters.ratings.length > 0) count += filters.ratings.length;
-    if (filters.mediaType && filters.mediaType !== 'all') count++;
-    if (filters.orientation && filters.orientation !== 'all') count++;
-    if (filters.years && filters.years.length > 0) count += filters.years.length;
-    if (filters.cameraMakes && filters.cameraMakes.length > 0) count += filters.cameraMakes.length;
-    if (filters.cameraModels && filters.cameraModels.length > 0) count += filters.cameraModels.length;
-    if (filters.countries && filters.countries.length > 0) count += filters.countries.length;
-    if (filters.states && filters.states.length > 0) count += filters.states.length;
-    if (filters.cities && filters.cities.length > 0) count += filters.cities.length;
-    if (filters.fileFormats && filters.fileFormats.length > 0) count += filters.fileFormats.length;
-    return count;
-  }
 
-  const activeFilterCount = getActiveFilterCount();
+  const activeFilterCount = countActiveFilters(filters);
 
   let content = null;
 
@@ -486,6 +477,7 @@ export default function PhotoListPage({ mode = 'library' }) {
         pageStartRecord={pageStartRecord}
         pageEndRecord={pageEndRecord}
         totalRecords={totalRecords}
+        isTotalApproximate={isCountApproximate}
         onPrev={handlePrevPage}
         onNext={handleNextPage}
         hasPrev={hasPrev}
@@ -494,6 +486,15 @@ export default function PhotoListPage({ mode = 'library' }) {
     );
   }
 
+  const sortOptionElements = SORT_OPTIONS.map(option => (
+    <option key={option.value} value={option.value}>{option.label}</option>
+  ));
+  const sortSelect = (
+    <select className="sort-select" value={sort} onChange={handleSortChange} title="Sort">
+      {sortOptionElements}
+    </select>
+  );
+
   let filterButton = null;
   if (!error) {
     let filterBadge = null;
@@ -512,6 +513,16 @@ export default function PhotoListPage({ mode = 'library' }) {
     );
   }
 
+  // Smart albums follow the team's decisions, so they can only be saved from the team view
+  let saveSmartAlbumButton = null;
+  if (!error && activeFilterCount > 0 && curator === 'team' && hasRole(user, 'curator')) {
+    saveSmartAlbumButton = (
+      <IconButton onClick={() => setIsSmartAlbumModalOpen(true)} title="Save as Smart Album">
+        <FolderIcon />
+      </IconButton>
+    );
+  }
+
 
   let loadingOverlay = null;
   if (isLoading) {
@@ -542,6 +553,16 @@ export default function PhotoListPage({ mode = 'library' }) {
     });
 
 
+    let favoriteButton = null;
+    if (isOwnDecisions) {
+      const isFavorite = selectedIndices.size === 1 && selectedPhoto ? selectedPhoto.isFavorite : false;
+      favoriteButton = (
+        <IconButton variant="rate" active={isFavorite} onClick={handleFavoriteClick} title="Favorite (F)" disabled={isCurating}>
+          <HeartIcon size={18} isFilled={isFavorite} />
+        </IconButton>
+      );
+    }
+
     let addToAlbumButton = null;
     if (!isCurateMode) {
       addToAlbumButton = (
@@ -552,6 +573,16 @@ export default function PhotoListPage({ mode = 'library' }) {
       );
     }
 
+    let timeShiftButton = null;
+    if (!isCurateMode && hasRole(user, 'curator')) {
+      timeShiftButton = (
+        <IconButton onClick={() => setIsTimeShiftModalOpen(true)} title="Shift Date & Time">
+          <ClockIcon />
+          <span>Shift Time</span>
+        </IconButton>
+      );
+    }
+
     actionButtons = (
       <div className="library-actions">
         <IconButton variant="pick" active={isPicked && currentRating === 0} onClick={handlePickClick} title="Pick (P)" disabled={isCurating}>
@@ -569,7 +600,9 @@ export default function PhotoListPage({ mode = 'library' }) {
         <div className="rating-buttons">
           {starElements}
         </div>
+        {favoriteButton}
         {addToAlbumButton}
+        {timeShiftButton}
       </div>
     );
   }
@@ -581,8 +614,26 @@ export default function PhotoListPage({ mode = 'library' }) {
 
   let albumModal = null;
   if (isAlbumModalOpen && hasSelection) {
-    const selectedPhotoPaths = Array.from(selectedIndices).map(index => photos[index].filePath);
-    albumModal = (<AddToAlbumModal selectedPhotos={selectedPhotoPaths} onClose={() => setIsAlbumModalOpen(false)} />);
+    const selectedPhotoIds = Array.from(selectedIndices).map(index => photos[index].photoId);
+    albumModal = (<AddToAlbumModal selectedPhotos={selectedPhotoIds} onClose={() => setIsAlbumModalOpen(false)} />);
+  }
+
+  let timeShiftModal = null;
+  if (isTimeShiftModalOpen && hasSelection) {
+    const selectedPhotos = Array.from(selectedIndices).map(index => photos[index]).filter(Boolean);
+    timeShiftModal = (
+      <TimeShiftModal
+        selectedPhotos={selectedPhotos}
+        onClose={() => setIsTimeShiftModalOpen(false)}
+        onShifted={() => setReloadCount(reloadCount + 1)}
+      />
+    );
+  }
+
+  let smartAlbumModal = null;
+  if (isSmartAlbumModalOpen) {
+    const smartRules = { filters: filtersToRules(filters), curation: config.smartCuration };
+    smartAlbumModal = (<CreateAlbumModal smartRules={smartRules} onClose={() => setIsSmartAlbumModalOpen(false)} />);
   }
 
   return (
@@ -595,7 +646,10 @@ export default function PhotoListPage({ mode = 'library' }) {
           {actionButtons}
         </div>
         <div className="right-actions">
+          <SegmentedControl options={CURATOR_OPTIONS} value={curator} onChange={handleCuratorChange} />
+          {sortSelect}
           {filterButton}
+          {saveSmartAlbumButton}
           {paginationElement}
         </div>
       </div>
@@ -606,8 +660,14 @@ export default function PhotoListPage({ mode = 'library' }) {
         onClose={() => setIsFilterPanelOpen(false)}
         filters={filters}
         onFiltersChange={handleFiltersChange}
+        scope={config.scope}
+        sort={sort}
+        curator={curator}
+        onApplySavedFilter={handleApplySavedFilter}
       />
       {albumModal}
+      {smartAlbumModal}
+      {timeShiftModal}
     </div>
   );
 }
@@ -0,0 +1,103 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import Button from '../../commons/components/Button.jsx';
+import { CloseIcon } from '../../commons/components/Icon.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import { filtersToRules, countActiveFilters } from './filterParams.js';
+import './SavedFilters.css';
+
+const { useState, useEffect } = React;
+
+// Named presets of the current filters and sort. Applying one may switch to another listing, since presets
+// keep their scope.
+export default function SavedFilters({ scope, sort, curator, filters, onApply }) {
+  const [savedFilters, setSavedFilters] = useState([]);
+  const [name, setName] = useState('');
+  const [isSaving, setIsSaving] = useState(false);
+
+  useEffect(() => {
+    fetchSavedFilters();
+  }, [curator]);
+
+  async function fetchSavedFilters() {
+    try {
+      const data = await ApiClient.getSavedFilters(curator);
+      setSavedFilters(data || []);
+    } catch (error) {
+      console.error('Failed to load saved filters:', error);
+    }
+  }
+
+  async function handleSave(e) {
+    e.preventDefault();
+    if (!name.trim()) {
+      return;
+    }
+
+    setIsSaving(true);
+    try {
+      const existing = savedFilters.find(s => s.name.toLowerCase() === name.trim().toLowerCase());
+      const savedFilter = { name: name.trim(), scope, sort: sort === 'newest' ? '' : sort, filters: filtersToRules(filters) };
+      if (existing) {
+        await ApiClient.updateSavedFilter(existing.savedFilterId, savedFilter);
+      } else {
+        await ApiClient.createSavedFilter(savedFilter);
+      }
+      showToast(`Saved "${name.trim()}"`);
+      setName('');
+      await fetchSavedFilters();
+    } catch (error) {
+      console.error('Failed to save filter:', error);
+    } finally {
+      setIsSaving(false);
+    }
+  }
+
+  async function handleDelete(e, savedFilter) {
+    e.stopPropagation();
+    try {
+      await ApiClient.deleteSavedFilter(savedFilter.savedFilterId);
+      await fetchSavedFilters();
+    } catch (error) {
+      console.error('Failed to delete saved filter:', error);
+    }
+  }
+
+  const items = savedFilters.map(savedFilter => (
+    <div key={savedFilter.savedFilterId} className="saved-filter" onClick={() => onApply(savedFilter)}>
+      <span className="saved-filter-name">{savedFilter.name}</span>
+      <span className="saved-filter-count">{savedFilter.photoCount.toLocaleString()}</span>
+      <div className="saved-filter-delete" onClick={(e) => handleDelete(e, savedFilter)} title="Delete">
+        <CloseIcon />
+      </div>
+    </div>
+  ));
+
+  let saveForm = null;
+  if (countActiveFilters(filters) > 0) {
+    saveForm = (
+      <form className="saved-filter-form" onSubmit={handleSave}>
+        <input
+          type="text"
+          className="filter-input"
+          placeholder="Name these filters"
+          value={name}
+          onChange={e => setName(e.target.value)}
+        />
+        <Button type="submit" isLoading={isSaving} isDisabled={!name.trim()}>Save</Button>
+      </form>
+    );
+  }
+
+  let emptyMessage = null;
+  if (savedFilters.length === 0 && !saveForm) {
+    emptyMessage = <div className="filter-hint">Pick some filters to save them here</div>;
+  }
+
+  return (
+    <div className="saved-filters">
+      {items}
+      {emptyMessage}
+      {saveForm}
+    </div>
+  );
+}
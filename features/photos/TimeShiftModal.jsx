@@ -0,0 +1,114 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import { ModalBackdrop, ModalContainer, ModalHeader, ModalContent, ModalFooter } from '../../commons/components/Modal.jsx';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import Checkbox from '../../commons/components/Checkbox.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import formatDateTime from '../../commons/utils/formatDateTime.js';
+import formatUTCOffset from '../../commons/utils/formatUTCOffset.js';
+import pluralize from '../../commons/utils/pluralize.js';
+import './TimeShiftModal.css';
+
+const { useState } = React;
+
+// Every quarter hour from UTC-12:00 to UTC+14:00, which covers all real timezones
+const UTC_OFFSET_OPTIONS = [];
+for (let minutes = -12 * 60; minutes <= 14 * 60; minutes += 15) {
+  UTC_OFFSET_OPTIONS.push(minutes);
+}
+
+export default function TimeShiftModal({ selectedPhotos, onClose, onShifted }) {
+  const [days, setDays] = useState('');
+  const [hours, setHours] = useState('');
+  const [minutes, setMinutes] = useState('');
+  const [seconds, setSeconds] = useState('');
+  const [utcOffset, setUtcOffset] = useState('');
+  const [writeToFile, setWriteToFile] = useState(false);
+  const [isLoading, setIsLoading] = useState(false);
+
+  const shiftSeconds = (parseInt(days, 10) || 0) * 86400 + (parseInt(hours, 10) || 0) * 3600 + (parseInt(minutes, 10) || 0) * 60 + (parseInt(seconds, 10) || 0);
+  const hasChange = shiftSeconds !== 0 || utcOffset !== '';
+
+  async function handleApply() {
+    if (!hasChange) {
+      showToast('Enter a time shift or pick a timezone');
+      return;
+    }
+
+    const photoIds = selectedPhotos.map(photo => photo.photoId);
+    const utcOffsetMinutes = utcOffset === '' ? null : parseInt(utcOffset, 10);
+
+    setIsLoading(true);
+    try {
+      const result = await ApiClient.shiftPhotoTimes(photoIds, shiftSeconds, utcOffsetMinutes, writeToFile);
+      let message = `Updated ${result.updated} ${pluralize(result.updated, 'photo')}`;
+      if (result.failed > 0) {
+        message += `, ${result.failed} ${pluralize(result.failed, 'file')} could not be written`;
+      }
+      showToast(message);
+      onShifted();
+      onClose();
+    } catch (error) {
+      console.error('Failed to shift photo times:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  // Preview on the first photo with a capture time, using its own wall clock time
+  let previewElement = null;
+  const previewPhoto = selectedPhotos.find(photo => photo.localDateTime);
+  if (previewPhoto && hasChange) {
+    const shifted = new Date(new Date(previewPhoto.localDateTime + 'Z').getTime() + shiftSeconds * 1000);
+    const shiftedLocal = shifted.toISOString().slice(0, 19);
+    const currentOffset = previewPhoto.utcOffset ?? null;
+    const newOffset = utcOffset === '' ? currentOffset : parseInt(utcOffset, 10);
+
+    previewElement = (
+      <div className="time-shift-modal-preview">
+        <div>{formatDateTime(previewPhoto.localDateTime)} {formatUTCOffset(currentOffset)}</div>
+        <div>→ {formatDateTime(shiftedLocal)} {formatUTCOffset(newOffset)}</div>
+      </div>
+    );
+  }
+
+  const offsetOptions = UTC_OFFSET_OPTIONS.map(offset => (
+    <option key={offset} value={offset}>{formatUTCOffset(offset)}</option>
+  ));
+
+  return (
+    <ModalBackdrop onClose={onClose}>
+      <ModalContainer className="time-shift-modal">
+        <ModalHeader title="Shift Date & Time" onClose={onClose} />
+        <ModalContent>
+          <p className="time-shift-modal-description">
+            Fix {selectedPhotos.length} {pluralize(selectedPhotos.length, 'photo')} taken with a wrong camera clock. Use negative values to move them earlier.
+          </p>
+          <div className="time-shift-modal-fields">
+            <Input id="time-shift-days" type="number" label="Days" value={days} onChange={(e) => setDays(e.target.value)} autoFocus />
+            <Input id="time-shift-hours" type="number" label="Hours" value={hours} onChange={(e) => setHours(e.target.value)} />
+            <Input id="time-shift-minutes" type="number" label="Minutes" value={minutes} onChange={(e) => setMinutes(e.target.value)} />
+            <Input id="time-shift-seconds" type="number" label="Seconds" value={seconds} onChange={(e) => setSeconds(e.target.value)} />
+          </div>
+          <label className="time-shift-modal-label" htmlFor="time-shift-timezone">Timezone</label>
+          <select id="time-shift-timezone" className="time-shift-modal-select" value={utcOffset} onChange={(e) => setUtcOffset(e.target.value)}>
+            <option value="">Keep current</option>
+            {offsetOptions}
+          </select>
+          <p className="time-shift-modal-hint">Setting a timezone keeps the time shown on the camera and changes when that was in UTC.</p>
+          <Checkbox checked={writeToFile} onChange={(e) => setWriteToFile(e.target.checked)} label="Write the corrected time into the files" />
+          <p className="time-shift-modal-hint">Changed files are no longer detected as duplicates of their originals on import.</p>
+          {previewElement}
+        </ModalContent>
+        <ModalFooter isRightAligned>
+          <Button onClick={onClose} variant="secondary">
+            Cancel
+          </Button>
+          <Button onClick={handleApply} disabled={isLoading || !hasChange} variant="primary">
+            Apply
+          </Button>
+        </ModalFooter>
+      </ModalContainer>
+    </ModalBackdrop>
+  );
+}
@@ -0,0 +1,108 @@
+import getVideoPreviewUrls from '../../commons/utils/getVideoPreviewUrls.js';
+import './VideoThumbnail.css';
+
+const { useState } = React;
+
+// Parses "00:00:02.000 --> 00:00:04.000" followed by "name.sprite.jpg#xywh=160,0,160,90" cues
+function parseSpriteIndex(text) {
+  const cues = [];
+  const blocks = text.split(/\n\n+/);
+
+  for (const block of blocks) {
+    const match = block.match(/#xywh=(\d+),(\d+),(\d+),(\d+)/);
+    if (match) {
+      cues.push({
+        x: parseInt(match[1]),
+        y: parseInt(match[2]),
+        width: parseInt(match[3]),
+        height: parseInt(match[4])
+      });
+    }
+  }
+
+  return cues;
+}
+
+export default function VideoThumbnail({ photoId, filePath, thumbnailUrl }) {
+  const [isHovering, setIsHovering] = useState(false);
+  const [cues, setCues] = useState(null);
+  const [cueIndex, setCueIndex] = useState(0);
+
+  const previewUrls = getVideoPreviewUrls(photoId);
+
+  async function loadSpriteIndex() {
+    if (cues !== null) {
+      return;
+    }
+
+    try {
+      const response = await fetch(previewUrls.spriteIndex);
+      if (!response.ok) {
+        setCues([]);
+        return;
+      }
+      setCues(parseSpriteIndex(await response.text()));
+    } catch (error) {
+      setCues([]);
+    }
+  }
+
+  function handleMouseEnter() {
+    setIsHovering(true);
+    loadSpriteIndex();
+  }
+
+  function handleMouseLeave() {
+    setIsHovering(false);
+    setCueIndex(0);
+  }
+
+  function handleMouseMove(e) {
+    if (!cues || cues.length === 0) {
+      return;
+    }
+    const rect = e.currentTarget.getBoundingClientRect();
+    const fraction = Math.min(Math.max((e.clientX - rect.left) / rect.width, 0), 0.999);
+    setCueIndex(Math.floor(fraction * cues.length));
+  }
+
+  let preview = null;
+  if (isHovering && cues && cues.length > 0) {
+    const cue = cues[cueIndex];
+    const columns = Math.max(...cues.map(c => c.x)) / cue.width + 1;
+    const rows = Math.max(...cues.map(c => c.y)) / cue.height + 1;
+    const column = cue.x / cue.width;
+    const row = cue.y / cue.height;
+
+    const style = {
+      backgroundImage: `url(${previewUrls.sprite})`,
+      backgroundSize: `${columns * 100}% ${rows * 100}%`,
+      backgroundPosition: `${columns > 1 ? (column / (columns - 1)) * 100 : 0}% ${rows > 1 ? (row / (rows - 1)) * 100 : 0}%`
+    };
+
+    preview = (
+      <div className="video-thumbnail-scrub" style={style}>
+        <div className="video-thumbnail-progress" style={{ width: `${((cueIndex + 1) / cues.length) * 100}%` }} />
+      </div>
+    );
+  } else if (isHovering && cues) {
+    preview = <img src={previewUrls.animated} alt="" className="video-thumbnail-animated" />;
+  }
+
+  return (
+    <div
+      className="video-thumbnail"
+      onMouseEnter={handleMouseEnter}
+      onMouseLeave={handleMouseLeave}
+      onMouseMove={handleMouseMove}
+    >
+      <img
+        src={thumbnailUrl}
+        alt={filePath}
+        className="gallery-media"
+        loading="lazy"
+      />
+      {preview}
+    </div>
+  );
+}
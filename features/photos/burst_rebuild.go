@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"os"
 	"riffle/commons/utils"
 )
 
@@ -19,8 +20,10 @@ func HandleRebuildBurstData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	libraryPath := os.Getenv("LIBRARY_PATH")
+
 	go func() {
-		if err := RebuildBurstData(); err != nil {
+		if err := RebuildBurstData(libraryPath); err != nil {
 			slog.Error("failed to rebuild burst data", "error", err)
 			return
 		}
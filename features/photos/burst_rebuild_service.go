@@ -3,12 +3,13 @@ package photos
 import (
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"riffle/commons/hash"
 	"riffle/commons/sqlite"
 	"sync/atomic"
 )
 
-func RebuildBurstData() error {
+func RebuildBurstData(libraryPath string) error {
 	slog.Info("starting burst data rebuild")
 
 	UpdateBurstProgress(StatusBurstRebuildProcessing, 0, 0)
@@ -35,7 +36,7 @@ func RebuildBurstData() error {
 	var failed atomic.Int32
 
 	for _, photo := range allPhotos {
-		photoPath := photo.FilePath
+		photoPath := filepath.Join(libraryPath, photo.FilePath)
 
 		dhash, err := hash.ComputeDhash(photoPath)
 		if err != nil {
@@ -46,7 +47,7 @@ func RebuildBurstData() error {
 		}
 
 		dhashStr := fmt.Sprintf("%016x", dhash)
-		if err := UpdatePhotoDhash(photoPath, dhashStr); err != nil {
+		if err := UpdatePhotoDhash(photo.PhotoID, dhashStr); err != nil {
 			slog.Error("failed to update dhash in database", "photo", photoPath, "error", err)
 			failed.Add(1)
 		}
@@ -65,7 +66,7 @@ func RebuildBurstData() error {
 
 func GetAllImagePhotos() ([]Photo, error) {
 	query := `
-		SELECT file_path
+		SELECT photo_id, file_path
 		FROM photos
 		WHERE is_video = 0
 		ORDER BY date_time DESC
@@ -82,7 +83,7 @@ func GetAllImagePhotos() ([]Photo, error) {
 	var photosList []Photo
 	for rows.Next() {
 		var photo Photo
-		if err := rows.Scan(&photo.FilePath); err != nil {
+		if err := rows.Scan(&photo.PhotoID, &photo.FilePath); err != nil {
 			slog.Error("error scanning photo row", "error", err)
 			continue
 		}
@@ -92,14 +93,14 @@ func GetAllImagePhotos() ([]Photo, error) {
 	return photosList, nil
 }
 
-func UpdatePhotoDhash(filePath, dhash string) error {
+func UpdatePhotoDhash(photoID int64, dhash string) error {
 	query := `
 		UPDATE photos
 		SET dhash = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE file_path = ?
+		WHERE photo_id = ?
 	`
 
-	_, err := sqlite.DB.Exec(query, dhash, filePath)
+	_, err := sqlite.DB.Exec(query, dhash, photoID)
 	if err != nil {
 		err = fmt.Errorf("error updating photo dhash: %w", err)
 		slog.Error(err.Error())
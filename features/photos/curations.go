@@ -0,0 +1,56 @@
+package photos
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"riffle/commons/utils"
+	"riffle/features/auth"
+	"strconv"
+)
+
+type PhotoCurationsResponse struct {
+	Summary   *CurationSummary `json:"summary"`
+	Curations []UserCuration   `json:"curations"`
+}
+
+func HandleGetPhotoCurations(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PHOTO_ID", "Invalid photo ID")
+		return
+	}
+
+	summary, curations, err := GetPhotoCurations(photoID)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photo curations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PhotoCurationsResponse{Summary: summary, Curations: curations})
+}
+
+// Reads the curator query param: "me" for the signed-in user, a user ID, or "team"/empty for the aggregate
+func ParseCurationUser(r *http.Request) int64 {
+	curator := r.URL.Query().Get("curator")
+	if curator == "" || curator == "team" {
+		return TeamCuration
+	}
+
+	if curator == "me" {
+		if user := auth.GetCurrentUser(r); user != nil {
+			return user.UserID
+		}
+		return TeamCuration
+	}
+
+	userID, err := strconv.ParseInt(curator, 10, 64)
+	if err != nil || userID <= 0 {
+		slog.Warn("ignoring invalid curator param", "curator", curator)
+		return TeamCuration
+	}
+
+	return userID
+}
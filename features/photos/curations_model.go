@@ -0,0 +1,189 @@
+package photos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+)
+
+// TeamCuration selects the aggregate of everyone's decisions instead of a single user's
+const TeamCuration int64 = 0
+
+type UserCuration struct {
+	UserID     int64  `json:"userId"`
+	Username   string `json:"username"`
+	IsCurated  bool   `json:"isCurated"`
+	IsTrashed  bool   `json:"isTrashed"`
+	IsFavorite bool   `json:"isFavorite"`
+	Rating     int    `json:"rating"`
+	UpdatedAt  string `json:"updatedAt"`
+}
+
+type CurationSummary struct {
+	CuratorCount    int  `json:"curatorCount"`
+	PickCount       int  `json:"pickCount"`
+	RejectCount     int  `json:"rejectCount"`
+	FavoriteCount   int  `json:"favoriteCount"`
+	ConsensusRating int  `json:"consensusRating"`
+	AnyPicked       bool `json:"anyPicked"`
+	AllRejected     bool `json:"allRejected"`
+}
+
+// Returns a FROM source that exposes the same columns as photos, plus is_favorite, with is_curated,
+// is_trashed and rating taken from the given user's decisions. Aliased as photos so existing where
+// clauses and filters apply unchanged.
+func curationSource(userID int64) string {
+	if userID == TeamCuration {
+		return `(
+			SELECT
+				p.*,
+				EXISTS (SELECT 1 FROM photo_curations pc WHERE pc.photo_id = p.photo_id AND pc.is_favorite = 1) AS is_favorite
+			FROM
+				photos p
+		) AS photos`
+	}
+
+	// The user's decisions come before p.*, so they are the columns the names resolve to; SQLite renames the
+	// team's duplicates (is_curated:1 and so on) and nothing refers to those
+	return fmt.Sprintf(`(
+		SELECT
+			COALESCE(pc.is_curated, 0) AS is_curated,
+			COALESCE(pc.is_trashed, 0) AS is_trashed,
+			COALESCE(pc.is_favorite, 0) AS is_favorite,
+			COALESCE(pc.rating, 0) AS rating,
+			p.*
+		FROM
+			photos p
+		LEFT JOIN
+			photo_curations pc ON pc.photo_id = p.photo_id AND pc.user_id = %d
+	) AS photos`, userID)
+}
+
+// Records a user's decision for a photo and refreshes the team aggregate on photos.
+// A nil isFavorite leaves the user's favorite flag as it was.
+func UpdateUserPhotoCuration(userID, photoID int64, isCurated, isTrashed bool, rating int, isFavorite *bool) error {
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting curation transaction: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertQuery := `
+		INSERT INTO photo_curations (user_id, photo_id, is_curated, is_trashed, is_favorite, rating)
+		VALUES (?, ?, ?, ?, COALESCE(?, 0), ?)
+		ON CONFLICT (user_id, photo_id) DO UPDATE SET
+			is_curated = excluded.is_curated,
+			is_trashed = excluded.is_trashed,
+			is_favorite = COALESCE(?, is_favorite),
+			rating = excluded.rating,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err = tx.Exec(upsertQuery, userID, photoID, isCurated, isTrashed, isFavorite, rating, isFavorite)
+	if err != nil {
+		err = fmt.Errorf("error updating user curation: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	// An unflagged, unrated, unfavorited row carries no decision
+	deleteQuery := `
+		DELETE FROM photo_curations
+		WHERE user_id = ? AND photo_id = ? AND is_curated = 0 AND is_trashed = 0 AND is_favorite = 0 AND rating = 0
+	`
+
+	_, err = tx.Exec(deleteQuery, userID, photoID)
+	if err != nil {
+		err = fmt.Errorf("error clearing user curation: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	if err = refreshTeamCuration(tx, photoID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing curation: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// A photo is picked for the team if anyone picked it and rejected if everyone who decided rejected it.
+// auth.DeleteUser runs the same update, so keep the two in step.
+func refreshTeamCuration(tx *sql.Tx, photoID int64) error {
+	query := `
+		UPDATE photos
+		SET
+			is_curated = COALESCE((SELECT any_picked OR all_rejected FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			is_trashed = COALESCE((SELECT all_rejected AND NOT any_picked FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			rating = COALESCE((SELECT consensus_rating FROM photo_curation_summary s WHERE s.photo_id = photos.photo_id), 0),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			photo_id = ?
+	`
+
+	_, err := tx.Exec(query, photoID)
+	if err != nil {
+		err = fmt.Errorf("error refreshing team curation: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func GetPhotoCurations(photoID int64) (*CurationSummary, []UserCuration, error) {
+	summaryQuery := `
+		SELECT curator_count, pick_count, reject_count, favorite_count, consensus_rating, any_picked, all_rejected
+		FROM photo_curation_summary
+		WHERE photo_id = ?
+	`
+
+	summary := &CurationSummary{}
+	err := sqlite.DB.QueryRow(summaryQuery, photoID).Scan(
+		&summary.CuratorCount, &summary.PickCount, &summary.RejectCount, &summary.FavoriteCount,
+		&summary.ConsensusRating, &summary.AnyPicked, &summary.AllRejected,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = fmt.Errorf("error getting curation summary: %w", err)
+		slog.Error(err.Error())
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT pc.user_id, u.username, pc.is_curated, pc.is_trashed, pc.is_favorite, pc.rating, pc.updated_at
+		FROM photo_curations pc
+		JOIN users u ON u.user_id = pc.user_id
+		WHERE pc.photo_id = ?
+		ORDER BY u.username ASC
+	`
+
+	rows, err := sqlite.DB.Query(query, photoID)
+	if err != nil {
+		err = fmt.Errorf("error getting user curations: %w", err)
+		slog.Error(err.Error())
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	curations := []UserCuration{}
+	for rows.Next() {
+		var c UserCuration
+		if err := rows.Scan(&c.UserID, &c.Username, &c.IsCurated, &c.IsTrashed, &c.IsFavorite, &c.Rating, &c.UpdatedAt); err != nil {
+			err = fmt.Errorf("error scanning user curation: %w", err)
+			slog.Error(err.Error())
+			return nil, nil, err
+		}
+		curations = append(curations, c)
+	}
+
+	return summary, curations, nil
+}
@@ -1,31 +1,189 @@
 package photos
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"riffle/commons/sqlite"
+	"strings"
 )
 
+// Photos are grouped by day in the capture time sorts, and by import, rating or camera in those sorts
 type Group struct {
-	Date       string `json:"date"`
-	PhotoCount int    `json:"photoCount"`
+	Date       string `json:"date"`            // Day groups, and the day an import started
+	Title      string `json:"title,omitempty"` // Groups other than days
+	ImportID   int64  `json:"importId,omitempty"`
+	PhotoCount int    `json:"photoCount"` // Photos of the group on this page
 	TotalSize  int64  `json:"totalSize"`
+
+	// A group can span pages; these cover all of its photos, including the ones on other pages. They're
+	// named for days, the first grouping.
+	DayPhotoCount       int   `json:"dayPhotoCount"`
+	DayTotalSize        int64 `json:"dayTotalSize"`
+	IsContinued         bool  `json:"isContinued"`
+	ContinuesOnNextPage bool  `json:"continuesOnNextPage"`
+}
+
+// Approximate counts stop here, so deep libraries don't pay for a full count on every page
+const maxApproximateCount = 10000
+
+// PhotoScope narrows a listing to a subset of the library, such as an album's photos
+type PhotoScope struct {
+	Condition string // SQL condition over photos columns
+	Args      []any
+	OrderBy   string // Replaces the default order unless a sort is asked for. Groups and cursors are left out.
+	OrderArgs []any
+}
+
+// PageRequest picks a page either by cursor, which stays put while photos are imported, or by offset.
+// After and Before are cursors from a previous page; Offset is only used when neither is set.
+type PageRequest struct {
+	Sort               string // One of the Sort constants, newest first when empty
+	Limit              int
+	Offset             int
+	After              string
+	Before             string
+	IsCountApproximate bool
+}
+
+type PhotosPage struct {
+	Photos             []Photo
+	Groups             []Group
+	TotalRecords       int
+	IsCountApproximate bool // TotalRecords is a lower bound
+	PageStartRecord    int  // Only known when paging by offset
+	PageEndRecord      int
+	NextCursor         string
+	PrevCursor         string
 }
 
-func GetPhotosWithDayGroups(limit, offset int, isCurated, isTrashed bool, filters *PhotoFilters) ([]Photo, []Group, int, int, int, error) {
-	whereClause := "WHERE 1=1"
-	args := []any{}
+// curationUserID picks whose decisions decide curated/trashed/rating; TeamCuration uses the aggregate
+func GetPhotosWithDayGroups(page PageRequest, isCurated, isTrashed bool, curationUserID int64, filters *PhotoFilters) (*PhotosPage, error) {
+	return getPhotosPage(page, listingWhereClause(isCurated, isTrashed), nil, PhotoScope{}, curationUserID, filters)
+}
+
+// Picks the library (curated), the photos still to curate, or the trash, which ignores isCurated
+func listingWhereClause(isCurated, isTrashed bool) string {
+	if isTrashed {
+		return "WHERE is_trashed = 1"
+	}
 
 	if isCurated {
-		whereClause += " AND is_curated = 1 AND is_trashed = 0"
+		return "WHERE 1=1 AND is_curated = 1 AND is_trashed = 0"
+	}
+
+	return "WHERE 1=1 AND is_curated = 0 AND is_trashed = 0"
+}
+
+// Lists the photos in scope regardless of curation state, still showing curationUserID's decisions
+func GetScopedPhotosWithDayGroups(page PageRequest, scope PhotoScope, curationUserID int64, filters *PhotoFilters) (*PhotosPage, error) {
+	whereClause := "WHERE (" + scope.Condition + ")"
+	return getPhotosPage(page, whereClause, scope.Args, scope, curationUserID, filters)
+}
+
+func getPhotosPage(page PageRequest, whereClause string, whereArgs []any, scope PhotoScope, curationUserID int64, filters *PhotoFilters) (*PhotosPage, error) {
+	conditions, args := withFilters(whereClause, whereArgs, filters)
+	source := curationSource(curationUserID)
+	listing := getListingSort(page.Sort)
+	isKeyOrder := scope.OrderBy == "" || page.Sort != ""
+
+	orderBy := listing.orderBy(false)
+	pageConditions := conditions
+	pageArgs := append([]any{}, args...)
+	offset := page.Offset
+	isBackward := false
+
+	switch {
+	case !isKeyOrder:
+		orderBy = scope.OrderBy
+		pageArgs = append(pageArgs, scope.OrderArgs...)
+	case page.Before != "":
+		key, err := decodeCursor(page.Before, page.Sort)
+		if err != nil {
+			return nil, err
+		}
+		pageConditions += listing.seekCondition(true)
+		pageArgs = append(append(pageArgs, key[0]), key...)
+		orderBy = listing.orderBy(true)
+		offset = 0
+		isBackward = true
+	case page.After != "":
+		key, err := decodeCursor(page.After, page.Sort)
+		if err != nil {
+			return nil, err
+		}
+		pageConditions += listing.seekCondition(false)
+		pageArgs = append(append(pageArgs, key[0]), key...)
+		offset = 0
+	}
+
+	// One extra photo tells whether there's another page in the direction being read
+	photos, keys, err := queryPagePhotos(source, pageConditions, orderBy, listing, pageArgs, page.Limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(photos) > page.Limit
+	if hasMore {
+		photos = photos[:page.Limit]
+		keys = keys[:page.Limit]
+	}
+
+	hasPrev := offset > 0 || page.After != ""
+	hasNext := hasMore
+
+	if isBackward {
+		// Reading back reached the start, so the first page is served whole instead of a short one
+		if !hasMore {
+			firstPage := PageRequest{Sort: page.Sort, Limit: page.Limit, IsCountApproximate: page.IsCountApproximate}
+			return getPhotosPage(firstPage, whereClause, whereArgs, scope, curationUserID, filters)
+		}
+
+		for i, j := 0, len(photos)-1; i < j; i, j = i+1, j-1 {
+			photos[i], photos[j] = photos[j], photos[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		hasPrev = true
+		hasNext = true
+	}
+
+	result := &PhotosPage{
+		Photos: photos,
+		Groups: []Group{},
+	}
+
+	if page.IsCountApproximate {
+		result.TotalRecords, result.IsCountApproximate = getApproximateCount(source, conditions, maxApproximateCount, args...)
 	} else {
-		whereClause += " AND is_curated = 0 AND is_trashed = 0"
+		result.TotalRecords = getCount(source, conditions, args...)
 	}
 
-	if isTrashed {
-		whereClause = "WHERE is_trashed = 1"
+	if isKeyOrder && len(photos) > 0 {
+		result.Groups, err = getGroupsForPage(listing, source, conditions, args, photos, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasPrev {
+			result.PrevCursor = encodeCursor(listing.name, keys[0])
+		}
+		if hasNext {
+			result.NextCursor = encodeCursor(listing.name, keys[len(keys)-1])
+		}
+	}
+
+	if page.After == "" && page.Before == "" && len(photos) > 0 {
+		result.PageStartRecord = offset + 1
+		result.PageEndRecord = offset + len(photos)
 	}
 
+	return result, nil
+}
+
+func withFilters(whereClause string, whereArgs []any, filters *PhotoFilters) (string, []any) {
+	args := append([]any{}, whereArgs...)
+
 	if filters != nil {
 		filterSQL, filterArgs := BuildFilterConditions(filters)
 		if filterSQL != "" {
@@ -34,140 +192,185 @@ func GetPhotosWithDayGroups(limit, offset int, isCurated, isTrashed bool, filter
 		}
 	}
 
-	totalRecords := getCount(whereClause, args...)
-
-	groups, err := getGroupsForPage(whereClause, args, limit, offset)
-	if err != nil {
-		return nil, nil, 0, 0, 0, err
-	}
+	return whereClause, args
+}
 
-	photoQuery := fmt.Sprintf(`
+// Also selects each photo's key in the listing sort, whichever order the page is read in
+func queryPagePhotos(source, whereClause, orderBy string, listing listingSort, args []any, limit, offset int) ([]Photo, []pageKey, error) {
+	query := fmt.Sprintf(`
 		SELECT
-			file_path, original_filepath, sha256_hash, dhash, file_size,
-			date_time, camera_make, camera_model, width, height, orientation,
+			photo_id, file_path, original_filepath, sha256_hash, dhash, file_size,
+			date_time, local_date_time, utc_offset, camera_make, camera_model, width, height, orientation,
 			latitude, longitude, iso, f_number, exposure_time, focal_length,
+			lens_make, lens_model, focal_length_35mm, exposure_program, exposure_mode, exposure_compensation,
+			metering_mode, white_balance, flash, software, color_space, color_profile,
+			altitude, gps_direction, camera_serial,
 			file_format, mime_type, is_video, duration,
+			video_codec, audio_codec, frame_rate, rotation,
 			file_created_at, file_modified_at,
-			city, state, country_name,
-			is_curated, is_trashed, rating, notes,
-			created_at, updated_at, thumbnail_path
+			city, county, state, country_name,
+			is_curated, is_trashed, is_favorite, rating, notes,
+			created_at, updated_at, thumbnail_path,
+			%s
 		FROM
-			photos
+			%s
 		%s
 		ORDER BY
-			date_time DESC,
-			created_at DESC
+			%s
 		LIMIT
 			?
 		OFFSET
 			?
-	`, whereClause)
+	`, listing.selectKeyColumns(), source, whereClause, orderBy)
 
-	photoArgs := append([]any{}, args...)
-	photoArgs = append(photoArgs, limit, offset)
+	queryArgs := append([]any{}, args...)
+	queryArgs = append(queryArgs, limit, offset)
 
-	rows, err := sqlite.DB.Query(photoQuery, photoArgs...)
+	rows, err := sqlite.DB.Query(query, queryArgs...)
 	if err != nil {
 		err = fmt.Errorf("error querying photos: %w", err)
 		slog.Error(err.Error())
-		return nil, nil, 0, 0, 0, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	photos := []Photo{}
+	keys := []pageKey{}
 	for rows.Next() {
 		var p Photo
-		err := rows.Scan(
-			&p.FilePath, &p.OriginalFilepath, &p.Sha256Hash, &p.Dhash, &p.FileSize,
-			&p.DateTime, &p.CameraMake, &p.CameraModel, &p.Width, &p.Height, &p.Orientation,
+		k := make(pageKey, len(listing.columns))
+		dest := []any{
+			&p.PhotoID, &p.FilePath, &p.OriginalFilepath, &p.Sha256Hash, &p.Dhash, &p.FileSize,
+			&p.DateTime, &p.LocalDateTime, &p.UTCOffset, &p.CameraMake, &p.CameraModel, &p.Width, &p.Height, &p.Orientation,
 			&p.Latitude, &p.Longitude, &p.ISO, &p.FNumber, &p.ExposureTime, &p.FocalLength,
+			&p.LensMake, &p.LensModel, &p.FocalLength35mm, &p.ExposureProgram, &p.ExposureMode, &p.ExposureCompensation,
+			&p.MeteringMode, &p.WhiteBalance, &p.Flash, &p.Software, &p.ColorSpace, &p.ColorProfile,
+			&p.Altitude, &p.GPSDirection, &p.CameraSerial,
 			&p.FileFormat, &p.MimeType, &p.IsVideo, &p.Duration,
+			&p.VideoCodec, &p.AudioCodec, &p.FrameRate, &p.Rotation,
 			&p.FileCreatedAt, &p.FileModifiedAt,
-			&p.City, &p.State, &p.CountryName,
-			&p.IsCurated, &p.IsTrashed, &p.Rating, &p.Notes,
+			&p.City, &p.County, &p.State, &p.CountryName,
+			&p.IsCurated, &p.IsTrashed, &p.IsFavorite, &p.Rating, &p.Notes,
 			&p.CreatedAt, &p.UpdatedAt, &p.ThumbnailPath,
-		)
-		if err != nil {
+		}
+		for i := range k {
+			dest = append(dest, &k[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
 			err = fmt.Errorf("error scanning photo: %w", err)
 			slog.Error(err.Error())
-			return nil, nil, 0, 0, 0, err
+			return nil, nil, err
 		}
 		photos = append(photos, p)
+		keys = append(keys, k)
 	}
 
 	if err = rows.Err(); err != nil {
 		err = fmt.Errorf("error iterating photos: %w", err)
 		slog.Error(err.Error())
-		return nil, nil, 0, 0, 0, err
+		return nil, nil, err
 	}
 
-	pageStartRecord := offset + 1
-	pageEndRecord := offset + len(photos)
-	if pageEndRecord > totalRecords {
-		pageEndRecord = totalRecords
+	return photos, keys, nil
+}
+
+// Groups follow the photos on the page. Only the first and last group can reach onto other pages, so
+// only those are counted again over the whole listing.
+func getGroupsForPage(listing listingSort, source, whereClause string, args []any, photos []Photo, keys []pageKey) ([]Group, error) {
+	groups := []Group{}
+	if listing.groupBy == groupNone {
+		return groups, nil
 	}
-	if totalRecords == 0 {
-		pageStartRecord = 0
-		pageEndRecord = 0
+
+	groupStarts := []int{}
+
+	for i, photo := range photos {
+		if i == 0 || !listing.isSameGroup(keys[i], keys[i-1]) {
+			groups = append(groups, listing.newGroup(keys[i]))
+			groupStarts = append(groupStarts, i)
+		}
+
+		group := &groups[len(groups)-1]
+		group.PhotoCount++
+		group.TotalSize += photo.FileSize
 	}
 
-	return photos, groups, totalRecords, pageStartRecord, pageEndRecord, nil
+	for i := range groups {
+		groups[i].DayPhotoCount = groups[i].PhotoCount
+		groups[i].DayTotalSize = groups[i].TotalSize
+
+		if groups[i].ImportID > 0 {
+			if err := loadImportDate(&groups[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	edges := []int{0}
+	if len(groups) > 1 {
+		edges = append(edges, len(groups)-1)
+	}
+
+	for _, i := range edges {
+		first := keys[groupStarts[i]]
+		last := keys[groupStarts[i]+groups[i].PhotoCount-1]
+		if err := loadGroupTotals(&groups[i], listing, source, whereClause, args, first, last); err != nil {
+			return nil, err
+		}
+	}
+
+	return groups, nil
 }
 
-func getGroupsForPage(whereClause string, args []any, limit, offset int) ([]Group, error) {
+func loadGroupTotals(group *Group, listing listingSort, source, whereClause string, args []any, first, last pageKey) error {
+	before, after := ">", "<"
+	if listing.isAscending {
+		before, after = "<", ">"
+	}
+
+	keyPlaceholders := placeholders(len(listing.columns))
 	query := fmt.Sprintf(`
 		SELECT
-			COALESCE(SUBSTR(date_time, 1, 10), 'Unknown') as day_date,
-			COUNT(*) as photo_count,
-			SUM(file_size) as total_size
-		FROM (
-			SELECT
-				date_time, file_size
-			FROM
-				photos
+			COUNT(*),
+			COALESCE(SUM(file_size), 0),
+			COALESCE(SUM((%s) %s (%s)), 0),
+			COALESCE(SUM((%s) %s (%s)), 0)
+		FROM
 			%s
-			ORDER BY
-				date_time DESC,
-				created_at DESC
-			LIMIT
-				?
-			OFFSET
-				?
-		)
-		GROUP BY
-			day_date
-		ORDER BY
-			day_date DESC
-	`, whereClause)
+		%s
+		AND (%s) = (%s)
+	`, listing.keyColumns(), before, keyPlaceholders, listing.keyColumns(), after, keyPlaceholders,
+		source, whereClause, strings.Join(listing.columns[:listing.groupSize], ", "), placeholders(listing.groupSize))
 
-	groupArgs := append([]any{}, args...)
-	groupArgs = append(groupArgs, limit, offset)
+	queryArgs := append(append([]any{}, first...), last...)
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, first[:listing.groupSize]...)
 
-	rows, err := sqlite.DB.Query(query, groupArgs...)
+	var beforeCount, afterCount int
+	err := sqlite.DB.QueryRow(query, queryArgs...).Scan(&group.DayPhotoCount, &group.DayTotalSize, &beforeCount, &afterCount)
 	if err != nil {
-		err = fmt.Errorf("error querying groups: %w", err)
+		err = fmt.Errorf("error querying group totals: %w", err)
 		slog.Error(err.Error())
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	groups := []Group{}
-	for rows.Next() {
-		var g Group
-		err := rows.Scan(&g.Date, &g.PhotoCount, &g.TotalSize)
-		if err != nil {
-			err = fmt.Errorf("error scanning group: %w", err)
-			slog.Error(err.Error())
-			return nil, err
-		}
-		groups = append(groups, g)
-	}
+	group.IsContinued = beforeCount > 0
+	group.ContinuesOnNextPage = afterCount > 0
 
-	if err = rows.Err(); err != nil {
-		err = fmt.Errorf("error iterating groups: %w", err)
+	return nil
+}
+
+// Import groups show the day the import started
+func loadImportDate(group *Group) error {
+	err := sqlite.DB.QueryRow(`SELECT COALESCE(SUBSTR(started_at, 1, 10), '') FROM import_sessions WHERE import_id = ?`, group.ImportID).Scan(&group.Date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		err = fmt.Errorf("error querying import date: %w", err)
 		slog.Error(err.Error())
-		return nil, err
+		return err
 	}
-
-	return groups, nil
+	return nil
 }
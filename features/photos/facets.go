@@ -0,0 +1,252 @@
+package photos
+
+import (
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+)
+
+// Each facet counts the photos that match every active filter except the facet's own, so its values show what
+// picking them would give. Values without photos aren't listed.
+type FilterFacets struct {
+	Ratings      []FacetValue[int]    `json:"ratings"`
+	Years        []FacetValue[int]    `json:"years"`
+	CameraMakes  []FacetValue[string] `json:"cameraMakes"`
+	CameraModels []FacetValue[string] `json:"cameraModels"`
+	LensModels   []FacetValue[string] `json:"lensModels"`
+	Places       []FacetValue[string] `json:"places"`
+	Countries    []CountryFacet       `json:"countries"`
+	FileFormats  []FacetValue[string] `json:"fileFormats"`
+}
+
+type FacetValue[T int | string] struct {
+	Value T   `json:"value"`
+	Count int `json:"count"`
+}
+
+// States and cities are listed under their country, with cities that have no state directly under it
+type CountryFacet struct {
+	Value  string               `json:"value"`
+	Count  int                  `json:"count"`
+	States []StateFacet         `json:"states"`
+	Cities []FacetValue[string] `json:"cities"`
+}
+
+type StateFacet struct {
+	Value  string               `json:"value"`
+	Count  int                  `json:"count"`
+	Cities []FacetValue[string] `json:"cities"`
+}
+
+const placeNameColumn = "(SELECT pl.name FROM places pl WHERE pl.place_id = photos.place_id)"
+
+// Counts the facets of the library, the photos still to curate or the trash
+func GetFilterFacets(isCurated, isTrashed bool, curationUserID int64, filters *PhotoFilters) (*FilterFacets, error) {
+	return getFilterFacets(listingWhereClause(isCurated, isTrashed), nil, curationUserID, filters)
+}
+
+// Counts the facets of the photos in scope regardless of curation state
+func GetScopedFilterFacets(scope PhotoScope, curationUserID int64, filters *PhotoFilters) (*FilterFacets, error) {
+	return getFilterFacets("WHERE ("+scope.Condition+")", scope.Args, curationUserID, filters)
+}
+
+func getFilterFacets(whereClause string, whereArgs []any, curationUserID int64, filters *PhotoFilters) (*FilterFacets, error) {
+	if filters == nil {
+		filters = &PhotoFilters{}
+	}
+
+	source := curationSource(curationUserID)
+	facets := &FilterFacets{}
+	var err error
+
+	withoutRatings := withoutFilters(filters, func(f *PhotoFilters) {
+		f.Ratings = nil
+	})
+	facets.Ratings, err = countFacet[int](source, whereClause, whereArgs, withoutRatings, "rating", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	withoutYears := withoutFilters(filters, func(f *PhotoFilters) {
+		f.Years, f.ExcludeYears = nil, nil
+	})
+	facets.Years, err = countFacet[int](source, whereClause, whereArgs, withoutYears, "CAST(SUBSTR(local_date_time, 1, 4) AS INTEGER)", "DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	withoutMakes := withoutFilters(filters, func(f *PhotoFilters) {
+		f.CameraMakes, f.ExcludeCameraMakes = nil, nil
+	})
+	facets.CameraMakes, err = countFacet[string](source, whereClause, whereArgs, withoutMakes, "camera_make", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	withoutModels := withoutFilters(filters, func(f *PhotoFilters) {
+		f.CameraModels, f.ExcludeCameraModels = nil, nil
+	})
+	facets.CameraModels, err = countFacet[string](source, whereClause, whereArgs, withoutModels, "camera_model", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	withoutLenses := withoutFilters(filters, func(f *PhotoFilters) {
+		f.LensModels, f.ExcludeLensModels = nil, nil
+	})
+	facets.LensModels, err = countFacet[string](source, whereClause, whereArgs, withoutLenses, "lens_model", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	withoutPlaces := withoutFilters(filters, func(f *PhotoFilters) {
+		f.Places, f.ExcludePlaces = nil, nil
+	})
+	facets.Places, err = countFacet[string](source, whereClause, whereArgs, withoutPlaces, placeNameColumn, "COLLATE NOCASE ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	// Countries, states and cities are one facet, so picking a city still lists the other countries
+	withoutLocations := withoutFilters(filters, func(f *PhotoFilters) {
+		f.Countries, f.ExcludeCountries = nil, nil
+		f.States, f.ExcludeStates = nil, nil
+		f.Cities, f.ExcludeCities = nil, nil
+	})
+	facets.Countries, err = countLocationFacet(source, whereClause, whereArgs, withoutLocations)
+	if err != nil {
+		return nil, err
+	}
+
+	withoutFormats := withoutFilters(filters, func(f *PhotoFilters) {
+		f.FileFormats, f.ExcludeFileFormats = nil, nil
+	})
+	facets.FileFormats, err = countFacet[string](source, whereClause, whereArgs, withoutFormats, "file_format", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// Copies the filters with a facet's own selections cleared
+func withoutFilters(filters *PhotoFilters, reset func(*PhotoFilters)) *PhotoFilters {
+	copied := *filters
+	reset(&copied)
+	return &copied
+}
+
+// Counts photos per value of column, leaving out photos without one
+func countFacet[T int | string](source, whereClause string, whereArgs []any, filters *PhotoFilters, column, order string) ([]FacetValue[T], error) {
+	whereClause, args := withFilters(whereClause, whereArgs, filters)
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS facet_value,
+			COUNT(*)
+		FROM
+			%s
+		%s AND %s IS NOT NULL AND %s != ''
+		GROUP BY
+			facet_value
+		ORDER BY
+			facet_value %s
+	`, column, source, whereClause, column, column, order)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying facet %s: %w", column, err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue[T]{}
+	for rows.Next() {
+		var value FacetValue[T]
+		if err := rows.Scan(&value.Value, &value.Count); err != nil {
+			err = fmt.Errorf("error scanning facet %s: %w", column, err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating facet %s: %w", column, err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Counts every country, state and city pair at once and builds the tree from the sorted rows
+func countLocationFacet(source, whereClause string, whereArgs []any, filters *PhotoFilters) ([]CountryFacet, error) {
+	whereClause, args := withFilters(whereClause, whereArgs, filters)
+
+	query := fmt.Sprintf(`
+		SELECT
+			country_name,
+			COALESCE(state, '') AS state_name,
+			COALESCE(city, '') AS city_name,
+			COUNT(*)
+		FROM
+			%s
+		%s AND country_name IS NOT NULL AND country_name != ''
+		GROUP BY
+			country_name, state_name, city_name
+		ORDER BY
+			country_name ASC, state_name ASC, city_name ASC
+	`, source, whereClause)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying location facet: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	countries := []CountryFacet{}
+	for rows.Next() {
+		var country, state, city string
+		var count int
+		if err := rows.Scan(&country, &state, &city, &count); err != nil {
+			err = fmt.Errorf("error scanning location facet: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+
+		if len(countries) == 0 || countries[len(countries)-1].Value != country {
+			countries = append(countries, CountryFacet{Value: country, States: []StateFacet{}, Cities: []FacetValue[string]{}})
+		}
+		c := &countries[len(countries)-1]
+		c.Count += count
+
+		if state == "" {
+			if city != "" {
+				c.Cities = append(c.Cities, FacetValue[string]{Value: city, Count: count})
+			}
+			continue
+		}
+
+		if len(c.States) == 0 || c.States[len(c.States)-1].Value != state {
+			c.States = append(c.States, StateFacet{Value: state, Cities: []FacetValue[string]{}})
+		}
+		s := &c.States[len(c.States)-1]
+		s.Count += count
+
+		if city != "" {
+			s.Cities = append(s.Cities, FacetValue[string]{Value: city, Count: count})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating location facet: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return countries, nil
+}
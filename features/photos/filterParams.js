@@ -0,0 +1,250 @@
+// Filters live in the URL so filtered views can be linked to and survive reloads
+
+// Values to leave out, each matching one of the lists above
+export const EXCLUDE_FILTER_KEYS = [
+  'excludeYears',
+  'excludeCameraMakes',
+  'excludeCameraModels',
+  'excludePlaces',
+  'excludeCountries',
+  'excludeStates',
+  'excludeCities',
+  'excludeFileFormats',
+  'excludeLensModels',
+];
+
+// Filters that take a single value: range ends, dates, has GPS / notes and the search query
+export const VALUE_FILTER_KEYS = [
+  'isoMin', 'isoMax',
+  'fNumberMin', 'fNumberMax',
+  'exposureTimeMin', 'exposureTimeMax',
+  'focalLengthMin', 'focalLengthMax',
+  'focalLength35mmMin', 'focalLength35mmMax',
+  'exposureCompensationMin', 'exposureCompensationMax',
+  'fileSizeMin', 'fileSizeMax',
+  'megapixelsMin', 'megapixelsMax',
+  'durationMin', 'durationMax',
+  'dateFrom', 'dateTo',
+  'hasGps', 'hasNotes',
+  'q',
+];
+
+// Every filter param set to null, to clear them with updateSearchParams
+export const CLEARED_FILTER_PARAMS = {
+  ratings: null,
+  mediaType: null,
+  orientation: null,
+  years: null,
+  cameraMakes: null,
+  cameraModels: null,
+  places: null,
+  countries: null,
+  states: null,
+  cities: null,
+  fileFormats: null,
+  lensModels: null,
+  ...Object.fromEntries([...EXCLUDE_FILTER_KEYS, ...VALUE_FILTER_KEYS].map(key => [key, null])),
+};
+
+export function parseFiltersFromUrl(searchParams) {
+  const filters = {};
+
+  const ratings = searchParams.getAll('ratings');
+  if (ratings.length > 0) {
+    filters.ratings = ratings.map(r => parseInt(r, 10)).filter(r => !isNaN(r));
+  }
+
+  const mediaType = searchParams.get('mediaType');
+  if (mediaType && mediaType !== 'all') {
+    filters.mediaType = mediaType;
+  }
+
+  const orientation = searchParams.get('orientation');
+  if (orientation && orientation !== 'all') {
+    filters.orientation = orientation;
+  }
+
+  const years = searchParams.getAll('years');
+  if (years.length > 0) {
+    filters.years = years.map(y => parseInt(y, 10)).filter(y => !isNaN(y));
+  }
+
+  const cameraMakes = searchParams.getAll('cameraMakes');
+  if (cameraMakes.length > 0) {
+    filters.cameraMakes = cameraMakes;
+  }
+
+  const cameraModels = searchParams.getAll('cameraModels');
+  if (cameraModels.length > 0) {
+    filters.cameraModels = cameraModels;
+  }
+
+  const places = searchParams.getAll('places');
+  if (places.length > 0) {
+    filters.places = places;
+  }
+
+  const countries = searchParams.getAll('countries');
+  if (countries.length > 0) {
+    filters.countries = countries;
+  }
+
+  const states = searchParams.getAll('states');
+  if (states.length > 0) {
+    filters.states = states;
+  }
+
+  const cities = searchParams.getAll('cities');
+  if (cities.length > 0) {
+    filters.cities = cities;
+  }
+
+  const fileFormats = searchParams.getAll('fileFormats');
+  if (fileFormats.length > 0) {
+    filters.fileFormats = fileFormats;
+  }
+
+  const lensModels = searchParams.getAll('lensModels');
+  if (lensModels.length > 0) {
+    filters.lensModels = lensModels;
+  }
+
+  EXCLUDE_FILTER_KEYS.forEach(key => {
+    const values = searchParams.getAll(key);
+    if (values.length > 0) {
+      filters[key] = key === 'excludeYears' ? values.map(y => parseInt(y, 10)).filter(y => !isNaN(y)) : values;
+    }
+  });
+
+  VALUE_FILTER_KEYS.forEach(key => {
+    const value = searchParams.get(key);
+    if (value) {
+      filters[key] = value;
+    }
+  });
+
+  return filters;
+}
+
+export function filtersToUrlParams(filters) {
+  const params = {};
+
+  if (filters.ratings && filters.ratings.length > 0) {
+    params.ratings = filters.ratings;
+  }
+  if (filters.mediaType && filters.mediaType !== 'all') {
+    params.mediaType = filters.mediaType;
+  }
+  if (filters.orientation && filters.orientation !== 'all') {
+    params.orientation = filters.orientation;
+  }
+  if (filters.years && filters.years.length > 0) {
+    params.years = filters.years;
+  }
+  if (filters.cameraMakes && filters.cameraMakes.length > 0) {
+    params.cameraMakes = filters.cameraMakes;
+  }
+  if (filters.cameraModels && filters.cameraModels.length > 0) {
+    params.cameraModels = filters.cameraModels;
+  }
+  if (filters.places && filters.places.length > 0) {
+    params.places = filters.places;
+  }
+  if (filters.countries && filters.countries.length > 0) {
+    params.countries = filters.countries;
+  }
+  if (filters.states && filters.states.length > 0) {
+    params.states = filters.states;
+  }
+  if (filters.cities && filters.cities.length > 0) {
+    params.cities = filters.cities;
+  }
+  if (filters.fileFormats && filters.fileFormats.length > 0) {
+    params.fileFormats = filters.fileFormats;
+  }
+  if (filters.lensModels && filters.lensModels.length > 0) {
+    params.lensModels = filters.lensModels;
+  }
+  EXCLUDE_FILTER_KEYS.forEach(key => {
+    if (filters[key] && filters[key].length > 0) {
+      params[key] = filters[key];
+    }
+  });
+  VALUE_FILTER_KEYS.forEach(key => {
+    if (filters[key]) {
+      params[key] = filters[key];
+    }
+  });
+
+  return params;
+}
+
+export function countActiveFilters(filters) {
+  let count = 0;
+  if (filters.ratings && filters.ratings.length > 0) count += filters.ratings.length;
+  if (filters.mediaType && filters.mediaType !== 'all') count++;
+  if (filters.orientation && filters.orientation !== 'all') count++;
+  if (filters.years && filters.years.length > 0) count += filters.years.length;
+  if (filters.cameraMakes && filters.cameraMakes.length > 0) count += filters.cameraMakes.length;
+  if (filters.cameraModels && filters.cameraModels.length > 0) count += filters.cameraModels.length;
+  if (filters.places && filters.places.length > 0) count += filters.places.length;
+  if (filters.countries && filters.countries.length > 0) count += filters.countries.length;
+  if (filters.states && filters.states.length > 0) count += filters.states.length;
+  if (filters.cities && filters.cities.length > 0) count += filters.cities.length;
+  if (filters.fileFormats && filters.fileFormats.length > 0) count += filters.fileFormats.length;
+  if (filters.lensModels && filters.lensModels.length > 0) count += filters.lensModels.length;
+  EXCLUDE_FILTER_KEYS.forEach(key => {
+    if (filters[key] && filters[key].length > 0) count += filters[key].length;
+  });
+  VALUE_FILTER_KEYS.forEach(key => {
+    if (filters[key]) count++;
+  });
+  return count;
+}
+
+// Smart album rules store filters the way the server reads them: ranges as {min, max}, booleans and the
+// search as query
+export function filtersToRules(filters) {
+  const rules = {};
+  Object.entries(filters).forEach(([key, value]) => {
+    if (!VALUE_FILTER_KEYS.includes(key)) {
+      rules[key] = value;
+      return;
+    }
+
+    const bound = key.match(/^(.+)(Min|Max)$/);
+    if (bound) {
+      const [, range, end] = bound;
+      rules[range] = { ...rules[range], [end.toLowerCase()]: parseFloat(value) };
+    } else if (key === 'hasGps' || key === 'hasNotes') {
+      rules[key] = value === 'true';
+    } else if (key === 'q') {
+      rules.query = value;
+    } else {
+      rules[key] = value;
+    }
+  });
+  return rules;
+}
+
+// The reverse of filtersToRules, for applying saved filters
+export function rulesToFilters(rules) {
+  const filters = {};
+  Object.entries(rules || {}).forEach(([key, value]) => {
+    if (value === null || value === undefined || value === '') {
+      return;
+    }
+
+    if (key === 'query') {
+      filters.q = value;
+    } else if (key === 'hasGps' || key === 'hasNotes') {
+      filters[key] = String(value);
+    } else if (typeof value === 'object' && !Array.isArray(value)) {
+      if (value.min !== undefined) filters[key + 'Min'] = String(value.min);
+      if (value.max !== undefined) filters[key + 'Max'] = String(value.max);
+    } else {
+      filters[key] = value;
+    }
+  });
+  return filters;
+}
@@ -0,0 +1,279 @@
+package photos
+
+import (
+	"fmt"
+	"riffle/commons/normalization"
+	"riffle/commons/query"
+	"strconv"
+	"strings"
+)
+
+// A number the query syntax can compare, and how its values are written
+type numericQueryField struct {
+	column string
+	parse  func(string) (float64, bool)
+}
+
+const megapixelsColumn = "(CAST(width AS REAL) * CAST(height AS REAL) / 1000000.0)"
+
+var numericQueryFields = map[string]numericQueryField{
+	"iso":        {"iso", parseQueryNumber},
+	"f":          {"f_number", parseAperture},
+	"aperture":   {"f_number", parseAperture},
+	"shutter":    {"exposure_time", parseShutterSpeed},
+	"exposure":   {"exposure_time", parseShutterSpeed},
+	"focal":      {"focal_length", parseFocalLength},
+	"focal35":    {"focal_length_35mm", parseFocalLength},
+	"ev":         {"exposure_compensation", parseExposureCompensation},
+	"altitude":   {"altitude", parseAltitude},
+	"size":       {"file_size", parseFileSize},
+	"width":      {"width", parseQueryNumber},
+	"height":     {"height", parseQueryNumber},
+	"mp":         {megapixelsColumn, parseQueryNumber},
+	"megapixels": {megapixelsColumn, parseQueryNumber},
+	"duration":   {"duration", parseDuration},
+	"rating":     {"rating", parseQueryNumber},
+}
+
+// Text fields match without regard to case; fields matched with LIKE, and free text, match part of the value
+var textQueryFields = map[string]string{
+	"camera":   "(camera_make LIKE ? ESCAPE '\\' OR camera_model LIKE ? ESCAPE '\\')",
+	"make":     "camera_make LIKE ? ESCAPE '\\'",
+	"model":    "camera_model LIKE ? ESCAPE '\\'",
+	"lens":     "lens_model LIKE ? ESCAPE '\\'",
+	"program":  "exposure_program LIKE ? ESCAPE '\\'",
+	"metering": "metering_mode LIKE ? ESCAPE '\\'",
+	"wb":       "white_balance LIKE ? ESCAPE '\\'",
+	"flash":    "flash LIKE ? ESCAPE '\\'",
+	"software": "software LIKE ? ESCAPE '\\'",
+	"serial":   "camera_serial = ?",
+	"country":  "country_name = ? COLLATE NOCASE",
+	"state":    "state = ? COLLATE NOCASE",
+	"city":     "city = ? COLLATE NOCASE",
+	"place":    "place_id IN (SELECT place_id FROM places WHERE name = ? COLLATE NOCASE)",
+	"format":   "file_format = ? COLLATE NOCASE",
+}
+
+// Checks the query parses and only uses known fields with valid values
+func validateFilterQuery(input string) error {
+	_, _, err := buildQueryCondition(input)
+	return err
+}
+
+func buildQueryCondition(input string) (string, []any, error) {
+	node, err := query.Parse(input)
+	if err != nil || node == nil {
+		return "", nil, err
+	}
+	return compileQueryNode(node)
+}
+
+func compileQueryNode(node query.Node) (string, []any, error) {
+	switch n := node.(type) {
+	case query.And:
+		return compileQueryNodes(n.Nodes, " AND ")
+	case query.Or:
+		return compileQueryNodes(n.Nodes, " OR ")
+	case query.Not:
+		condition, args, err := compileQueryNode(n.Node)
+		if err != nil {
+			return "", nil, err
+		}
+		// A photo without the value, such as one with no country, isn't in Japan either
+		return fmt.Sprintf("NOT COALESCE(%s, 0)", condition), args, nil
+	case query.Term:
+		return compileQueryTerm(n)
+	}
+	return "", nil, fmt.Errorf("%w: unsupported expression", query.ErrInvalidQuery)
+}
+
+func compileQueryNodes(nodes []query.Node, separator string) (string, []any, error) {
+	conditions := make([]string, len(nodes))
+	var args []any
+
+	for i, node := range nodes {
+		condition, nodeArgs, err := compileQueryNode(node)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions[i] = condition
+		args = append(args, nodeArgs...)
+	}
+
+	return "(" + strings.Join(conditions, separator) + ")", args, nil
+}
+
+func compileQueryTerm(term query.Term) (string, []any, error) {
+	if field, ok := numericQueryFields[term.Key]; ok {
+		return compileNumericTerm(term, field)
+	}
+
+	if term.Key == "date" || term.Key == "year" {
+		return compileDateTerm(term)
+	}
+
+	if term.Key != "" && term.Op != query.OpEqual {
+		return "", nil, fmt.Errorf("%w: %s can't be compared with %s", query.ErrInvalidQuery, term.Key, term.Op)
+	}
+
+	if condition, ok := textQueryFields[term.Key]; ok {
+		arg := term.Value
+		if strings.Contains(condition, "LIKE") {
+			arg = likeContains(term.Value)
+		}
+		args := []any{arg}
+		if term.Key == "camera" {
+			args = append(args, arg)
+		}
+		return "(" + condition + ")", args, nil
+	}
+
+	value := strings.ToLower(term.Value)
+
+	switch term.Key {
+	case "":
+		pattern := likeContains(term.Value)
+		condition := `(file_path LIKE ? ESCAPE '\' OR notes LIKE ? ESCAPE '\' OR city LIKE ? ESCAPE '\' OR state LIKE ? ESCAPE '\' OR country_name LIKE ? ESCAPE '\')`
+		return condition, []any{pattern, pattern, pattern, pattern, pattern}, nil
+	case "type":
+		switch value {
+		case "photo", "photos":
+			return "(is_video = 0)", nil, nil
+		case "video", "videos":
+			return "(is_video = 1)", nil, nil
+		}
+	case "orientation":
+		if condition, ok := orientationConditions[value]; ok {
+			return "(" + condition + ")", nil, nil
+		}
+	case "has":
+		switch value {
+		case "gps":
+			return "(" + hasGPSCondition + ")", nil, nil
+		case "notes":
+			return "(" + hasNotesCondition + ")", nil, nil
+		}
+	default:
+		return "", nil, fmt.Errorf("%w: unknown field %s", query.ErrInvalidQuery, term.Key)
+	}
+
+	return "", nil, fmt.Errorf("%w: unknown value %s for %s", query.ErrInvalidQuery, term.Value, term.Key)
+}
+
+func compileNumericTerm(term query.Term, field numericQueryField) (string, []any, error) {
+	value, ok := field.parse(term.Value)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s isn't a valid %s", query.ErrInvalidQuery, term.Value, term.Key)
+	}
+
+	if term.Op == query.OpRange {
+		to, ok := field.parse(term.To)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s isn't a valid %s", query.ErrInvalidQuery, term.To, term.Key)
+		}
+		return fmt.Sprintf("(%s BETWEEN ? AND ?)", field.column), []any{value, to}, nil
+	}
+
+	return fmt.Sprintf("(%s %s ?)", field.column, term.Op), []any{value}, nil
+}
+
+// Dates are the local capture days, and a year or month covers all of its days
+func compileDateTerm(term query.Term) (string, []any, error) {
+	first, last, err := periodBounds(term.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s isn't a date such as 2019, 2019-03 or 2019-03-15", query.ErrInvalidQuery, term.Value)
+	}
+
+	const day = "SUBSTR(local_date_time, 1, 10)"
+
+	switch term.Op {
+	case query.OpGreater:
+		return fmt.Sprintf("(%s > ?)", day), []any{last}, nil
+	case query.OpGreaterOrEqual:
+		return fmt.Sprintf("(%s >= ?)", day), []any{first}, nil
+	case query.OpLess:
+		return fmt.Sprintf("(%s < ?)", day), []any{first}, nil
+	case query.OpLessOrEqual:
+		return fmt.Sprintf("(%s <= ?)", day), []any{last}, nil
+	case query.OpRange:
+		_, rangeLast, err := periodBounds(term.To)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s isn't a date such as 2019, 2019-03 or 2019-03-15", query.ErrInvalidQuery, term.To)
+		}
+		last = rangeLast
+	}
+
+	return fmt.Sprintf("(%s BETWEEN ? AND ?)", day), []any{first, last}, nil
+}
+
+func likeContains(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
+
+func parseQueryNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	return number, err == nil
+}
+
+// f/2.8, f2.8 or 2.8
+func parseAperture(value string) (float64, bool) {
+	value = strings.TrimPrefix(strings.ToLower(value), "f")
+	return parseQueryNumber(strings.TrimPrefix(value, "/"))
+}
+
+// 1/250 or 2s, in seconds
+func parseShutterSpeed(value string) (float64, bool) {
+	seconds := normalization.NormalizeExposureTime(strings.TrimSuffix(strings.ToLower(value), "s"))
+	if seconds == nil {
+		return 0, false
+	}
+	return *seconds, true
+}
+
+func parseFocalLength(value string) (float64, bool) {
+	return parseQueryNumber(strings.TrimSuffix(strings.ToLower(value), "mm"))
+}
+
+// -2/3, +1 or 0.7, in stops
+func parseExposureCompensation(value string) (float64, bool) {
+	ev := normalization.NormalizeExposureCompensation(strings.ToUpper(value))
+	if ev == nil {
+		return 0, false
+	}
+	return *ev, true
+}
+
+// 1200 or 1200m, in meters
+func parseAltitude(value string) (float64, bool) {
+	return parseQueryNumber(strings.TrimSuffix(strings.ToLower(value), "m"))
+}
+
+// 20mb, 1.5gb, 500kb or bytes, with 1024 to a kilobyte like the file sizes shown in the app
+func parseFileSize(value string) (float64, bool) {
+	value = strings.TrimSuffix(strings.ToLower(value), "b")
+	multiplier := 1.0
+	for suffix, m := range map[string]float64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30} {
+		if strings.HasSuffix(value, suffix) {
+			value = strings.TrimSuffix(value, suffix)
+			multiplier = m
+			break
+		}
+	}
+	number, ok := parseQueryNumber(value)
+	return number * multiplier, ok
+}
+
+// 90, 90s, 1:30 or 2m, in seconds
+func parseDuration(value string) (float64, bool) {
+	value = strings.ToLower(value)
+	if minutes, ok := strings.CutSuffix(value, "m"); ok {
+		number, ok := parseQueryNumber(minutes)
+		return number * 60, ok
+	}
+	seconds := normalization.NormalizeDuration(value)
+	if seconds == nil {
+		return 0, false
+	}
+	return float64(*seconds), true
+}
@@ -3,20 +3,10 @@ package photos
 import (
 	"fmt"
 	"log/slog"
-	"riffle/commons/sqlite"
 	"strings"
+	"time"
 )
 
-type FilterOptions struct {
-	CameraMakes  []string `json:"cameraMakes"`
-	CameraModels []string `json:"cameraModels"`
-	Countries    []string `json:"countries"`
-	States       []string `json:"states"`
-	Cities       []string `json:"cities"`
-	FileFormats  []string `json:"fileFormats"`
-	Years        []int    `json:"years"`
-}
-
 type PhotoFilters struct {
 	Ratings      []int    `json:"ratings"`
 	MediaType    string   `json:"mediaType"`
@@ -24,231 +14,232 @@ type PhotoFilters struct {
 	Years        []int    `json:"years"`
 	CameraMakes  []string `json:"cameraMakes"`
 	CameraModels []string `json:"cameraModels"`
+	Places       []string `json:"places"`
 	Countries    []string `json:"countries"`
 	States       []string `json:"states"`
 	Cities       []string `json:"cities"`
 	FileFormats  []string `json:"fileFormats"`
+	LensModels   []string `json:"lensModels,omitempty"`
+
+	// Photos with any of these values are left out; photos without a value stay in
+	ExcludeYears        []int    `json:"excludeYears,omitempty"`
+	ExcludeCameraMakes  []string `json:"excludeCameraMakes,omitempty"`
+	ExcludeCameraModels []string `json:"excludeCameraModels,omitempty"`
+	ExcludePlaces       []string `json:"excludePlaces,omitempty"`
+	ExcludeCountries    []string `json:"excludeCountries,omitempty"`
+	ExcludeStates       []string `json:"excludeStates,omitempty"`
+	ExcludeCities       []string `json:"excludeCities,omitempty"`
+	ExcludeFileFormats  []string `json:"excludeFileFormats,omitempty"`
+	ExcludeLensModels   []string `json:"excludeLensModels,omitempty"`
+
+	ISO                  *NumberRange `json:"iso,omitempty"`
+	FNumber              *NumberRange `json:"fNumber,omitempty"`
+	ExposureTime         *NumberRange `json:"exposureTime,omitempty"`         // Seconds
+	FocalLength          *NumberRange `json:"focalLength,omitempty"`          // Millimeters
+	FocalLength35mm      *NumberRange `json:"focalLength35mm,omitempty"`      // Millimeters, full frame equivalent
+	ExposureCompensation *NumberRange `json:"exposureCompensation,omitempty"` // EV
+	FileSize             *NumberRange `json:"fileSize,omitempty"`             // Bytes
+	Width                *NumberRange `json:"width,omitempty"`
+	Height               *NumberRange `json:"height,omitempty"`
+	Megapixels           *NumberRange `json:"megapixels,omitempty"`
+	Duration             *NumberRange `json:"duration,omitempty"` // Seconds
+
+	DateFrom string `json:"dateFrom,omitempty"` // YYYY-MM-DD local capture day, inclusive
+	DateTo   string `json:"dateTo,omitempty"`
+
+	HasGPS   *bool `json:"hasGps,omitempty"`
+	HasNotes *bool `json:"hasNotes,omitempty"`
+
+	Query string `json:"query,omitempty"` // Search syntax such as iso:>3200 -country:Japan, see commons/query
 }
 
-func BuildFilterConditions(filters *PhotoFilters) (string, []any) {
-	if filters == nil {
-		return "", nil
-	}
+// Either end can be left open; both ends are included
+type NumberRange struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
 
-	var conditions []string
-	var args []any
+var orientationConditions = map[string]string{
+	"landscape": "CAST(width AS INTEGER) > CAST(height AS INTEGER)",
+	"portrait":  "CAST(width AS INTEGER) < CAST(height AS INTEGER)",
+	"square":    "CAST(width AS INTEGER) = CAST(height AS INTEGER)",
+}
 
-	if len(filters.Ratings) > 0 {
-		placeholders := make([]string, len(filters.Ratings))
-		for i, r := range filters.Ratings {
-			placeholders[i] = "?"
-			args = append(args, r)
-		}
-		conditions = append(conditions, fmt.Sprintf("rating IN (%s)", strings.Join(placeholders, ",")))
-	}
+const (
+	hasGPSCondition   = "latitude IS NOT NULL AND longitude IS NOT NULL"
+	hasNotesCondition = "notes IS NOT NULL AND TRIM(notes) != ''"
+)
 
-	switch filters.MediaType {
-	case "photos":
-		conditions = append(conditions, "is_video = 0")
-	case "videos":
-		conditions = append(conditions, "is_video = 1")
+func (filters *PhotoFilters) Validate() error {
+	for _, date := range []string{filters.DateFrom, filters.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("dates must be in YYYY-MM-DD format")
+		}
 	}
 
-	switch filters.Orientation {
-	case "landscape":
-		conditions = append(conditions, "CAST(width AS INTEGER) > CAST(height AS INTEGER)")
-	case "portrait":
-		conditions = append(conditions, "CAST(width AS INTEGER) < CAST(height AS INTEGER)")
-	case "square":
-		conditions = append(conditions, "CAST(width AS INTEGER) = CAST(height AS INTEGER)")
+	if filters.DateFrom != "" && filters.DateTo != "" && filters.DateFrom > filters.DateTo {
+		return fmt.Errorf("start date must be before end date")
 	}
 
-	if len(filters.Years) > 0 {
-		placeholders := make([]string, len(filters.Years))
-		for i, y := range filters.Years {
-			placeholders[i] = "?"
-			args = append(args, y)
+	for _, r := range filters.ranges() {
+		if r.Range != nil && r.Range.Min != nil && r.Range.Max != nil && *r.Range.Min > *r.Range.Max {
+			return fmt.Errorf("minimum must not be above maximum")
 		}
-		conditions = append(conditions, fmt.Sprintf("CAST(strftime('%%Y', date_time) AS INTEGER) IN (%s)", strings.Join(placeholders, ",")))
 	}
 
-	if len(filters.CameraMakes) > 0 {
-		placeholders := make([]string, len(filters.CameraMakes))
-		for i, m := range filters.CameraMakes {
-			placeholders[i] = "?"
-			args = append(args, m)
-		}
-		conditions = append(conditions, fmt.Sprintf("camera_make IN (%s)", strings.Join(placeholders, ",")))
-	}
+	return validateFilterQuery(filters.Query)
+}
 
-	if len(filters.CameraModels) > 0 {
-		placeholders := make([]string, len(filters.CameraModels))
-		for i, m := range filters.CameraModels {
-			placeholders[i] = "?"
-			args = append(args, m)
-		}
-		conditions = append(conditions, fmt.Sprintf("camera_model IN (%s)", strings.Join(placeholders, ",")))
-	}
+type columnRange struct {
+	Column string
+	Range  *NumberRange
+}
 
-	if len(filters.Countries) > 0 {
-		placeholders := make([]string, len(filters.Countries))
-		for i, c := range filters.Countries {
-			placeholders[i] = "?"
-			args = append(args, c)
-		}
-		conditions = append(conditions, fmt.Sprintf("country_name IN (%s)", strings.Join(placeholders, ",")))
+func (filters *PhotoFilters) ranges() []columnRange {
+	return []columnRange{
+		{"iso", filters.ISO},
+		{"f_number", filters.FNumber},
+		{"exposure_time", filters.ExposureTime},
+		{"focal_length", filters.FocalLength},
+		{"focal_length_35mm", filters.FocalLength35mm},
+		{"exposure_compensation", filters.ExposureCompensation},
+		{"file_size", filters.FileSize},
+		{"width", filters.Width},
+		{"height", filters.Height},
+		{megapixelsColumn, filters.Megapixels},
+		{"duration", filters.Duration},
 	}
+}
 
-	if len(filters.States) > 0 {
-		placeholders := make([]string, len(filters.States))
-		for i, s := range filters.States {
-			placeholders[i] = "?"
-			args = append(args, s)
-		}
-		conditions = append(conditions, fmt.Sprintf("state IN (%s)", strings.Join(placeholders, ",")))
+func BuildFilterConditions(filters *PhotoFilters) (string, []any) {
+	if filters == nil {
+		return "", nil
 	}
 
-	if len(filters.Cities) > 0 {
-		placeholders := make([]string, len(filters.Cities))
-		for i, c := range filters.Cities {
-			placeholders[i] = "?"
-			args = append(args, c)
-		}
-		conditions = append(conditions, fmt.Sprintf("city IN (%s)", strings.Join(placeholders, ",")))
-	}
+	var conditions []string
+	var args []any
 
-	if len(filters.FileFormats) > 0 {
-		placeholders := make([]string, len(filters.FileFormats))
-		for i, f := range filters.FileFormats {
-			placeholders[i] = "?"
-			args = append(args, f)
+	addIn := func(expression string, values []any) {
+		if len(values) == 0 {
+			return
 		}
-		conditions = append(conditions, fmt.Sprintf("file_format IN (%s)", strings.Join(placeholders, ",")))
+		conditions = append(conditions, fmt.Sprintf(expression, placeholders(len(values))))
+		args = append(args, values...)
 	}
 
-	if len(conditions) == 0 {
-		return "", nil
+	addNotIn := func(expression string, values []any) {
+		if len(values) == 0 {
+			return
+		}
+		conditions = append(conditions, fmt.Sprintf("COALESCE(NOT "+expression+", 1)", placeholders(len(values))))
+		args = append(args, values...)
 	}
 
-	return " AND " + strings.Join(conditions, " AND "), args
-}
+	addIn("rating IN (%s)", toArgs(filters.Ratings))
 
-func GetFilterOptions() (*FilterOptions, error) {
-	options := &FilterOptions{
-		CameraMakes:  []string{},
-		CameraModels: []string{},
-		Countries:    []string{},
-		States:       []string{},
-		Cities:       []string{},
-		FileFormats:  []string{},
-		Years:        []int{},
+	switch filters.MediaType {
+	case "photos":
+		conditions = append(conditions, "is_video = 0")
+	case "videos":
+		conditions = append(conditions, "is_video = 1")
 	}
 
-	cameraMakes, err := getDistinctStrings("camera_make")
-	if err != nil {
-		return nil, err
+	if condition, ok := orientationConditions[filters.Orientation]; ok {
+		conditions = append(conditions, condition)
 	}
-	options.CameraMakes = cameraMakes
 
-	cameraModels, err := getDistinctStrings("camera_model")
-	if err != nil {
-		return nil, err
-	}
-	options.CameraModels = cameraModels
+	const yearExpression = "CAST(SUBSTR(local_date_time, 1, 4) AS INTEGER) IN (%s)"
+	const placeExpression = "place_id IN (SELECT place_id FROM places WHERE name IN (%s))"
 
-	countries, err := getDistinctStrings("country_name")
-	if err != nil {
-		return nil, err
-	}
-	options.Countries = countries
+	addIn(yearExpression, toArgs(filters.Years))
+	addIn("camera_make IN (%s)", toArgs(filters.CameraMakes))
+	addIn("camera_model IN (%s)", toArgs(filters.CameraModels))
+	addIn(placeExpression, toArgs(filters.Places))
+	addIn("country_name IN (%s)", toArgs(filters.Countries))
+	addIn("state IN (%s)", toArgs(filters.States))
+	addIn("city IN (%s)", toArgs(filters.Cities))
+	addIn("file_format IN (%s)", toArgs(filters.FileFormats))
+	addIn("lens_model IN (%s)", toArgs(filters.LensModels))
+
+	addNotIn(yearExpression, toArgs(filters.ExcludeYears))
+	addNotIn("camera_make IN (%s)", toArgs(filters.ExcludeCameraMakes))
+	addNotIn("camera_model IN (%s)", toArgs(filters.ExcludeCameraModels))
+	addNotIn(placeExpression, toArgs(filters.ExcludePlaces))
+	addNotIn("country_name IN (%s)", toArgs(filters.ExcludeCountries))
+	addNotIn("state IN (%s)", toArgs(filters.ExcludeStates))
+	addNotIn("city IN (%s)", toArgs(filters.ExcludeCities))
+	addNotIn("file_format IN (%s)", toArgs(filters.ExcludeFileFormats))
+	addNotIn("lens_model IN (%s)", toArgs(filters.ExcludeLensModels))
 
-	states, err := getDistinctStrings("state")
-	if err != nil {
-		return nil, err
+	for _, r := range filters.ranges() {
+		if r.Range == nil {
+			continue
+		}
+		if r.Range.Min != nil {
+			conditions = append(conditions, r.Column+" >= ?")
+			args = append(args, *r.Range.Min)
+		}
+		if r.Range.Max != nil {
+			conditions = append(conditions, r.Column+" <= ?")
+			args = append(args, *r.Range.Max)
+		}
 	}
-	options.States = states
 
-	cities, err := getDistinctStrings("city")
-	if err != nil {
-		return nil, err
+	if filters.DateFrom != "" {
+		conditions = append(conditions, "SUBSTR(local_date_time, 1, 10) >= ?")
+		args = append(args, filters.DateFrom)
 	}
-	options.Cities = cities
 
-	fileFormats, err := getDistinctStrings("file_format")
-	if err != nil {
-		return nil, err
+	if filters.DateTo != "" {
+		conditions = append(conditions, "SUBSTR(local_date_time, 1, 10) <= ?")
+		args = append(args, filters.DateTo)
 	}
-	options.FileFormats = fileFormats
 
-	years, err := getDistinctYears()
-	if err != nil {
-		return nil, err
+	if filters.HasGPS != nil {
+		conditions = append(conditions, hasCondition(hasGPSCondition, *filters.HasGPS))
 	}
-	options.Years = years
 
-	return options, nil
-}
+	if filters.HasNotes != nil {
+		conditions = append(conditions, hasCondition(hasNotesCondition, *filters.HasNotes))
+	}
 
-func getDistinctStrings(column string) ([]string, error) {
-	query := fmt.Sprintf(`
-		SELECT DISTINCT %s
-		FROM photos
-		WHERE %s IS NOT NULL AND %s != ''
-		ORDER BY %s ASC
-	`, column, column, column, column)
-
-	rows, err := sqlite.DB.Query(query)
-	if err != nil {
-		err = fmt.Errorf("error querying distinct %s: %w", column, err)
-		slog.Error(err.Error())
-		return nil, err
-	}
-	defer rows.Close()
-
-	var values []string
-	for rows.Next() {
-		var value string
-		if err := rows.Scan(&value); err != nil {
-			continue
+	if filters.Query != "" {
+		querySQL, queryArgs, err := buildQueryCondition(filters.Query)
+		if err != nil {
+			// Filters are validated as they come in, so this is a saved query that no longer parses
+			slog.Warn("ignoring photos for invalid filter query", "query", filters.Query, "error", err)
+			conditions = append(conditions, "0")
+		} else if querySQL != "" {
+			conditions = append(conditions, querySQL)
+			args = append(args, queryArgs...)
 		}
-		values = append(values, value)
 	}
 
-	if values == nil {
-		values = []string{}
+	if len(conditions) == 0 {
+		return "", nil
 	}
 
-	return values, nil
+	return " AND " + strings.Join(conditions, " AND "), args
 }
 
-func getDistinctYears() ([]int, error) {
-	query := `
-		SELECT DISTINCT CAST(strftime('%Y', date_time) AS INTEGER) as year
-		FROM photos
-		WHERE date_time IS NOT NULL
-		ORDER BY year DESC
-	`
-
-	rows, err := sqlite.DB.Query(query)
-	if err != nil {
-		err = fmt.Errorf("error querying distinct years: %w", err)
-		slog.Error(err.Error())
-		return nil, err
-	}
-	defer rows.Close()
-
-	var years []int
-	for rows.Next() {
-		var year int
-		if err := rows.Scan(&year); err != nil {
-			continue
-		}
-		years = append(years, year)
+func hasCondition(condition string, has bool) string {
+	if has {
+		return "(" + condition + ")"
 	}
+	return "NOT (" + condition + ")"
+}
 
-	if years == nil {
-		years = []int{}
-	}
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", count), ",")
+}
 
-	return years, nil
+func toArgs[T any](values []T) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
 }
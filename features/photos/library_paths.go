@@ -0,0 +1,103 @@
+package photos
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"riffle/commons/sqlite"
+	"riffle/commons/utils"
+	"strconv"
+	"strings"
+)
+
+// Looks up the photo referenced by the {id} path value and resolves it to an absolute path inside the library.
+// Writes the error response and returns false if the photo can't be served.
+func resolvePhotoPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	photoID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || photoID <= 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid photo ID")
+		return "", false
+	}
+
+	relativePath, err := GetPhotoFilePath(photoID)
+	if err != nil {
+		if errors.Is(err, ErrPhotoNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Photo not found")
+			return "", false
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photo")
+		return "", false
+	}
+
+	filePath, err := utils.ResolveLibraryPath(os.Getenv("LIBRARY_PATH"), relativePath)
+	if err != nil {
+		slog.Error("photo path escapes library", "photoId", photoID, "path", relativePath)
+		utils.SendErrorResponse(w, http.StatusForbidden, "ACCESS_DENIED", "Cannot access file")
+		return "", false
+	}
+
+	return filePath, true
+}
+
+// Databases created before photo IDs stored absolute paths. Rewrite them relative to the library
+// (and thumbnails) root so the library can be relocated by changing LIBRARY_PATH alone.
+// Does nothing once every path is relative.
+func ConvertToRelativePaths(libraryPath, thumbnailsPath string) error {
+	var absoluteCount int
+	err := sqlite.DB.QueryRow(`SELECT COUNT(*) FROM photos WHERE file_path LIKE '/%'`).Scan(&absoluteCount)
+	if err != nil {
+		err = fmt.Errorf("error counting absolute photo paths: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	if absoluteCount == 0 {
+		return nil
+	}
+
+	slog.Info("converting photo paths to library-relative paths", "count", absoluteCount)
+
+	libraryPrefix := strings.TrimSuffix(libraryPath, "/") + "/"
+	thumbnailsPrefix := strings.TrimSuffix(thumbnailsPath, "/") + "/"
+
+	tx, err := sqlite.DB.Begin()
+	if err != nil {
+		err = fmt.Errorf("error starting path conversion transaction: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []struct {
+		query  string
+		prefix string
+	}{
+		{`UPDATE photos SET file_path = substr(file_path, length(?1) + 1) WHERE substr(file_path, 1, length(?1)) = ?1`, libraryPrefix},
+		{`UPDATE photos SET thumbnail_path = substr(thumbnail_path, length(?1) + 1) WHERE substr(thumbnail_path, 1, length(?1)) = ?1`, thumbnailsPrefix},
+		{`UPDATE exported_photos SET file_path = substr(file_path, length(?1) + 1) WHERE substr(file_path, 1, length(?1)) = ?1`, libraryPrefix},
+		{`UPDATE imported_photos SET file_path = substr(file_path, length(?1) + 1) WHERE substr(file_path, 1, length(?1)) = ?1`, libraryPrefix},
+	}
+
+	for _, q := range queries {
+		if _, err := tx.Exec(q.query, q.prefix); err != nil {
+			err = fmt.Errorf("error converting paths to relative: %w", err)
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("error committing path conversion: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	var remaining int
+	if err := sqlite.DB.QueryRow(`SELECT COUNT(*) FROM photos WHERE file_path LIKE '/%'`).Scan(&remaining); err == nil && remaining > 0 {
+		slog.Warn("some photo paths are outside LIBRARY_PATH and were left absolute; they won't be served", "count", remaining)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package photos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+const (
+	SortNewest   = "newest" // Capture time, newest first
+	SortOldest   = "oldest"
+	SortImported = "imported" // Import time, latest import first
+	SortRating   = "rating"
+	SortSize     = "size" // Largest first
+	SortFilename = "filename"
+	SortCamera   = "camera"
+)
+
+type groupKind int
+
+const (
+	groupNone groupKind = iota
+	groupByDay
+	groupByImport
+	groupByRating
+	groupByCamera
+)
+
+// A listing order. All key columns sort the same way, so a cursor is a row value the next page continues
+// past, and file_path at the end makes every key unique. Photos with the same leading groupSize key
+// values are grouped on the page.
+type listingSort struct {
+	name        string
+	columns     []string
+	isAscending bool
+	groupBy     groupKind
+	groupSize   int
+}
+
+// Listings are newest first by the day photos were taken on in their own timezone, and in the order they
+// were taken within a day. Undated photos have an empty day and sort last.
+const (
+	pageDayColumn    = `COALESCE(SUBSTR(local_date_time, 1, 10), '')`
+	oldestDayColumn  = `COALESCE(SUBSTR(local_date_time, 1, 10), '~')`
+	importedAtColumn = `COALESCE(imported_at, created_at)`
+	importIDColumn   = `COALESCE(import_id, 0)`
+	ratingColumn     = `COALESCE(rating, 0)`
+	fileNameColumn   = `LOWER(REPLACE(file_path, RTRIM(file_path, REPLACE(file_path, '/', '')), ''))`
+	unknownSortsLast = "~" // Stands in for missing text in ascending sorts, after letters and digits
+)
+
+var listingSorts = map[string]listingSort{
+	SortNewest: {
+		columns: []string{pageDayColumn, `COALESCE(date_time, '')`, `created_at`, `file_path`},
+		groupBy: groupByDay, groupSize: 1,
+	},
+	SortOldest: {
+		columns:     []string{oldestDayColumn, `COALESCE(date_time, '')`, `created_at`, `file_path`},
+		isAscending: true,
+		groupBy:     groupByDay, groupSize: 1,
+	},
+	SortImported: {
+		columns: []string{importIDColumn, importedAtColumn, `file_path`},
+		groupBy: groupByImport, groupSize: 1,
+	},
+	SortRating: {
+		columns: []string{ratingColumn, pageDayColumn, `COALESCE(date_time, '')`, `created_at`, `file_path`},
+		groupBy: groupByRating, groupSize: 1,
+	},
+	SortSize: {
+		columns: []string{`COALESCE(file_size, 0)`, `file_path`},
+	},
+	SortFilename: {
+		columns:     []string{fileNameColumn, `file_path`},
+		isAscending: true,
+	},
+	SortCamera: {
+		columns:     []string{`COALESCE(camera_make, '~')`, `COALESCE(camera_model, '~')`, `COALESCE(date_time, '~')`, `file_path`},
+		isAscending: true,
+		groupBy:     groupByCamera, groupSize: 2,
+	},
+}
+
+// Timestamps are selected as text so cursors hold them the way they're stored
+var timestampColumns = map[string]bool{
+	`created_at`:     true,
+	importedAtColumn: true,
+}
+
+func init() {
+	for name, sort := range listingSorts {
+		sort.name = name
+		listingSorts[name] = sort
+	}
+}
+
+func isValidSort(sort string) bool {
+	_, ok := listingSorts[sort]
+	return sort == "" || ok
+}
+
+// Falls back to newest first, which is also what an empty sort means
+func getListingSort(sort string) listingSort {
+	if listing, ok := listingSorts[sort]; ok {
+		return listing
+	}
+	return listingSorts[SortNewest]
+}
+
+func (s listingSort) orderBy(isReversed bool) string {
+	direction := "DESC"
+	if s.isAscending != isReversed {
+		direction = "ASC"
+	}
+
+	terms := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		terms[i] = column + " " + direction
+	}
+	return strings.Join(terms, ", ")
+}
+
+func (s listingSort) keyColumns() string {
+	return strings.Join(s.columns, ", ")
+}
+
+func (s listingSort) selectKeyColumns() string {
+	terms := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		terms[i] = column
+		if timestampColumns[column] {
+			terms[i] = fmt.Sprintf("CAST(%s AS TEXT)", column)
+		}
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Condition for the photos after a key in the listing order, or before it when isBackward. The leading
+// column is also bounded on its own, which lets SQLite seek an index where a row value comparison alone
+// scans it. Takes the leading key value followed by the whole key.
+func (s listingSort) seekCondition(isBackward bool) string {
+	lead, comparison := "<=", "<"
+	if s.isAscending != isBackward {
+		lead, comparison = ">=", ">"
+	}
+	return fmt.Sprintf(" AND %s %s ? AND (%s) %s (%s)", s.columns[0], lead, s.keyColumns(), comparison, placeholders(len(s.columns)))
+}
+
+func (s listingSort) isSameGroup(a, b pageKey) bool {
+	for i := 0; i < s.groupSize; i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels a group by its key. Day groups keep their date in Date; the others get a Title.
+func (s listingSort) newGroup(key pageKey) Group {
+	switch s.groupBy {
+	case groupByDay:
+		date, _ := key[0].(string)
+		if date == "" || date == unknownSortsLast {
+			date = "Unknown"
+		}
+		return Group{Date: date}
+	case groupByImport:
+		importID := keyInt(key[0])
+		if importID == 0 {
+			return Group{Title: "Earlier imports"}
+		}
+		return Group{Title: fmt.Sprintf("Import #%d", importID), ImportID: importID}
+	case groupByRating:
+		switch rating := keyInt(key[0]); rating {
+		case 0:
+			return Group{Title: "Unrated"}
+		case 1:
+			return Group{Title: "1 star"}
+		default:
+			return Group{Title: fmt.Sprintf("%d stars", rating)}
+		}
+	case groupByCamera:
+		return Group{Title: cameraTitle(key[0], key[1])}
+	}
+	return Group{}
+}
+
+// Models often repeat the make, as in Canon / Canon EOS R5
+func cameraTitle(makeValue, modelValue any) string {
+	cameraMake, _ := makeValue.(string)
+	cameraModel, _ := modelValue.(string)
+	if cameraMake == unknownSortsLast {
+		cameraMake = ""
+	}
+	if cameraModel == unknownSortsLast {
+		cameraModel = ""
+	}
+
+	switch {
+	case cameraMake == "" && cameraModel == "":
+		return "Unknown camera"
+	case cameraModel == "":
+		return cameraMake
+	case cameraMake == "" || strings.HasPrefix(strings.ToLower(cameraModel), strings.ToLower(cameraMake)):
+		return cameraModel
+	}
+	return cameraMake + " " + cameraModel
+}
+
+func keyInt(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
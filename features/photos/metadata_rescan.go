@@ -0,0 +1,67 @@
+package photos
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"riffle/commons/utils"
+)
+
+// Both are optional; without either every photo is rescanned
+type MetadataRescanRequest struct {
+	PhotoIDs []int64       `json:"photoIds"`
+	Filters  *PhotoFilters `json:"filters"`
+}
+
+type MetadataRescanResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+func HandleRescanMetadata(w http.ResponseWriter, r *http.Request) {
+	currentProgress := GetMetadataRescanProgress()
+	if currentProgress.Status == StatusMetadataRescanProcessing {
+		utils.SendErrorResponse(w, http.StatusConflict, "RESCAN_IN_PROGRESS", "Metadata rescan already in progress")
+		return
+	}
+
+	var req MetadataRescanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.Filters != nil {
+		if err := req.Filters.Validate(); err != nil {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+			return
+		}
+	}
+
+	libraryPath := os.Getenv("LIBRARY_PATH")
+
+	go func() {
+		if err := RescanPhotoMetadata(libraryPath, req.Filters, req.PhotoIDs); err != nil {
+			slog.Error("failed to rescan metadata", "error", err)
+			return
+		}
+		slog.Info("metadata rescan completed")
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MetadataRescanResponse{
+		Success: true,
+		Message: "metadata rescan started",
+	})
+}
+
+func HandleGetMetadataRescanProgress(w http.ResponseWriter, r *http.Request) {
+	progress := GetMetadataRescanProgress()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(progress)
+}
@@ -0,0 +1,112 @@
+package photos
+
+import (
+	"sync"
+)
+
+type MetadataRescanStatus string
+
+const (
+	StatusMetadataRescanIdle       MetadataRescanStatus = "idle"
+	StatusMetadataRescanProcessing MetadataRescanStatus = "processing"
+	StatusMetadataRescanComplete   MetadataRescanStatus = "complete"
+)
+
+// Only the first changes are listed so polling stays cheap on large libraries; the field counts cover every photo
+const maxReportedMetadataChanges = 500
+
+type MetadataRescanProgress struct {
+	Status      MetadataRescanStatus  `json:"status"`
+	Completed   int                   `json:"completed"`
+	Total       int                   `json:"total"`
+	Percent     int                   `json:"percent"`
+	Updated     int                   `json:"updated"`
+	Unchanged   int                   `json:"unchanged"`
+	Failed      int                   `json:"failed"` // Files that couldn't be read, left as they were
+	FieldCounts map[string]int        `json:"fieldCounts"`
+	Changes     []PhotoMetadataChange `json:"changes"`
+}
+
+type PhotoMetadataChange struct {
+	PhotoID  int64         `json:"photoId"`
+	FilePath string        `json:"filePath"`
+	Fields   []FieldChange `json:"fields"`
+}
+
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue any    `json:"oldValue"`
+	NewValue any    `json:"newValue"`
+}
+
+var (
+	metadataRescanProgressMutex   sync.RWMutex
+	currentMetadataRescanProgress = MetadataRescanProgress{
+		Status:      StatusMetadataRescanIdle,
+		FieldCounts: map[string]int{},
+		Changes:     []PhotoMetadataChange{},
+	}
+)
+
+func StartMetadataRescanProgress(total int) {
+	metadataRescanProgressMutex.Lock()
+	defer metadataRescanProgressMutex.Unlock()
+
+	currentMetadataRescanProgress = MetadataRescanProgress{
+		Status:      StatusMetadataRescanProcessing,
+		Total:       total,
+		FieldCounts: map[string]int{},
+		Changes:     []PhotoMetadataChange{},
+	}
+}
+
+// Counts a photo as done. A nil change means the photo was unchanged.
+func RecordMetadataRescanResult(change *PhotoMetadataChange, err error) {
+	metadataRescanProgressMutex.Lock()
+	defer metadataRescanProgressMutex.Unlock()
+
+	progress := &currentMetadataRescanProgress
+	progress.Completed++
+
+	switch {
+	case err != nil:
+		progress.Failed++
+	case change == nil:
+		progress.Unchanged++
+	default:
+		progress.Updated++
+		for _, field := range change.Fields {
+			progress.FieldCounts[field.Field]++
+		}
+		if len(progress.Changes) < maxReportedMetadataChanges {
+			progress.Changes = append(progress.Changes, *change)
+		}
+	}
+
+	if progress.Total > 0 {
+		progress.Percent = int(float64(progress.Completed) / float64(progress.Total) * 100)
+	}
+}
+
+func UpdateMetadataRescanStatus(status MetadataRescanStatus) {
+	metadataRescanProgressMutex.Lock()
+	defer metadataRescanProgressMutex.Unlock()
+
+	currentMetadataRescanProgress.Status = status
+}
+
+// Returns a copy that's safe to encode while the rescan goes on
+func GetMetadataRescanProgress() MetadataRescanProgress {
+	metadataRescanProgressMutex.RLock()
+	defer metadataRescanProgressMutex.RUnlock()
+
+	progress := currentMetadataRescanProgress
+
+	progress.FieldCounts = make(map[string]int, len(currentMetadataRescanProgress.FieldCounts))
+	for field, count := range currentMetadataRescanProgress.FieldCounts {
+		progress.FieldCounts[field] = count
+	}
+	progress.Changes = append([]PhotoMetadataChange{}, currentMetadataRescanProgress.Changes...)
+
+	return progress
+}
@@ -0,0 +1,354 @@
+package photos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/cache"
+	"riffle/commons/exif"
+	"riffle/commons/sqlite"
+	"riffle/commons/utils"
+	"riffle/features/geocoding"
+	"strings"
+)
+
+var errNoMetadata = errors.New("no metadata could be read from the file")
+
+// A photos column filled from the processed EXIF data. Dimensions and durations of videos come from the video
+// stream when the file's metadata has none, so those keep their stored value when missing.
+type rescanField struct {
+	Name            string // As in the photo JSON
+	Column          string
+	Key             string
+	KeepWhenMissing bool
+}
+
+var rescanFields = []rescanField{
+	{"cameraMake", "camera_make", "Make", false},
+	{"cameraModel", "camera_model", "Model", false},
+	{"width", "width", "Width", true},
+	{"height", "height", "Height", true},
+	{"orientation", "orientation", "Orientation", false},
+	{"iso", "iso", "ISO", false},
+	{"fNumber", "f_number", "FNumber", false},
+	{"exposureTime", "exposure_time", "ExposureTime", false},
+	{"focalLength", "focal_length", "FocalLength", false},
+	{"duration", "duration", "Duration", true},
+	{"lensMake", "lens_make", "LensMake", false},
+	{"lensModel", "lens_model", "LensModel", false},
+	{"focalLength35mm", "focal_length_35mm", "FocalLength35mm", false},
+	{"exposureProgram", "exposure_program", "ExposureProgram", false},
+	{"exposureMode", "exposure_mode", "ExposureMode", false},
+	{"exposureCompensation", "exposure_compensation", "ExposureCompensation", false},
+	{"meteringMode", "metering_mode", "MeteringMode", false},
+	{"whiteBalance", "white_balance", "WhiteBalance", false},
+	{"flash", "flash", "Flash", false},
+	{"software", "software", "Software", false},
+	{"colorSpace", "color_space", "ColorSpace", false},
+	{"colorProfile", "color_profile", "ColorProfile", false},
+	{"cameraSerial", "camera_serial", "CameraSerial", false},
+}
+
+// Left alone for photos geotagged by hand
+var rescanLocationFields = []rescanField{
+	{"latitude", "latitude", "Latitude", false},
+	{"longitude", "longitude", "Longitude", false},
+	{"altitude", "altitude", "Altitude", false},
+	{"gpsDirection", "gps_direction", "GPSDirection", false},
+}
+
+type rescanPhoto struct {
+	PhotoID  int64
+	FilePath string
+}
+
+type storedMetadata struct {
+	Values           map[string]any // By column
+	LocalDateTime    sql.NullString
+	UTCOffset        sql.NullInt64
+	IsTimeManual     bool
+	IsLocationManual bool
+}
+
+// Reads the metadata of the given photos, or of those matching the filters, again with the current extract,
+// normalize and validate steps, and updates the columns that come out different. Capture times and coordinates
+// set by hand are kept.
+func RescanPhotoMetadata(libraryPath string, filters *PhotoFilters, photoIDs []int64) error {
+	slog.Info("starting metadata rescan")
+
+	StartMetadataRescanProgress(0)
+
+	photos, err := getRescanPhotos(filters, photoIDs)
+	if err != nil {
+		UpdateMetadataRescanStatus(StatusMetadataRescanIdle)
+		return err
+	}
+
+	slog.Info("rescanning photo metadata", "totalPhotos", len(photos))
+	StartMetadataRescanProgress(len(photos))
+
+	updated := 0
+	for _, photo := range photos {
+		change, err := rescanPhotoMetadata(libraryPath, photo)
+		if err != nil {
+			slog.Error("failed to rescan photo metadata", "photo", photo.FilePath, "error", err)
+		} else if change != nil {
+			updated++
+		}
+		RecordMetadataRescanResult(change, err)
+	}
+
+	if updated > 0 {
+		cache.InvalidateOnMetadataChange()
+	}
+
+	UpdateMetadataRescanStatus(StatusMetadataRescanComplete)
+	progress := GetMetadataRescanProgress()
+	slog.Info("metadata rescan complete", "total", progress.Total, "updated", progress.Updated, "failed", progress.Failed)
+
+	return nil
+}
+
+// Returns the changes written, or nil when the photo was up to date
+func rescanPhotoMetadata(libraryPath string, photo rescanPhoto) (*PhotoMetadataChange, error) {
+	filePath, err := utils.ResolveLibraryPath(libraryPath, photo.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	exifData, err := exif.ProcessExifData(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(exifData) == 0 {
+		return nil, errNoMetadata
+	}
+
+	stored, err := getStoredMetadata(photo.PhotoID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &PhotoMetadataChange{PhotoID: photo.PhotoID, FilePath: photo.FilePath, Fields: []FieldChange{}}
+	var sets []string
+	var args []any
+
+	compare := func(fields []rescanField) {
+		for _, field := range fields {
+			newValue, ok := exifData[field.Key]
+			if !ok && field.KeepWhenMissing {
+				continue
+			}
+
+			oldValue := stored.Values[field.Column]
+			if isSameMetadataValue(oldValue, newValue) {
+				continue
+			}
+
+			change.Fields = append(change.Fields, FieldChange{Field: field.Name, OldValue: oldValue, NewValue: newValue})
+			sets = append(sets, field.Column+" = ?")
+			args = append(args, newValue)
+		}
+	}
+
+	compare(rescanFields)
+
+	if !stored.IsLocationManual {
+		fieldCount := len(change.Fields)
+		compare(rescanLocationFields)
+
+		if hasPositionChange(change.Fields[fieldCount:]) {
+			location, err := reverseGeocodeMetadata(exifData)
+			if err == nil {
+				placeID, city, county, state, countryName, countryCode := location.Columns()
+				sets = append(sets, "place_id = ?", "city = ?", "county = ?", "state = ?", "country_name = ?", "country_code = ?")
+				args = append(args, placeID, city, county, state, countryName, countryCode)
+			}
+		}
+	}
+
+	// A file without a capture time keeps the stored one, which import took from the file dates
+	if dtStr, ok := exifData["DateTime"].(string); ok && !stored.IsTimeManual {
+		if captureTime := utils.ParseCaptureTime(dtStr, exifData); captureTime != nil {
+			timeChanges := captureTimeChanges(stored, *captureTime)
+			if len(timeChanges) > 0 {
+				change.Fields = append(change.Fields, timeChanges...)
+				sets = append(sets, "date_time = ?", "local_date_time = ?", "utc_offset = ?")
+				args = append(args, captureTime.UTC(), captureTime.LocalDateTime(), captureTime.UTCOffset())
+			}
+		}
+	}
+
+	if len(change.Fields) == 0 {
+		return nil, nil
+	}
+
+	if err := updateRescannedMetadata(photo.PhotoID, sets, args); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func hasPositionChange(changes []FieldChange) bool {
+	for _, change := range changes {
+		if change.Field == "latitude" || change.Field == "longitude" {
+			return true
+		}
+	}
+	return false
+}
+
+// Same rule as import: zero coordinates are what cameras write without a fix, so they have no place
+func reverseGeocodeMetadata(exifData map[string]any) (*geocoding.Location, error) {
+	lat, latOk := exifData["Latitude"].(float64)
+	lon, lonOk := exifData["Longitude"].(float64)
+	if !latOk || !lonOk || lat == 0 || lon == 0 {
+		return nil, nil
+	}
+	return geocoding.ReverseGeocode(lat, lon)
+}
+
+func captureTimeChanges(stored storedMetadata, captureTime utils.CaptureTime) []FieldChange {
+	changes := []FieldChange{}
+
+	var oldLocal any
+	if stored.LocalDateTime.Valid {
+		oldLocal = stored.LocalDateTime.String
+	}
+	if newLocal := captureTime.LocalDateTime(); oldLocal != newLocal {
+		changes = append(changes, FieldChange{Field: "localDateTime", OldValue: oldLocal, NewValue: newLocal})
+	}
+
+	var oldOffset any
+	if stored.UTCOffset.Valid {
+		oldOffset = stored.UTCOffset.Int64
+	}
+	if newOffset := captureTime.UTCOffset(); !isSameMetadataValue(oldOffset, newOffset) {
+		changes = append(changes, FieldChange{Field: "utcOffset", OldValue: oldOffset, NewValue: newOffset})
+	}
+
+	return changes
+}
+
+// Stored numbers come back as int64 or float64 whichever the column holds, so numbers are compared by value
+func isSameMetadataValue(stored, fresh any) bool {
+	if stored == nil || fresh == nil {
+		return stored == nil && fresh == nil
+	}
+
+	if a, ok := toFloat64(stored); ok {
+		if b, ok := toFloat64(fresh); ok {
+			return a == b
+		}
+	}
+
+	return fmt.Sprint(stored) == fmt.Sprint(fresh)
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func getRescanPhotos(filters *PhotoFilters, photoIDs []int64) ([]rescanPhoto, error) {
+	query := `SELECT photo_id, file_path FROM photos WHERE 1 = 1`
+	var args []any
+
+	if len(photoIDs) > 0 {
+		query += fmt.Sprintf(" AND photo_id IN (%s)", placeholders(len(photoIDs)))
+		args = append(args, toArgs(photoIDs)...)
+	}
+
+	filterSQL, filterArgs := BuildFilterConditions(filters)
+	query += filterSQL + " ORDER BY photo_id"
+	args = append(args, filterArgs...)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error getting photos to rescan: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []rescanPhoto{}
+	for rows.Next() {
+		var photo rescanPhoto
+		if err := rows.Scan(&photo.PhotoID, &photo.FilePath); err != nil {
+			err = fmt.Errorf("error scanning photo to rescan: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		photos = append(photos, photo)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating photos to rescan: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+func getStoredMetadata(photoID int64) (storedMetadata, error) {
+	fields := append(append([]rescanField{}, rescanFields...), rescanLocationFields...)
+
+	columns := make([]string, len(fields))
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Column
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, local_date_time, utc_offset, COALESCE(is_time_manual, 0), COALESCE(is_location_manual, 0)
+		FROM photos
+		WHERE photo_id = ?
+	`, strings.Join(columns, ", "))
+
+	stored := storedMetadata{Values: make(map[string]any, len(fields))}
+
+	dest := make([]any, len(fields))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	dest = append(dest, &stored.LocalDateTime, &stored.UTCOffset, &stored.IsTimeManual, &stored.IsLocationManual)
+
+	if err := sqlite.DB.QueryRow(query, photoID).Scan(dest...); err != nil {
+		err = fmt.Errorf("error getting stored metadata: %w", err)
+		slog.Error(err.Error())
+		return stored, err
+	}
+
+	for i, field := range fields {
+		value := values[i]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		stored.Values[field.Column] = value
+	}
+
+	return stored, nil
+}
+
+func updateRescannedMetadata(photoID int64, sets []string, args []any) error {
+	query := fmt.Sprintf(`UPDATE photos SET %s, updated_at = CURRENT_TIMESTAMP WHERE photo_id = ?`, strings.Join(sets, ", "))
+
+	_, err := sqlite.DB.Exec(query, append(args, photoID)...)
+	if err != nil {
+		err = fmt.Errorf("error updating rescanned metadata: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package photos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Position of a photo in a listing's sort order: the values of the sort's key columns. Missing values are
+// filled in by the key columns so every photo has a comparable key.
+type pageKey []any
+
+type cursor struct {
+	Sort string `json:"s"`
+	Key  []any  `json:"k"`
+}
+
+// Cursors are opaque to clients, which only pass back the ones they were given
+func encodeCursor(sort string, k pageKey) string {
+	data, _ := json.Marshal(cursor{Sort: sort, Key: k})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// A cursor only continues the sort it was made for. Numbers come back as float64, which SQLite compares
+// with the integer columns by value.
+func decodeCursor(value, sort string) (pageKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		// Cursors from before sorting was added are the newest first key on its own
+		if err := json.Unmarshal(data, &c.Key); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		c.Sort = SortNewest
+	}
+
+	listing := getListingSort(sort)
+	if c.Sort != listing.name || len(c.Key) != len(listing.columns) {
+		return nil, ErrInvalidCursor
+	}
+
+	for _, value := range c.Key {
+		switch value.(type) {
+		case string, float64:
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return pageKey(c.Key), nil
+}
@@ -0,0 +1,301 @@
+package photos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"riffle/commons/sqlite"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Sets up an in-memory database with the migrations applied
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find migrations: %v", err)
+	}
+	version := func(path string) int {
+		v, _ := strconv.Atoi(strings.Split(filepath.Base(path), "_")[0])
+		return v
+	}
+	sort.Slice(files, func(i, j int) bool { return version(files[i]) < version(files[j]) })
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if _, err := db.Exec(string(content)); err != nil {
+			t.Fatalf("Failed to apply %s: %v", file, err)
+		}
+	}
+
+	previous := sqlite.DB
+	sqlite.DB = db
+	t.Cleanup(func() { sqlite.DB = previous })
+
+	return db
+}
+
+// Inserts curated photos whose sort keys are often missing and often tied, so a page boundary lands inside
+// runs of equal values and between known and unknown ones
+func insertPagingPhotos(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	for importID := 1; importID <= 2; importID++ {
+		_, err := db.Exec(`INSERT INTO import_sessions (import_id, import_path, import_mode, started_at, status) VALUES (?, '/import', 'copy', '2024-01-01 00:00:00', 'completed')`, importID)
+		if err != nil {
+			t.Fatalf("Failed to insert import session: %v", err)
+		}
+	}
+
+	nullable := func(isNull bool, value any) any {
+		if isNull {
+			return nil
+		}
+		return value
+	}
+
+	for i := 0; i < 40; i++ {
+		dateTime := fmt.Sprintf("2024-03-0%d 10:00:00", i%4+1)
+		localDateTime := strings.Replace(dateTime, " ", "T", 1)
+
+		_, err := db.Exec(`
+			INSERT INTO photos (
+				file_path, sha256_hash, file_size, file_format, mime_type, date_time, local_date_time,
+				camera_make, camera_model, rating, import_id, imported_at, created_at, is_curated
+			) VALUES (?, ?, ?, 'jpg', 'image/jpeg', ?, ?, ?, ?, ?, ?, ?, ?, 1)`,
+			// The same names in different folders, and in different case
+			fmt.Sprintf("%s/IMG_%02d.JPG", []string{"a", "B", "c", "d", "E"}[i%5], i%8),
+			fmt.Sprintf("hash%d", i),
+			(i%4)*1000,
+			nullable(i%6 == 0, dateTime),
+			nullable(i%6 == 0 || i%7 == 0, localDateTime),
+			nullable(i%5 == 0, []string{"Canon", "Sony"}[i%2]),
+			nullable(i%3 == 0, []string{"EOS R5", "A7 IV"}[i%2]),
+			i%3,
+			nullable(i%3 == 0, i%2+1),
+			nullable(i%4 == 0, fmt.Sprintf("2024-01-0%d 00:00:00", i%2+1)),
+			fmt.Sprintf("2024-01-0%d 00:00:00", i%3+1),
+		)
+		if err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+}
+
+func pagePaths(page *PhotosPage) []string {
+	paths := make([]string, len(page.Photos))
+	for i, photo := range page.Photos {
+		paths[i] = photo.FilePath
+	}
+	return paths
+}
+
+func TestPagingMatchesUnpagedListing(t *testing.T) {
+	db := setupTestDB(t)
+	insertPagingPhotos(t, db)
+
+	sorts := []string{"", SortNewest, SortOldest, SortImported, SortRating, SortSize, SortFilename, SortCamera}
+	limits := []int{1, 3, 7}
+
+	for _, sortName := range sorts {
+		listing, err := GetPhotosWithDayGroups(PageRequest{Sort: sortName, Limit: 100}, true, false, TeamCuration, nil)
+		if err != nil {
+			t.Fatalf("GetPhotosWithDayGroups(%q) error = %v", sortName, err)
+		}
+		expected := pagePaths(listing)
+		if len(expected) != 40 {
+			t.Fatalf("Unpaged %q listing has %d photos, want 40", sortName, len(expected))
+		}
+
+		label := sortName
+		if label == "" {
+			label = "default"
+		}
+
+		for _, limit := range limits {
+			t.Run(fmt.Sprintf("%s by %d", label, limit), func(t *testing.T) {
+				// Forward from the first page
+				var forward []string
+				var pages []*PhotosPage
+				page, err := GetPhotosWithDayGroups(PageRequest{Sort: sortName, Limit: limit}, true, false, TeamCuration, nil)
+				for {
+					if err != nil {
+						t.Fatalf("Reading forward error = %v", err)
+					}
+					forward = append(forward, pagePaths(page)...)
+					pages = append(pages, page)
+					if page.NextCursor == "" || len(pages) > 40 {
+						break
+					}
+					page, err = GetPhotosWithDayGroups(PageRequest{Sort: sortName, Limit: limit, After: page.NextCursor}, true, false, TeamCuration, nil)
+				}
+
+				if !reflect.DeepEqual(forward, expected) {
+					t.Fatalf("Reading forward = %v, want %v", forward, expected)
+				}
+
+				// Back from the last page, each page ending where the one after it starts
+				end := len(expected) - len(pages[len(pages)-1].Photos)
+				prevCursor := pages[len(pages)-1].PrevCursor
+				for prevCursor != "" {
+					page, err := GetPhotosWithDayGroups(PageRequest{Sort: sortName, Limit: limit, Before: prevCursor}, true, false, TeamCuration, nil)
+					if err != nil {
+						t.Fatalf("Reading back error = %v", err)
+					}
+					paths := pagePaths(page)
+
+					// Reaching the start serves the first page whole
+					if page.PrevCursor == "" {
+						if !reflect.DeepEqual(paths, expected[:limit]) || end > limit {
+							t.Fatalf("Reading back to the start = %v, want %v", paths, expected[:limit])
+						}
+						break
+					}
+
+					if len(paths) > end || !reflect.DeepEqual(paths, expected[end-len(paths):end]) {
+						t.Fatalf("Reading back before %d = %v, want the photos before it in %v", end, paths, expected)
+					}
+					end -= len(paths)
+					prevCursor = page.PrevCursor
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	newest := encodeCursor(SortNewest, pageKey{"2024-03-01", "2024-03-01 10:00:00", "2024-01-01 00:00:00", "a/IMG_01.JPG"})
+	size := encodeCursor(SortSize, pageKey{float64(1000), "a/IMG_01.JPG"})
+
+	tests := []struct {
+		name     string
+		value    string
+		sort     string
+		expected pageKey
+	}{
+		{
+			name:     "Newest first",
+			value:    newest,
+			sort:     SortNewest,
+			expected: pageKey{"2024-03-01", "2024-03-01 10:00:00", "2024-01-01 00:00:00", "a/IMG_01.JPG"},
+		},
+		{
+			name:     "Empty sort is newest first",
+			value:    newest,
+			sort:     "",
+			expected: pageKey{"2024-03-01", "2024-03-01 10:00:00", "2024-01-01 00:00:00", "a/IMG_01.JPG"},
+		},
+		{
+			name:     "Numbers",
+			value:    size,
+			sort:     SortSize,
+			expected: pageKey{float64(1000), "a/IMG_01.JPG"},
+		},
+		{
+			name:     "Key on its own, from before sorting",
+			value:    "WyIyMDI0LTAzLTAxIiwiMjAyNC0wMy0wMSAxMDowMDowMCIsIjIwMjQtMDEtMDEgMDA6MDA6MDAiLCJhL0lNR18wMS5KUEciXQ",
+			sort:     SortNewest,
+			expected: pageKey{"2024-03-01", "2024-03-01 10:00:00", "2024-01-01 00:00:00", "a/IMG_01.JPG"},
+		},
+		{
+			name:  "Another sort's cursor",
+			value: size,
+			sort:  SortNewest,
+		},
+		{
+			name:  "Wrong number of key values",
+			value: encodeCursor(SortSize, pageKey{"a/IMG_01.JPG"}),
+			sort:  SortSize,
+		},
+		{
+			name:  "Key values that aren't text or numbers",
+			value: encodeCursor(SortSize, pageKey{nil, "a/IMG_01.JPG"}),
+			sort:  SortSize,
+		},
+		{
+			name:  "Not base64",
+			value: "not a cursor!",
+			sort:  SortNewest,
+		},
+		{
+			name:  "Not JSON",
+			value: "bm90IGpzb24",
+			sort:  SortNewest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := decodeCursor(tt.value, tt.sort)
+			if tt.expected == nil {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("decodeCursor() = %v, %v, want %v", key, err, ErrInvalidCursor)
+				}
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(key, tt.expected) {
+				t.Errorf("decodeCursor() = %v, %v, want %v", key, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSeekCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		sort       string
+		isBackward bool
+		expected   string
+	}{
+		{
+			name:     "Descending, forward",
+			sort:     SortSize,
+			expected: " AND COALESCE(file_size, 0) <= ? AND (COALESCE(file_size, 0), file_path) < (?,?)",
+		},
+		{
+			name:       "Descending, backward",
+			sort:       SortSize,
+			isBackward: true,
+			expected:   " AND COALESCE(file_size, 0) >= ? AND (COALESCE(file_size, 0), file_path) > (?,?)",
+		},
+		{
+			name:     "Ascending, forward",
+			sort:     SortFilename,
+			expected: " AND " + fileNameColumn + " >= ? AND (" + fileNameColumn + ", file_path) > (?,?)",
+		},
+		{
+			name:       "Ascending, backward",
+			sort:       SortFilename,
+			isBackward: true,
+			expected:   " AND " + fileNameColumn + " <= ? AND (" + fileNameColumn + ", file_path) < (?,?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := getListingSort(tt.sort).seekCondition(tt.isBackward); result != tt.expected {
+				t.Errorf("seekCondition() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package photos
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -10,25 +11,17 @@ import (
 	"riffle/commons/cache"
 	"riffle/commons/media"
 	"riffle/commons/utils"
+	"riffle/features/auth"
 	"strconv"
 	"strings"
 )
 
 func HandleServePhoto(w http.ResponseWriter, r *http.Request) {
-	encodedPath := r.URL.Query().Get("path")
-	if encodedPath == "" {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_PATH", "Path parameter required")
+	filePath, ok := resolvePhotoPath(w, r)
+	if !ok {
 		return
 	}
 
-	decodedPath, err := base64.URLEncoding.DecodeString(encodedPath)
-	if err != nil {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PATH", "Invalid path encoding")
-		return
-	}
-
-	filePath := string(decodedPath)
-
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -47,6 +40,12 @@ func HandleServePhoto(w http.ResponseWriter, r *http.Request) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	contentType := media.GetContentType(ext)
 
+	serveFile(w, r, filePath, contentType)
+}
+
+// http.ServeContent handles Range requests, so large videos can be streamed and seeked. Callers that must not be
+// cached, like share links, set their own Cache-Control first.
+func serveFile(w http.ResponseWriter, r *http.Request, filePath, contentType string) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		slog.Error("failed to open file", "path", filePath, "error", err)
@@ -55,22 +54,86 @@ func HandleServePhoto(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	fileInfo, err := file.Stat()
+	if err != nil {
+		slog.Error("failed to stat file", "path", filePath, "error", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "READ_ERROR", "Failed to read file")
+		return
+	}
+
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}
 
 	http.ServeContent(w, r, filepath.Base(filePath), fileInfo.ModTime(), file)
 }
 
 type PhotosResponse struct {
-	Photos          []Photo `json:"photos"`
-	Groups          []Group `json:"groups"`
-	Bursts          []Burst `json:"bursts,omitempty"`
-	TotalRecords    int     `json:"totalRecords"`
-	PageStartRecord int     `json:"pageStartRecord"`
-	PageEndRecord   int     `json:"pageEndRecord"`
+	Photos             []Photo `json:"photos"`
+	Groups             []Group `json:"groups"`
+	Bursts             []Burst `json:"bursts,omitempty"`
+	TotalRecords       int     `json:"totalRecords"`
+	IsCountApproximate bool    `json:"isCountApproximate,omitempty"`
+	PageStartRecord    int     `json:"pageStartRecord"`
+	PageEndRecord      int     `json:"pageEndRecord"`
+	NextCursor         string  `json:"nextCursor,omitempty"`
+	PrevCursor         string  `json:"prevCursor,omitempty"`
+}
+
+func NewPhotosResponse(page *PhotosPage) PhotosResponse {
+	return PhotosResponse{
+		Photos:             page.Photos,
+		Groups:             page.Groups,
+		Bursts:             DetectBursts(page.Photos),
+		TotalRecords:       page.TotalRecords,
+		IsCountApproximate: page.IsCountApproximate,
+		PageStartRecord:    page.PageStartRecord,
+		PageEndRecord:      page.PageEndRecord,
+		NextCursor:         page.NextCursor,
+		PrevCursor:         page.PrevCursor,
+	}
+}
+
+// Reads after/before cursors or an offset, and count=approximate to cap the total count
+func ParsePageRequest(r *http.Request) (PageRequest, error) {
+	query := r.URL.Query()
+	page := PageRequest{
+		Sort:               query.Get("sort"),
+		Limit:              100,
+		After:              query.Get("after"),
+		Before:             query.Get("before"),
+		IsCountApproximate: query.Get("count") == "approximate",
+	}
+
+	if !isValidSort(page.Sort) {
+		return page, ErrInvalidSort
+	}
+
+	if page.After != "" && page.Before != "" {
+		return page, ErrInvalidCursor
+	}
+
+	for _, cursor := range []string{page.After, page.Before} {
+		if cursor == "" {
+			continue
+		}
+		if _, err := decodeCursor(cursor, page.Sort); err != nil {
+			return page, err
+		}
+	}
+
+	if o := query.Get("offset"); o != "" {
+		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
+			page.Offset = v
+		}
+	}
+
+	return page, nil
 }
 
-func parseFiltersFromQuery(r *http.Request) *PhotoFilters {
+// Returns nil when no filter is set, and an error describing the first invalid one
+func ParseFiltersFromQuery(r *http.Request) (*PhotoFilters, error) {
 	query := r.URL.Query()
 
 	filters := &PhotoFilters{}
@@ -114,6 +177,11 @@ func parseFiltersFromQuery(r *http.Request) *PhotoFilters {
 		hasFilters = true
 	}
 
+	if places := query["places"]; len(places) > 0 {
+		filters.Places = places
+		hasFilters = true
+	}
+
 	if countries := query["countries"]; len(countries) > 0 {
 		filters.Countries = countries
 		hasFilters = true
@@ -134,140 +202,227 @@ func parseFiltersFromQuery(r *http.Request) *PhotoFilters {
 		hasFilters = true
 	}
 
+	if lensModels := query["lensModels"]; len(lensModels) > 0 {
+		filters.LensModels = lensModels
+		hasFilters = true
+	}
+
+	excludeLists := map[string]*[]string{
+		"excludeCameraMakes":  &filters.ExcludeCameraMakes,
+		"excludeCameraModels": &filters.ExcludeCameraModels,
+		"excludePlaces":       &filters.ExcludePlaces,
+		"excludeCountries":    &filters.ExcludeCountries,
+		"excludeStates":       &filters.ExcludeStates,
+		"excludeCities":       &filters.ExcludeCities,
+		"excludeFileFormats":  &filters.ExcludeFileFormats,
+		"excludeLensModels":   &filters.ExcludeLensModels,
+	}
+	for param, list := range excludeLists {
+		if values := query[param]; len(values) > 0 {
+			*list = values
+			hasFilters = true
+		}
+	}
+
+	for _, ys := range query["excludeYears"] {
+		if y, err := strconv.Atoi(ys); err == nil {
+			filters.ExcludeYears = append(filters.ExcludeYears, y)
+			hasFilters = true
+		}
+	}
+
+	// Ranges come as isoMin/isoMax, fNumberMin and so on
+	rangeParams := map[string]**NumberRange{
+		"iso":                  &filters.ISO,
+		"fNumber":              &filters.FNumber,
+		"exposureTime":         &filters.ExposureTime,
+		"focalLength":          &filters.FocalLength,
+		"focalLength35mm":      &filters.FocalLength35mm,
+		"exposureCompensation": &filters.ExposureCompensation,
+		"fileSize":             &filters.FileSize,
+		"width":                &filters.Width,
+		"height":               &filters.Height,
+		"megapixels":           &filters.Megapixels,
+		"duration":             &filters.Duration,
+	}
+	for param, target := range rangeParams {
+		numberRange := &NumberRange{}
+		for suffix, bound := range map[string]**float64{"Min": &numberRange.Min, "Max": &numberRange.Max} {
+			value := query.Get(param + suffix)
+			if value == "" {
+				continue
+			}
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", param+suffix)
+			}
+			*bound = &number
+		}
+		if numberRange.Min != nil || numberRange.Max != nil {
+			*target = numberRange
+			hasFilters = true
+		}
+	}
+
+	if dateFrom := query.Get("dateFrom"); dateFrom != "" {
+		filters.DateFrom = dateFrom
+		hasFilters = true
+	}
+
+	if dateTo := query.Get("dateTo"); dateTo != "" {
+		filters.DateTo = dateTo
+		hasFilters = true
+	}
+
+	for param, target := range map[string]**bool{"hasGps": &filters.HasGPS, "hasNotes": &filters.HasNotes} {
+		if value := query.Get(param); value != "" {
+			has := value == "true"
+			*target = &has
+			hasFilters = true
+		}
+	}
+
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filters.Query = q
+		hasFilters = true
+	}
+
 	if !hasFilters {
-		return nil
+		return nil, nil
+	}
+
+	if err := filters.Validate(); err != nil {
+		return nil, err
 	}
 
-	return filters
+	return filters, nil
 }
 
 func HandleGetPhotos(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	filters := parseFiltersFromQuery(r)
-	limit := 100
+	filters, err := ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
 
-	offset := 0
-	if o := query.Get("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = v
-		}
+	page, err := ParsePageRequest(r)
+	if errors.Is(err, ErrInvalidSort) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SORT", "Sort must be newest, oldest, imported, rating, size, filename or camera")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_CURSOR", "Invalid page cursor")
+		return
 	}
 
-	photos, groups, totalRecords, pageStartRecord, pageEndRecord, err := GetPhotosWithDayGroups(limit, offset, true, false, filters)
+	photosPage, err := GetPhotosWithDayGroups(page, true, false, ParseCurationUser(r), filters)
 	if err != nil {
 		slog.Error("failed to get photos with groups", "error", err)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photos")
 		return
 	}
 
-	bursts := DetectBursts(photos)
-
-	response := PhotosResponse{
-		Photos:          photos,
-		Groups:          groups,
-		Bursts:          bursts,
-		TotalRecords:    totalRecords,
-		PageStartRecord: pageStartRecord,
-		PageEndRecord:   pageEndRecord,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(NewPhotosResponse(photosPage))
 }
 
 func HandleGetUncuratedPhotos(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	filters := parseFiltersFromQuery(r)
-	limit := 100
+	filters, err := ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
 
-	offset := 0
-	if o := query.Get("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = v
-		}
+	page, err := ParsePageRequest(r)
+	if errors.Is(err, ErrInvalidSort) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SORT", "Sort must be newest, oldest, imported, rating, size, filename or camera")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_CURSOR", "Invalid page cursor")
+		return
 	}
 
-	photos, groups, totalRecords, pageStartRecord, pageEndRecord, err := GetPhotosWithDayGroups(limit, offset, false, false, filters)
+	photosPage, err := GetPhotosWithDayGroups(page, false, false, ParseCurationUser(r), filters)
 	if err != nil {
 		slog.Error("failed to get uncurated photos with groups", "error", err)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photos")
 		return
 	}
 
-	bursts := DetectBursts(photos)
-
-	response := PhotosResponse{
-		Photos:          photos,
-		Groups:          groups,
-		Bursts:          bursts,
-		TotalRecords:    totalRecords,
-		PageStartRecord: pageStartRecord,
-		PageEndRecord:   pageEndRecord,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(NewPhotosResponse(photosPage))
 }
 
 func HandleGetTrashedPhotos(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	filters := parseFiltersFromQuery(r)
-	limit := 100
+	filters, err := ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
 
-	offset := 0
-	if o := query.Get("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = v
-		}
+	page, err := ParsePageRequest(r)
+	if errors.Is(err, ErrInvalidSort) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SORT", "Sort must be newest, oldest, imported, rating, size, filename or camera")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_CURSOR", "Invalid page cursor")
+		return
 	}
 
-	photos, groups, totalRecords, pageStartRecord, pageEndRecord, err := GetPhotosWithDayGroups(limit, offset, false, true, filters)
+	photosPage, err := GetPhotosWithDayGroups(page, false, true, ParseCurationUser(r), filters)
 	if err != nil {
 		slog.Error("failed to get trashed photos with groups", "error", err)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photos")
 		return
 	}
 
-	bursts := DetectBursts(photos)
-
-	response := PhotosResponse{
-		Photos:          photos,
-		Groups:          groups,
-		Bursts:          bursts,
-		TotalRecords:    totalRecords,
-		PageStartRecord: pageStartRecord,
-		PageEndRecord:   pageEndRecord,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(NewPhotosResponse(photosPage))
 }
 
-func HandleGetFilterOptions(w http.ResponseWriter, r *http.Request) {
-	if cache.FiltersCache.CheckAndRespond(w, r, 3600) {
+// Counts change with every curation, so the browser always checks its copy. A user's own decisions are only
+// valid for them.
+func HandleGetFilterFacets(w http.ResponseWriter, r *http.Request) {
+	isCurated, isTrashed, ok := parseTimelineScope(r)
+	if !ok {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SCOPE", "Scope must be library, uncurated or trash")
 		return
 	}
 
-	options, err := GetFilterOptions()
+	filters, err := ParseFiltersFromQuery(r)
 	if err != nil {
-		slog.Error("failed to get filter options", "error", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	curationUserID := ParseCurationUser(r)
+	if cache.FiltersCache.CheckAndRespondForUser(w, r, 0, curationUserID) {
+		return
+	}
+
+	facets, err := GetFilterFacets(isCurated, isTrashed, curationUserID, filters)
+	if err != nil {
+		slog.Error("failed to get filter facets", "error", err)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch filter options")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(options)
+	json.NewEncoder(w).Encode(facets)
 }
 
 type CurateRequest struct {
-	FilePath  string `json:"filePath"`
-	IsCurated bool   `json:"isCurated"`
-	IsTrashed bool   `json:"isTrashed"`
-	Rating    int    `json:"rating"`
+	PhotoID    int64 `json:"photoId"`
+	IsCurated  bool  `json:"isCurated"`
+	IsTrashed  bool  `json:"isTrashed"`
+	IsFavorite *bool `json:"isFavorite"`
+	Rating     int   `json:"rating"`
 }
 
 func HandleCuratePhoto(w http.ResponseWriter, r *http.Request) {
@@ -277,8 +432,8 @@ func HandleCuratePhoto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.FilePath == "" {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_PATH", "File path is required")
+	if req.PhotoID <= 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_ID", "Photo ID is required")
 		return
 	}
 
@@ -287,7 +442,13 @@ func HandleCuratePhoto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := UpdatePhotoCuration(req.FilePath, req.IsCurated, req.IsTrashed, req.Rating)
+	user := auth.GetCurrentUser(r)
+	if user == nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+		return
+	}
+
+	err := UpdateUserPhotoCuration(user.UserID, req.PhotoID, req.IsCurated, req.IsTrashed, req.Rating, req.IsFavorite)
 	if err != nil {
 		slog.Error("failed to curate photo", "error", err)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, "CURATE_ERROR", "Failed to update photo")
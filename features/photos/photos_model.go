@@ -1,68 +1,137 @@
 package photos
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"riffle/commons/media"
 	"riffle/commons/sqlite"
 )
 
+var ErrPhotoNotFound = errors.New("photo not found")
+
 type Photo struct {
-	FilePath         string   `json:"filePath"`
-	OriginalFilepath *string  `json:"originalFilepath,omitempty"`
-	Sha256Hash       string   `json:"sha256Hash"`
-	Dhash            *string  `json:"dhash,omitempty"`
-	FileSize         int64    `json:"fileSize"`
-	DateTime         *string  `json:"dateTime,omitempty"`
-	CameraMake       *string  `json:"cameraMake,omitempty"`
-	CameraModel      *string  `json:"cameraModel,omitempty"`
-	Width            *int     `json:"width,omitempty"`
-	Height           *int     `json:"height,omitempty"`
-	Orientation      *int     `json:"orientation,omitempty"`
-	Latitude         *float64 `json:"latitude,omitempty"`
-	Longitude        *float64 `json:"longitude,omitempty"`
-	ISO              *int     `json:"iso,omitempty"`
-	FNumber          *float64 `json:"fNumber,omitempty"`
-	ExposureTime     *float64 `json:"exposureTime,omitempty"`
-	FocalLength      *float64 `json:"focalLength,omitempty"`
-	FileFormat       string   `json:"fileFormat"`
-	MimeType         string   `json:"mimeType"`
-	IsVideo          bool     `json:"isVideo"`
-	Duration         *int     `json:"duration,omitempty"`
-	FileCreatedAt    *string  `json:"fileCreatedAt,omitempty"`
-	FileModifiedAt   *string  `json:"fileModifiedAt,omitempty"`
-	City             *string  `json:"city,omitempty"`
-	State            *string  `json:"state,omitempty"`
-	CountryName      *string  `json:"countryCode,omitempty"`
-	IsCurated        bool     `json:"isCurated"`
-	IsTrashed        bool     `json:"isTrashed"`
-	Rating           int      `json:"rating"`
-	Notes            *string  `json:"notes,omitempty"`
-	CreatedAt        string   `json:"createdAt"`
-	UpdatedAt        string   `json:"updatedAt"`
-	ThumbnailPath    *string  `json:"thumbnailPath,omitempty"`
-	TotalRecords     int      `json:"totalRecords,omitempty"`
+	PhotoID              int64    `json:"photoId"`
+	FilePath             string   `json:"filePath"`
+	OriginalFilepath     *string  `json:"originalFilepath,omitempty"`
+	Sha256Hash           string   `json:"sha256Hash"`
+	Dhash                *string  `json:"dhash,omitempty"`
+	FileSize             int64    `json:"fileSize"`
+	DateTime             *string  `json:"dateTime,omitempty"`
+	LocalDateTime        *string  `json:"localDateTime,omitempty"`
+	UTCOffset            *int     `json:"utcOffset,omitempty"`
+	CameraMake           *string  `json:"cameraMake,omitempty"`
+	CameraModel          *string  `json:"cameraModel,omitempty"`
+	Width                *int     `json:"width,omitempty"`
+	Height               *int     `json:"height,omitempty"`
+	Orientation          *int     `json:"orientation,omitempty"`
+	Latitude             *float64 `json:"latitude,omitempty"`
+	Longitude            *float64 `json:"longitude,omitempty"`
+	ISO                  *int     `json:"iso,omitempty"`
+	FNumber              *float64 `json:"fNumber,omitempty"`
+	ExposureTime         *float64 `json:"exposureTime,omitempty"`
+	FocalLength          *float64 `json:"focalLength,omitempty"`
+	LensMake             *string  `json:"lensMake,omitempty"`
+	LensModel            *string  `json:"lensModel,omitempty"`
+	FocalLength35mm      *float64 `json:"focalLength35mm,omitempty"`
+	ExposureProgram      *string  `json:"exposureProgram,omitempty"`
+	ExposureMode         *string  `json:"exposureMode,omitempty"`
+	ExposureCompensation *float64 `json:"exposureCompensation,omitempty"` // EV
+	MeteringMode         *string  `json:"meteringMode,omitempty"`
+	WhiteBalance         *string  `json:"whiteBalance,omitempty"`
+	Flash                *string  `json:"flash,omitempty"`
+	Software             *string  `json:"software,omitempty"`
+	ColorSpace           *string  `json:"colorSpace,omitempty"`
+	ColorProfile         *string  `json:"colorProfile,omitempty"`
+	Altitude             *float64 `json:"altitude,omitempty"`     // Meters
+	GPSDirection         *float64 `json:"gpsDirection,omitempty"` // Degrees
+	CameraSerial         *string  `json:"cameraSerial,omitempty"`
+	FileFormat           string   `json:"fileFormat"`
+	MimeType             string   `json:"mimeType"`
+	IsVideo              bool     `json:"isVideo"`
+	Duration             *int     `json:"duration,omitempty"`
+	VideoCodec           *string  `json:"videoCodec,omitempty"`
+	AudioCodec           *string  `json:"audioCodec,omitempty"`
+	FrameRate            *float64 `json:"frameRate,omitempty"`
+	Rotation             *int     `json:"rotation,omitempty"`
+	FileCreatedAt        *string  `json:"fileCreatedAt,omitempty"`
+	FileModifiedAt       *string  `json:"fileModifiedAt,omitempty"`
+	City                 *string  `json:"city,omitempty"`
+	County               *string  `json:"county,omitempty"`
+	State                *string  `json:"state,omitempty"`
+	CountryName          *string  `json:"countryCode,omitempty"`
+	IsCurated            bool     `json:"isCurated"`
+	IsTrashed            bool     `json:"isTrashed"`
+	IsFavorite           bool     `json:"isFavorite"`
+	Rating               int      `json:"rating"`
+	Notes                *string  `json:"notes,omitempty"`
+	CreatedAt            string   `json:"createdAt"`
+	UpdatedAt            string   `json:"updatedAt"`
+	ThumbnailPath        *string  `json:"thumbnailPath,omitempty"`
+	TotalRecords         int      `json:"totalRecords,omitempty"`
+}
+
+// Returns the library-relative file path of a photo
+func GetPhotoFilePath(photoID int64) (string, error) {
+	query := `SELECT file_path FROM photos WHERE photo_id = ?`
+
+	var filePath string
+	err := sqlite.DB.QueryRow(query, photoID).Scan(&filePath)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrPhotoNotFound
+		}
+		err = fmt.Errorf("error getting photo file path: %w", err)
+		slog.Error(err.Error())
+		return "", err
+	}
+
+	return filePath, nil
 }
 
-func UpdatePhotoCuration(filePath string, isCurated, isTrashed bool, rating int) error {
-	query := `
-		UPDATE photos
-		SET is_curated = ?, is_trashed = ?, rating = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE file_path = ?
-	`
+func GetPhotoOrientation(photoID int64) (int, error) {
+	query := `SELECT COALESCE(orientation, 1) FROM photos WHERE photo_id = ?`
 
-	_, err := sqlite.DB.Exec(query, isCurated, isTrashed, rating, filePath)
+	var orientation int
+	err := sqlite.DB.QueryRow(query, photoID).Scan(&orientation)
 	if err != nil {
-		err = fmt.Errorf("error updating photo curation: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPhotoNotFound
+		}
+		err = fmt.Errorf("error getting photo orientation: %w", err)
 		slog.Error(err.Error())
-		return err
+		return 0, err
 	}
 
-	return nil
+	return orientation, nil
+}
+
+// Returns the codecs probed at import, or nil when the video was never probed
+func GetPhotoVideoCodecs(photoID int64) (*media.VideoInfo, error) {
+	query := `SELECT video_codec, audio_codec FROM photos WHERE photo_id = ?`
+
+	var videoCodec, audioCodec sql.NullString
+	err := sqlite.DB.QueryRow(query, photoID).Scan(&videoCodec, &audioCodec)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPhotoNotFound
+		}
+		err = fmt.Errorf("error getting photo video codecs: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	if !videoCodec.Valid {
+		return nil, nil
+	}
+
+	return &media.VideoInfo{VideoCodec: videoCodec.String, AudioCodec: audioCodec.String}, nil
 }
 
 // To prevent full table scan
-func getCount(whereClause string, args ...any) int {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM photos %s", whereClause)
+func getCount(source, whereClause string, args ...any) int {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", source, whereClause)
 	var count int
 	err := sqlite.DB.QueryRow(query, args...).Scan(&count)
 	if err != nil {
@@ -71,3 +140,19 @@ func getCount(whereClause string, args ...any) int {
 	}
 	return count
 }
+
+// Counts up to limit matching photos; the boolean reports that there are more
+func getApproximateCount(source, whereClause string, limit int, args ...any) (int, bool) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 FROM %s %s LIMIT ?)", source, whereClause)
+	var count int
+	queryArgs := append(append([]any{}, args...), limit+1)
+	err := sqlite.DB.QueryRow(query, queryArgs...).Scan(&count)
+	if err != nil {
+		slog.Error("error getting approximate count", "error", err)
+		return 0, false
+	}
+	if count > limit {
+		return limit, true
+	}
+	return count, false
+}
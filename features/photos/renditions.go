@@ -0,0 +1,44 @@
+package photos
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"riffle/commons/media"
+	"riffle/commons/utils"
+	"strconv"
+)
+
+// Serves a display-sized JPEG of the photo, generating it on first request. Videos get the playable video.
+func HandleServeRendition(w http.ResponseWriter, r *http.Request) {
+	filePath, ok := resolvePhotoPath(w, r)
+	if !ok {
+		return
+	}
+
+	if media.IsVideoFile(filePath) {
+		HandleServeVideo(w, r)
+		return
+	}
+
+	thumbnailPath := media.GetThumbnailPath(os.Getenv("LIBRARY_PATH"), os.Getenv("THUMBNAILS_PATH"), filePath)
+	renditionPath := media.GetRenditionPath(thumbnailPath)
+
+	if _, err := os.Stat(renditionPath); os.IsNotExist(err) {
+		photoID, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+		orientation, err := GetPhotoOrientation(photoID)
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch photo")
+			return
+		}
+
+		if err := media.GenerateRendition(filePath, renditionPath, orientation); err != nil {
+			slog.Error("failed to generate rendition", "path", filePath, "error", err)
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "RENDITION_ERROR", "Failed to prepare photo")
+			return
+		}
+	}
+
+	serveThumbnailFile(w, r, renditionPath, "image/jpeg")
+}
@@ -0,0 +1,183 @@
+package photos
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"riffle/commons/cache"
+	"riffle/commons/utils"
+	"riffle/features/auth"
+	"strconv"
+	"strings"
+)
+
+const maxSavedFilterNameLength = 100
+
+type SavedFilterRequest struct {
+	Name    string       `json:"name"`
+	Scope   string       `json:"scope"`
+	Sort    string       `json:"sort"`
+	Filters PhotoFilters `json:"filters"`
+}
+
+// Counts follow the curator param like the listings they open. The list is revalidated on every request,
+// so saving a filter or curating a photo shows up straight away.
+func HandleGetSavedFilters(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetCurrentUser(r)
+	if user == nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+		return
+	}
+
+	if cache.SavedFiltersCache.CheckAndRespondForUser(w, r, 0, user.UserID) {
+		return
+	}
+
+	savedFilters, err := GetSavedFilters(user.UserID, ParseCurationUser(r))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch saved filters")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(savedFilters)
+}
+
+func HandleCreateSavedFilter(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetCurrentUser(r)
+	if user == nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+		return
+	}
+
+	var req SavedFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	if errCode, errMessage := validateSavedFilter(&req); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	savedFilter := SavedFilter{Name: req.Name, Scope: req.Scope, Sort: req.Sort, Filters: req.Filters}
+
+	created, err := CreateSavedFilter(user.UserID, savedFilter)
+	if err != nil {
+		if errors.Is(err, ErrSavedFilterNameTaken) {
+			utils.SendErrorResponse(w, http.StatusConflict, "SAVED_FILTER_NAME_TAKEN", "A saved filter with this name already exists")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_ERROR", "Failed to save filter")
+		return
+	}
+
+	cache.SavedFiltersCache.Invalidate()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func HandleUpdateSavedFilter(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetCurrentUser(r)
+	if user == nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+		return
+	}
+
+	savedFilterID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid saved filter ID")
+		return
+	}
+
+	var req SavedFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_BODY", "Invalid request body")
+		return
+	}
+
+	if errCode, errMessage := validateSavedFilter(&req); errCode != "" {
+		utils.SendErrorResponse(w, http.StatusBadRequest, errCode, errMessage)
+		return
+	}
+
+	savedFilter := SavedFilter{Name: req.Name, Scope: req.Scope, Sort: req.Sort, Filters: req.Filters}
+
+	updated, err := UpdateSavedFilter(savedFilterID, user.UserID, savedFilter)
+	if err != nil {
+		if errors.Is(err, ErrSavedFilterNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Saved filter not found")
+			return
+		}
+		if errors.Is(err, ErrSavedFilterNameTaken) {
+			utils.SendErrorResponse(w, http.StatusConflict, "SAVED_FILTER_NAME_TAKEN", "A saved filter with this name already exists")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "UPDATE_ERROR", "Failed to update saved filter")
+		return
+	}
+
+	cache.SavedFiltersCache.Invalidate()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func HandleDeleteSavedFilter(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetCurrentUser(r)
+	if user == nil {
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign in required")
+		return
+	}
+
+	savedFilterID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid saved filter ID")
+		return
+	}
+
+	if err := DeleteSavedFilter(savedFilterID, user.UserID); err != nil {
+		if errors.Is(err, ErrSavedFilterNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Saved filter not found")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "DELETE_ERROR", "Failed to delete saved filter")
+		return
+	}
+
+	cache.SavedFiltersCache.Invalidate()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// Fills in the default scope, then returns an error code and message when the request isn't valid
+func validateSavedFilter(req *SavedFilterRequest) (string, string) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxSavedFilterNameLength {
+		return "INVALID_NAME", "Name must be between 1 and 100 characters"
+	}
+
+	if req.Scope == "" {
+		req.Scope = ScopeLibrary
+	}
+	if _, _, ok := scopeFlags(req.Scope); !ok {
+		return "INVALID_SCOPE", "Scope must be library, uncurated or trash"
+	}
+
+	if !isValidSort(req.Sort) {
+		return "INVALID_SORT", "Unknown sort order"
+	}
+
+	if err := req.Filters.Validate(); err != nil {
+		return "INVALID_FILTERS", err.Error()
+	}
+
+	return "", ""
+}
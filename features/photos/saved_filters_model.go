@@ -0,0 +1,196 @@
+package photos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"strings"
+)
+
+var ErrSavedFilterNotFound = errors.New("saved filter not found")
+var ErrSavedFilterNameTaken = errors.New("saved filter name already taken")
+
+type SavedFilter struct {
+	SavedFilterID int64        `json:"savedFilterId"`
+	Name          string       `json:"name"`
+	Scope         string       `json:"scope"` // library, uncurated or trash
+	Sort          string       `json:"sort"`
+	Filters       PhotoFilters `json:"filters"`
+	PhotoCount    int          `json:"photoCount"`
+	CreatedAt     string       `json:"createdAt"`
+	UpdatedAt     string       `json:"updatedAt"`
+}
+
+// Lists the user's saved filters by name, each counted in the given curator's view
+func GetSavedFilters(userID, curationUserID int64) ([]SavedFilter, error) {
+	query := `
+		SELECT
+			saved_filter_id, name, scope, sort, filters, created_at, updated_at
+		FROM
+			saved_filters
+		WHERE
+			user_id = ?
+		ORDER BY
+			name COLLATE NOCASE
+	`
+
+	rows, err := sqlite.DB.Query(query, userID)
+	if err != nil {
+		err = fmt.Errorf("error querying saved filters: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	savedFilters := []SavedFilter{}
+	for rows.Next() {
+		savedFilter, err := scanSavedFilter(rows)
+		if err != nil {
+			return nil, err
+		}
+		savedFilters = append(savedFilters, *savedFilter)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating saved filters: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	source := curationSource(curationUserID)
+	for i := range savedFilters {
+		savedFilters[i].PhotoCount = countSavedFilterPhotos(&savedFilters[i], source)
+	}
+
+	return savedFilters, nil
+}
+
+func GetSavedFilterByID(savedFilterID, userID int64) (*SavedFilter, error) {
+	query := `
+		SELECT
+			saved_filter_id, name, scope, sort, filters, created_at, updated_at
+		FROM
+			saved_filters
+		WHERE
+			saved_filter_id = ? AND user_id = ?
+	`
+
+	savedFilter, err := scanSavedFilter(sqlite.DB.QueryRow(query, savedFilterID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedFilterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return savedFilter, nil
+}
+
+func CreateSavedFilter(userID int64, savedFilter SavedFilter) (*SavedFilter, error) {
+	filtersJSON, err := json.Marshal(savedFilter.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding saved filter: %w", err)
+	}
+
+	query := `
+		INSERT INTO saved_filters (user_id, name, scope, sort, filters)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := sqlite.DB.Exec(query, userID, savedFilter.Name, savedFilter.Scope, savedFilter.Sort, string(filtersJSON))
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, ErrSavedFilterNameTaken
+		}
+		err = fmt.Errorf("error creating saved filter: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	savedFilterID, _ := result.LastInsertId()
+	return GetSavedFilterByID(savedFilterID, userID)
+}
+
+func UpdateSavedFilter(savedFilterID, userID int64, savedFilter SavedFilter) (*SavedFilter, error) {
+	filtersJSON, err := json.Marshal(savedFilter.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding saved filter: %w", err)
+	}
+
+	query := `
+		UPDATE saved_filters
+		SET name = ?, scope = ?, sort = ?, filters = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE saved_filter_id = ? AND user_id = ?
+	`
+
+	result, err := sqlite.DB.Exec(query, savedFilter.Name, savedFilter.Scope, savedFilter.Sort, string(filtersJSON), savedFilterID, userID)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, ErrSavedFilterNameTaken
+		}
+		err = fmt.Errorf("error updating saved filter: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrSavedFilterNotFound
+	}
+
+	return GetSavedFilterByID(savedFilterID, userID)
+}
+
+func DeleteSavedFilter(savedFilterID, userID int64) error {
+	result, err := sqlite.DB.Exec(`DELETE FROM saved_filters WHERE saved_filter_id = ? AND user_id = ?`, savedFilterID, userID)
+	if err != nil {
+		err = fmt.Errorf("error deleting saved filter: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrSavedFilterNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSavedFilter(row rowScanner) (*SavedFilter, error) {
+	var savedFilter SavedFilter
+	var filtersJSON string
+
+	err := row.Scan(&savedFilter.SavedFilterID, &savedFilter.Name, &savedFilter.Scope, &savedFilter.Sort, &filtersJSON, &savedFilter.CreatedAt, &savedFilter.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		err = fmt.Errorf("error scanning saved filter: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(filtersJSON), &savedFilter.Filters); err != nil {
+		err = fmt.Errorf("error decoding saved filter %d: %w", savedFilter.SavedFilterID, err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &savedFilter, nil
+}
+
+func countSavedFilterPhotos(savedFilter *SavedFilter, source string) int {
+	isCurated, isTrashed, _ := scopeFlags(savedFilter.Scope)
+	whereClause, args := withFilters(listingWhereClause(isCurated, isTrashed), nil, &savedFilter.Filters)
+	return getCount(source, whereClause, args...)
+}
+
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
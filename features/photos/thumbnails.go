@@ -1,14 +1,13 @@
 package photos
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"riffle/commons/media"
 	"riffle/commons/utils"
-	"strings"
 )
 
 type ThumbnailRebuildResponse struct {
@@ -17,31 +16,52 @@ type ThumbnailRebuildResponse struct {
 }
 
 func HandleServeThumbnail(w http.ResponseWriter, r *http.Request) {
-	encodedPath := r.URL.Query().Get("path")
-	if encodedPath == "" {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_PATH", "Path parameter required")
+	thumbnailPath, ok := resolveThumbnailPath(w, r)
+	if !ok {
 		return
 	}
 
-	decodedPath, err := base64.URLEncoding.DecodeString(encodedPath)
-	if err != nil {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PATH", "Invalid path encoding")
+	serveThumbnailFile(w, r, thumbnailPath, "image/jpeg")
+}
+
+func HandleServeVideoPreview(w http.ResponseWriter, r *http.Request) {
+	thumbnailPath, ok := resolveThumbnailPath(w, r)
+	if !ok {
 		return
 	}
 
-	filePath := string(decodedPath)
-	libraryPath := os.Getenv("LIBRARY_PATH")
-	thumbnailsPath := os.Getenv("THUMBNAILS_PATH")
+	serveThumbnailFile(w, r, media.GetVideoPreviewPath(thumbnailPath), "image/webp")
+}
 
-	if !strings.HasPrefix(filePath, libraryPath) {
-		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_PATH", "File not in library")
+func HandleServeVideoSprite(w http.ResponseWriter, r *http.Request) {
+	thumbnailPath, ok := resolveThumbnailPath(w, r)
+	if !ok {
 		return
 	}
 
-	thumbnailPath := strings.Replace(filePath, libraryPath, thumbnailsPath, 1)
-	ext := filepath.Ext(thumbnailPath)
-	thumbnailPath = strings.TrimSuffix(thumbnailPath, ext) + ".jpg"
+	serveThumbnailFile(w, r, media.GetVideoSpritePath(thumbnailPath), "image/jpeg")
+}
 
+func HandleServeVideoSpriteVTT(w http.ResponseWriter, r *http.Request) {
+	thumbnailPath, ok := resolveThumbnailPath(w, r)
+	if !ok {
+		return
+	}
+
+	serveThumbnailFile(w, r, media.GetVideoSpriteVTTPath(thumbnailPath), "text/vtt; charset=utf-8")
+}
+
+func resolveThumbnailPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	filePath, ok := resolvePhotoPath(w, r)
+	if !ok {
+		return "", false
+	}
+
+	return media.GetThumbnailPath(os.Getenv("LIBRARY_PATH"), os.Getenv("THUMBNAILS_PATH"), filePath), true
+}
+
+// Keeps a Cache-Control the caller already set, like serveFile
+func serveThumbnailFile(w http.ResponseWriter, r *http.Request, thumbnailPath, contentType string) {
 	thumbnailInfo, err := os.Stat(thumbnailPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -60,8 +80,10 @@ func HandleServeThumbnail(w http.ResponseWriter, r *http.Request) {
 	}
 	defer thumbnailFile.Close()
 
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Content-Type", contentType)
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}
 	http.ServeContent(w, r, filepath.Base(thumbnailPath), thumbnailInfo.ModTime(), thumbnailFile)
 }
 
@@ -40,10 +40,11 @@ func RebuildThumbnails(libraryPath, thumbnailsPath string) error {
 	failed := 0
 
 	for _, photo := range allPhotos {
-		thumbnailPath := media.GetThumbnailPath(libraryPath, thumbnailsPath, photo.FilePath)
+		photoPath := filepath.Join(libraryPath, photo.FilePath)
+		thumbnailPath := media.GetThumbnailPath(libraryPath, thumbnailsPath, photoPath)
 
-		if _, err := os.Stat(photo.FilePath); os.IsNotExist(err) {
-			slog.Warn("photo file does not exist, skipping", "path", photo.FilePath)
+		if _, err := os.Stat(photoPath); os.IsNotExist(err) {
+			slog.Warn("photo file does not exist, skipping", "path", photoPath)
 			failed++
 			completed++
 			continue
@@ -56,8 +57,8 @@ func RebuildThumbnails(libraryPath, thumbnailsPath string) error {
 			continue
 		}
 
-		if err := media.GenerateThumbnail(photo.FilePath, thumbnailPath, photo.Orientation, photo.IsVideo); err != nil {
-			slog.Error("failed to generate thumbnail", "photo", photo.FilePath, "error", err)
+		if err := media.GenerateThumbnail(photoPath, thumbnailPath, photo.Orientation, photo.IsVideo); err != nil {
+			slog.Error("failed to generate thumbnail", "photo", photoPath, "error", err)
 			failed++
 		}
 
@@ -0,0 +1,67 @@
+package photos
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"riffle/commons/cache"
+	"riffle/commons/exif"
+	"riffle/commons/utils"
+	"time"
+)
+
+// Real timezones range from UTC-12:00 to UTC+14:00
+const (
+	minUTCOffsetMinutes = -12 * 60
+	maxUTCOffsetMinutes = 14 * 60
+)
+
+type TimeShiftRequest struct {
+	PhotoIDs         []int64 `json:"photoIds"`
+	ShiftSeconds     int64   `json:"shiftSeconds"`
+	UTCOffsetMinutes *int    `json:"utcOffsetMinutes"` // Sets the timezone, keeping the wall clock time
+	WriteToFile      bool    `json:"writeToFile"`
+}
+
+func HandleShiftPhotoTimes(w http.ResponseWriter, r *http.Request) {
+	var req TimeShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if len(req.PhotoIDs) == 0 {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "Photo IDs are required")
+		return
+	}
+
+	if req.ShiftSeconds == 0 && req.UTCOffsetMinutes == nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "MISSING_DATA", "A time shift or a timezone is required")
+		return
+	}
+
+	if req.UTCOffsetMinutes != nil && (*req.UTCOffsetMinutes < minUTCOffsetMinutes || *req.UTCOffsetMinutes > maxUTCOffsetMinutes) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_TIMEZONE", "Timezone must be between UTC-12:00 and UTC+14:00")
+		return
+	}
+
+	if req.WriteToFile && !exif.CanWriteMetadata() {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "WRITE_UNSUPPORTED", "Writing to files needs exiftool, which isn't installed")
+		return
+	}
+
+	shift := time.Duration(req.ShiftSeconds) * time.Second
+	result, err := ShiftPhotoTimes(req.PhotoIDs, shift, req.UTCOffsetMinutes, req.WriteToFile, os.Getenv("LIBRARY_PATH"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "TIME_SHIFT_ERROR", "Failed to shift photo times")
+		return
+	}
+
+	if result.Updated > 0 {
+		cache.InvalidateOnDateChange()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
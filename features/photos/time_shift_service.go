@@ -0,0 +1,206 @@
+package photos
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"riffle/commons/exif"
+	"riffle/commons/hash"
+	"riffle/commons/sqlite"
+	"riffle/commons/utils"
+	"strings"
+	"time"
+)
+
+type TimeShiftResult struct {
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"` // Photos without a capture time
+	Failed  int `json:"failed"`  // Photos whose file couldn't be written, left unchanged
+}
+
+type photoCaptureTime struct {
+	PhotoID       int64
+	FilePath      string
+	IsVideo       bool
+	DateTime      sql.NullString
+	LocalDateTime sql.NullString
+	UTCOffset     sql.NullInt64
+}
+
+// Corrects the capture time of photos taken with a wrong camera clock. The shift moves the time, then the
+// offset, when given, sets the timezone keeping the wall clock time. With writeToFile the corrected time is
+// also written into each file; a photo whose file can't be written keeps its old time.
+func ShiftPhotoTimes(photoIDs []int64, shift time.Duration, utcOffsetMinutes *int, writeToFile bool, libraryPath string) (*TimeShiftResult, error) {
+	photos, err := getPhotoCaptureTimes(photoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TimeShiftResult{Skipped: len(photoIDs) - len(photos)}
+
+	for _, photo := range photos {
+		captureTime := currentCaptureTime(photo)
+		if captureTime == nil {
+			result.Skipped++
+			continue
+		}
+
+		captureTime.Time = captureTime.Time.Add(shift)
+		if utcOffsetMinutes != nil {
+			t := captureTime.Time
+			captureTime.Time = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.FixedZone("", *utcOffsetMinutes*60))
+			captureTime.HasOffset = true
+		}
+
+		if writeToFile {
+			if err := writeCaptureTimeToFile(photo, *captureTime, libraryPath); err != nil {
+				slog.Error("failed to write capture time to file", "photoId", photo.PhotoID, "error", err)
+				result.Failed++
+				continue
+			}
+		}
+
+		if err := updatePhotoCaptureTime(photo.PhotoID, *captureTime); err != nil {
+			return nil, err
+		}
+		result.Updated++
+	}
+
+	slog.Info("shifted photo times", "updated", result.Updated, "skipped", result.Skipped, "failed", result.Failed)
+
+	return result, nil
+}
+
+func getPhotoCaptureTimes(photoIDs []int64) ([]photoCaptureTime, error) {
+	if len(photoIDs) == 0 {
+		return []photoCaptureTime{}, nil
+	}
+
+	placeholders := make([]string, len(photoIDs))
+	args := make([]any, len(photoIDs))
+	for i, photoID := range photoIDs {
+		placeholders[i] = "?"
+		args[i] = photoID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT photo_id, file_path, is_video, date_time, local_date_time, utc_offset
+		FROM photos
+		WHERE photo_id IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying photo capture times: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []photoCaptureTime{}
+	for rows.Next() {
+		var p photoCaptureTime
+		if err := rows.Scan(&p.PhotoID, &p.FilePath, &p.IsVideo, &p.DateTime, &p.LocalDateTime, &p.UTCOffset); err != nil {
+			err = fmt.Errorf("error scanning photo capture time: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		photos = append(photos, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating photo capture times: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// Rebuilds the capture time from the stored columns. Without a recorded offset, the offset is the one
+// between the stored UTC and wall clock times, i.e. the timezone import assumed.
+func currentCaptureTime(photo photoCaptureTime) *utils.CaptureTime {
+	if !photo.LocalDateTime.Valid || !photo.DateTime.Valid {
+		return nil
+	}
+
+	local, err := time.Parse(utils.LocalDateTimeFormat, photo.LocalDateTime.String)
+	if err != nil {
+		return nil
+	}
+
+	offsetSeconds := 0
+	if photo.UTCOffset.Valid {
+		offsetSeconds = int(photo.UTCOffset.Int64) * 60
+	} else {
+		utc := utils.ParseDateTime(photo.DateTime.String)
+		if utc == nil {
+			return nil
+		}
+		offsetSeconds = int(local.Sub(*utc).Seconds())
+	}
+
+	return &utils.CaptureTime{
+		Time:      time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.FixedZone("", offsetSeconds)),
+		HasOffset: photo.UTCOffset.Valid,
+	}
+}
+
+// Writing changes the file, so its hash and size are refreshed. The modification time is kept.
+func writeCaptureTimeToFile(photo photoCaptureTime, captureTime utils.CaptureTime, libraryPath string) error {
+	filePath, err := utils.ResolveLibraryPath(libraryPath, photo.FilePath)
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading file info: %w", err)
+	}
+
+	if err := exif.WriteCaptureTime(filePath, captureTime.Time, captureTime.HasOffset, photo.IsVideo); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(filePath, time.Now(), fileInfo.ModTime()); err != nil {
+		slog.Warn("failed to restore file modification time", "file", filePath, "error", err)
+	}
+
+	sha256Hash, err := hash.ComputeSHA256(filePath)
+	if err != nil {
+		return err
+	}
+
+	newFileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading file info: %w", err)
+	}
+
+	query := `UPDATE photos SET sha256_hash = ?, file_size = ?, updated_at = CURRENT_TIMESTAMP WHERE photo_id = ?`
+	if _, err := sqlite.DB.Exec(query, sha256Hash, newFileInfo.Size(), photo.PhotoID); err != nil {
+		err = fmt.Errorf("error updating photo hash: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Marks the time as set by hand, so re-reading the metadata keeps it
+func updatePhotoCaptureTime(photoID int64, captureTime utils.CaptureTime) error {
+	query := `
+		UPDATE photos
+		SET date_time = ?, local_date_time = ?, utc_offset = ?, is_time_manual = 1, updated_at = CURRENT_TIMESTAMP
+		WHERE photo_id = ?
+	`
+
+	_, err := sqlite.DB.Exec(query, captureTime.UTC(), captureTime.LocalDateTime(), captureTime.UTCOffset(), photoID)
+	if err != nil {
+		err = fmt.Errorf("error updating photo capture time: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
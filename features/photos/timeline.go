@@ -0,0 +1,87 @@
+package photos
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"riffle/commons/utils"
+)
+
+const (
+	ScopeLibrary   = "library"
+	ScopeUncurated = "uncurated"
+	ScopeTrash     = "trash"
+)
+
+// Which listing a scope is: the library, the photos still to curate, or the trash
+func scopeFlags(scope string) (isCurated, isTrashed, ok bool) {
+	switch scope {
+	case "", ScopeLibrary:
+		return true, false, true
+	case ScopeUncurated:
+		return false, false, true
+	case ScopeTrash:
+		return false, true, true
+	}
+	return false, false, false
+}
+
+// Reads which listing a timeline is for
+func parseTimelineScope(r *http.Request) (isCurated, isTrashed, ok bool) {
+	return scopeFlags(r.URL.Query().Get("scope"))
+}
+
+func HandleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	isCurated, isTrashed, ok := parseTimelineScope(r)
+	if !ok {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SCOPE", "Scope must be library, uncurated or trash")
+		return
+	}
+
+	filters, err := ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	timeline, err := GetTimeline(isCurated, isTrashed, ParseCurationUser(r), filters)
+	if err != nil {
+		slog.Error("failed to get timeline", "error", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch timeline")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(timeline)
+}
+
+func HandleGetTimelinePosition(w http.ResponseWriter, r *http.Request) {
+	isCurated, isTrashed, ok := parseTimelineScope(r)
+	if !ok {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SCOPE", "Scope must be library, uncurated or trash")
+		return
+	}
+
+	filters, err := ParseFiltersFromQuery(r)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_FILTERS", err.Error())
+		return
+	}
+
+	position, err := GetTimelinePosition(r.URL.Query().Get("date"), isCurated, isTrashed, ParseCurationUser(r), filters)
+	if errors.Is(err, ErrInvalidTimelineDate) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_DATE", "Date must be a year, month or day such as 2019, 2019-03 or 2019-03-15")
+		return
+	}
+	if err != nil {
+		slog.Error("failed to get timeline position", "error", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to find the date")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(position)
+}
@@ -0,0 +1,169 @@
+package photos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"time"
+)
+
+var ErrInvalidTimelineDate = errors.New("invalid timeline date")
+
+type TimelinePeriod struct {
+	Period string `json:"period"` // 2019, 2019-03 or 2019-03-15
+	Count  int    `json:"count"`
+	Offset int    `json:"offset"` // Photos listed before the period, so the rail can be laid out and jumped by offset
+}
+
+// Periods are newest first like the listing, with undated photos counted apart since they sort last
+type Timeline struct {
+	Years        []TimelinePeriod `json:"years"`
+	Months       []TimelinePeriod `json:"months"`
+	Days         []TimelinePeriod `json:"days"`
+	UndatedCount int              `json:"undatedCount"`
+	TotalRecords int              `json:"totalRecords"`
+}
+
+// Where a period starts in the listing. The cursor is the after cursor of the page starting there, empty
+// when that's the first page.
+type TimelinePosition struct {
+	Cursor string `json:"cursor,omitempty"`
+	Offset int    `json:"offset"`
+}
+
+// Counts per day the same way pages group them, then rolls the days up into months and years
+func GetTimeline(isCurated, isTrashed bool, curationUserID int64, filters *PhotoFilters) (*Timeline, error) {
+	whereClause, args := withFilters(listingWhereClause(isCurated, isTrashed), nil, filters)
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS day_date,
+			COUNT(*) AS photo_count
+		FROM
+			%s
+		%s
+		GROUP BY
+			day_date
+		ORDER BY
+			day_date DESC
+	`, pageDayColumn, curationSource(curationUserID), whereClause)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying timeline: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	timeline := &Timeline{
+		Years:  []TimelinePeriod{},
+		Months: []TimelinePeriod{},
+		Days:   []TimelinePeriod{},
+	}
+
+	for rows.Next() {
+		var day string
+		var count int
+		if err := rows.Scan(&day, &count); err != nil {
+			err = fmt.Errorf("error scanning timeline day: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+
+		if day == "" {
+			timeline.UndatedCount = count
+			timeline.TotalRecords += count
+			continue
+		}
+
+		timeline.Days = append(timeline.Days, TimelinePeriod{Period: day, Count: count, Offset: timeline.TotalRecords})
+		timeline.Months = addToPeriod(timeline.Months, day[:7], count, timeline.TotalRecords)
+		timeline.Years = addToPeriod(timeline.Years, day[:4], count, timeline.TotalRecords)
+		timeline.TotalRecords += count
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("error iterating timeline: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return timeline, nil
+}
+
+// Days come newest first, so a period is always the last one added or a new one
+func addToPeriod(periods []TimelinePeriod, period string, count, offset int) []TimelinePeriod {
+	if len(periods) > 0 && periods[len(periods)-1].Period == period {
+		periods[len(periods)-1].Count += count
+		return periods
+	}
+	return append(periods, TimelinePeriod{Period: period, Count: count, Offset: offset})
+}
+
+// Resolves a year, month or day to the newest first page starting at its newest photo, or at the first older one
+// when the period itself has no photos
+func GetTimelinePosition(date string, isCurated, isTrashed bool, curationUserID int64, filters *PhotoFilters) (*TimelinePosition, error) {
+	_, lastDay, err := periodBounds(date)
+	if err != nil {
+		return nil, err
+	}
+
+	whereClause, args := withFilters(listingWhereClause(isCurated, isTrashed), nil, filters)
+	whereClause += fmt.Sprintf(" AND %s > ?", pageDayColumn)
+	args = append(args, lastDay)
+
+	source := curationSource(curationUserID)
+	position := &TimelinePosition{
+		Offset: getCount(source, whereClause, args...),
+	}
+
+	// The oldest photo after the period is the last one on the page before it
+	listing := getListingSort(SortNewest)
+	query := fmt.Sprintf(`
+		SELECT
+			%s
+		FROM
+			%s
+		%s
+		ORDER BY
+			%s
+		LIMIT 1
+	`, listing.selectKeyColumns(), source, whereClause, listing.orderBy(true))
+
+	k := make(pageKey, len(listing.columns))
+	dest := make([]any, len(k))
+	for i := range k {
+		dest[i] = &k[i]
+	}
+	err = sqlite.DB.QueryRow(query, args...).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return position, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("error querying timeline position: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	position.Cursor = encodeCursor(listing.name, k)
+
+	return position, nil
+}
+
+// Returns the first and last day of a year, month or day. Days are compared as text, so the last day of
+// a month only needs to sort after all of its days.
+func periodBounds(date string) (string, string, error) {
+	if _, err := time.Parse("2006-01-02", date); err == nil {
+		return date, date, nil
+	}
+	if _, err := time.Parse("2006-01", date); err == nil {
+		return date + "-01", date + "-31", nil
+	}
+	if _, err := time.Parse("2006", date); err == nil {
+		return date + "-01-01", date + "-12-31", nil
+	}
+	return "", "", ErrInvalidTimelineDate
+}
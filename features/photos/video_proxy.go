@@ -0,0 +1,58 @@
+package photos
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"riffle/commons/media"
+	"riffle/commons/utils"
+	"strconv"
+)
+
+func HandleServeVideo(w http.ResponseWriter, r *http.Request) {
+	filePath, ok := resolvePhotoPath(w, r)
+	if !ok {
+		return
+	}
+
+	libraryPath := os.Getenv("LIBRARY_PATH")
+	thumbnailsPath := os.Getenv("THUMBNAILS_PATH")
+
+	if !media.IsVideoFile(filePath) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "NOT_VIDEO", "File is not a video")
+		return
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "File not found")
+			return
+		}
+		utils.SendErrorResponse(w, http.StatusForbidden, "ACCESS_DENIED", "Cannot access file")
+		return
+	}
+
+	photoID, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	playablePath, err := GetPlayableVideoPath(photoID, filePath, libraryPath, thumbnailsPath)
+	if errors.Is(err, ErrVideoProxyPending) {
+		// The player retries until the proxy is ready
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Retry-After", "5")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"status": "transcoding"})
+		return
+	}
+	if err != nil {
+		slog.Error("failed to prepare playable video", "path", filePath, "error", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "TRANSCODE_ERROR", "Failed to prepare video for playback")
+		return
+	}
+
+	contentType := media.GetContentType(filepath.Ext(playablePath))
+	serveFile(w, r, playablePath, contentType)
+}
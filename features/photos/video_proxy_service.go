@@ -0,0 +1,127 @@
+package photos
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"riffle/commons/media"
+	"sync"
+	"time"
+)
+
+var ErrVideoProxyPending = errors.New("video proxy is being generated")
+
+var (
+	// Videos imported without their codecs, probed once per run instead of on every range request
+	probedVideos sync.Map
+
+	// Proxies queued or being generated, so each video is transcoded once however many requests ask for it
+	pendingProxies sync.Map
+
+	// Sources ffmpeg couldn't transcode, so requests fail fast instead of queueing the same transcode again
+	failedProxies sync.Map
+
+	// One transcode at a time, as each ffmpeg process takes all the CPU it can get
+	proxySlot = make(chan struct{}, 1)
+)
+
+// A failed transcode is retried after a backoff that doubles with each attempt, in case ffmpeg failed for a
+// passing reason like a full disk, and straight away once the source file changes
+const (
+	proxyRetryMinBackoff = time.Minute
+	proxyRetryMaxBackoff = time.Hour
+)
+
+type proxyFailure struct {
+	err           error
+	failedAt      time.Time
+	attempts      int
+	sourceModTime time.Time
+}
+
+func (f proxyFailure) canRetry(videoPath string) bool {
+	if info, err := os.Stat(videoPath); err == nil && !info.ModTime().Equal(f.sourceModTime) {
+		return true
+	}
+
+	backoff := proxyRetryMinBackoff << min(f.attempts-1, 6)
+	return time.Since(f.failedAt) >= min(backoff, proxyRetryMaxBackoff)
+}
+
+// Returns the path of a file the browser can play: the original if it is already browser friendly, or its
+// proxy. A missing proxy is generated in the background and ErrVideoProxyPending returned until it's ready.
+func GetPlayableVideoPath(photoID int64, videoPath, libraryPath, thumbnailsPath string) (string, error) {
+	info, err := getVideoInfo(photoID, videoPath)
+	if err != nil {
+		return "", err
+	}
+
+	if media.IsBrowserPlayable(videoPath, info) {
+		return videoPath, nil
+	}
+
+	proxyPath := media.GetVideoProxyPath(libraryPath, thumbnailsPath, videoPath)
+	if _, err := os.Stat(proxyPath); err == nil {
+		return proxyPath, nil
+	}
+
+	if failure, failed := failedProxies.Load(proxyPath); failed && !failure.(proxyFailure).canRetry(videoPath) {
+		return "", failure.(proxyFailure).err
+	}
+
+	queueVideoProxy(videoPath, proxyPath, info)
+	return "", ErrVideoProxyPending
+}
+
+func getVideoInfo(photoID int64, videoPath string) (*media.VideoInfo, error) {
+	info, err := GetPhotoVideoCodecs(photoID)
+	if err != nil || info != nil {
+		return info, err
+	}
+
+	if probed, ok := probedVideos.Load(videoPath); ok {
+		return probed.(*media.VideoInfo), nil
+	}
+
+	info, err = media.ProbeVideo(videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+	probedVideos.Store(videoPath, info)
+
+	return info, nil
+}
+
+func queueVideoProxy(videoPath, proxyPath string, info *media.VideoInfo) {
+	if _, pending := pendingProxies.LoadOrStore(proxyPath, true); pending {
+		return
+	}
+
+	go func() {
+		defer pendingProxies.Delete(proxyPath)
+
+		proxySlot <- struct{}{}
+		defer func() { <-proxySlot }()
+
+		slog.Info("generating video proxy", "video", videoPath, "videoCodec", info.VideoCodec, "audioCodec", info.AudioCodec)
+		if err := media.GenerateVideoProxy(videoPath, proxyPath); err != nil {
+			recordProxyFailure(videoPath, proxyPath, err)
+			return
+		}
+		failedProxies.Delete(proxyPath)
+	}()
+}
+
+func recordProxyFailure(videoPath, proxyPath string, err error) {
+	failure := proxyFailure{err: err, failedAt: time.Now(), attempts: 1}
+	if previous, ok := failedProxies.Load(proxyPath); ok {
+		failure.attempts = previous.(proxyFailure).attempts + 1
+	}
+	if info, statErr := os.Stat(videoPath); statErr == nil {
+		failure.sourceModTime = info.ModTime()
+	}
+
+	slog.Warn("video proxy failed", "video", videoPath, "attempts", failure.attempts, "error", err)
+	failedProxies.Store(proxyPath, failure)
+}
@@ -0,0 +1,313 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import FormSection from '../../commons/components/FormSection.jsx';
+import { Table, TableHeader, TableHeaderCell, TableBody, TableRow, TableCell } from '../../commons/components/Table.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import formatCount from '../../commons/utils/formatCount.js';
+import formatFileSize from '../../commons/utils/formatFileSize.js';
+import formatDateTime from '../../commons/utils/formatDateTime.js';
+
+const { useState, useEffect, useRef } = React;
+
+const emptyPlace = { name: '', latitude: '', longitude: '', radiusMeters: '500' };
+
+export default function GeocodingPane() {
+  const [status, setStatus] = useState(null);
+  const [places, setPlaces] = useState([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [form, setForm] = useState(emptyPlace);
+  const [editingPlaceId, setEditingPlaceId] = useState(null);
+  const [isSaving, setIsSaving] = useState(false);
+  const pollingIntervalRef = useRef(null);
+
+  useEffect(() => {
+    async function loadAll() {
+      await Promise.all([loadStatus(), loadPlaces()]);
+      setIsLoading(false);
+    }
+
+    loadAll();
+
+    return () => {
+      if (pollingIntervalRef.current) {
+        clearInterval(pollingIntervalRef.current);
+      }
+    };
+  }, []);
+
+  async function loadStatus() {
+    try {
+      const result = await ApiClient.getGeocodingStatus();
+      setStatus(result);
+      if (result.isReloading) {
+        startPollingStatus();
+      }
+    } catch (error) {
+      console.error('Failed to load geocoding status:', error);
+    }
+  }
+
+  async function loadPlaces() {
+    try {
+      const result = await ApiClient.getPlaces();
+      setPlaces(result);
+    } catch (error) {
+      console.error('Failed to load places:', error);
+    }
+  }
+
+  function startPollingStatus() {
+    if (pollingIntervalRef.current) {
+      return;
+    }
+
+    pollingIntervalRef.current = setInterval(async () => {
+      try {
+        const result = await ApiClient.getGeocodingStatus();
+        setStatus(result);
+        if (!result.isReloading) {
+          clearInterval(pollingIntervalRef.current);
+          pollingIntervalRef.current = null;
+        }
+      } catch (error) {
+        console.error('Failed to fetch geocoding status:', error);
+        clearInterval(pollingIntervalRef.current);
+        pollingIntervalRef.current = null;
+      }
+    }, 1000);
+  }
+
+  async function handleReloadClick() {
+    try {
+      await ApiClient.reloadGeocoding();
+      setStatus({ ...status, isReloading: true, lastReloadError: '' });
+      startPollingStatus();
+    } catch (error) {
+      console.error('Failed to reload GeoNames data:', error);
+    }
+  }
+
+  function handleFormChange(field, value) {
+    setForm({ ...form, [field]: value });
+  }
+
+  function handleEditClick(place) {
+    setEditingPlaceId(place.placeId);
+    setForm({
+      name: place.name,
+      latitude: String(place.latitude),
+      longitude: String(place.longitude),
+      radiusMeters: String(place.radiusMeters)
+    });
+  }
+
+  function handleCancelEdit() {
+    setEditingPlaceId(null);
+    setForm(emptyPlace);
+  }
+
+  async function handleSavePlace() {
+    const place = {
+      name: form.name.trim(),
+      latitude: parseFloat(form.latitude),
+      longitude: parseFloat(form.longitude),
+      radiusMeters: parseFloat(form.radiusMeters)
+    };
+
+    if (!place.name || isNaN(place.latitude) || isNaN(place.longitude) || isNaN(place.radiusMeters)) {
+      showToast('Enter a name, latitude, longitude and radius');
+      return;
+    }
+
+    setIsSaving(true);
+    try {
+      if (editingPlaceId) {
+        await ApiClient.updatePlace(editingPlaceId, place);
+        showToast('Place updated');
+      } else {
+        await ApiClient.createPlace(place);
+        showToast('Place added');
+      }
+      handleCancelEdit();
+      loadPlaces();
+    } catch (error) {
+      console.error('Failed to save place:', error);
+    } finally {
+      setIsSaving(false);
+    }
+  }
+
+  async function handleDeleteClick(place) {
+    if (!window.confirm(`Delete ${place.name}? Its photos go back to their GeoNames city.`)) {
+      return;
+    }
+
+    try {
+      await ApiClient.deletePlace(place.placeId);
+      showToast('Place deleted');
+      if (editingPlaceId === place.placeId) {
+        handleCancelEdit();
+      }
+      loadPlaces();
+    } catch (error) {
+      console.error('Failed to delete place:', error);
+    }
+  }
+
+  if (isLoading) {
+    return (
+      <div className="settings-tab-content">
+        <p>Loading geocoding...</p>
+      </div>
+    );
+  }
+
+  let statusSection = null;
+  if (status) {
+    const fileRows = status.files.map(file => {
+      let fileState = 'Missing';
+      if (file.isPresent && file.isChanged) {
+        fileState = 'Changed since load';
+      } else if (file.isPresent && file.loadedAt) {
+        fileState = 'Loaded';
+      } else if (file.isPresent) {
+        fileState = 'Not loaded';
+      } else if (file.loadedAt) {
+        fileState = 'Removed since load';
+      }
+
+      return (
+        <TableRow key={file.name}>
+          <TableCell>{file.name}</TableCell>
+          <TableCell>{file.isPresent ? formatFileSize(file.size) : '-'}</TableCell>
+          <TableCell>{formatDateTime(file.modifiedAt) || '-'}</TableCell>
+          <TableCell>{formatDateTime(file.loadedAt) || '-'}</TableCell>
+          <TableCell>{fileState}</TableCell>
+        </TableRow>
+      );
+    });
+
+    let reloadMessage = null;
+    if (status.isReloading) {
+      reloadMessage = <div className="progress-text">Reloading GeoNames data...</div>;
+    } else if (status.lastReloadError) {
+      reloadMessage = <div className="progress-text">Reload failed: {status.lastReloadError}</div>;
+    }
+
+    const language = status.language || 'default names';
+    const summary = `${formatCount(status.cities, 0)} cities, ${formatCount(status.localizedNames, 0)} localized names (${language}), ${formatCount(status.places, 0)} custom places`;
+
+    statusSection = (
+      <FormSection
+        title="GeoNames Data"
+        description={`Loaded from ${status.path}. Reloading reads the files again and then updates the location of every photo with GPS.`}
+      >
+        <p>{summary}</p>
+        <Table>
+          <TableHeader>
+            <TableHeaderCell>File</TableHeaderCell>
+            <TableHeaderCell>Size</TableHeaderCell>
+            <TableHeaderCell>Modified</TableHeaderCell>
+            <TableHeaderCell>Loaded</TableHeaderCell>
+            <TableHeaderCell>Status</TableHeaderCell>
+          </TableHeader>
+          <TableBody>
+            {fileRows}
+          </TableBody>
+        </Table>
+        <Button onClick={handleReloadClick} isLoading={status.isReloading}>
+          {status.isReloading ? 'Reloading...' : 'Reload Data'}
+        </Button>
+        {reloadMessage}
+      </FormSection>
+    );
+  }
+
+  const placeRows = places.map(place => {
+    return (
+      <TableRow key={place.placeId}>
+        <TableCell>{place.name}</TableCell>
+        <TableCell>{place.latitude.toFixed(5)}, {place.longitude.toFixed(5)}</TableCell>
+        <TableCell>{formatCount(place.radiusMeters, 0)} m</TableCell>
+        <TableCell>{formatCount(place.photoCount, 0)}</TableCell>
+        <TableCell>
+          <Button variant="ghost" onClick={() => handleEditClick(place)}>Edit</Button>
+          <Button variant="ghost" onClick={() => handleDeleteClick(place)}>Delete</Button>
+        </TableCell>
+      </TableRow>
+    );
+  });
+
+  let placesTable = <p>No custom places yet.</p>;
+  if (places.length > 0) {
+    placesTable = (
+      <Table>
+        <TableHeader>
+          <TableHeaderCell>Name</TableHeaderCell>
+          <TableHeaderCell>Center</TableHeaderCell>
+          <TableHeaderCell>Radius</TableHeaderCell>
+          <TableHeaderCell>Photos</TableHeaderCell>
+          <TableHeaderCell></TableHeaderCell>
+        </TableHeader>
+        <TableBody>
+          {placeRows}
+        </TableBody>
+      </Table>
+    );
+  }
+
+  let cancelButton = null;
+  if (editingPlaceId) {
+    cancelButton = <Button variant="ghost" onClick={handleCancelEdit}>Cancel</Button>;
+  }
+
+  return (
+    <div className="settings-tab-content">
+      <h3>Geocoding</h3>
+      <p>Photo locations come from the offline GeoNames data. Custom places such as "Home" or a favorite park take precedence over the nearest city.</p>
+
+      <div className="settings-form">
+        {statusSection}
+
+        <FormSection title="Custom Places" description="Photos within the radius of a place are tagged with it. When places overlap, the one with the nearest center wins.">
+          {placesTable}
+        </FormSection>
+
+        <FormSection title={editingPlaceId ? 'Edit Place' : 'Add Place'} description="Radius between 10 m and 100 km.">
+          <Input
+            id="place-name"
+            placeholder="Name"
+            value={form.name}
+            onChange={(e) => handleFormChange('name', e.target.value)}
+          />
+          <Input
+            id="place-latitude"
+            type="number"
+            placeholder="Latitude"
+            value={form.latitude}
+            onChange={(e) => handleFormChange('latitude', e.target.value)}
+          />
+          <Input
+            id="place-longitude"
+            type="number"
+            placeholder="Longitude"
+            value={form.longitude}
+            onChange={(e) => handleFormChange('longitude', e.target.value)}
+          />
+          <Input
+            id="place-radius"
+            type="number"
+            placeholder="Radius in meters"
+            value={form.radiusMeters}
+            onChange={(e) => handleFormChange('radiusMeters', e.target.value)}
+          />
+          <Button variant="primary" onClick={handleSavePlace} isLoading={isSaving}>
+            {editingPlaceId ? 'Save Place' : 'Add Place'}
+          </Button>
+          {cancelButton}
+        </FormSection>
+      </div>
+    </div>
+  );
+}
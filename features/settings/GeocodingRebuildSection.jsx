@@ -0,0 +1,114 @@
+import Button from '../../commons/components/Button.jsx';
+import ApiClient from '../../commons/http/ApiClient.js';
+import formatCount from '../../commons/utils/formatCount.js';
+import FormSection from '../../commons/components/FormSection.jsx';
+
+const { useState, useEffect, useRef } = React;
+
+export default function GeocodingRebuildSection() {
+  const [isProcessing, setIsProcessing] = useState(false);
+  const [progress, setProgress] = useState(null);
+  const pollingIntervalRef = useRef(null);
+
+  useEffect(() => {
+    async function checkOngoingRebuild() {
+      try {
+        const progressData = await ApiClient.getGeocodingRebuildProgress();
+        if (progressData.status === 'processing') {
+          setIsProcessing(true);
+          setProgress(progressData);
+          startPollingProgress();
+        }
+      } catch (error) {
+        console.error('Failed to check geocoding rebuild status', error);
+      }
+    }
+
+    checkOngoingRebuild();
+
+    return () => {
+      if (pollingIntervalRef.current) {
+        clearInterval(pollingIntervalRef.current);
+      }
+    };
+  }, []);
+
+  async function handleRebuildClick() {
+    setIsProcessing(true);
+    setProgress({ status: 'processing', percent: 0 });
+
+    try {
+      await ApiClient.rebuildGeocoding();
+      startPollingProgress();
+    } catch (error) {
+      console.error('Failed to start geocoding rebuild', error);
+      setIsProcessing(false);
+      setProgress(null);
+    }
+  }
+
+  function startPollingProgress() {
+    if (pollingIntervalRef.current) {
+      clearInterval(pollingIntervalRef.current);
+    }
+
+    pollingIntervalRef.current = setInterval(async () => {
+      try {
+        const progressData = await ApiClient.getGeocodingRebuildProgress();
+        setProgress(progressData);
+
+        // Idle means the job stopped before starting, e.g. the GeoNames data went missing
+        if (progressData.status === 'complete' || progressData.status === 'idle') {
+          clearInterval(pollingIntervalRef.current);
+          pollingIntervalRef.current = null;
+          setIsProcessing(false);
+          setTimeout(() => {
+            setProgress(null);
+          }, 3000);
+        }
+      } catch (error) {
+        console.error('Failed to fetch geocoding progress', error);
+        clearInterval(pollingIntervalRef.current);
+        pollingIntervalRef.current = null;
+        setIsProcessing(false);
+        setProgress(null);
+      }
+    }, 500);
+  }
+
+  const buttonText = isProcessing ? 'Rebuilding...' : 'Rebuild Locations';
+
+  let progressText = null;
+
+  if (progress) {
+    if (progress.status === 'processing') {
+      const completedText = formatCount(progress.completed, 0);
+      const totalText = formatCount(progress.total, 0);
+      const percent = progress.percent || 0;
+      progressText = `Processing ${completedText} / ${totalText} (${percent}%)`;
+    }
+
+    if (progress.status === 'complete') {
+      const resolvedText = formatCount(progress.resolved, 0);
+      const totalText = formatCount(progress.total, 0);
+      progressText = `Found places for ${resolvedText} of ${totalText} photos with GPS`;
+    }
+  }
+
+  let progressElement = null;
+  if (progressText) {
+    progressElement = <div className="progress-text">{progressText}</div>;
+  }
+
+  return (
+    <FormSection
+      title="Photo Locations"
+      description="Look up the city, county, state and country of every photo with GPS again. Useful after adding or updating the GeoNames data."
+    >
+      <Button onClick={handleRebuildClick} isLoading={isProcessing}>
+        {buttonText}
+      </Button>
+      {progressElement}
+    </FormSection>
+  );
+}
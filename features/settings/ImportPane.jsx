@@ -7,6 +7,7 @@ const { useState, useEffect } = React;
 export default function ImportPane() {
   const [importMode, setImportMode] = useState('copy');
   const [duplicateHandling, setDuplicateHandling] = useState('keep');
+  const [videoTranscodeOnImport, setVideoTranscodeOnImport] = useState('false');
   const [isLoading, setIsLoading] = useState(true);
 
   useEffect(() => {
@@ -18,6 +19,7 @@ export default function ImportPane() {
       const settings = await ApiClient.getSettings();
       setImportMode(settings.import_mode);
       setDuplicateHandling(settings.import_duplicate_handling);
+      setVideoTranscodeOnImport(settings.video_transcode_on_import);
     } catch (error) {
       console.error('Failed to load settings:', error);
     } finally {
@@ -47,6 +49,17 @@ export default function ImportPane() {
     }
   }
 
+  async function handleVideoTranscodeChange(newValue) {
+    const previousValue = videoTranscodeOnImport;
+    setVideoTranscodeOnImport(newValue);
+    try {
+      await ApiClient.updateSetting('video_transcode_on_import', newValue);
+    } catch (error) {
+      console.error('Failed to save setting:', error);
+      setVideoTranscodeOnImport(previousValue);
+    }
+  }
+
   if (isLoading) {
     return (
       <div className="settings-tab-content">
@@ -65,6 +78,11 @@ export default function ImportPane() {
     { value: 'delete', label: 'Delete' }
   ];
 
+  const videoTranscodeOptions = [
+    { value: 'false', label: 'On Demand' },
+    { value: 'true', label: 'During Import' }
+  ];
+
   let duplicateSection = null;
   if (importMode === 'move') {
     duplicateSection = (
@@ -97,6 +115,16 @@ export default function ImportPane() {
         />
       </FormSection>
       {duplicateSection}
+      <FormSection
+        title="Video Playback"
+        description="Videos the browser can't play (MKV, AVI, WMV, HEVC) are converted to H.264 MP4 playback copies stored alongside thumbnails. Convert them the first time they are played, or ahead of time during import."
+      >
+        <SegmentedControl
+          options={videoTranscodeOptions}
+          value={videoTranscodeOnImport}
+          onChange={handleVideoTranscodeChange}
+        />
+      </FormSection>
     </div>
   );
 }
@@ -1,4 +1,6 @@
 import ThumbnailRebuildSection from './ThumbnailRebuildSection.jsx';
+import GeocodingRebuildSection from './GeocodingRebuildSection.jsx';
+import MetadataRescanSection from './MetadataRescanSection.jsx';
 
 export default function LibraryPane() {
   return (
@@ -7,6 +9,8 @@ export default function LibraryPane() {
       <p>Rebuild and optimize your photo library's index and cached assets.</p>
 
       <ThumbnailRebuildSection />
+      <GeocodingRebuildSection />
+      <MetadataRescanSection />
     </div>
   );
 }
@@ -0,0 +1,169 @@
+import Button from '../../commons/components/Button.jsx';
+import ApiClient from '../../commons/http/ApiClient.js';
+import formatCount from '../../commons/utils/formatCount.js';
+import FormSection from '../../commons/components/FormSection.jsx';
+import SettingsInput from '../../commons/components/SettingsInput.jsx';
+
+const { useState, useEffect, useRef } = React;
+
+function formatValue(value) {
+  if (value === null || value === undefined) {
+    return '—';
+  }
+  if (typeof value === 'number' && !Number.isInteger(value)) {
+    return String(Math.round(value * 10000) / 10000);
+  }
+  return String(value);
+}
+
+export default function MetadataRescanSection() {
+  const [isProcessing, setIsProcessing] = useState(false);
+  const [progress, setProgress] = useState(null);
+  const [query, setQuery] = useState('');
+  const pollingIntervalRef = useRef(null);
+
+  useEffect(() => {
+    async function checkOngoingRescan() {
+      try {
+        const progressData = await ApiClient.getMetadataRescanProgress();
+        if (progressData.status === 'processing') {
+          setIsProcessing(true);
+          setProgress(progressData);
+          startPollingProgress();
+        }
+      } catch (error) {
+        console.error('Failed to check metadata rescan status', error);
+      }
+    }
+
+    checkOngoingRescan();
+
+    return () => {
+      if (pollingIntervalRef.current) {
+        clearInterval(pollingIntervalRef.current);
+      }
+    };
+  }, []);
+
+  async function handleRescanClick() {
+    setIsProcessing(true);
+    setProgress({ status: 'processing', percent: 0 });
+
+    try {
+      // The search syntax from the filter panel picks the photos, all of them when empty
+      const filters = query.trim() ? { query: query.trim() } : null;
+      await ApiClient.rescanMetadata(filters);
+      startPollingProgress();
+    } catch (error) {
+      console.error('Failed to start metadata rescan', error);
+      setIsProcessing(false);
+      setProgress(null);
+    }
+  }
+
+  function startPollingProgress() {
+    if (pollingIntervalRef.current) {
+      clearInterval(pollingIntervalRef.current);
+    }
+
+    pollingIntervalRef.current = setInterval(async () => {
+      try {
+        const progressData = await ApiClient.getMetadataRescanProgress();
+        setProgress(progressData);
+
+        // The report stays up until the next rescan, unlike the other rebuilds
+        if (progressData.status === 'complete' || progressData.status === 'idle') {
+          clearInterval(pollingIntervalRef.current);
+          pollingIntervalRef.current = null;
+          setIsProcessing(false);
+        }
+      } catch (error) {
+        console.error('Failed to fetch metadata rescan progress', error);
+        clearInterval(pollingIntervalRef.current);
+        pollingIntervalRef.current = null;
+        setIsProcessing(false);
+        setProgress(null);
+      }
+    }, 500);
+  }
+
+  const buttonText = isProcessing ? 'Re-reading...' : 'Re-read Metadata';
+
+  let progressText = null;
+  let reportElement = null;
+
+  if (progress) {
+    if (progress.status === 'processing') {
+      const completedText = formatCount(progress.completed, 0);
+      const totalText = formatCount(progress.total, 0);
+      const percent = progress.percent || 0;
+      progressText = `Processing ${completedText} / ${totalText} (${percent}%)`;
+    }
+
+    if (progress.status === 'complete') {
+      const updatedText = formatCount(progress.updated, 0);
+      const totalText = formatCount(progress.total, 0);
+      progressText = `Updated ${updatedText} of ${totalText} photos`;
+      if (progress.failed > 0) {
+        progressText += `, ${formatCount(progress.failed, 0)} couldn't be read`;
+      }
+
+      const fieldCounts = Object.entries(progress.fieldCounts || {})
+        .sort((a, b) => b[1] - a[1])
+        .map(([field, count]) => `${field} (${formatCount(count, 0)})`)
+        .join(', ');
+
+      const changeElements = (progress.changes || []).map(change => (
+        <li key={change.photoId}>
+          <div className="metadata-change-file">{change.filePath}</div>
+          {change.fields.map(field => (
+            <div key={field.field} className="metadata-change-field">
+              {field.field}: {formatValue(field.oldValue)} → {formatValue(field.newValue)}
+            </div>
+          ))}
+        </li>
+      ));
+
+      let moreText = null;
+      if (progress.updated > changeElements.length) {
+        moreText = <div className="progress-text">Showing the first {formatCount(changeElements.length, 0)} changed photos</div>;
+      }
+
+      if (changeElements.length > 0) {
+        reportElement = (
+          <div className="metadata-rescan-report">
+            <div className="progress-text">Changed fields: {fieldCounts}</div>
+            <ul className="metadata-change-list">{changeElements}</ul>
+            {moreText}
+          </div>
+        );
+      }
+    }
+  }
+
+  let progressElement = null;
+  if (progressText) {
+    progressElement = <div className="progress-text">{progressText}</div>;
+  }
+
+  return (
+    <FormSection
+      title="Photo Metadata"
+      description="Read the camera, date and location details of your photos from their files again, to pick up improvements in how metadata is read. Capture times and locations you've changed by hand are kept."
+    >
+      <SettingsInput
+        id="metadata-rescan-query"
+        label="Only photos matching"
+        type="text"
+        value={query}
+        onChange={e => setQuery(e.target.value)}
+        description={'A search such as camera:"X-T5" or date:2019, leave empty for all photos.'}
+      />
+      <Button onClick={handleRescanClick} isLoading={isProcessing}>
+        {buttonText}
+      </Button>
+      {progressElement}
+      {reportElement}
+    </FormSection>
+  );
+}
@@ -1,10 +1,12 @@
-import { ImportIcon, DatabaseIcon, ExportIcon, StackIcon } from '../../commons/components/Icon.jsx';
+import { ImportIcon, DatabaseIcon, ExportIcon, StackIcon, UsersIcon, MapPinIcon } from '../../commons/components/Icon.jsx';
 import Link, { navigateTo } from '../../commons/components/Link.jsx';
 import { useRouter } from '../../commons/components/Router.jsx';
 import ImportPane from './ImportPane.jsx';
 import LibraryPane from './LibraryPane.jsx';
 import ExportPane from './ExportPane.jsx';
 import BurstPane from './BurstPane.jsx';
+import GeocodingPane from './GeocodingPane.jsx';
+import UsersPane from './UsersPane.jsx';
 import './SettingsPage.css';
 
 const { useEffect } = React;
@@ -13,7 +15,9 @@ const tabs = [
   { id: 'import', path: '/settings/import', label: 'Import', icon: <ImportIcon className="settings-tab-icon" />, component: ImportPane },
   { id: 'library', path: '/settings/library', label: 'Library', icon: <DatabaseIcon className="settings-tab-icon" />, component: LibraryPane },
   { id: 'burst', path: '/settings/burst', label: 'Burst', icon: <StackIcon className="settings-tab-icon" />, component: BurstPane },
-  { id: 'export', path: '/settings/export', label: 'Export', icon: <ExportIcon className="settings-tab-icon" />, component: ExportPane }
+  { id: 'geocoding', path: '/settings/geocoding', label: 'Geocoding', icon: <MapPinIcon className="settings-tab-icon" />, component: GeocodingPane },
+  { id: 'export', path: '/settings/export', label: 'Export', icon: <ExportIcon className="settings-tab-icon" />, component: ExportPane },
+  { id: 'users', path: '/settings/users', label: 'Users', icon: <UsersIcon className="settings-tab-icon" />, component: UsersPane }
 ];
 
 export default function SettingsPage() {
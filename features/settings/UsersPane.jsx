@@ -0,0 +1,171 @@
+import ApiClient from '../../commons/http/ApiClient.js';
+import Button from '../../commons/components/Button.jsx';
+import Input from '../../commons/components/Input.jsx';
+import FormSection from '../../commons/components/FormSection.jsx';
+import SegmentedControl from '../../commons/components/SegmentedControl.jsx';
+import { Table, TableHeader, TableHeaderCell, TableBody, TableRow, TableCell } from '../../commons/components/Table.jsx';
+import { showToast } from '../../commons/components/Toast.jsx';
+import { useCurrentUser } from '../auth/AuthProvider.jsx';
+
+const { useState, useEffect } = React;
+
+const roleOptions = [
+  { value: 'viewer', label: 'Viewer' },
+  { value: 'curator', label: 'Curator' },
+  { value: 'admin', label: 'Admin' }
+];
+
+export default function UsersPane() {
+  const { user: currentUser } = useCurrentUser();
+  const [users, setUsers] = useState([]);
+  const [isLoading, setIsLoading] = useState(true);
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [role, setRole] = useState('viewer');
+  const [isCreating, setIsCreating] = useState(false);
+
+  useEffect(() => {
+    loadUsers();
+  }, []);
+
+  async function loadUsers() {
+    try {
+      const result = await ApiClient.getUsers();
+      setUsers(result);
+    } catch (error) {
+      console.error('Failed to load users:', error);
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  async function handleCreateUser() {
+    if (!username.trim() || !password) {
+      showToast('Enter a username and password');
+      return;
+    }
+
+    setIsCreating(true);
+    try {
+      await ApiClient.createUser(username.trim(), password, role);
+      showToast('User created');
+      setUsername('');
+      setPassword('');
+      setRole('viewer');
+      loadUsers();
+    } catch (error) {
+      console.error('Failed to create user:', error);
+    } finally {
+      setIsCreating(false);
+    }
+  }
+
+  async function handleRoleChange(user, newRole) {
+    try {
+      await ApiClient.updateUser(user.userId, { role: newRole });
+      loadUsers();
+    } catch (error) {
+      console.error('Failed to update user:', error);
+    }
+  }
+
+  async function handleResetPassword(user) {
+    const newPassword = window.prompt(`New password for ${user.username}`);
+    if (!newPassword) {
+      return;
+    }
+
+    try {
+      await ApiClient.updateUser(user.userId, { password: newPassword });
+      showToast('Password updated');
+    } catch (error) {
+      console.error('Failed to update password:', error);
+    }
+  }
+
+  async function handleDeleteUser(user) {
+    if (!window.confirm(`Delete ${user.username}?`)) {
+      return;
+    }
+
+    try {
+      await ApiClient.deleteUser(user.userId);
+      showToast('User deleted');
+      loadUsers();
+    } catch (error) {
+      console.error('Failed to delete user:', error);
+    }
+  }
+
+  if (isLoading) {
+    return (
+      <div className="settings-tab-content">
+        <p>Loading users...</p>
+      </div>
+    );
+  }
+
+  const rows = users.map(user => {
+    const isCurrentUser = user.userId === currentUser.userId;
+
+    let deleteButton = null;
+    if (!isCurrentUser) {
+      deleteButton = <Button variant="ghost" onClick={() => handleDeleteUser(user)}>Delete</Button>;
+    }
+
+    return (
+      <TableRow key={user.userId}>
+        <TableCell>{user.username}</TableCell>
+        <TableCell>
+          <SegmentedControl options={roleOptions} value={user.role} onChange={(newRole) => handleRoleChange(user, newRole)} />
+        </TableCell>
+        <TableCell>
+          <Button variant="ghost" onClick={() => handleResetPassword(user)}>Reset Password</Button>
+          {deleteButton}
+        </TableCell>
+      </TableRow>
+    );
+  });
+
+  return (
+    <div className="settings-tab-content">
+      <h3>Users</h3>
+      <p>Viewers can browse the library. Curators can also import, curate, manage albums and export. Admins can also change settings and manage users.</p>
+
+      <div className="settings-form">
+        <FormSection title="Accounts">
+          <Table>
+            <TableHeader>
+              <TableHeaderCell>Username</TableHeaderCell>
+              <TableHeaderCell>Role</TableHeaderCell>
+              <TableHeaderCell></TableHeaderCell>
+            </TableHeader>
+            <TableBody>
+              {rows}
+            </TableBody>
+          </Table>
+        </FormSection>
+
+        <FormSection title="Add User" description="Passwords must be at least 8 characters.">
+          <Input
+            id="new-username"
+            placeholder="Username"
+            value={username}
+            onChange={(e) => setUsername(e.target.value)}
+          />
+          <Input
+            id="new-password"
+            type="password"
+            placeholder="Password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+          />
+          <SegmentedControl options={roleOptions} value={role} onChange={setRole} />
+          <Button variant="primary" onClick={handleCreateUser} isLoading={isCreating}>
+            Add User
+          </Button>
+        </FormSection>
+      </div>
+    </div>
+  );
+}
@@ -117,6 +117,14 @@ func GetBurstDhashThreshold() (int, error) {
 	return threshold, nil
 }
 
+func GetVideoTranscodeOnImport() (bool, error) {
+	value, err := GetSetting("video_transcode_on_import")
+	if err != nil {
+		return false, err
+	}
+	return value == "true", nil
+}
+
 func HandleGetSettings(w http.ResponseWriter, r *http.Request) {
 	settings, err := GetAllSettings()
 	if err != nil {
@@ -210,6 +218,10 @@ func validate(key, value string) error {
 		if threshold < 0 || threshold > 64 {
 			return fmt.Errorf("burst_dhash_threshold must be between 0 and 64")
 		}
+	case "video_transcode_on_import":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("video_transcode_on_import must be 'true' or 'false'")
+		}
 	}
 	return nil
 }
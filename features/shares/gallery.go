@@ -0,0 +1,247 @@
+package shares
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"riffle/features/albums"
+	"riffle/features/auth"
+	"riffle/features/photos"
+	"strconv"
+)
+
+//go:embed gallery.html
+var galleryHTML string
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(galleryHTML))
+
+type galleryPage struct {
+	State         string // gallery, password, expired or not_found
+	AlbumName     string
+	BasePath      string
+	ExpiresAt     string
+	AllowDownload bool
+	Photos        []albums.AlbumPhoto
+	Error         string
+}
+
+type shareHandler func(w http.ResponseWriter, r *http.Request, share *Share)
+
+func HandleSharedGallery(w http.ResponseWriter, r *http.Request) {
+	share, ok := resolveShare(w, r)
+	if !ok {
+		return
+	}
+
+	if !isUnlocked(r, share) {
+		renderGallery(w, http.StatusUnauthorized, passwordPage(share, ""))
+		return
+	}
+
+	galleryPhotos, err := albums.GetAlbumPhotos(share.AlbumID)
+	if err != nil {
+		http.Error(w, "Failed to load album", http.StatusInternalServerError)
+		return
+	}
+
+	LogShareAccess(share.ShareID, "view", nil, clientIP(r), r.UserAgent())
+
+	page := galleryPage{
+		State:         "gallery",
+		AlbumName:     share.AlbumName,
+		BasePath:      sharePath(share),
+		AllowDownload: share.AllowDownload,
+		Photos:        galleryPhotos,
+	}
+	if share.ExpiresAt != nil {
+		page.ExpiresAt = share.ExpiresAt.Format("2 Jan 2006")
+	}
+
+	renderGallery(w, http.StatusOK, page)
+}
+
+// Checks the submitted password and remembers it in a cookie scoped to the share's path
+func HandleUnlockSharedGallery(w http.ResponseWriter, r *http.Request) {
+	share, ok := resolveShare(w, r)
+	if !ok {
+		return
+	}
+
+	if share.PasswordHash == nil {
+		http.Redirect(w, r, sharePath(share), http.StatusSeeOther)
+		return
+	}
+
+	if !auth.CheckPasswordHash(*share.PasswordHash, r.FormValue("password")) {
+		LogShareAccess(share.ShareID, "unlock_failed", nil, clientIP(r), r.UserAgent())
+		renderGallery(w, http.StatusUnauthorized, passwordPage(share, "Incorrect password"))
+		return
+	}
+
+	LogShareAccess(share.ShareID, "unlock", nil, clientIP(r), r.UserAgent())
+
+	cookie := &http.Cookie{
+		Name:     unlockCookieName(share),
+		Value:    unlockKey(share),
+		Path:     sharePath(share),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if share.ExpiresAt != nil {
+		cookie.Expires = *share.ExpiresAt
+	}
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, sharePath(share), http.StatusSeeOther)
+}
+
+func HandleSharedThumbnail(w http.ResponseWriter, r *http.Request) {
+	withSharedPhoto(w, r, func(w http.ResponseWriter, r *http.Request, share *Share) {
+		photos.HandleServeThumbnail(w, r)
+	})
+}
+
+func HandleSharedRendition(w http.ResponseWriter, r *http.Request) {
+	withSharedPhoto(w, r, func(w http.ResponseWriter, r *http.Request, share *Share) {
+		photos.HandleServeRendition(w, r)
+	})
+}
+
+func HandleSharedDownload(w http.ResponseWriter, r *http.Request) {
+	withSharedPhoto(w, r, func(w http.ResponseWriter, r *http.Request, share *Share) {
+		if !share.AllowDownload {
+			http.Error(w, "Downloads are disabled for this link", http.StatusForbidden)
+			return
+		}
+
+		photoID, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		filePath, err := photos.GetPhotoFilePath(photoID)
+		if err != nil {
+			http.Error(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+
+		LogShareAccess(share.ShareID, "download", &photoID, clientIP(r), r.UserAgent())
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(filePath)))
+		photos.HandleServePhoto(w, r)
+	})
+}
+
+// Runs the handler only if the share is usable and the {id} photo belongs to its album
+func withSharedPhoto(w http.ResponseWriter, r *http.Request, handler shareHandler) {
+	share, ok := resolveShare(w, r)
+	if !ok {
+		return
+	}
+
+	if !isUnlocked(r, share) {
+		http.Error(w, "Password required", http.StatusUnauthorized)
+		return
+	}
+
+	photoID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	// Smart albums are resolved at request time, so a photo can drop out of a shared album
+	inAlbum, err := albums.ContainsPhoto(share.AlbumID, photoID)
+	if err != nil {
+		http.Error(w, "Failed to load photo", http.StatusInternalServerError)
+		return
+	}
+	if !inAlbum {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+
+	handler(w, r, share)
+}
+
+// Looks up the {token} share and renders the not found/expired page if it can't be used. Nothing under a share
+// link is cached, so revoking it, letting it expire or changing its password takes effect on the next request.
+func resolveShare(w http.ResponseWriter, r *http.Request) (*Share, bool) {
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	share, err := GetShareByToken(r.PathValue("token"))
+	if err != nil {
+		if errors.Is(err, ErrShareNotFound) {
+			renderGallery(w, http.StatusNotFound, galleryPage{State: "not_found"})
+			return nil, false
+		}
+		http.Error(w, "Failed to load share link", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if share.IsExpired() {
+		LogShareAccess(share.ShareID, "expired", nil, clientIP(r), r.UserAgent())
+		renderGallery(w, http.StatusGone, galleryPage{State: "expired"})
+		return nil, false
+	}
+
+	return share, true
+}
+
+func isUnlocked(r *http.Request, share *Share) bool {
+	if share.PasswordHash == nil {
+		return true
+	}
+
+	cookie, err := r.Cookie(unlockCookieName(share))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(unlockKey(share))) == 1
+}
+
+// Derived from the password hash, so changing or removing the password invalidates old cookies
+func unlockKey(share *Share) string {
+	sum := sha256.Sum256([]byte(share.Token + ":" + *share.PasswordHash))
+	return hex.EncodeToString(sum[:])
+}
+
+func unlockCookieName(share *Share) string {
+	return fmt.Sprintf("riffle_share_%d", share.ShareID)
+}
+
+func sharePath(share *Share) string {
+	return "/s/" + share.Token + "/"
+}
+
+func passwordPage(share *Share, errMessage string) galleryPage {
+	return galleryPage{
+		State:     "password",
+		AlbumName: share.AlbumName,
+		BasePath:  sharePath(share),
+		Error:     errMessage,
+	}
+}
+
+func renderGallery(w http.ResponseWriter, status int, page galleryPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	if err := galleryTemplate.Execute(w, page); err != nil {
+		slog.Error("error rendering shared gallery", "error", err)
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
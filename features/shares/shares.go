@@ -0,0 +1,163 @@
+package shares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"riffle/commons/utils"
+	"riffle/features/albums"
+	"riffle/features/auth"
+	"strconv"
+	"time"
+)
+
+const accessLogLimit = 200
+
+type CreateShareRequest struct {
+	Password      string     `json:"password"`
+	AllowDownload bool       `json:"allowDownload"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+}
+
+func HandleGetAlbumShares(w http.ResponseWriter, r *http.Request) {
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return
+	}
+
+	shares, err := GetAlbumShares(albumID)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_SHARES_ERROR", "Failed to get share links")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shares)
+}
+
+func HandleCreateAlbumShare(w http.ResponseWriter, r *http.Request) {
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return
+	}
+
+	if _, err := albums.GetAlbumByID(albumID); err != nil {
+		utils.SendErrorResponse(w, http.StatusNotFound, "ALBUM_NOT_FOUND", "Album not found")
+		return
+	}
+
+	var req CreateShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_EXPIRY", "Expiry must be in the future")
+		return
+	}
+
+	var passwordHash *string
+	if req.Password != "" {
+		if len(req.Password) > auth.MaxPasswordLength {
+			utils.SendErrorResponse(w, http.StatusBadRequest, "PASSWORD_TOO_LONG", "Password must be at most 72 characters")
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_SHARE_ERROR", "Failed to create share link")
+			return
+		}
+		passwordHash = &hash
+	}
+
+	var createdBy int64
+	if user := auth.GetCurrentUser(r); user != nil {
+		createdBy = user.UserID
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		utcExpiry := req.ExpiresAt.UTC()
+		expiresAt = &utcExpiry
+	}
+
+	share, err := CreateShare(albumID, passwordHash, req.AllowDownload, expiresAt, createdBy)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "CREATE_SHARE_ERROR", "Failed to create share link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(share)
+}
+
+func HandleDeleteAlbumShare(w http.ResponseWriter, r *http.Request) {
+	share, ok := getAlbumShareFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := DeleteShare(share.ShareID); err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "DELETE_SHARE_ERROR", "Failed to revoke share link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func HandleGetShareAccessLog(w http.ResponseWriter, r *http.Request) {
+	share, ok := getAlbumShareFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	accesses, err := GetShareAccessLog(share.ShareID, accessLogLimit)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_ACCESS_LOG_ERROR", "Failed to get access log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(accesses)
+}
+
+// Resolves {id}/{shareId} and makes sure the share belongs to that album
+func getAlbumShareFromPath(w http.ResponseWriter, r *http.Request) (*Share, bool) {
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_ALBUM_ID", "Invalid album ID")
+		return nil, false
+	}
+
+	shareID, err := strconv.ParseInt(r.PathValue("shareId"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(w, http.StatusBadRequest, "INVALID_SHARE_ID", "Invalid share ID")
+		return nil, false
+	}
+
+	share, err := GetShareByID(shareID)
+	if err != nil {
+		if errors.Is(err, ErrShareNotFound) {
+			utils.SendErrorResponse(w, http.StatusNotFound, "SHARE_NOT_FOUND", "Share link not found")
+			return nil, false
+		}
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "GET_SHARE_ERROR", "Failed to get share link")
+		return nil, false
+	}
+
+	if share.AlbumID != albumID {
+		utils.SendErrorResponse(w, http.StatusNotFound, "SHARE_NOT_FOUND", "Share link not found")
+		return nil, false
+	}
+
+	return share, true
+}
@@ -0,0 +1,200 @@
+package shares
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"time"
+)
+
+var ErrShareNotFound = errors.New("share not found")
+
+type Share struct {
+	ShareID       int64      `json:"shareId"`
+	AlbumID       int        `json:"albumId"`
+	AlbumName     string     `json:"albumName"`
+	Token         string     `json:"token"`
+	PasswordHash  *string    `json:"-"`
+	HasPassword   bool       `json:"hasPassword"`
+	AllowDownload bool       `json:"allowDownload"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	CreatedBy     *string    `json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ViewCount     int        `json:"viewCount"`
+	LastAccessAt  *string    `json:"lastAccessAt"`
+}
+
+func (s *Share) IsExpired() bool {
+	return s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt)
+}
+
+type ShareAccess struct {
+	AccessID   int64     `json:"accessId"`
+	Event      string    `json:"event"`
+	PhotoID    *int64    `json:"photoId"`
+	IPAddress  string    `json:"ipAddress"`
+	UserAgent  string    `json:"userAgent"`
+	AccessedAt time.Time `json:"accessedAt"`
+}
+
+const shareColumns = `
+	s.share_id, s.album_id, a.name, s.token, s.password_hash, s.allow_download, s.expires_at,
+	u.username, s.created_at,
+	(SELECT COUNT(*) FROM album_share_access_log l WHERE l.share_id = s.share_id AND l.event = 'view'),
+	(SELECT MAX(accessed_at) FROM album_share_access_log l WHERE l.share_id = s.share_id)`
+
+const shareJoins = `
+	FROM album_shares s
+	JOIN albums a ON a.album_id = s.album_id
+	LEFT JOIN users u ON u.user_id = s.created_by`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShare(row rowScanner) (*Share, error) {
+	var share Share
+	err := row.Scan(
+		&share.ShareID, &share.AlbumID, &share.AlbumName, &share.Token, &share.PasswordHash, &share.AllowDownload, &share.ExpiresAt,
+		&share.CreatedBy, &share.CreatedAt, &share.ViewCount, &share.LastAccessAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	share.HasPassword = share.PasswordHash != nil
+	return &share, nil
+}
+
+func CreateShare(albumID int, passwordHash *string, allowDownload bool, expiresAt *time.Time, createdBy int64) (*Share, error) {
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		err = fmt.Errorf("error generating share token: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	query := `
+		INSERT INTO album_shares (album_id, token, password_hash, allow_download, expires_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := sqlite.DB.Exec(query, albumID, token, passwordHash, allowDownload, expiresAt, createdBy)
+	if err != nil {
+		err = fmt.Errorf("error creating share: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	shareID, err := result.LastInsertId()
+	if err != nil {
+		err = fmt.Errorf("error getting share id: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return GetShareByID(shareID)
+}
+
+func GetShareByID(shareID int64) (*Share, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.share_id = ?`, shareColumns, shareJoins)
+	return getShare(query, shareID)
+}
+
+func GetShareByToken(token string) (*Share, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.token = ?`, shareColumns, shareJoins)
+	return getShare(query, token)
+}
+
+func getShare(query string, arg any) (*Share, error) {
+	share, err := scanShare(sqlite.DB.QueryRow(query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShareNotFound
+		}
+		err = fmt.Errorf("error getting share: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	return share, nil
+}
+
+func GetAlbumShares(albumID int) ([]Share, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.album_id = ? ORDER BY s.created_at DESC`, shareColumns, shareJoins)
+
+	rows, err := sqlite.DB.Query(query, albumID)
+	if err != nil {
+		err = fmt.Errorf("error getting album shares: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]Share, 0)
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			slog.Error("error scanning share row", "error", err)
+			continue
+		}
+		shares = append(shares, *share)
+	}
+
+	return shares, nil
+}
+
+func DeleteShare(shareID int64) error {
+	_, err := sqlite.DB.Exec(`DELETE FROM album_shares WHERE share_id = ?`, shareID)
+	if err != nil {
+		err = fmt.Errorf("error deleting share: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func LogShareAccess(shareID int64, event string, photoID *int64, ipAddress, userAgent string) {
+	query := `
+		INSERT INTO album_share_access_log (share_id, event, photo_id, ip_address, user_agent)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := sqlite.DB.Exec(query, shareID, event, photoID, ipAddress, userAgent)
+	if err != nil {
+		slog.Error("error logging share access", "shareId", shareID, "event", event, "error", err)
+	}
+}
+
+func GetShareAccessLog(shareID int64, limit int) ([]ShareAccess, error) {
+	query := `
+		SELECT access_id, event, photo_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), accessed_at
+		FROM album_share_access_log
+		WHERE share_id = ?
+		ORDER BY accessed_at DESC, access_id DESC
+		LIMIT ?
+	`
+
+	rows, err := sqlite.DB.Query(query, shareID, limit)
+	if err != nil {
+		err = fmt.Errorf("error getting share access log: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	accesses := make([]ShareAccess, 0)
+	for rows.Next() {
+		var access ShareAccess
+		if err := rows.Scan(&access.AccessID, &access.Event, &access.PhotoID, &access.IPAddress, &access.UserAgent, &access.AccessedAt); err != nil {
+			slog.Error("error scanning share access row", "error", err)
+			continue
+		}
+		accesses = append(accesses, access)
+	}
+
+	return accesses, nil
+}
@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"riffle/commons/cache"
+	"riffle/commons/utils"
+)
+
+func HandleGetStats(w http.ResponseWriter, r *http.Request) {
+	if cache.StatsCache.CheckAndRespond(w, r, 3600) {
+		return
+	}
+
+	stats, err := GetStats()
+	if err != nil {
+		slog.Error("failed to get stats", "error", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "FETCH_ERROR", "Failed to fetch stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
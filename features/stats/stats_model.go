@@ -0,0 +1,414 @@
+package stats
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"riffle/commons/sqlite"
+	"strings"
+)
+
+// Everything but the trash is counted, with the trash summed up on its own
+type Stats struct {
+	TotalPhotos  int              `json:"totalPhotos"`
+	TotalVideos  int              `json:"totalVideos"`
+	TotalSize    int64            `json:"totalSize"`
+	UndatedCount int              `json:"undatedCount"`
+	Years        []PeriodCount    `json:"years"`
+	Months       []PeriodCount    `json:"months"`
+	Cameras      []CameraCount    `json:"cameras"`
+	Lenses       []LensCount      `json:"lenses"`
+	FocalLengths []HistogramBin   `json:"focalLengths"` // Millimeters
+	Apertures    []HistogramBin   `json:"apertures"`    // f-numbers
+	ISOs         []HistogramBin   `json:"isos"`
+	Imports      []ImportKeepRate `json:"imports"`
+	Formats      []FormatStorage  `json:"formats"`
+	TopLocations []LocationCount  `json:"topLocations"`
+	Trash        StorageTotal     `json:"trash"`
+}
+
+type PeriodCount struct {
+	Period string `json:"period"` // 2019 or 2019-03
+	Count  int    `json:"count"`
+}
+
+type CameraCount struct {
+	Make  string `json:"make"`
+	Model string `json:"model"`
+	Count int    `json:"count"`
+}
+
+type LensCount struct {
+	Make  string `json:"make"`
+	Model string `json:"model"`
+	Count int    `json:"count"`
+}
+
+// Photos from min up to but not including max, with no max on the last bin
+type HistogramBin struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+// How many of an import's photos were picked. Rejected photos still count towards the total.
+type ImportKeepRate struct {
+	ImportID   int64   `json:"importId"`
+	ImportPath string  `json:"importPath"`
+	StartedAt  string  `json:"startedAt"`
+	Total      int     `json:"total"`
+	Picked     int     `json:"picked"`
+	Rejected   int     `json:"rejected"`
+	KeepRate   float64 `json:"keepRate"` // Picked over total, from 0 to 1
+}
+
+// Trashed files are still on disk, so they're included
+type FormatStorage struct {
+	Format    string `json:"format"`
+	Count     int    `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+type LocationCount struct {
+	Country string `json:"country"`
+	State   string `json:"state,omitempty"`
+	City    string `json:"city,omitempty"`
+	Count   int    `json:"count"`
+}
+
+type StorageTotal struct {
+	Count     int   `json:"count"`
+	TotalSize int64 `json:"totalSize"`
+}
+
+const topLocationsLimit = 10
+
+// Bin edges follow the usual lens focal lengths and full stops
+var (
+	focalLengthEdges = []float64{14, 18, 24, 28, 35, 50, 70, 85, 105, 135, 200, 300, 400, 600}
+	apertureEdges    = []float64{1.4, 2, 2.8, 4, 5.6, 8, 11, 16, 22}
+	isoEdges         = []float64{200, 400, 800, 1600, 3200, 6400, 12800, 25600}
+)
+
+func GetStats() (*Stats, error) {
+	stats := &Stats{}
+
+	if err := loadTotals(stats); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	if stats.Years, err = getPeriodCounts(4); err != nil {
+		return nil, err
+	}
+
+	if stats.Months, err = getPeriodCounts(7); err != nil {
+		return nil, err
+	}
+
+	if stats.Cameras, err = getCameraCounts(); err != nil {
+		return nil, err
+	}
+
+	if stats.Lenses, err = getLensCounts(); err != nil {
+		return nil, err
+	}
+
+	if stats.FocalLengths, err = getHistogram("focal_length", focalLengthEdges); err != nil {
+		return nil, err
+	}
+
+	if stats.Apertures, err = getHistogram("f_number", apertureEdges); err != nil {
+		return nil, err
+	}
+
+	if stats.ISOs, err = getHistogram("iso", isoEdges); err != nil {
+		return nil, err
+	}
+
+	if stats.Imports, err = getImportKeepRates(); err != nil {
+		return nil, err
+	}
+
+	if stats.Formats, err = getFormatStorage(); err != nil {
+		return nil, err
+	}
+
+	if stats.TopLocations, err = getTopLocations(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func loadTotals(stats *Stats) error {
+	query := `
+		SELECT
+			COALESCE(SUM(CASE WHEN is_trashed = 0 AND is_video = 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_trashed = 0 AND is_video = 1 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_trashed = 0 THEN file_size ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_trashed = 0 AND local_date_time IS NULL THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_trashed = 1 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_trashed = 1 THEN file_size ELSE 0 END), 0)
+		FROM photos
+	`
+
+	err := sqlite.DB.QueryRow(query).Scan(&stats.TotalPhotos, &stats.TotalVideos, &stats.TotalSize, &stats.UndatedCount, &stats.Trash.Count, &stats.Trash.TotalSize)
+	if err != nil {
+		err = fmt.Errorf("error querying stats totals: %w", err)
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Counts photos per local capture year or month, the period being that many leading characters of the date
+func getPeriodCounts(length int) ([]PeriodCount, error) {
+	query := `
+		SELECT SUBSTR(local_date_time, 1, ?) AS period, COUNT(*)
+		FROM photos
+		WHERE is_trashed = 0 AND local_date_time IS NOT NULL
+		GROUP BY period
+		ORDER BY period DESC
+	`
+
+	rows, err := sqlite.DB.Query(query, length)
+	if err != nil {
+		err = fmt.Errorf("error querying photos per period: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []PeriodCount{}
+	for rows.Next() {
+		var count PeriodCount
+		if err := rows.Scan(&count.Period, &count.Count); err != nil {
+			err = fmt.Errorf("error scanning photos per period: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func getCameraCounts() ([]CameraCount, error) {
+	query := `
+		SELECT COALESCE(camera_make, ''), camera_model, COUNT(*) AS photo_count
+		FROM photos
+		WHERE is_trashed = 0 AND camera_model IS NOT NULL AND camera_model != ''
+		GROUP BY camera_make, camera_model
+		ORDER BY photo_count DESC, camera_model ASC
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error querying photos per camera: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []CameraCount{}
+	for rows.Next() {
+		var count CameraCount
+		if err := rows.Scan(&count.Make, &count.Model, &count.Count); err != nil {
+			err = fmt.Errorf("error scanning photos per camera: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func getLensCounts() ([]LensCount, error) {
+	query := `
+		SELECT COALESCE(lens_make, ''), lens_model, COUNT(*) AS photo_count
+		FROM photos
+		WHERE is_trashed = 0 AND lens_model IS NOT NULL AND lens_model != ''
+		GROUP BY lens_make, lens_model
+		ORDER BY photo_count DESC, lens_model ASC
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error querying photos per lens: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []LensCount{}
+	for rows.Next() {
+		var count LensCount
+		if err := rows.Scan(&count.Make, &count.Model, &count.Count); err != nil {
+			err = fmt.Errorf("error scanning photos per lens: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// Sorts each photo into a bin in SQL, leaving out photos without the value. Every bin is listed, empty or not,
+// so histograms line up across libraries.
+func getHistogram(column string, edges []float64) ([]HistogramBin, error) {
+	cases := make([]string, len(edges))
+	args := make([]any, len(edges))
+	for i, edge := range edges {
+		cases[i] = fmt.Sprintf("WHEN %s < ? THEN %d", column, i)
+		args[i] = edge
+	}
+
+	query := fmt.Sprintf(`
+		SELECT CASE %s ELSE %d END AS bin, COUNT(*)
+		FROM photos
+		WHERE is_trashed = 0 AND %s > 0
+		GROUP BY bin
+	`, strings.Join(cases, " "), len(edges), column)
+
+	rows, err := sqlite.DB.Query(query, args...)
+	if err != nil {
+		err = fmt.Errorf("error querying %s histogram: %w", column, err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	bins := make([]HistogramBin, len(edges)+1)
+	for i := range bins {
+		if i > 0 {
+			bins[i].Min = edges[i-1]
+		}
+		if i < len(edges) {
+			upper := edges[i]
+			bins[i].Max = &upper
+		}
+	}
+
+	for rows.Next() {
+		var bin, count int
+		if err := rows.Scan(&bin, &count); err != nil {
+			err = fmt.Errorf("error scanning %s histogram: %w", column, err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		bins[bin].Count = count
+	}
+
+	return bins, rows.Err()
+}
+
+// Newest imports first. Photos from before imports were recorded aren't in any.
+func getImportKeepRates() ([]ImportKeepRate, error) {
+	query := `
+		SELECT
+			s.import_id,
+			s.import_path,
+			s.started_at,
+			COUNT(p.photo_id),
+			COALESCE(SUM(CASE WHEN p.is_curated = 1 AND p.is_trashed = 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN p.is_trashed = 1 THEN 1 ELSE 0 END), 0)
+		FROM import_sessions s
+		JOIN photos p ON p.import_id = s.import_id
+		GROUP BY s.import_id
+		ORDER BY s.started_at DESC
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error querying import keep rates: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []ImportKeepRate{}
+	for rows.Next() {
+		var rate ImportKeepRate
+		var startedAt sql.NullString
+		if err := rows.Scan(&rate.ImportID, &rate.ImportPath, &startedAt, &rate.Total, &rate.Picked, &rate.Rejected); err != nil {
+			err = fmt.Errorf("error scanning import keep rate: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		rate.StartedAt = startedAt.String
+		if rate.Total > 0 {
+			rate.KeepRate = float64(rate.Picked) / float64(rate.Total)
+		}
+		imports = append(imports, rate)
+	}
+
+	return imports, rows.Err()
+}
+
+func getFormatStorage() ([]FormatStorage, error) {
+	query := `
+		SELECT LOWER(COALESCE(file_format, '')) AS format, COUNT(*), COALESCE(SUM(file_size), 0) AS total_size
+		FROM photos
+		GROUP BY format
+		ORDER BY total_size DESC
+	`
+
+	rows, err := sqlite.DB.Query(query)
+	if err != nil {
+		err = fmt.Errorf("error querying storage by format: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	formats := []FormatStorage{}
+	for rows.Next() {
+		var format FormatStorage
+		if err := rows.Scan(&format.Format, &format.Count, &format.TotalSize); err != nil {
+			err = fmt.Errorf("error scanning storage by format: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		formats = append(formats, format)
+	}
+
+	return formats, rows.Err()
+}
+
+// Cities with the most photos, or the state or country when a photo's place is only known that far
+func getTopLocations() ([]LocationCount, error) {
+	query := `
+		SELECT country_name, COALESCE(state, '') AS state_name, COALESCE(city, '') AS city_name, COUNT(*) AS photo_count
+		FROM photos
+		WHERE is_trashed = 0 AND country_name IS NOT NULL AND country_name != ''
+		GROUP BY country_name, state_name, city_name
+		ORDER BY photo_count DESC, country_name ASC, state_name ASC, city_name ASC
+		LIMIT ?
+	`
+
+	rows, err := sqlite.DB.Query(query, topLocationsLimit)
+	if err != nil {
+		err = fmt.Errorf("error querying top locations: %w", err)
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []LocationCount{}
+	for rows.Next() {
+		var location LocationCount
+		if err := rows.Scan(&location.Country, &location.State, &location.City, &location.Count); err != nil {
+			err = fmt.Errorf("error scanning top locations: %w", err)
+			slog.Error(err.Error())
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
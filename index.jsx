@@ -9,33 +9,40 @@ import PhotoListPage from './features/photos/PhotoListPage.jsx';
 import CalendarPage from './features/calendar/CalendarPage.jsx';
 import SettingsPage from './features/settings/SettingsPage.jsx';
 import ExportPage from './features/export/ExportPage.jsx';
+import GeotagPage from './features/geotagging/GeotagPage.jsx';
 import AlbumsPage from './features/albums/AlbumsPage.jsx';
 import AlbumDetailPage from './features/albums/AlbumDetailPage.jsx';
+import AuthProvider from './features/auth/AuthProvider.jsx';
 
 function App() {
   return (
     <ToastProvider>
-      <div className="app-layout">
-        <Sidebar />
-        <div className="main-content">
-          <Router>
-            <Route path="/" component={ImportPage} />
-            <Route path="/import" component={ImportPage} />
-            <Route path="/curate" component={CuratePage} />
-            <Route path="/library" component={LibraryPage} />
-            <Route path="/albums" component={AlbumsPage} />
-            <Route path="/albums/:albumId" component={AlbumDetailPage} />
-            <Route path="/calendar" component={CalendarPage} />
-            <Route path="/export" component={ExportPage} />
-            <Route path="/trash" component={TrashPage} />
-            <Route path="/settings" component={SettingsPage} />
-            <Route path="/settings/import" component={SettingsPage} />
-            <Route path="/settings/library" component={SettingsPage} />
-            <Route path="/settings/burst" component={SettingsPage} />
-            <Route path="/settings/export" component={SettingsPage} />
-          </Router>
+      <AuthProvider>
+        <div className="app-layout">
+          <Sidebar />
+          <div className="main-content">
+            <Router>
+              <Route path="/" component={ImportPage} />
+              <Route path="/import" component={ImportPage} />
+              <Route path="/curate" component={CuratePage} />
+              <Route path="/library" component={LibraryPage} />
+              <Route path="/albums" component={AlbumsPage} />
+              <Route path="/albums/:albumId" component={AlbumDetailPage} />
+              <Route path="/calendar" component={CalendarPage} />
+              <Route path="/export" component={ExportPage} />
+              <Route path="/geotag" component={GeotagPage} />
+              <Route path="/trash" component={TrashPage} />
+              <Route path="/settings" component={SettingsPage} />
+              <Route path="/settings/import" component={SettingsPage} />
+              <Route path="/settings/library" component={SettingsPage} />
+              <Route path="/settings/burst" component={SettingsPage} />
+              <Route path="/settings/geocoding" component={SettingsPage} />
+              <Route path="/settings/export" component={SettingsPage} />
+              <Route path="/settings/users" component={SettingsPage} />
+            </Router>
+          </div>
         </div>
-      </div>
+      </AuthProvider>
     </ToastProvider>
   );
 }
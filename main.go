@@ -11,12 +11,17 @@ import (
 	"riffle/commons/sqlite"
 	"riffle/commons/utils"
 	"riffle/features/albums"
+	"riffle/features/auth"
 	"riffle/features/calendar"
 	"riffle/features/export"
 	"riffle/features/geocoding"
+	"riffle/features/geotagging"
 	"riffle/features/ingest"
+	"riffle/features/mapview"
 	"riffle/features/photos"
 	"riffle/features/settings"
+	"riffle/features/shares"
+	"riffle/features/stats"
 	"syscall"
 
 	"github.com/joho/godotenv"
@@ -55,10 +60,18 @@ func main() {
 
 	sqlite.Migrate(migrations)
 
+	if err := photos.ConvertToRelativePaths(os.Getenv("LIBRARY_PATH"), os.Getenv("THUMBNAILS_PATH")); err != nil {
+		slog.Error("error converting photo paths", "error", err)
+	}
+
 	if err := geocoding.Init(); err != nil {
 		slog.Error("error initializing geocoding", "error", err)
 	}
 
+	if err := exif.Init(); err != nil {
+		slog.Warn("error initializing exiftool", "error", err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -92,42 +105,93 @@ func loadEnv() {
 	}
 }
 
-func newRouter() *http.ServeMux {
+func newRouter() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /api/import/sessions/", ingest.HandleCreateImportSession)
-	mux.HandleFunc("GET /api/import/sessions/", ingest.HandleGetImportSessions)
-	mux.HandleFunc("GET /api/import/sessions/progress/", ingest.HandleImportProgress)
-	mux.HandleFunc("GET /api/photos/", photos.HandleGetPhotos)
-	mux.HandleFunc("GET /api/photos/uncurated/", photos.HandleGetUncuratedPhotos)
-	mux.HandleFunc("GET /api/photos/trashed/", photos.HandleGetTrashedPhotos)
-	mux.HandleFunc("GET /api/photos/filters/", photos.HandleGetFilterOptions)
-	mux.HandleFunc("POST /api/photos/curate/", photos.HandleCuratePhoto)
-	mux.HandleFunc("GET /api/photo/", photos.HandleServePhoto)
-	mux.HandleFunc("POST /api/thumbnails/rebuild/", photos.HandleRebuildThumbnails)
-	mux.HandleFunc("GET /api/thumbnails/rebuild/progress/", photos.HandleGetThumbnailProgress)
-	mux.HandleFunc("GET /api/thumbnails/", photos.HandleServeThumbnail)
-	mux.HandleFunc("POST /api/burst/rebuild/", photos.HandleRebuildBurstData)
-	mux.HandleFunc("GET /api/burst/rebuild/progress/", photos.HandleGetBurstRebuildProgress)
-	mux.HandleFunc("GET /api/calendar/months/", calendar.HandleGetCalendarMonths)
-	mux.HandleFunc("GET /api/settings/", settings.HandleGetSettings)
-	mux.HandleFunc("POST /api/settings/", settings.HandleUpdateSetting)
-	mux.HandleFunc("GET /api/albums/", albums.HandleGetAlbums)
-	mux.HandleFunc("GET /api/albums/{id}/", albums.HandleGetAlbum)
-	mux.HandleFunc("GET /api/albums/{id}/photos/", albums.HandleGetAlbumPhotos)
-	mux.HandleFunc("POST /api/albums/", albums.HandleCreateAlbum)
-	mux.HandleFunc("PUT /api/albums/photos/", albums.HandleAddPhotosToAlbums)
-	mux.HandleFunc("DELETE /api/albums/{id}/photos/", albums.HandleRemovePhotosFromAlbum)
-	mux.HandleFunc("DELETE /api/albums/{id}/", albums.HandleDeleteAlbum)
-	mux.HandleFunc("GET /api/photo/albums/", albums.HandleGetPhotoAlbums)
-	mux.HandleFunc("POST /api/export/sessions/", export.HandleCreateExportSession)
-	mux.HandleFunc("GET /api/export/sessions/", export.HandleGetExportSessions)
-	mux.HandleFunc("GET /api/export/sessions/progress/", export.HandleExportProgress)
+	mux.HandleFunc("GET /api/auth/status/", auth.HandleGetAuthStatus)
+	mux.HandleFunc("POST /api/auth/setup/", auth.HandleSetup)
+	mux.HandleFunc("POST /api/auth/login/", auth.HandleLogin)
+	mux.HandleFunc("POST /api/auth/logout/", auth.HandleLogout)
+	mux.HandleFunc("GET /api/users/", auth.RequireRole(auth.RoleAdmin, auth.HandleGetUsers))
+	mux.HandleFunc("POST /api/users/", auth.RequireRole(auth.RoleAdmin, auth.HandleCreateUser))
+	mux.HandleFunc("PUT /api/users/{id}/", auth.RequireRole(auth.RoleAdmin, auth.HandleUpdateUser))
+	mux.HandleFunc("DELETE /api/users/{id}/", auth.RequireRole(auth.RoleAdmin, auth.HandleDeleteUser))
+	mux.HandleFunc("POST /api/import/sessions/", auth.RequireRole(auth.RoleCurator, ingest.HandleCreateImportSession))
+	mux.HandleFunc("GET /api/import/sessions/", auth.RequireRole(auth.RoleViewer, ingest.HandleGetImportSessions))
+	mux.HandleFunc("GET /api/import/sessions/progress/", auth.RequireRole(auth.RoleViewer, ingest.HandleImportProgress))
+	mux.HandleFunc("GET /api/photos/", auth.RequireRole(auth.RoleViewer, photos.HandleGetPhotos))
+	mux.HandleFunc("GET /api/photos/uncurated/", auth.RequireRole(auth.RoleViewer, photos.HandleGetUncuratedPhotos))
+	mux.HandleFunc("GET /api/photos/trashed/", auth.RequireRole(auth.RoleViewer, photos.HandleGetTrashedPhotos))
+	mux.HandleFunc("GET /api/photos/facets/", auth.RequireRole(auth.RoleViewer, photos.HandleGetFilterFacets))
+	mux.HandleFunc("GET /api/photos/timeline/", auth.RequireRole(auth.RoleViewer, photos.HandleGetTimeline))
+	mux.HandleFunc("GET /api/photos/timeline/position/", auth.RequireRole(auth.RoleViewer, photos.HandleGetTimelinePosition))
+	mux.HandleFunc("GET /api/saved-filters/", auth.RequireRole(auth.RoleViewer, photos.HandleGetSavedFilters))
+	mux.HandleFunc("POST /api/saved-filters/", auth.RequireRole(auth.RoleViewer, photos.HandleCreateSavedFilter))
+	mux.HandleFunc("PUT /api/saved-filters/{id}/", auth.RequireRole(auth.RoleViewer, photos.HandleUpdateSavedFilter))
+	mux.HandleFunc("DELETE /api/saved-filters/{id}/", auth.RequireRole(auth.RoleViewer, photos.HandleDeleteSavedFilter))
+	mux.HandleFunc("POST /api/photos/curate/", auth.RequireRole(auth.RoleCurator, photos.HandleCuratePhoto))
+	mux.HandleFunc("POST /api/photos/time-shift/", auth.RequireRole(auth.RoleCurator, photos.HandleShiftPhotoTimes))
+	mux.HandleFunc("GET /api/photo/{id}/", auth.RequireRole(auth.RoleViewer, photos.HandleServePhoto))
+	mux.HandleFunc("GET /api/photo/{id}/video/", auth.RequireRole(auth.RoleViewer, photos.HandleServeVideo))
+	mux.HandleFunc("GET /api/photo/{id}/curations/", auth.RequireRole(auth.RoleViewer, photos.HandleGetPhotoCurations))
+	mux.HandleFunc("GET /api/photo/{id}/rendition/", auth.RequireRole(auth.RoleViewer, photos.HandleServeRendition))
+	mux.HandleFunc("POST /api/thumbnails/rebuild/", auth.RequireRole(auth.RoleAdmin, photos.HandleRebuildThumbnails))
+	mux.HandleFunc("GET /api/thumbnails/rebuild/progress/", auth.RequireRole(auth.RoleViewer, photos.HandleGetThumbnailProgress))
+	mux.HandleFunc("GET /api/thumbnails/{id}/", auth.RequireRole(auth.RoleViewer, photos.HandleServeThumbnail))
+	mux.HandleFunc("GET /api/thumbnails/{id}/preview/", auth.RequireRole(auth.RoleViewer, photos.HandleServeVideoPreview))
+	mux.HandleFunc("GET /api/thumbnails/{id}/sprite/", auth.RequireRole(auth.RoleViewer, photos.HandleServeVideoSprite))
+	mux.HandleFunc("GET /api/thumbnails/{id}/sprite/vtt/", auth.RequireRole(auth.RoleViewer, photos.HandleServeVideoSpriteVTT))
+	mux.HandleFunc("POST /api/burst/rebuild/", auth.RequireRole(auth.RoleAdmin, photos.HandleRebuildBurstData))
+	mux.HandleFunc("GET /api/burst/rebuild/progress/", auth.RequireRole(auth.RoleViewer, photos.HandleGetBurstRebuildProgress))
+	mux.HandleFunc("POST /api/metadata/rescan/", auth.RequireRole(auth.RoleAdmin, photos.HandleRescanMetadata))
+	mux.HandleFunc("GET /api/metadata/rescan/progress/", auth.RequireRole(auth.RoleViewer, photos.HandleGetMetadataRescanProgress))
+	mux.HandleFunc("POST /api/geocoding/rebuild/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleRebuildGeocoding))
+	mux.HandleFunc("GET /api/geocoding/rebuild/progress/", auth.RequireRole(auth.RoleViewer, geocoding.HandleGetRebuildProgress))
+	mux.HandleFunc("GET /api/geocoding/status/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleGetStatus))
+	mux.HandleFunc("POST /api/geocoding/reload/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleReload))
+	mux.HandleFunc("GET /api/places/", auth.RequireRole(auth.RoleViewer, geocoding.HandleGetPlaces))
+	mux.HandleFunc("POST /api/places/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleCreatePlace))
+	mux.HandleFunc("PUT /api/places/{id}/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleUpdatePlace))
+	mux.HandleFunc("DELETE /api/places/{id}/", auth.RequireRole(auth.RoleAdmin, geocoding.HandleDeletePlace))
+	mux.HandleFunc("GET /api/calendar/months/", auth.RequireRole(auth.RoleViewer, calendar.HandleGetCalendarMonths))
+	mux.HandleFunc("GET /api/stats/", auth.RequireRole(auth.RoleViewer, stats.HandleGetStats))
+	mux.HandleFunc("GET /api/map/clusters/", auth.RequireRole(auth.RoleViewer, mapview.HandleGetClusters))
+	mux.HandleFunc("GET /api/map/photos/", auth.RequireRole(auth.RoleViewer, mapview.HandleGetMapPhotos))
+	mux.HandleFunc("POST /api/geotag/preview/", auth.RequireRole(auth.RoleCurator, geotagging.HandlePreviewGeotags))
+	mux.HandleFunc("POST /api/geotag/apply/", auth.RequireRole(auth.RoleCurator, geotagging.HandleApplyGeotags))
+	mux.HandleFunc("GET /api/settings/", auth.RequireRole(auth.RoleViewer, settings.HandleGetSettings))
+	mux.HandleFunc("POST /api/settings/", auth.RequireRole(auth.RoleAdmin, settings.HandleUpdateSetting))
+	mux.HandleFunc("GET /api/albums/", auth.RequireRole(auth.RoleViewer, albums.HandleGetAlbums))
+	mux.HandleFunc("GET /api/albums/{id}/", auth.RequireRole(auth.RoleViewer, albums.HandleGetAlbum))
+	mux.HandleFunc("GET /api/albums/{id}/photos/", auth.RequireRole(auth.RoleViewer, albums.HandleGetAlbumPhotos))
+	mux.HandleFunc("GET /api/albums/{id}/facets/", auth.RequireRole(auth.RoleViewer, albums.HandleGetAlbumFacets))
+	mux.HandleFunc("POST /api/albums/", auth.RequireRole(auth.RoleCurator, albums.HandleCreateAlbum))
+	// Anchored to its exact path, otherwise it overlaps the per-album routes below and the mux panics
+	mux.HandleFunc("PUT /api/albums/photos/{$}", auth.RequireRole(auth.RoleCurator, albums.HandleAddPhotosToAlbums))
+	mux.HandleFunc("PUT /api/albums/{id}/", auth.RequireRole(auth.RoleCurator, albums.HandleUpdateAlbum))
+	mux.HandleFunc("PUT /api/albums/{id}/order/", auth.RequireRole(auth.RoleCurator, albums.HandleReorderAlbumPhotos))
+	mux.HandleFunc("DELETE /api/albums/{id}/photos/", auth.RequireRole(auth.RoleCurator, albums.HandleRemovePhotosFromAlbum))
+	mux.HandleFunc("DELETE /api/albums/{id}/", auth.RequireRole(auth.RoleCurator, albums.HandleDeleteAlbum))
+	mux.HandleFunc("GET /api/photo/{id}/albums/", auth.RequireRole(auth.RoleViewer, albums.HandleGetPhotoAlbums))
+	mux.HandleFunc("GET /api/albums/{id}/shares/", auth.RequireRole(auth.RoleCurator, shares.HandleGetAlbumShares))
+	mux.HandleFunc("POST /api/albums/{id}/shares/", auth.RequireRole(auth.RoleCurator, shares.HandleCreateAlbumShare))
+	mux.HandleFunc("DELETE /api/albums/{id}/shares/{shareId}/", auth.RequireRole(auth.RoleCurator, shares.HandleDeleteAlbumShare))
+	mux.HandleFunc("GET /api/albums/{id}/shares/{shareId}/access/", auth.RequireRole(auth.RoleCurator, shares.HandleGetShareAccessLog))
+	mux.HandleFunc("POST /api/export/sessions/", auth.RequireRole(auth.RoleCurator, export.HandleCreateExportSession))
+	mux.HandleFunc("GET /api/export/sessions/", auth.RequireRole(auth.RoleViewer, export.HandleGetExportSessions))
+	mux.HandleFunc("GET /api/export/sessions/progress/", auth.RequireRole(auth.RoleViewer, export.HandleExportProgress))
+
+	// Public share links; access is checked against the share token, not a session
+	mux.HandleFunc("GET /s/{token}/", shares.HandleSharedGallery)
+	mux.HandleFunc("POST /s/{token}/", shares.HandleUnlockSharedGallery)
+	mux.HandleFunc("GET /s/{token}/thumbnails/{id}/", shares.HandleSharedThumbnail)
+	mux.HandleFunc("GET /s/{token}/photos/{id}/", shares.HandleSharedRendition)
+	mux.HandleFunc("GET /s/{token}/photos/{id}/download/", shares.HandleSharedDownload)
 
 	mux.HandleFunc("GET /assets/", handleStaticAssets)
 	mux.HandleFunc("GET /", handleRoot)
 
-	return mux
+	return auth.Middleware(mux)
 }
 
 func handleRoot(w http.ResponseWriter, r *http.Request) {
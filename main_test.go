@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// net/http panics when two patterns overlap without one being more specific, which only shows at startup
+func TestNewRouterRegistersAllRoutes(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("registering routes panicked: %v", r)
+		}
+	}()
+
+	newRouter()
+}
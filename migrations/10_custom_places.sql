@@ -0,0 +1,23 @@
+-- User-defined places such as "Home" or "Studio". A photo within a place's radius is tagged with it,
+-- ahead of the GeoNames city; the nearest center wins where places overlap.
+CREATE TABLE IF NOT EXISTS places (
+    place_id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    name           TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    latitude       REAL NOT NULL,
+    longitude      REAL NOT NULL,
+    radius_meters  REAL NOT NULL,
+    created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE photos ADD COLUMN place_id INTEGER REFERENCES places (place_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_photos_place_id ON photos(place_id);
+
+-- The GeoNames files behind the loaded cities, so the status can tell when the files on disk are newer
+CREATE TABLE IF NOT EXISTS geonames_sources (
+    file_name    TEXT PRIMARY KEY,
+    file_size    INTEGER NOT NULL,
+    modified_at  TIMESTAMP NOT NULL,
+    loaded_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,14 @@
+-- Wall clock time and UTC offset (minutes east of UTC, NULL when the file didn't record one) at capture,
+-- so photos are grouped by the day they were taken on rather than the UTC day.
+ALTER TABLE photos ADD COLUMN local_date_time TEXT;
+ALTER TABLE photos ADD COLUMN utc_offset INTEGER;
+
+-- Some fallbacks stored an offset instead of UTC
+UPDATE photos SET date_time = strftime('%Y-%m-%dT%H:%M:%SZ', date_time)
+WHERE date_time IS NOT NULL AND date_time NOT LIKE '%Z';
+
+-- The original offsets weren't kept, so existing photos get the server's timezone, the same one import assumed
+UPDATE photos SET local_date_time = strftime('%Y-%m-%dT%H:%M:%S', date_time, 'localtime')
+WHERE date_time IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_photos_local_day ON photos(SUBSTR(local_date_time, 1, 10) DESC, date_time DESC, created_at DESC);
@@ -0,0 +1,9 @@
+-- Pages seek on the full listing order, with file_path making the key unique, instead of skipping rows
+DROP INDEX IF EXISTS idx_photos_local_day;
+
+CREATE INDEX IF NOT EXISTS idx_photos_page_key ON photos(
+    COALESCE(SUBSTR(local_date_time, 1, 10), '') DESC,
+    COALESCE(date_time, '') DESC,
+    created_at DESC,
+    file_path DESC
+);
@@ -0,0 +1,13 @@
+-- Named filter presets. filters is a JSON-encoded photos.PhotoFilters; an empty sort is the default order.
+CREATE TABLE IF NOT EXISTS saved_filters (
+    saved_filter_id  INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER NOT NULL,
+    name             TEXT NOT NULL COLLATE NOCASE,
+    scope            TEXT NOT NULL DEFAULT 'library' CHECK(scope IN ('library', 'uncurated', 'trash')),
+    sort             TEXT NOT NULL DEFAULT '',
+    filters          TEXT NOT NULL DEFAULT '{}',
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
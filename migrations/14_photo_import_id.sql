@@ -0,0 +1,16 @@
+-- The import session that brought each photo in, so photos can be listed and grouped by import.
+-- Photos from before sessions were recorded have none.
+ALTER TABLE photos ADD COLUMN import_id INTEGER REFERENCES import_sessions (import_id) ON DELETE SET NULL;
+
+UPDATE photos SET import_id = (
+    SELECT MAX(ip.import_id) FROM imported_photos ip
+    WHERE ip.file_path = photos.file_path AND ip.status = 'success'
+);
+
+CREATE INDEX IF NOT EXISTS idx_photos_import_key ON photos(
+    is_curated,
+    is_trashed,
+    COALESCE(import_id, 0) DESC,
+    COALESCE(imported_at, created_at) DESC,
+    file_path DESC
+);
@@ -0,0 +1,19 @@
+-- Lens and exposure details beyond the basics. Text fields keep exiftool's printed values, such as
+-- "Aperture-priority AE" or "Auto"; photos imported before this have none until they're re-scanned.
+ALTER TABLE photos ADD COLUMN lens_make             TEXT;
+ALTER TABLE photos ADD COLUMN lens_model            TEXT;
+ALTER TABLE photos ADD COLUMN focal_length_35mm     REAL;
+ALTER TABLE photos ADD COLUMN exposure_program      TEXT;
+ALTER TABLE photos ADD COLUMN exposure_mode         TEXT;
+ALTER TABLE photos ADD COLUMN exposure_compensation REAL; -- EV
+ALTER TABLE photos ADD COLUMN metering_mode         TEXT;
+ALTER TABLE photos ADD COLUMN white_balance         TEXT;
+ALTER TABLE photos ADD COLUMN flash                 TEXT;
+ALTER TABLE photos ADD COLUMN software              TEXT;
+ALTER TABLE photos ADD COLUMN color_space           TEXT;
+ALTER TABLE photos ADD COLUMN color_profile         TEXT;
+ALTER TABLE photos ADD COLUMN altitude              REAL; -- Meters, negative below sea level
+ALTER TABLE photos ADD COLUMN gps_direction         REAL; -- Degrees the camera faced, 0 to 360
+ALTER TABLE photos ADD COLUMN camera_serial         TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_photos_lens_model ON photos(lens_model);
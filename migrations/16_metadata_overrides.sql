@@ -0,0 +1,4 @@
+-- Capture times and coordinates set by hand, through a time shift or geotagging, so re-reading the
+-- metadata from the files doesn't undo them. Earlier edits weren't recorded and can't be told apart.
+ALTER TABLE photos ADD COLUMN is_time_manual     BOOLEAN DEFAULT 0;
+ALTER TABLE photos ADD COLUMN is_location_manual BOOLEAN DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE photos ADD COLUMN video_codec TEXT;
+ALTER TABLE photos ADD COLUMN audio_codec TEXT;
+ALTER TABLE photos ADD COLUMN frame_rate  REAL;
+ALTER TABLE photos ADD COLUMN rotation    INTEGER;
+
+INSERT OR IGNORE INTO settings (key, value) VALUES ('video_transcode_on_import', 'false'); -- "true", "false"
@@ -0,0 +1,114 @@
+-- Photos were keyed by absolute file_path. Rebuild the table around a stable integer photo_id so the
+-- API never takes file paths and the library can be relocated. file_path becomes library-relative;
+-- existing absolute paths are rewritten on startup once LIBRARY_PATH is known (see photos.ConvertToRelativePaths).
+
+CREATE TABLE photos_new (
+    photo_id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path        TEXT NOT NULL UNIQUE,
+    original_filepath TEXT,
+    sha256_hash      TEXT NOT NULL UNIQUE,
+    dhash            TEXT,
+    file_size        INTEGER NOT NULL,
+    file_format      TEXT,
+    mime_type        TEXT,
+    is_video         BOOLEAN DEFAULT 0,
+    duration         INTEGER,
+    video_codec      TEXT,
+    audio_codec      TEXT,
+    frame_rate       REAL,
+    rotation         INTEGER,
+    date_time        TIMESTAMP,
+    camera_make      TEXT,
+    camera_model     TEXT,
+    width            INTEGER,
+    height           INTEGER,
+    orientation      INTEGER,
+    latitude         REAL,
+    longitude        REAL,
+    iso              INTEGER,
+    f_number         REAL,
+    exposure_time    REAL,
+    focal_length     REAL,
+    city             TEXT,
+    state            TEXT,
+    country_name     TEXT,
+    is_curated       BOOLEAN DEFAULT 0,
+    is_trashed       BOOLEAN DEFAULT 0,
+    rating           INTEGER DEFAULT 0 CHECK(rating BETWEEN 0 AND 5),
+    notes            TEXT,
+    file_created_at  TIMESTAMP,
+    file_modified_at TIMESTAMP,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    imported_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    thumbnail_path   TEXT
+);
+
+INSERT INTO photos_new (
+    file_path, original_filepath, sha256_hash, dhash, file_size, file_format, mime_type,
+    is_video, duration, video_codec, audio_codec, frame_rate, rotation,
+    date_time, camera_make, camera_model, width, height, orientation,
+    latitude, longitude, iso, f_number, exposure_time, focal_length,
+    city, state, country_name, is_curated, is_trashed, rating, notes,
+    file_created_at, file_modified_at, created_at, updated_at, imported_at, thumbnail_path
+)
+SELECT
+    file_path, original_filepath, sha256_hash, dhash, file_size, file_format, mime_type,
+    is_video, duration, video_codec, audio_codec, frame_rate, rotation,
+    date_time, camera_make, camera_model, width, height, orientation,
+    latitude, longitude, iso, f_number, exposure_time, focal_length,
+    city, state, country_name, is_curated, is_trashed, rating, notes,
+    file_created_at, file_modified_at, created_at, updated_at, imported_at, thumbnail_path
+FROM photos
+ORDER BY imported_at ASC, created_at ASC;
+
+CREATE TABLE photo_tags_new (
+    photo_id    INTEGER NOT NULL,
+    tag_id      INTEGER NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (photo_id, tag_id),
+    FOREIGN KEY (photo_id) REFERENCES photos_new (photo_id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE
+);
+
+INSERT INTO photo_tags_new (photo_id, tag_id, created_at)
+SELECT p.photo_id, pt.tag_id, pt.created_at
+FROM photo_tags pt
+JOIN photos_new p ON p.file_path = pt.file_path;
+
+CREATE TABLE album_photos_new (
+    album_id    INTEGER NOT NULL,
+    photo_id    INTEGER NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (album_id, photo_id),
+    FOREIGN KEY (album_id) REFERENCES albums (album_id) ON DELETE CASCADE,
+    FOREIGN KEY (photo_id) REFERENCES photos_new (photo_id) ON DELETE CASCADE
+);
+
+INSERT INTO album_photos_new (album_id, photo_id, created_at)
+SELECT ap.album_id, p.photo_id, ap.created_at
+FROM album_photos ap
+JOIN photos_new p ON p.file_path = ap.file_path;
+
+-- Drop the children before photos, otherwise ON DELETE CASCADE empties them during the drop
+DROP TABLE photo_tags;
+DROP TABLE album_photos;
+DROP TABLE photos;
+
+ALTER TABLE photos_new RENAME TO photos;
+ALTER TABLE photo_tags_new RENAME TO photo_tags;
+ALTER TABLE album_photos_new RENAME TO album_photos;
+
+ALTER TABLE exported_photos ADD COLUMN photo_id INTEGER;
+
+UPDATE exported_photos
+SET photo_id = (SELECT p.photo_id FROM photos p WHERE p.file_path = exported_photos.file_path);
+
+CREATE INDEX IF NOT EXISTS idx_photos_date_time ON photos(date_time);
+CREATE INDEX IF NOT EXISTS idx_photos_rating ON photos(rating);
+CREATE INDEX IF NOT EXISTS idx_photos_is_video ON photos(is_video);
+CREATE INDEX IF NOT EXISTS idx_photos_camera ON photos(camera_make, camera_model);
+CREATE INDEX IF NOT EXISTS idx_photos_trashed_date ON photos(is_trashed, date_time DESC);
+CREATE INDEX IF NOT EXISTS idx_photos_curated_trashed_date ON photos(is_curated, is_trashed, date_time DESC, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_album_photos_photo_id ON album_photos(photo_id);
+CREATE INDEX IF NOT EXISTS idx_exported_photos_photo_id ON exported_photos(photo_id);
@@ -0,0 +1,62 @@
+-- Each user keeps their own pick/reject/rating/favorite per photo. The is_curated, is_trashed and rating
+-- columns on photos become the team aggregate and are recomputed from this table whenever someone curates.
+CREATE TABLE IF NOT EXISTS photo_curations (
+    user_id      INTEGER NOT NULL,
+    photo_id     INTEGER NOT NULL,
+    is_curated   BOOLEAN DEFAULT 0,
+    is_trashed   BOOLEAN DEFAULT 0,
+    is_favorite  BOOLEAN DEFAULT 0,
+    rating       INTEGER DEFAULT 0 CHECK(rating BETWEEN 0 AND 5),
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, photo_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (photo_id) REFERENCES photos (photo_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_photo_curations_photo_id ON photo_curations(photo_id);
+
+-- One row per photo with at least one decision. Users who only favorited a photo don't count
+-- towards all_rejected, and the consensus rating ignores unrated entries.
+CREATE VIEW IF NOT EXISTS photo_curation_summary AS
+SELECT
+    photo_id,
+    COUNT(*) AS curator_count,
+    SUM(is_curated = 1 AND is_trashed = 0) AS pick_count,
+    SUM(is_trashed = 1) AS reject_count,
+    SUM(is_favorite = 1) AS favorite_count,
+    COALESCE(CAST(ROUND(AVG(CASE WHEN rating > 0 THEN rating END)) AS INTEGER), 0) AS consensus_rating,
+    MAX(is_curated = 1 AND is_trashed = 0) AS any_picked,
+    COALESCE(MIN(CASE WHEN is_curated = 1 OR is_trashed = 1 THEN is_trashed = 1 END), 0) AS all_rejected
+FROM photo_curations
+GROUP BY photo_id;
+
+-- Decisions made before accounts existed belong to the first admin
+INSERT OR IGNORE INTO photo_curations (user_id, photo_id, is_curated, is_trashed, rating)
+SELECT u.user_id, p.photo_id, p.is_curated, p.is_trashed, p.rating
+FROM photos p
+JOIN (SELECT MIN(user_id) AS user_id FROM users WHERE role = 'admin') u ON u.user_id IS NOT NULL
+WHERE p.is_curated = 1 OR p.is_trashed = 1 OR p.rating > 0;
+
+-- Upgrading installs have no users yet when this migration runs, so the legacy decisions are handed to
+-- whoever signs up first. The pending row is consumed by that claim, so later signups never take over the
+-- photos columns, which by then are the team aggregate.
+CREATE TABLE IF NOT EXISTS legacy_curation_claim (
+    pending INTEGER PRIMARY KEY CHECK(pending = 1)
+);
+
+INSERT OR IGNORE INTO legacy_curation_claim (pending)
+SELECT 1
+WHERE NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin');
+
+CREATE TRIGGER IF NOT EXISTS claim_legacy_curations
+AFTER INSERT ON users
+WHEN EXISTS (SELECT 1 FROM legacy_curation_claim)
+BEGIN
+    INSERT OR IGNORE INTO photo_curations (user_id, photo_id, is_curated, is_trashed, rating)
+    SELECT NEW.user_id, photo_id, is_curated, is_trashed, rating
+    FROM photos
+    WHERE is_curated = 1 OR is_trashed = 1 OR rating > 0;
+
+    DELETE FROM legacy_curation_claim;
+END;
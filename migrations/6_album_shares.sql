@@ -0,0 +1,29 @@
+-- Read-only public links to an album. The token is part of the URL so it's stored as-is;
+-- the optional password is a bcrypt hash like user passwords.
+CREATE TABLE IF NOT EXISTS album_shares (
+    share_id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    album_id        INTEGER NOT NULL,
+    token           TEXT NOT NULL UNIQUE,
+    password_hash   TEXT,
+    allow_download  BOOLEAN DEFAULT 0,
+    expires_at      TIMESTAMP,
+    created_by      INTEGER,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (album_id) REFERENCES albums (album_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_shares_album_id ON album_shares(album_id);
+
+CREATE TABLE IF NOT EXISTS album_share_access_log (
+    access_id    INTEGER PRIMARY KEY AUTOINCREMENT,
+    share_id     INTEGER NOT NULL,
+    event        TEXT NOT NULL CHECK(event IN ('view', 'download', 'unlock', 'unlock_failed', 'expired')),
+    photo_id     INTEGER,
+    ip_address   TEXT,
+    user_agent   TEXT,
+    accessed_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (share_id) REFERENCES album_shares (share_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_share_access_log_share_id ON album_share_access_log(share_id, accessed_at);
@@ -0,0 +1,3 @@
+-- JSON-encoded albums.SmartAlbumRules. Albums with rules compute their photos at query time
+-- and never have rows in album_photos.
+ALTER TABLE albums ADD COLUMN smart_rules TEXT;
@@ -0,0 +1,26 @@
+-- A chosen cover replaces the automatic one (first photo for regular albums, newest match for smart albums)
+ALTER TABLE albums ADD COLUMN cover_photo_id INTEGER REFERENCES photos (photo_id) ON DELETE SET NULL;
+
+-- Any album can hold child albums. Deleting a parent moves its children to the top level.
+ALTER TABLE albums ADD COLUMN parent_album_id INTEGER REFERENCES albums (album_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_albums_parent_album_id ON albums(parent_album_id);
+
+-- Manual photo order within regular albums. Existing albums keep the newest-first order they were shown in.
+ALTER TABLE album_photos ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+UPDATE album_photos
+SET position = (
+    SELECT ranked.position
+    FROM (
+        SELECT
+            ap.album_id,
+            ap.photo_id,
+            ROW_NUMBER() OVER (PARTITION BY ap.album_id ORDER BY p.date_time DESC, ap.photo_id) AS position
+        FROM album_photos ap
+        JOIN photos p ON p.photo_id = ap.photo_id
+    ) ranked
+    WHERE ranked.album_id = album_photos.album_id AND ranked.photo_id = album_photos.photo_id
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_photos_position ON album_photos(album_id, position);
@@ -0,0 +1,10 @@
+-- County (GeoNames admin2) and a name in the configured language for each city. The cities are cleared so
+-- they are loaded again with the new columns on the next start; photos keep their places until a rebuild.
+ALTER TABLE cities ADD COLUMN county TEXT;
+ALTER TABLE cities ADD COLUMN localized_name TEXT;
+
+DELETE FROM cities;
+DELETE FROM cities_rtree;
+
+ALTER TABLE photos ADD COLUMN county TEXT;
+ALTER TABLE photos ADD COLUMN country_code TEXT;